@@ -0,0 +1,248 @@
+package godet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AxeScriptURL is the URL axe-core is downloaded from, if AxeScript is not set.
+var AxeScriptURL = "https://cdnjs.cloudflare.com/ajax/libs/axe-core/4.8.4/axe.min.js"
+
+// AxeScript is the axe-core source injected by RunAxe. If empty, it's downloaded (once) from AxeScriptURL.
+// Set it to use a bundled or custom version of axe-core.
+var AxeScript string
+
+var axeScript struct {
+	sync.Mutex
+	source string
+}
+
+// loadAxeScript returns AxeScript or the script downloaded from AxeScriptURL.
+func loadAxeScript() (string, error) {
+	if AxeScript != "" {
+		return AxeScript, nil
+	}
+
+	axeScript.Lock()
+	defer axeScript.Unlock()
+
+	if axeScript.source != "" {
+		return axeScript.source, nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Get(AxeScriptURL)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cannot download axe-core from %v: %v", AxeScriptURL, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	axeScript.source = string(data)
+	return axeScript.source, nil
+}
+
+// AxeNode is an element violating an accessibility rule.
+type AxeNode struct {
+	// Selector is the selector of the element (frames and shadow roots are separated by DeepSelectorSeparator).
+	Selector       string `json:"selector"`
+	HTML           string `json:"html"`
+	Impact         string `json:"impact"`
+	FailureSummary string `json:"failureSummary"`
+}
+
+// AxeViolation is an accessibility rule violated by the page.
+type AxeViolation struct {
+	ID          string    `json:"id"`
+	Impact      string    `json:"impact"` // minor, moderate, serious or critical
+	Description string    `json:"description"`
+	Help        string    `json:"help"`
+	HelpURL     string    `json:"helpUrl"`
+	Tags        []string  `json:"tags"`
+	Nodes       []AxeNode `json:"nodes"`
+}
+
+// AxeResult is the result of RunAxe.
+type AxeResult struct {
+	URL        string         `json:"url"`
+	Time       time.Time      `json:"time"`
+	Engine     string         `json:"engine"`
+	Violations []AxeViolation `json:"violations"`
+	Passes     int            `json:"passes"`
+	Incomplete int            `json:"incomplete"`
+}
+
+// AxeOption is the functional option for RunAxe.
+type AxeOption func(params Params)
+
+// AxeTags only runs the rules with the specified tags (i.e. "wcag2a", "wcag2aa", "best-practice").
+func AxeTags(tags ...string) AxeOption {
+	return func(params Params) {
+		params["runOnly"] = Params{"type": "tag", "values": tags}
+	}
+}
+
+// AxeRules only runs the specified rules (i.e. "color-contrast", "image-alt").
+func AxeRules(rules ...string) AxeOption {
+	return func(params Params) {
+		params["runOnly"] = Params{"type": "rule", "values": rules}
+	}
+}
+
+// AxeContext only checks the elements matching the selector (default the whole document).
+func AxeContext(selector string) AxeOption {
+	return func(params Params) {
+		params["context"] = selector
+	}
+}
+
+// runAxeJS runs axe-core and returns the violations, with the element targets flattened.
+const runAxeJS = `(function(options) {
+	var context = options.context || document;
+	delete options.context;
+
+	function target(t) {
+		return t.map(function(s) { return Array.isArray(s) ? s.join(" >>> ") : s; }).join(" >>> ");
+	}
+
+	return axe.run(context, options).then(function(r) {
+		return JSON.stringify({
+			url: r.url,
+			engine: r.testEngine.name + " " + r.testEngine.version,
+			passes: r.passes.length,
+			incomplete: r.incomplete.length,
+			violations: r.violations.map(function(v) {
+				return {
+					id: v.id, impact: v.impact || "", description: v.description, help: v.help, helpUrl: v.helpUrl, tags: v.tags,
+					nodes: v.nodes.map(function(n) {
+						return {selector: target(n.target), html: n.html, impact: n.impact || "", failureSummary: n.failureSummary || ""};
+					})
+				};
+			})
+		});
+	});
+})(%v)`
+
+// RunAxe injects axe-core (see AxeScript) in the current page, runs the accessibility checks
+// and returns the violations, with the selectors of the offending elements and the impact levels.
+// The page should be loaded.
+func (remote *RemoteDebugger) RunAxe(options ...AxeOption) (*AxeResult, error) {
+	script, err := loadAxeScript()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := remote.Evaluate(`typeof axe === "object" && typeof axe.run === "function"`)
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded != true {
+		if _, err := remote.Evaluate(script); err != nil {
+			return nil, err
+		}
+	}
+
+	params := Params{}
+	for _, opt := range options {
+		opt(params)
+	}
+
+	jparams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := remote.Evaluate(fmt.Sprintf(runAxeJS, string(jparams)), AwaitPromise(true))
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := res.(string)
+	if !ok {
+		return nil, errors.New("axe-core didn't return a result")
+	}
+
+	result := &AxeResult{Time: time.Now()}
+	if err := json.Unmarshal([]byte(s), result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Count returns the number of violating elements with the specified impact ("" for all of them).
+func (r *AxeResult) Count(impact string) int {
+	count := 0
+
+	for _, v := range r.Violations {
+		for _, n := range v.Nodes {
+			if impact == "" || n.Impact == impact || (n.Impact == "" && v.Impact == impact) {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// WriteHTML writes the violations as a standalone HTML page.
+func (r *AxeResult) WriteHTML(w io.Writer) error {
+	return axeTemplate.Execute(w, r)
+}
+
+var axeTemplate = template.Must(template.New("axe").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Accessibility report {{.URL}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.violation { border: 1px solid #ccc; border-left-width: 6px; border-radius: 4px; margin-bottom: 1em; padding: 0.5em; }
+.critical { border-left-color: #900; }
+.serious { border-left-color: #d40; }
+.moderate { border-left-color: #e90; }
+.minor { border-left-color: #999; }
+.violation h3 { margin: 0 0 0.5em 0; font-size: 1em; }
+.meta { color: #666; font-size: 0.9em; }
+table { border-collapse: collapse; width: 100%; margin-top: 0.5em; }
+td { border-top: 1px solid #eee; padding: 0.3em; vertical-align: top; font-size: 0.9em; }
+code { white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Accessibility report</h1>
+<p class="meta">{{.URL}} &mdash; {{.Time.Format "2006-01-02 15:04:05"}} &mdash; {{.Engine}}<br>
+{{len .Violations}} violations, {{.Passes}} passed rules, {{.Incomplete}} to review</p>
+{{range .Violations}}
+<div class="violation {{.Impact}}">
+<h3>{{.Impact}}: {{.Help}} ({{.ID}})</h3>
+<div class="meta">{{.Description}} &mdash; <a href="{{.HelpURL}}">more info</a> &mdash; {{join .Tags ", "}}</div>
+<table>
+{{range .Nodes}}<tr><td><code>{{.Selector}}</code></td><td><code>{{.HTML}}</code></td><td>{{.FailureSummary}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}
+</body>
+</html>
+`))
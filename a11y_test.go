@@ -0,0 +1,101 @@
+package godet
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testAxeResult = `{"url": "http://example.com/", "engine": "axe-core 4.8.4", "passes": 20, "incomplete": 1,
+	"violations": [
+		{"id": "image-alt", "impact": "critical", "help": "Images must have alternate text", "tags": ["wcag2a"],
+		 "nodes": [{"selector": "img.logo", "html": "<img class=\"logo\">", "impact": "critical"},
+		           {"selector": "iframe >>> img", "html": "<img>", "impact": "critical"}]},
+		{"id": "color-contrast", "impact": "serious", "help": "Elements must have sufficient color contrast", "tags": ["wcag2aa"],
+		 "nodes": [{"selector": "#footer > a", "html": "<a href=\"/about\">About</a>"}]}
+	]}`
+
+func TestRunAxe(t *testing.T) {
+	var lock sync.Mutex
+	var injected int
+	var options []string
+
+	AxeScript = "window.axe = {run: function() {}};"
+	defer func() { AxeScript = "" }()
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		var value interface{}
+
+		switch expr := params.String("expression"); {
+		case strings.HasPrefix(expr, "typeof axe"):
+			value = injected > 0
+
+		case expr == AxeScript:
+			injected++
+
+		case strings.Contains(expr, "axe.run"):
+			options = append(options, expr[strings.LastIndex(expr, "})(")+3:len(expr)-1])
+			value = testAxeResult
+		}
+
+		return Params{"result": Params{"type": "string", "value": value}}, nil
+	}))
+
+	res, err := remote.RunAxe(AxeTags("wcag2a", "wcag2aa"), AxeContext("#main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := remote.RunAxe(AxeRules("image-alt")); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	if injected != 1 {
+		t.Errorf("axe-core injected %v times, want 1", injected)
+	}
+
+	wantOptions := []string{
+		`{"context":"#main","runOnly":{"type":"tag","values":["wcag2a","wcag2aa"]}}`,
+		`{"runOnly":{"type":"rule","values":["image-alt"]}}`,
+	}
+	if strings.Join(options, "\n") != strings.Join(wantOptions, "\n") {
+		t.Errorf("options %q, want %q", options, wantOptions)
+	}
+	lock.Unlock()
+
+	if res.URL != "http://example.com/" || res.Engine != "axe-core 4.8.4" || res.Passes != 20 || res.Incomplete != 1 || len(res.Violations) != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if res.Violations[0].Nodes[1].Selector != "iframe >>> img" {
+		t.Errorf("unexpected node %+v", res.Violations[0].Nodes[1])
+	}
+
+	// the node impact defaults to the violation impact
+	for impact, want := range map[string]int{"": 3, "critical": 2, "serious": 1, "minor": 0} {
+		if count := res.Count(impact); count != want {
+			t.Errorf("Count(%q) = %v, want %v", impact, count, want)
+		}
+	}
+
+	var b strings.Builder
+	if err := res.WriteHTML(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"2 violations, 20 passed rules, 1 to review",
+		`<div class="violation critical">`,
+		"<h3>serious: Elements must have sufficient color contrast (color-contrast)</h3>",
+		"<code>#footer &gt; a</code>",
+		"wcag2aa",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report doesn't contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package godet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gobs/httpclient"
+)
+
+// ADBPath is the adb executable used by the Android helpers.
+var ADBPath = "adb"
+
+// ChromeDevtoolsSocket is the devtools socket name of Chrome on Android.
+const ChromeDevtoolsSocket = "chrome_devtools_remote"
+
+// ADBDevice is an Android device connected via adb.
+type ADBDevice struct {
+	Serial string
+	State  string // device, unauthorized, offline...
+	Model  string
+}
+
+// adb runs an adb command on the device (any device, if serial is empty) and returns its output.
+func adb(serial string, args ...string) ([]byte, error) {
+	if serial != "" {
+		args = append([]string{"-s", serial}, args...)
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(ADBPath, args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("adb %v: %v", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	return out, err
+}
+
+// ADBDevices returns the devices connected via adb.
+func ADBDevices() ([]ADBDevice, error) {
+	out, err := adb("", "devices", "-l")
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []ADBDevice
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] == "List" || strings.HasPrefix(fields[0], "*") {
+			continue
+		}
+
+		device := ADBDevice{Serial: fields[0], State: fields[1]}
+		for _, f := range fields[2:] {
+			if strings.HasPrefix(f, "model:") {
+				device.Model = strings.TrimPrefix(f, "model:")
+			}
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, scanner.Err()
+}
+
+// ADBDevtoolsSockets returns the names of the devtools sockets open on the device: Chrome
+// (chrome_devtools_remote), WebViews (webview_devtools_remote_<pid>) and other browsers
+// (<package>_devtools_remote).
+func ADBDevtoolsSockets(serial string) ([]string, error) {
+	out, err := adb(serial, "shell", "cat", "/proc/net/unix")
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []string
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := fields[len(fields)-1]
+		if !strings.HasPrefix(name, "@") || !strings.Contains(name, "devtools_remote") {
+			continue
+		}
+
+		if name = name[1:]; !seen[name] {
+			seen[name] = true
+			sockets = append(sockets, name)
+		}
+	}
+
+	return sockets, scanner.Err()
+}
+
+// ADBForwarding is a local port forwarded to a devtools socket on an Android device.
+type ADBForwarding struct {
+	Serial string
+	Socket string
+
+	// Port is the local address (localhost:port), that can be passed to Connect.
+	Port string
+}
+
+// ADBForward forwards a free local port to the devtools socket on the device
+// (any device, if serial is empty). If socket is empty, ChromeDevtoolsSocket is used.
+func ADBForward(serial, socket string) (*ADBForwarding, error) {
+	if socket == "" {
+		socket = ChromeDevtoolsSocket
+	}
+
+	out, err := adb(serial, "forward", "tcp:0", "localabstract:"+socket)
+	if err != nil {
+		return nil, err
+	}
+
+	port := strings.TrimSpace(string(out))
+	if port == "" {
+		return nil, fmt.Errorf("adb forward: no port allocated")
+	}
+
+	return &ADBForwarding{Serial: serial, Socket: socket, Port: "localhost:" + port}, nil
+}
+
+// Pages returns the pages open on the device.
+func (f *ADBForwarding) Pages() ([]*Tab, error) {
+	return newRemoteDebugger(httpclient.NewHttpClient("http://"+f.Port), false).TabList("page")
+}
+
+// Connect connects to the browser on the device (see Connect).
+func (f *ADBForwarding) Connect(verbose bool, options ...ConnectOption) (*RemoteDebugger, error) {
+	return Connect(f.Port, verbose, options...)
+}
+
+// Remove removes the port forwarding.
+func (f *ADBForwarding) Remove() error {
+	_, err := adb(f.Serial, "forward", "--remove", "tcp:"+strings.TrimPrefix(f.Port, "localhost:"))
+	return err
+}
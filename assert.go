@@ -0,0 +1,150 @@
+package godet
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// matchURLPattern returns true if the URL matches the pattern, where '*' matches any sequence
+// of characters and '?' a single character (as for the Fetch patterns).
+func matchURLPattern(pattern, url string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+
+	// path.Match supports the same wildcards but '*' stops at '/'
+	ok, _ := path.Match(strings.Replace(pattern, "/", "\x00", -1), strings.Replace(url, "/", "\x00", -1))
+	return ok
+}
+
+// HeaderMatcher checks the value of a response header (see AssertHeader).
+type HeaderMatcher struct {
+	// Description describes the expectation, i.e. "present" or "contains max-age".
+	Description string
+
+	// Match returns true if the header value (present is false for missing headers) is as expected.
+	Match func(value string, present bool) bool
+}
+
+// HeaderPresent expects the header to be present.
+func HeaderPresent() HeaderMatcher {
+	return HeaderMatcher{"present", func(_ string, present bool) bool { return present }}
+}
+
+// HeaderAbsent expects the header to be missing (i.e. X-Powered-By).
+func HeaderAbsent() HeaderMatcher {
+	return HeaderMatcher{"absent", func(_ string, present bool) bool { return !present }}
+}
+
+// HeaderEquals expects the header value to be equal (ignoring case) to one of the values.
+func HeaderEquals(values ...string) HeaderMatcher {
+	return HeaderMatcher{fmt.Sprintf("one of %q", values), func(value string, present bool) bool {
+		for _, v := range values {
+			if present && strings.EqualFold(strings.TrimSpace(value), v) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// HeaderContains expects the header value to contain s (ignoring case).
+func HeaderContains(s string) HeaderMatcher {
+	return HeaderMatcher{fmt.Sprintf("contains %q", s), func(value string, present bool) bool {
+		return present && strings.Contains(strings.ToLower(value), strings.ToLower(s))
+	}}
+}
+
+// HeaderMatches expects the header value to match the regular expression.
+func HeaderMatches(re *regexp.Regexp) HeaderMatcher {
+	return HeaderMatcher{fmt.Sprintf("matches %q", re), func(value string, present bool) bool {
+		return present && re.MatchString(value)
+	}}
+}
+
+// HeaderFailure is a response that didn't satisfy a header assertion.
+type HeaderFailure struct {
+	URL      string `json:"url"`
+	Header   string `json:"header"`
+	Value    string `json:"value,omitempty"`
+	Present  bool   `json:"present"`
+	Expected string `json:"expected"`
+}
+
+func (f HeaderFailure) String() string {
+	if !f.Present {
+		return fmt.Sprintf("%v: header %v missing, expected %v", f.URL, f.Header, f.Expected)
+	}
+
+	return fmt.Sprintf("%v: header %v is %q, expected %v", f.URL, f.Header, f.Value, f.Expected)
+}
+
+// responseHeader returns the value of the header (with case-insensitive lookup).
+func responseHeader(headers map[string]string, name string) (string, bool) {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// checkHeader appends a failure if the response header doesn't satisfy the matcher.
+func checkHeader(failures []HeaderFailure, r ObservedResponse, header string, matcher HeaderMatcher) []HeaderFailure {
+	value, present := responseHeader(r.Headers, header)
+	if matcher.Match(value, present) {
+		return failures
+	}
+
+	return append(failures, HeaderFailure{
+		URL:      r.URL,
+		Header:   header,
+		Value:    value,
+		Present:  present,
+		Expected: matcher.Description,
+	})
+}
+
+// AssertHeader checks the header of the responses recorded by RecordResponses whose URL matches
+// urlPattern (with '*' and '?' wildcards) and returns the responses failing the matcher.
+// An empty result means the assertion passed.
+func (remote *RemoteDebugger) AssertHeader(urlPattern, header string, matcher HeaderMatcher) []HeaderFailure {
+	var failures []HeaderFailure
+
+	for _, r := range remote.Responses() {
+		if r.Status != 0 && matchURLPattern(urlPattern, r.URL) {
+			failures = checkHeader(failures, r, header, matcher)
+		}
+	}
+
+	return failures
+}
+
+// AssertSecurityHeaders checks the common security headers (Content-Security-Policy, Strict-Transport-Security
+// for https, X-Frame-Options and X-Content-Type-Options) on the documents matching urlPattern.
+func (remote *RemoteDebugger) AssertSecurityHeaders(urlPattern string) []HeaderFailure {
+	var failures []HeaderFailure
+
+	for _, r := range remote.Responses() {
+		if r.Status == 0 || r.Type != ResourceTypeDocument || !matchURLPattern(urlPattern, r.URL) {
+			continue
+		}
+
+		failures = checkHeader(failures, r, "Content-Security-Policy", HeaderPresent())
+		failures = checkHeader(failures, r, "X-Content-Type-Options", HeaderEquals("nosniff"))
+
+		if strings.HasPrefix(r.URL, "https:") {
+			failures = checkHeader(failures, r, "Strict-Transport-Security", HeaderContains("max-age="))
+		}
+
+		// frame-ancestors in the CSP supersedes X-Frame-Options
+		if csp, _ := responseHeader(r.Headers, "Content-Security-Policy"); !strings.Contains(csp, "frame-ancestors") {
+			failures = checkHeader(failures, r, "X-Frame-Options", HeaderEquals("DENY", "SAMEORIGIN"))
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,247 @@
+// Package bidi implements a WebDriver BiDi client (https://w3c.github.io/webdriver-bidi/),
+// as an alternative backend to the Chrome DevTools Protocol.
+//
+// Client implements godet.Driver, so that code written against the high-level helpers
+// can target both protocols.
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// ErrorClose is returned for the pending and new commands after the connection is closed
+var ErrorClose = errors.New("closed")
+
+// Error is an error returned by the remote end.
+type Error struct {
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+	Stacktrace string `json:"stacktrace"`
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("%v: %v", err.Code, err.Message)
+}
+
+// message is a command result, an error or an event.
+type message struct {
+	Type   string          `json:"type"` // success, error or event
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Error
+}
+
+// EventCallback is called with the parameters of an event.
+type EventCallback func(params json.RawMessage)
+
+// Client is a WebDriver BiDi session.
+type Client struct {
+	// SessionID is the id of the session created by Connect.
+	SessionID string
+
+	// Capabilities are the capabilities of the session.
+	Capabilities map[string]interface{}
+
+	// Context is the browsing context used by the Driver methods (the first top-level one, by default).
+	Context string
+
+	ws      *websocket.Conn
+	verbose bool
+
+	sync.Mutex
+	id        int
+	pending   map[int]chan message
+	callbacks map[string]EventCallback
+	closed    bool
+}
+
+// Connect connects to a WebDriver BiDi endpoint and creates a new session. The address is either
+// a websocket URL (i.e. "ws://localhost:9222/session") or host:port, in which case "/session" is used.
+func Connect(addr string, verbose bool) (*Client, error) {
+	if !strings.HasPrefix(addr, "ws://") && !strings.HasPrefix(addr, "wss://") {
+		addr = "ws://" + addr + "/session"
+	}
+
+	ws, _, err := websocket.Dial(context.Background(), addr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.SetReadLimit(-1)
+
+	c := &Client{
+		ws:        ws,
+		verbose:   verbose,
+		pending:   map[int]chan message{},
+		callbacks: map[string]EventCallback{},
+	}
+
+	go c.readMessages()
+
+	var session struct {
+		SessionID    string                 `json:"sessionId"`
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+
+	if err := c.Send("session.new", map[string]interface{}{"capabilities": map[string]interface{}{}}, &session); err != nil {
+		c.ws.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+
+	c.SessionID, c.Capabilities = session.SessionID, session.Capabilities
+
+	contexts, err := c.GetTree("", 0)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if len(contexts) > 0 {
+		c.Context = contexts[0].Context
+	}
+
+	return c, nil
+}
+
+func (c *Client) readMessages() {
+	for {
+		var msg message
+
+		if err := wsjson.Read(context.Background(), c.ws, &msg); err != nil {
+			if c.verbose {
+				log.Println("read message:", err)
+			}
+			break
+		}
+
+		if msg.Type == "event" {
+			if c.verbose {
+				log.Println("EVENT", msg.Method, string(msg.Params))
+			}
+
+			c.Lock()
+			cb := c.callbacks[msg.Method]
+			c.Unlock()
+
+			if cb != nil {
+				cb(msg.Params)
+			}
+
+			continue
+		}
+
+		if c.verbose {
+			log.Println("REPLY", msg.ID, msg.Type, string(msg.Result))
+		}
+
+		c.Lock()
+		ch := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.Unlock()
+
+		if ch != nil {
+			ch <- msg
+		}
+	}
+
+	c.Lock()
+	c.closed = true
+	for id, ch := range c.pending {
+		delete(c.pending, id)
+		close(ch)
+	}
+	c.Unlock()
+}
+
+// Send sends a command and decodes the result into result (if not nil).
+func (c *Client) Send(method string, params interface{}, result interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		return ErrorClose
+	}
+
+	c.id++
+	id := c.id
+	ch := make(chan message, 1)
+	c.pending[id] = ch
+	c.Unlock()
+
+	command := map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": params,
+	}
+
+	if c.verbose {
+		log.Printf("SEND %#v\n", command)
+	}
+
+	if err := wsjson.Write(context.Background(), c.ws, command); err != nil {
+		c.Lock()
+		delete(c.pending, id)
+		c.Unlock()
+		return err
+	}
+
+	msg, ok := <-ch
+	if !ok {
+		return ErrorClose
+	}
+
+	if msg.Type == "error" {
+		return msg.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(msg.Result, result)
+}
+
+// OnEvent sets the callback for the specified event (i.e. "browsingContext.load"), or removes it if cb is nil.
+// The events must also be enabled with Subscribe.
+//
+// Callbacks are called from the goroutine reading the messages, so they should not block
+// (or send commands) but hand off the work to another goroutine.
+func (c *Client) OnEvent(method string, cb EventCallback) {
+	c.Lock()
+	if cb == nil {
+		delete(c.callbacks, method)
+	} else {
+		c.callbacks[method] = cb
+	}
+	c.Unlock()
+}
+
+// Subscribe enables the specified events or modules (i.e. "log" or "network.beforeRequestSent").
+func (c *Client) Subscribe(events ...string) error {
+	return c.Send("session.subscribe", map[string]interface{}{"events": events}, nil)
+}
+
+// Unsubscribe disables the specified events or modules.
+func (c *Client) Unsubscribe(events ...string) error {
+	return c.Send("session.unsubscribe", map[string]interface{}{"events": events}, nil)
+}
+
+// Close ends the session and closes the connection.
+func (c *Client) Close() error {
+	c.Send("session.end", nil, nil)
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
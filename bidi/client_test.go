@@ -0,0 +1,204 @@
+package bidi
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/raff/godet"
+)
+
+// fakeBiDi starts a WebDriver BiDi endpoint that answers each command with the result (or the error)
+// returned by reply, sending the events returned by it after the reply, and returns its address.
+func fakeBiDi(t *testing.T, reply func(method string, params map[string]interface{}) (interface{}, *Error, []message)) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session" {
+			http.NotFound(w, r)
+			return
+		}
+
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+
+		ctx := r.Context()
+
+		for {
+			var cmd struct {
+				ID     int                    `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			result, berr, events := reply(cmd.Method, cmd.Params)
+
+			msg := map[string]interface{}{"type": "success", "id": cmd.ID, "result": result}
+			if berr != nil {
+				msg = map[string]interface{}{"type": "error", "id": cmd.ID, "error": berr.Code, "message": berr.Message}
+			} else if result == nil {
+				msg["result"] = map[string]interface{}{}
+			}
+
+			if err := wsjson.Write(ctx, c, msg); err != nil {
+				return
+			}
+
+			for _, ev := range events {
+				if err := wsjson.Write(ctx, c, map[string]interface{}{"type": "event", "method": ev.Method, "params": ev.Params}); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestClient(t *testing.T) {
+	var lock sync.Mutex
+	var commands []string
+
+	addr := fakeBiDi(t, func(method string, params map[string]interface{}) (interface{}, *Error, []message) {
+		lock.Lock()
+		commands = append(commands, method)
+		lock.Unlock()
+
+		switch method {
+		case "session.new":
+			return map[string]interface{}{"sessionId": "s1", "capabilities": map[string]interface{}{"browserName": "firefox"}}, nil, nil
+
+		case "browsingContext.getTree":
+			return map[string]interface{}{"contexts": []ContextInfo{{Context: "ctx1", URL: "about:blank"}, {Context: "ctx2"}}}, nil, nil
+
+		case "browsingContext.navigate":
+			if params["context"] != "ctx1" || params["wait"] != "complete" {
+				t.Errorf("navigate params = %v", params)
+			}
+			if params["url"] == "http://invalid" {
+				return nil, &Error{Code: "unknown error", Message: "NS_ERROR_UNKNOWN_HOST"}, nil
+			}
+
+			return map[string]interface{}{"navigation": "n1", "url": params["url"]}, nil,
+				[]message{{Method: "browsingContext.load", Params: json.RawMessage(`{"context":"ctx1"}`)}}
+
+		case "script.evaluate":
+			if params["expression"] == "throw" {
+				return map[string]interface{}{"type": "exception", "exceptionDetails": ScriptError{Text: "Error: boom", LineNumber: 1, ColumnNumber: 7}}, nil, nil
+			}
+
+			return map[string]interface{}{"type": "success", "result": json.RawMessage(`{"type":"object","value":[
+				["n",{"type":"number","value":42}],
+				["inf",{"type":"number","value":"-Infinity"}],
+				["list",{"type":"array","value":[{"type":"string","value":"a"},{"type":"null"}]}],
+				["ok",{"type":"boolean","value":true}],
+				["el",{"type":"node","sharedId":"x"}]
+			]}`)}, nil, nil
+
+		case "storage.getCookies":
+			return map[string]interface{}{"cookies": []map[string]interface{}{
+				{"name": "sid", "value": map[string]interface{}{"type": "string", "value": "1234"}, "domain": "example.com", "path": "/", "sameSite": "lax", "httpOnly": true},
+			}}, nil, nil
+		}
+
+		return nil, nil, nil
+	})
+
+	c, err := Connect(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.SessionID != "s1" || c.Context != "ctx1" || c.Capabilities["browserName"] != "firefox" {
+		t.Errorf("session = %v %v %v", c.SessionID, c.Context, c.Capabilities)
+	}
+
+	loaded := make(chan string, 1)
+	c.OnEvent("browsingContext.load", func(params json.RawMessage) {
+		loaded <- string(params)
+	})
+
+	var d godet.Driver = c
+
+	if err := d.Navigate("http://example.com", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case params := <-loaded:
+		if params != `{"context":"ctx1"}` {
+			t.Errorf("load params = %v", params)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no load event")
+	}
+
+	if err := d.Navigate("http://invalid", 5*time.Second); err == nil || err.Error() != "unknown error: NS_ERROR_UNKNOWN_HOST" {
+		t.Errorf("navigate error = %v", err)
+	} else if _, ok := err.(Error); !ok {
+		t.Errorf("navigate error is %T, want bidi.Error", err)
+	}
+
+	res, err := d.Evaluate("value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := res.(map[string]interface{})
+	if inf, _ := m["inf"].(float64); !math.IsInf(inf, -1) {
+		t.Errorf("inf = %v", m["inf"])
+	}
+
+	delete(m, "inf")
+	want := map[string]interface{}{"n": 42.0, "list": []interface{}{"a", nil}, "ok": true, "el": "node"}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("evaluate = %#v, want %#v", m, want)
+	}
+
+	if _, err := d.Evaluate("throw"); err == nil || err.Error() != "Error: boom (1:7)" {
+		t.Errorf("evaluate error = %v", err)
+	}
+
+	cookies, err := d.Cookies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCookies := []godet.Cookie{{Name: "sid", Value: "1234", Domain: "example.com", Path: "/", HttpOnly: true, Session: true, SameSite: "Lax"}}
+	if !reflect.DeepEqual(cookies, wantCookies) {
+		t.Errorf("cookies = %+v, want %+v", cookies, wantCookies)
+	}
+
+	c.Close()
+
+	if err := c.Send("session.status", nil, nil); err == nil {
+		t.Error("no error after close")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	wantCommands := []string{"session.new", "browsingContext.getTree", "browsingContext.navigate", "browsingContext.navigate",
+		"script.evaluate", "script.evaluate", "storage.getCookies", "session.end"}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("commands = %q, want %q", commands, wantCommands)
+	}
+
+}
@@ -0,0 +1,116 @@
+package bidi
+
+import (
+	"encoding/base64"
+)
+
+// ContextInfo describes a browsing context (a tab, window or frame).
+type ContextInfo struct {
+	Context  string        `json:"context"`
+	URL      string        `json:"url"`
+	Parent   string        `json:"parent"`
+	Children []ContextInfo `json:"children"`
+}
+
+// GetTree returns the tree of browsing contexts starting at root (all the top-level ones, if empty),
+// up to the specified depth (unlimited, if 0).
+func (c *Client) GetTree(root string, depth int) ([]ContextInfo, error) {
+	params := map[string]interface{}{}
+	if root != "" {
+		params["root"] = root
+	}
+	if depth > 0 {
+		params["maxDepth"] = depth
+	}
+
+	var res struct {
+		Contexts []ContextInfo `json:"contexts"`
+	}
+
+	if err := c.Send("browsingContext.getTree", params, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Contexts, nil
+}
+
+// ReadinessState is the state to wait for, when navigating.
+type ReadinessState string
+
+const (
+	ReadinessNone        = ReadinessState("none")
+	ReadinessInteractive = ReadinessState("interactive")
+	ReadinessComplete    = ReadinessState("complete")
+)
+
+// NavigateContext navigates the browsing context to the URL and waits for the specified state.
+// It returns the navigation id and the final URL.
+func (c *Client) NavigateContext(context, url string, wait ReadinessState) (navigation, finalURL string, err error) {
+	var res struct {
+		Navigation string `json:"navigation"`
+		URL        string `json:"url"`
+	}
+
+	err = c.Send("browsingContext.navigate", map[string]interface{}{
+		"context": context,
+		"url":     url,
+		"wait":    wait,
+	}, &res)
+
+	return res.Navigation, res.URL, err
+}
+
+// Reload reloads the browsing context and waits for the specified state.
+func (c *Client) Reload(context string, ignoreCache bool, wait ReadinessState) error {
+	return c.Send("browsingContext.reload", map[string]interface{}{
+		"context":     context,
+		"ignoreCache": ignoreCache,
+		"wait":        wait,
+	}, nil)
+}
+
+// CaptureContextScreenshot captures a screenshot of the browsing context viewport.
+// The format is "png" or "jpeg", with quality between 0 and 100.
+func (c *Client) CaptureContextScreenshot(context, format string, quality int) ([]byte, error) {
+	params := map[string]interface{}{
+		"context": context,
+	}
+
+	if format != "" && format != "png" {
+		f := map[string]interface{}{"type": "image/" + format}
+		if quality > 0 {
+			f["quality"] = float64(quality) / 100
+		}
+		params["format"] = f
+	}
+
+	var res struct {
+		Data string `json:"data"`
+	}
+
+	if err := c.Send("browsingContext.captureScreenshot", params, &res); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(res.Data)
+}
+
+// CreateContext creates a new top-level browsing context ("tab" or "window") and returns its id.
+func (c *Client) CreateContext(ctype string) (string, error) {
+	var res struct {
+		Context string `json:"context"`
+	}
+
+	err := c.Send("browsingContext.create", map[string]interface{}{"type": ctype}, &res)
+	return res.Context, err
+}
+
+// CloseContext closes a top-level browsing context.
+func (c *Client) CloseContext(context string) error {
+	return c.Send("browsingContext.close", map[string]interface{}{"context": context}, nil)
+}
+
+// ActivateContext brings the browsing context to the foreground.
+func (c *Client) ActivateContext(context string) error {
+	return c.Send("browsingContext.activate", map[string]interface{}{"context": context}, nil)
+}
@@ -0,0 +1,125 @@
+package bidi
+
+import (
+	"context"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+var _ godet.Driver = (*Client)(nil)
+
+// Navigate navigates the current context to the URL and waits for the page to load.
+func (c *Client) Navigate(url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, _, err := c.NavigateContext(c.Context, url, ReadinessComplete)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return godet.ErrorTimeout
+	}
+}
+
+// Screenshot captures a screenshot of the current context viewport.
+func (c *Client) Screenshot(format string, quality int) ([]byte, error) {
+	return c.CaptureContextScreenshot(c.Context, format, quality)
+}
+
+// Evaluate evaluates a Javascript expression in the current context.
+func (c *Client) Evaluate(expr string) (interface{}, error) {
+	return c.EvaluateIn(c.Context, expr)
+}
+
+// cookie is the BiDi representation of a cookie.
+type cookie struct {
+	Name     string      `json:"name"`
+	Value    RemoteValue `json:"value"`
+	Domain   string      `json:"domain"`
+	Path     string      `json:"path"`
+	Size     int         `json:"size"`
+	HTTPOnly bool        `json:"httpOnly"`
+	Secure   bool        `json:"secure"`
+	SameSite string      `json:"sameSite"`
+	Expiry   float64     `json:"expiry"`
+}
+
+// sameSite maps the CDP sameSite values to BiDi and back.
+var sameSite = map[string]string{
+	"Strict": "strict", "Lax": "lax", "None": "none",
+	"strict": "Strict", "lax": "Lax", "none": "None",
+}
+
+// Cookies returns the cookies visible to the current context.
+func (c *Client) Cookies() ([]godet.Cookie, error) {
+	var res struct {
+		Cookies []cookie `json:"cookies"`
+	}
+
+	if err := c.Send("storage.getCookies", map[string]interface{}{
+		"partition": map[string]interface{}{"type": "context", "context": c.Context},
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	cookies := make([]godet.Cookie, 0, len(res.Cookies))
+
+	for _, ck := range res.Cookies {
+		value, _ := ck.Value.GoValue()
+		s, _ := value.(string)
+
+		cookies = append(cookies, godet.Cookie{
+			Name:     ck.Name,
+			Value:    s,
+			Domain:   ck.Domain,
+			Path:     ck.Path,
+			Size:     ck.Size,
+			Expires:  ck.Expiry,
+			HttpOnly: ck.HTTPOnly,
+			Secure:   ck.Secure,
+			Session:  ck.Expiry == 0,
+			SameSite: sameSite[ck.SameSite],
+		})
+	}
+
+	return cookies, nil
+}
+
+// SetCookies sets the cookies, in the partition of the current context.
+func (c *Client) SetCookies(cookies []godet.Cookie) error {
+	for _, ck := range cookies {
+		params := map[string]interface{}{
+			"name":     ck.Name,
+			"value":    map[string]interface{}{"type": "string", "value": ck.Value},
+			"domain":   ck.Domain,
+			"httpOnly": ck.HttpOnly,
+			"secure":   ck.Secure,
+		}
+		if ck.Path != "" {
+			params["path"] = ck.Path
+		}
+		if s := sameSite[ck.SameSite]; s != "" {
+			params["sameSite"] = s
+		}
+		if !ck.Session && ck.Expires > 0 {
+			params["expiry"] = int64(ck.Expires)
+		}
+
+		if err := c.Send("storage.setCookie", map[string]interface{}{
+			"cookie":    params,
+			"partition": map[string]interface{}{"type": "context", "context": c.Context},
+		}, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ScriptError is returned when the evaluated script throws an exception.
+type ScriptError struct {
+	Text         string `json:"text"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+}
+
+func (err ScriptError) Error() string {
+	return fmt.Sprintf("%v (%v:%v)", err.Text, err.LineNumber, err.ColumnNumber)
+}
+
+// RemoteValue is a serialized Javascript value.
+type RemoteValue struct {
+	Type   string          `json:"type"`
+	Value  json.RawMessage `json:"value"`
+	Handle string          `json:"handle"`
+}
+
+// GoValue converts the remote value into the equivalent Go value (as json.Unmarshal would):
+// objects and maps become map[string]interface{}, arrays and sets []interface{}, bigints and dates strings.
+// Values that can't be serialized (nodes, functions, windows...) are returned as the type name.
+func (v RemoteValue) GoValue() (interface{}, error) {
+	switch v.Type {
+	case "undefined", "null":
+		return nil, nil
+
+	case "string", "boolean", "bigint", "date":
+		var val interface{}
+		err := json.Unmarshal(v.Value, &val)
+		return val, err
+
+	case "number":
+		var val interface{}
+		if err := json.Unmarshal(v.Value, &val); err != nil {
+			return nil, err
+		}
+
+		if s, ok := val.(string); ok { // NaN, -0, Infinity, -Infinity
+			switch s {
+			case "NaN":
+				return math.NaN(), nil
+			case "-0":
+				return math.Copysign(0, -1), nil
+			case "Infinity":
+				return math.Inf(1), nil
+			case "-Infinity":
+				return math.Inf(-1), nil
+			}
+
+			return strconv.ParseFloat(s, 64)
+		}
+
+		return val, nil
+
+	case "array", "set":
+		var items []RemoteValue
+		if err := json.Unmarshal(v.Value, &items); err != nil {
+			return nil, err
+		}
+
+		list := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			val, err := item.GoValue()
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, val)
+		}
+
+		return list, nil
+
+	case "object", "map":
+		var entries [][2]json.RawMessage
+		if err := json.Unmarshal(v.Value, &entries); err != nil {
+			return nil, err
+		}
+
+		m := map[string]interface{}{}
+
+		for _, e := range entries {
+			var key string
+			if err := json.Unmarshal(e[0], &key); err != nil { // not a string: a serialized key
+				var rkey RemoteValue
+				if err := json.Unmarshal(e[0], &rkey); err != nil {
+					return nil, err
+				}
+
+				k, err := rkey.GoValue()
+				if err != nil {
+					return nil, err
+				}
+
+				key = fmt.Sprint(k)
+			}
+
+			var rval RemoteValue
+			if err := json.Unmarshal(e[1], &rval); err != nil {
+				return nil, err
+			}
+
+			val, err := rval.GoValue()
+			if err != nil {
+				return nil, err
+			}
+
+			m[key] = val
+		}
+
+		return m, nil
+	}
+
+	return v.Type, nil
+}
+
+// EvaluateIn evaluates a Javascript expression in the browsing context, awaiting the result if it's a promise.
+func (c *Client) EvaluateIn(context, expr string) (interface{}, error) {
+	var res struct {
+		Type             string      `json:"type"` // success or exception
+		Result           RemoteValue `json:"result"`
+		ExceptionDetails ScriptError `json:"exceptionDetails"`
+	}
+
+	if err := c.Send("script.evaluate", map[string]interface{}{
+		"expression":      expr,
+		"target":          map[string]interface{}{"context": context},
+		"awaitPromise":    true,
+		"resultOwnership": "none",
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	if res.Type == "exception" {
+		return nil, res.ExceptionDetails
+	}
+
+	return res.Result.GoValue()
+}
@@ -0,0 +1,68 @@
+package godet
+
+// BrowserContext is an isolated browser context, like an incognito profile:
+// its tabs don't share cookies, storage and cache with the other contexts.
+type BrowserContext struct {
+	remote *RemoteDebugger
+
+	// ID is the browserContextId.
+	ID string
+
+	proxyUsername string
+	proxyPassword string
+}
+
+// NewBrowserContext creates a new isolated browser context.
+// Options can be used to set a proxy for the context (see ContextProxy).
+func (remote *RemoteDebugger) NewBrowserContext(options ...BrowserContextOption) (*BrowserContext, error) {
+	params := Params{"disposeOnDetach": true}
+
+	for _, opt := range options {
+		opt(params)
+	}
+
+	res, err := remote.SendRequest("Target.createBrowserContext", params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BrowserContext{remote: remote, ID: Params(res).String("browserContextId")}, nil
+}
+
+// NewTab creates a tab in the browser context and returns a new connection to it,
+// with the same settings as the connection that created the context.
+func (bc *BrowserContext) NewTab(url string) (*RemoteDebugger, error) {
+	if url == "" {
+		url = "about:blank"
+	}
+
+	res, err := bc.remote.SendRequest("Target.createTarget", Params{
+		"url":              url,
+		"browserContextId": bc.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tab, err := bc.remote.connectTab(&Tab{ID: Params(res).String("targetId")})
+	if err != nil {
+		return nil, err
+	}
+
+	if bc.proxyUsername != "" {
+		if err := tab.SetProxyCredentials(bc.proxyUsername, bc.proxyPassword); err != nil {
+			tab.Close()
+			return nil, err
+		}
+	}
+
+	return tab, nil
+}
+
+// Close closes all the tabs in the browser context and disposes of it.
+func (bc *BrowserContext) Close() error {
+	_, err := bc.remote.SendRequest("Target.disposeBrowserContext", Params{
+		"browserContextId": bc.ID,
+	})
+	return err
+}
@@ -0,0 +1,113 @@
+package godet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorNoStats is returned by EnforceBudget if the network statistics are not collected (see CollectStats).
+var ErrorNoStats = errors.New("network statistics not collected")
+
+// PageBudget are the page weight and performance thresholds checked by EnforceBudget.
+// A zero value means no limit.
+type PageBudget struct {
+	TotalKB  int `json:"totalKB"`  // total transferred size, in KB
+	JSKB     int `json:"jsKB"`     // transferred size of the scripts, in KB
+	ImageKB  int `json:"imageKB"`  // transferred size of the images, in KB
+	Requests int `json:"requests"` // number of requests
+	LCPms    int `json:"lcpMs"`    // Largest Contentful Paint, in milliseconds
+}
+
+// BudgetViolation is a threshold exceeded by the page.
+type BudgetViolation struct {
+	Metric string  `json:"metric"` // "totalKB", "jsKB", "imageKB", "requests" or "lcpMs"
+	Limit  float64 `json:"limit"`
+	Actual float64 `json:"actual"`
+}
+
+func (v BudgetViolation) String() string {
+	return fmt.Sprintf("%v: %v exceeds the budget of %v", v.Metric, v.Actual, v.Limit)
+}
+
+// largestContentfulPaintJS returns the Largest Contentful Paint (in milliseconds) of the current page, or 0.
+const largestContentfulPaintJS = `new Promise(function(resolve) {
+	var lcp = 0;
+	try {
+		new PerformanceObserver(function(list) {
+			list.getEntries().forEach(function(e) { lcp = Math.max(lcp, e.startTime); });
+		}).observe({type: "largest-contentful-paint", buffered: true});
+	} catch (e) {}
+	setTimeout(function() { resolve(lcp); }, 100);
+})`
+
+// LargestContentfulPaint returns the Largest Contentful Paint of the current page, in milliseconds
+// (0 if not available).
+func (remote *RemoteDebugger) LargestContentfulPaint() (float64, error) {
+	res, err := remote.Evaluate(largestContentfulPaintJS, AwaitPromise(true))
+	if err != nil {
+		return 0, err
+	}
+
+	lcp, _ := res.(float64)
+	return lcp, nil
+}
+
+// EnforceBudget checks the network statistics collected so far (see CollectStats) and the Largest
+// Contentful Paint of the current page against the budget, and returns the exceeded thresholds
+// (none if the page is within the budget).
+//
+// It's meant for CI performance gates: enable CollectStats (and NetworkEvents) before loading the page,
+// then call EnforceBudget once it's loaded. ErrorNoStats is returned if the budget has network thresholds
+// but the statistics are not collected.
+func (remote *RemoteDebugger) EnforceBudget(budget PageBudget) ([]BudgetViolation, error) {
+	var violations []BudgetViolation
+
+	check := func(metric string, limit int, actual float64) {
+		if limit > 0 && actual > float64(limit) {
+			violations = append(violations, BudgetViolation{Metric: metric, Limit: float64(limit), Actual: actual})
+		}
+	}
+
+	if budget.TotalKB > 0 || budget.JSKB > 0 || budget.ImageKB > 0 || budget.Requests > 0 {
+		remote.Lock()
+		collecting := remote.stats != nil
+		remote.Unlock()
+
+		if !collecting {
+			return nil, ErrorNoStats
+		}
+
+		var total, js, images int64
+		requests := 0
+
+		for _, st := range remote.Stats() {
+			total += st.BytesReceived
+			requests += st.Requests
+
+			switch st.ResourceType {
+			case ResourceTypeScript:
+				js += st.BytesReceived
+			case ResourceTypeImage:
+				images += st.BytesReceived
+			}
+		}
+
+		kb := func(n int64) float64 { return float64(n*10/1024) / 10 }
+
+		check("totalKB", budget.TotalKB, kb(total))
+		check("jsKB", budget.JSKB, kb(js))
+		check("imageKB", budget.ImageKB, kb(images))
+		check("requests", budget.Requests, float64(requests))
+	}
+
+	if budget.LCPms > 0 {
+		lcp, err := remote.LargestContentfulPaint()
+		if err != nil {
+			return nil, err
+		}
+
+		check("lcpMs", budget.LCPms, float64(int(lcp)))
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,75 @@
+package godet
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEnforceBudget(t *testing.T) {
+	var lcps int32
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		if method == "Runtime.evaluate" {
+			atomic.AddInt32(&lcps, 1)
+			return Params{"result": Params{"type": "number", "value": 2512.7}}, nil
+		}
+
+		return nil, nil
+	}))
+
+	if _, err := remote.EnforceBudget(PageBudget{Requests: 10}); err != ErrorNoStats {
+		t.Errorf("expected ErrorNoStats, got %v", err)
+	}
+
+	remote.CollectStats(true)
+
+	load := func(id, url string, resourceType ResourceType, size int) {
+		fakeEvent(remote, "Network.requestWillBeSent", Params{
+			"requestId": id,
+			"type":      resourceType,
+			"request":   Params{"url": url, "method": "GET"},
+		})
+		fakeEvent(remote, "Network.loadingFinished", Params{"requestId": id, "encodedDataLength": size})
+	}
+
+	load("1", "https://example.com/", ResourceTypeDocument, 20*1024)
+	load("2", "https://example.com/app.js", ResourceTypeScript, 150*1024+512)
+	load("3", "https://cdn.example.net/lib.js", ResourceTypeScript, 50*1024)
+	load("4", "https://example.com/logo.png", ResourceTypeImage, 30*1024)
+
+	violations, err := remote.EnforceBudget(PageBudget{TotalKB: 300, JSKB: 200, ImageKB: 20, Requests: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []BudgetViolation{
+		{Metric: "jsKB", Limit: 200, Actual: 200.5},
+		{Metric: "imageKB", Limit: 20, Actual: 30},
+		{Metric: "requests", Limit: 3, Actual: 4},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations %+v, want %+v", violations, want)
+	}
+
+	if n := atomic.LoadInt32(&lcps); n != 0 {
+		t.Errorf("LCP evaluated %v times without an LCP budget", n)
+	}
+
+	if s := want[0].String(); s != "jsKB: 200.5 exceeds the budget of 200" {
+		t.Errorf("unexpected violation message %q", s)
+	}
+
+	violations, err = remote.EnforceBudget(PageBudget{LCPms: 2500})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []BudgetViolation{{Metric: "lcpMs", Limit: 2500, Actual: 2512}}; !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations %+v, want %+v", violations, want)
+	}
+
+	if violations, err := remote.EnforceBudget(PageBudget{TotalKB: 300, LCPms: 3000}); err != nil || violations != nil {
+		t.Errorf("page within the budget: %+v, %v", violations, err)
+	}
+}
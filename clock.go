@@ -0,0 +1,168 @@
+package godet
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrorNoBrowserClock is returned by CalibrateClock when the browser clocks cannot be read.
+var ErrorNoBrowserClock = errors.New("cannot read the browser clock")
+
+// clockSamples is the number of samples taken by CalibrateClock (the one with the shortest round trip is used).
+const clockSamples = 3
+
+// Clock converts the browser timestamps to local time.
+//
+// The protocol uses two kinds of timestamps: monotonic times (seconds since an arbitrary point,
+// i.e. the "timestamp" of the Network and Page events) and wall clock times (seconds or milliseconds
+// since the epoch, according to the browser clock, i.e. the "wallTime" of Network.requestWillBeSent
+// or the "timestamp" of Runtime.consoleAPICalled). Clock maps both to the local clock, so that the
+// times of all the events can be compared and reported consistently.
+type Clock struct {
+	// Skew is how much the browser wall clock is ahead of the local clock.
+	Skew time.Duration
+
+	// RoundTrip is the round trip time of the samples used for calibration: the conversions
+	// are accurate to about half of it.
+	RoundTrip time.Duration
+
+	// Calibrated is the local time of the calibration.
+	Calibrated time.Time
+
+	monotonic float64 // local time (seconds since the epoch) minus the browser monotonic time
+}
+
+// CalibrateClock samples the browser clocks and returns a Clock, to convert the browser timestamps to local time.
+//
+// The wall clock is sampled by evaluating performance.timeOrigin + performance.now() in the page,
+// the monotonic clock with Performance.getMetrics (enabling the Performance domain, if needed):
+// each sample is matched to the local time half way through its request.
+func (remote *RemoteDebugger) CalibrateClock() (*Clock, error) {
+	if err := remote.ensureDomain("Performance"); err != nil {
+		return nil, err
+	}
+
+	clock := &Clock{}
+
+	var wallRTT, monoRTT time.Duration
+
+	for i := 0; i < clockSamples; i++ {
+		start := time.Now()
+		res, err := remote.Evaluate("performance.timeOrigin + performance.now()")
+		rtt := time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+
+		browserWall, ok := res.(float64)
+		if !ok {
+			return nil, ErrorNoBrowserClock
+		}
+
+		if i == 0 || rtt < wallRTT {
+			local := start.Add(rtt / 2)
+			wallRTT = rtt
+			clock.Skew = time.Duration(browserWall*float64(time.Millisecond)) - time.Duration(local.UnixNano())
+		}
+
+		start = time.Now()
+		metrics, err := remote.SendRequest("Performance.getMetrics", nil)
+		rtt = time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+
+		mono := metricValue(metrics, "Timestamp")
+		if mono == 0 {
+			return nil, ErrorNoBrowserClock
+		}
+
+		if i == 0 || rtt < monoRTT {
+			local := start.Add(rtt / 2)
+			monoRTT = rtt
+			clock.monotonic = float64(local.UnixNano())/1e9 - mono
+		}
+	}
+
+	clock.RoundTrip = wallRTT
+	if monoRTT > wallRTT {
+		clock.RoundTrip = monoRTT
+	}
+
+	clock.Calibrated = time.Now()
+	return clock, nil
+}
+
+// metricValue returns the value of the named metric, in a Performance.getMetrics reply.
+func metricValue(res map[string]interface{}, name string) float64 {
+	metrics, _ := res["metrics"].([]interface{})
+
+	for _, m := range metrics {
+		if m, ok := m.(map[string]interface{}); ok && m["name"] == name {
+			v, _ := m["value"].(float64)
+			return v
+		}
+	}
+
+	return 0
+}
+
+// secondsTime converts seconds since the epoch to a time.Time.
+func secondsTime(secs float64) time.Time {
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+// Monotonic converts a browser monotonic time (in seconds) to local time.
+func (c *Clock) Monotonic(ts float64) time.Time {
+	return secondsTime(ts + c.monotonic)
+}
+
+// WallTime converts a browser wall clock time (in seconds since the epoch) to local time.
+func (c *Clock) WallTime(ts float64) time.Time {
+	return secondsTime(ts).Add(-c.Skew)
+}
+
+// Timestamp converts a browser wall clock time in milliseconds since the epoch
+// (i.e. the Runtime and Log timestamps) to local time.
+func (c *Clock) Timestamp(ms float64) time.Time {
+	return c.WallTime(ms / 1000)
+}
+
+// EventTime returns the local time of the event, from its params, or the zero time
+// if the event doesn't have a timestamp. It knows the kind of timestamp used by each domain:
+//
+//   - Runtime and Log (i.e. Runtime.consoleAPICalled, Log.entryAdded): milliseconds since the epoch;
+//   - Page.screencastFrame: seconds since the epoch (in the frame metadata);
+//   - Network.requestWillBeSent and Network.webSocketWillSendHandshakeRequest: the wall time;
+//   - all the other events (i.e. Network, Page.lifecycleEvent, Page.loadEventFired): monotonic time.
+func (c *Clock) EventTime(method string, params Params) time.Time {
+	if wallTime, ok := params["wallTime"].(float64); ok {
+		return c.WallTime(wallTime)
+	}
+
+	if method == "Page.screencastFrame" {
+		if ts, ok := Params(params.Map("metadata"))["timestamp"].(float64); ok {
+			return c.WallTime(ts)
+		}
+
+		return time.Time{}
+	}
+
+	if entry, ok := params["entry"].(map[string]interface{}); ok && strings.HasPrefix(method, "Log.") {
+		params = entry
+	}
+
+	ts, ok := params["timestamp"].(float64)
+	if !ok {
+		return time.Time{}
+	}
+
+	if strings.HasPrefix(method, "Runtime.") || strings.HasPrefix(method, "Log.") {
+		return c.Timestamp(ts)
+	}
+
+	return c.Monotonic(ts)
+}
@@ -0,0 +1,301 @@
+package godet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobs/httpclient"
+)
+
+// ErrorNoEndpoints is returned by Cluster.Acquire if no browser endpoint is available
+var ErrorNoEndpoints = errors.New("no endpoints available")
+
+// defaultMaxBackoff is the default Cluster.MaxBackoff.
+const defaultMaxBackoff = 5 * time.Minute
+
+// errEndpointBusy is returned by Cluster.open if the endpoint became full or down after it was selected.
+var errEndpointBusy = errors.New("endpoint busy")
+
+// EndpointResolver returns the current list of browser endpoints (host:port).
+type EndpointResolver func(ctx context.Context) ([]string, error)
+
+// StaticEndpoints returns a resolver for a fixed list of endpoints.
+func StaticEndpoints(addrs ...string) EndpointResolver {
+	return func(ctx context.Context) ([]string, error) {
+		return addrs, nil
+	}
+}
+
+// SRVEndpoints returns a resolver that looks up the endpoints in the DNS SRV records for
+// _service._proto.name (i.e. a Kubernetes headless service with a named port).
+// If service and proto are empty, name is looked up directly.
+func SRVEndpoints(service, proto, name string) EndpointResolver {
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var addrs []string
+		for _, r := range records {
+			addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(r.Target, "."), fmt.Sprint(r.Port)))
+		}
+
+		return addrs, nil
+	}
+}
+
+// EndpointStatus is the state of a browser endpoint in a Cluster.
+type EndpointStatus struct {
+	Addr     string
+	Tabs     int       // tabs currently allocated
+	Failures int       // consecutive failures
+	Down     time.Time // if in the future, the endpoint is not used until then
+}
+
+type endpoint struct {
+	EndpointStatus
+	remote *RemoteDebugger // not connected, used for the HTTP endpoints
+}
+
+// Cluster allocates tabs across multiple browsers (i.e. a headless Chrome deployment), picking
+// the endpoint with the fewest allocated tabs. The endpoints are resolved again periodically, so that
+// browsers can be added and removed, and the endpoints failing to open tabs are skipped for a while.
+type Cluster struct {
+	// MaxTabs is the maximum number of tabs per endpoint (0 for no limit).
+	MaxTabs int
+
+	// Refresh is how often the endpoints are resolved again (default 30 seconds).
+	Refresh time.Duration
+
+	// Backoff is how long a failing endpoint is skipped, doubling at each consecutive failure (default 5 seconds),
+	// up to MaxBackoff (default 5 minutes).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	resolve EndpointResolver
+	verbose bool
+
+	sync.Mutex
+	endpoints map[string]*endpoint
+	conns     map[*RemoteDebugger]*endpoint
+	resolved  time.Time
+}
+
+// NewCluster returns a Cluster using the endpoints returned by resolve.
+func NewCluster(resolve EndpointResolver, maxTabs int, verbose bool) *Cluster {
+	return &Cluster{
+		MaxTabs:    maxTabs,
+		Refresh:    30 * time.Second,
+		Backoff:    5 * time.Second,
+		MaxBackoff: defaultMaxBackoff,
+		resolve:    resolve,
+		verbose:    verbose,
+		endpoints:  map[string]*endpoint{},
+		conns:      map[*RemoteDebugger]*endpoint{},
+	}
+}
+
+// Resolve updates the list of endpoints. Endpoints that are gone are not used anymore,
+// but their allocated tabs can still be released.
+func (c *Cluster) Resolve(ctx context.Context) error {
+	addrs, err := c.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	current := map[string]bool{}
+
+	for _, addr := range addrs {
+		current[addr] = true
+
+		if c.endpoints[addr] == nil {
+			c.endpoints[addr] = &endpoint{
+				EndpointStatus: EndpointStatus{Addr: addr},
+				remote:         newRemoteDebugger(httpclient.NewHttpClient("http://"+addr), c.verbose),
+			}
+		}
+	}
+
+	for addr := range c.endpoints {
+		if !current[addr] {
+			delete(c.endpoints, addr)
+		}
+	}
+
+	c.resolved = time.Now()
+	return nil
+}
+
+// Endpoints returns the status of the current endpoints.
+func (c *Cluster) Endpoints() []EndpointStatus {
+	c.Lock()
+	defer c.Unlock()
+
+	var list []EndpointStatus
+	for _, ep := range c.endpoints {
+		list = append(list, ep.EndpointStatus)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Addr < list[j].Addr })
+	return list
+}
+
+// available returns true if the endpoint can open a new tab. Must be called with the lock held.
+func (c *Cluster) available(ep *endpoint, now time.Time) bool {
+	return !ep.Down.After(now) && (c.MaxTabs <= 0 || ep.Tabs < c.MaxTabs)
+}
+
+// backoff returns how long an endpoint is skipped after the specified number of consecutive failures.
+func (c *Cluster) backoff(failures int) time.Duration {
+	max := c.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	d := c.Backoff
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return d
+}
+
+// candidates returns the available endpoints, least loaded first.
+func (c *Cluster) candidates() []*endpoint {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+
+	var list []*endpoint
+	for _, ep := range c.endpoints {
+		if !c.available(ep, now) {
+			continue
+		}
+
+		list = append(list, ep)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Tabs != list[j].Tabs {
+			return list[i].Tabs < list[j].Tabs
+		}
+		return list[i].Addr < list[j].Addr
+	})
+
+	return list
+}
+
+// open opens a tab on the endpoint, updating its status.
+// It returns errEndpointBusy if the endpoint is not available anymore (i.e. another Acquire got the last tab).
+func (c *Cluster) open(ep *endpoint) (*RemoteDebugger, error) {
+	c.Lock()
+	if !c.available(ep, time.Now()) {
+		c.Unlock()
+		return nil, errEndpointBusy
+	}
+	ep.Tabs++
+	c.Unlock()
+
+	tab, err := ep.remote.createTab("about:blank")
+
+	var conn *RemoteDebugger
+	if err == nil {
+		if conn, err = ep.remote.connectTab(tab); err != nil {
+			ep.remote.CloseTab(tab)
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if err != nil {
+		ep.Tabs--
+		ep.Failures++
+		ep.Down = time.Now().Add(c.backoff(ep.Failures))
+		return nil, err
+	}
+
+	ep.Failures = 0
+	ep.Down = time.Time{}
+	c.conns[conn] = ep
+	return conn, nil
+}
+
+// Acquire opens a new tab on the least loaded endpoint and returns a connection to it.
+// If all the endpoints are busy (see MaxTabs) it waits until one is released, or the context is done.
+// The tab must be released with Release.
+func (c *Cluster) Acquire(ctx context.Context) (*RemoteDebugger, error) {
+	for {
+		c.Lock()
+		stale := time.Since(c.resolved) > c.Refresh
+		c.Unlock()
+
+		if stale {
+			if err := c.Resolve(ctx); err != nil && c.verbose {
+				log.Println("cannot resolve endpoints", err)
+			}
+		}
+
+		var lastErr error
+
+		for _, ep := range c.candidates() {
+			conn, err := c.open(ep)
+			if err == nil {
+				return conn, nil
+			}
+
+			if err != errEndpointBusy {
+				lastErr = err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ErrorNoEndpoints
+			}
+			return nil, fmt.Errorf("%v: %v", ctx.Err(), lastErr)
+
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// Release closes the tab acquired with Acquire, and its connection.
+func (c *Cluster) Release(conn *RemoteDebugger) error {
+	c.Lock()
+	ep := c.conns[conn]
+	delete(c.conns, conn)
+	if ep != nil {
+		ep.Tabs--
+	}
+	c.Unlock()
+
+	conn.Lock()
+	id := conn.current
+	conn.Unlock()
+
+	conn.Close()
+
+	if ep == nil {
+		return nil
+	}
+
+	return ep.remote.CloseTab(&Tab{ID: id})
+}
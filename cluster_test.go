@@ -0,0 +1,50 @@
+package godet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClusterBackoff(t *testing.T) {
+	c := NewCluster(StaticEndpoints(), 0, false)
+
+	tests := []struct {
+		failures int
+		backoff  time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{7, 5 * time.Minute},
+		{100, 5 * time.Minute}, // no overflow
+	}
+
+	for _, tt := range tests {
+		if d := c.backoff(tt.failures); d != tt.backoff {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, d, tt.backoff)
+		}
+	}
+
+	c.MaxBackoff = 0
+	if d := c.backoff(1000); d != defaultMaxBackoff {
+		t.Errorf("backoff without MaxBackoff = %v", d)
+	}
+}
+
+func TestClusterOpenBusy(t *testing.T) {
+	c := NewCluster(StaticEndpoints(), 2, false)
+
+	// the endpoints are not reachable: open must fail before trying to create a tab
+	full := &endpoint{EndpointStatus: EndpointStatus{Addr: "full:9222", Tabs: 2}}
+	down := &endpoint{EndpointStatus: EndpointStatus{Addr: "down:9222", Down: time.Now().Add(time.Minute)}}
+
+	for _, ep := range []*endpoint{full, down} {
+		if _, err := c.open(ep); err != errEndpointBusy {
+			t.Errorf("open(%s) = %v, want %v", ep.Addr, err, errEndpointBusy)
+		}
+	}
+
+	if full.Tabs != 2 || down.Tabs != 0 {
+		t.Errorf("tabs = %d, %d", full.Tabs, down.Tabs)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// a11yCommand executes the "a11y" command: it loads the page, runs axe-core and writes the violations.
+// It returns the number of violations.
+func a11yCommand(remote *godet.RemoteDebugger, w io.Writer, args []string, timeout time.Duration) (int, error) {
+	fs := flag.NewFlagSet("a11y", flag.ContinueOnError)
+	url := fs.String("url", "", "page to check (default the current page)")
+	axe := fs.String("axe", "", "axe-core script to inject (default downloaded from "+godet.AxeScriptURL+")")
+	format := fs.String("format", "json", "output format (json, html)")
+	out := fs.String("o", "", "output file (default stdout)")
+	tags := fs.String("tags", "", "only run the rules with the comma separated tags (i.e. wcag2a,wcag2aa)")
+	context := fs.String("context", "", "only check the elements matching the selector")
+
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+
+	if *format != "json" && *format != "html" {
+		return 0, errors.New("invalid format " + *format)
+	}
+
+	if *axe != "" {
+		script, err := ioutil.ReadFile(*axe)
+		if err != nil {
+			return 0, err
+		}
+
+		godet.AxeScript = string(script)
+	}
+
+	if *url == "" {
+		*url = fs.Arg(0)
+	}
+
+	if *url != "" {
+		if _, err := remote.NavigateAndWait(*url, timeout); err != nil {
+			return 0, err
+		}
+	}
+
+	var options []godet.AxeOption
+	if *tags != "" {
+		options = append(options, godet.AxeTags(strings.Split(*tags, ",")...))
+	}
+	if *context != "" {
+		options = append(options, godet.AxeContext(*context))
+	}
+
+	res, err := remote.RunAxe(options...)
+	if err != nil {
+		return 0, err
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return 0, err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "html" {
+		err = res.WriteHTML(w)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(res)
+	}
+
+	return len(res.Violations), err
+}
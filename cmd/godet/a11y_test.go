@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/raff/godet"
+)
+
+func TestA11yCommand(t *testing.T) {
+	defer func() { godet.AxeScript = "" }()
+
+	var lock sync.Mutex
+	var scripts, runs []string
+
+	remote := fakeBrowser(t, func(expr string) interface{} {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case strings.HasPrefix(expr, "typeof axe"):
+			return len(scripts) > 0
+
+		case strings.Contains(expr, "axe.run"):
+			runs = append(runs, expr[strings.LastIndex(expr, "})(")+3:len(expr)-1])
+			return `{"url": "http://example.com/", "engine": "axe-core 4.8.4", "passes": 3,
+				"violations": [{"id": "image-alt", "impact": "critical", "help": "Images must have alternate text",
+					"nodes": [{"selector": "img.logo", "html": "<img class=\"logo\">", "impact": "critical"}]}]}`
+		}
+
+		scripts = append(scripts, expr)
+		return nil
+	})
+
+	dir := t.TempDir()
+	axe := filepath.Join(dir, "axe.min.js")
+	if err := ioutil.WriteFile(axe, []byte("window.axe = {};"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+
+	violations, err := a11yCommand(remote, &b, []string{"-axe", axe, "-tags", "wcag2a,wcag2aa", "-context", "#main"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if violations != 1 {
+		t.Errorf("violations = %v, want 1", violations)
+	}
+
+	var res godet.AxeResult
+	if err := json.Unmarshal([]byte(b.String()), &res); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%v", err, b.String())
+	}
+
+	if res.Engine != "axe-core 4.8.4" || res.Passes != 3 || len(res.Violations) != 1 || res.Violations[0].Nodes[0].Selector != "img.logo" {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	report := filepath.Join(dir, "a11y.html")
+
+	if _, err := a11yCommand(remote, &b, []string{"-format", "html", "-o", report}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := ioutil.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(html), "critical: Images must have alternate text (image-alt)") {
+		t.Errorf("unexpected report:\n%s", html)
+	}
+
+	lock.Lock()
+	if len(scripts) != 1 || scripts[0] != "window.axe = {};" {
+		t.Errorf("injected scripts %q, want the -axe file once", scripts)
+	}
+
+	wantRuns := `{"context":"#main","runOnly":{"type":"tag","values":["wcag2a","wcag2aa"]}}` + "\n{}"
+	if strings.Join(runs, "\n") != wantRuns {
+		t.Errorf("axe runs %q, want %q", runs, wantRuns)
+	}
+	lock.Unlock()
+
+	if _, err := a11yCommand(remote, &b, []string{"-format", "xml"}, 0); err == nil || err.Error() != "invalid format xml" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
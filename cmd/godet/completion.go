@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// commands are the commands accepted as the first argument (instead of the URL to load).
+var commands = []string{"protocol", "pick", "codegen", "a11y", "completion"}
+
+const commandsUsage = `
+Commands:
+  godet [flags] protocol list [domain]
+	list the protocol methods supported by the browser
+  godet [flags] protocol describe Domain.method
+	print the documentation of a protocol method, event, type or domain
+  godet [flags] pick [url]
+	wait for a click on an element in the (headful) browser and print its CSS selector and XPath
+  godet [flags] codegen [url]
+	record the actions performed in the (headful) browser and print them as a Go program
+	or a scenario (see -codegen-format)
+  godet [flags] a11y [-url url] [-format json|html] [-o file] [-axe axe.min.js] [-tags wcag2a,wcag2aa] [-context selector]
+	run the axe-core accessibility checks on the page and print the violations
+  godet completion bash|zsh
+	print the shell completion script (i.e. source <(godet completion bash))
+`
+
+const bashCompletion = `# bash completion for %[1]v (source <(%[1]v completion bash))
+_%[2]v_complete() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+	local i cmd=""
+
+	for ((i=1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		protocol|pick|codegen|a11y|completion) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	case "$cmd/$prev" in
+	protocol/protocol)
+		COMPREPLY=($(compgen -W "list describe" -- "$cur")); return ;;
+	protocol/describe|protocol/list)
+		COMPREPLY=($(compgen -W "$("${COMP_WORDS[0]}" protocol list 2>/dev/null)" -- "$cur")); return ;;
+	completion/completion)
+		COMPREPLY=($(compgen -W "bash zsh" -- "$cur")); return ;;
+	esac
+
+	if [[ -n "$cmd" ]]; then
+		return
+	fi
+
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "%[3]v" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -W "%[4]v" -- "$cur"))
+	fi
+}
+complete -o default -F _%[2]v_complete %[1]v
+`
+
+const zshCompletion = `#compdef %[1]v
+# zsh completion for %[1]v (source <(%[1]v completion zsh))
+autoload -U +X bashcompinit && bashcompinit
+`
+
+// completionCommand prints the completion script for the shell.
+func completionCommand(w io.Writer, prog string, args []string) error {
+	if len(args) != 1 || (args[0] != "bash" && args[0] != "zsh") {
+		return fmt.Errorf("usage: %v completion bash|zsh", prog)
+	}
+
+	prog = filepath.Base(prog)
+
+	var flags []string
+	flag.VisitAll(func(f *flag.Flag) { flags = append(flags, "-"+f.Name) })
+
+	fname := strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+	script := fmt.Sprintf(bashCompletion, prog, fname, strings.Join(flags, " "), strings.Join(commands, " "))
+
+	if args[0] == "zsh" {
+		script = fmt.Sprintf(zshCompletion, prog) + script
+	}
+
+	_, err := io.WriteString(w, script)
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompletionCommand(t *testing.T) {
+	var b strings.Builder
+
+	if err := completionCommand(&b, "/usr/local/bin/godet.exe", []string{"bash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	script := b.String()
+
+	for _, want := range []string{
+		"_godet_exe_complete() {",
+		"complete -o default -F _godet_exe_complete godet.exe",
+		`compgen -W "protocol pick codegen a11y completion"`,
+		"-test.run", // the flags defined on flag.CommandLine
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("bash script doesn't contain %q:\n%v", want, script)
+		}
+	}
+
+	if strings.HasPrefix(script, "#compdef") {
+		t.Errorf("bash script starts with the zsh header")
+	}
+
+	b.Reset()
+
+	if err := completionCommand(&b, "godet", []string{"zsh"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if script := b.String(); !strings.HasPrefix(script, "#compdef godet\n") || !strings.Contains(script, "bashcompinit") || !strings.Contains(script, "-F _godet_complete godet") {
+		t.Errorf("unexpected zsh script:\n%v", script)
+	}
+
+	for _, args := range [][]string{nil, {"fish"}, {"bash", "zsh"}} {
+		if err := completionCommand(&b, "godet", args); err == nil || err.Error() != "usage: godet completion bash|zsh" {
+			t.Errorf("completion %v: unexpected error %v", args, err)
+		}
+	}
+}
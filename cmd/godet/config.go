@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// configFiles are the configuration files looked up in the current directory, if -config is not set.
+var configFiles = []string{"godet.yaml", "godet.yml", "godet.json"}
+
+// parseConfig parses the configuration, in JSON (an object, with arrays joined by spaces) or in a YAML subset:
+// top level "key: value" lines (with optionally quoted values), comments and "---".
+// Nested keys, lists and multi-line values are not supported.
+func parseConfig(data []byte, isJSON bool) (map[string]string, error) {
+	config := map[string]string{}
+
+	if isJSON {
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+
+		for k, v := range values {
+			switch v := v.(type) {
+			case []interface{}:
+				var parts []string
+				for _, p := range v {
+					parts = append(parts, fmt.Sprint(p))
+				}
+				config[k] = strings.Join(parts, " ")
+
+			default:
+				config[k] = fmt.Sprint(v)
+			}
+		}
+
+		return config, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for n := 1; scanner.Scan(); n++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line == "---" {
+			continue
+		}
+
+		if raw[0] == ' ' || raw[0] == '\t' {
+			return nil, fmt.Errorf("line %v: nested keys are not supported (only top level key: value lines)", n)
+		}
+		if line[0] == '-' {
+			return nil, fmt.Errorf("line %v: lists are not supported (use a space separated value)", n)
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("line %v: expected key: value", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+
+		if j := strings.Index(value, " #"); j >= 0 {
+			value = strings.TrimSpace(value[:j])
+		} else if strings.HasPrefix(value, "#") {
+			value = ""
+		}
+
+		switch {
+		case value == "":
+			return nil, fmt.Errorf("line %v: missing value for %v (nested keys are not supported)", n, key)
+		case value == "|" || value == ">" || value[0] == '[' || value[0] == '{':
+			return nil, fmt.Errorf("line %v: unsupported value for %v (only single line scalars are supported)", n, key)
+		}
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		config[key] = value
+	}
+
+	return config, scanner.Err()
+}
+
+// envPrefix is the prefix of the environment variables that set the flags: the flag name is uppercased,
+// with '-' replaced by '_' (i.e. -serve-tabs is set by GODET_SERVE_TABS).
+const envPrefix = "GODET_"
+
+// envAliases are additional environment variables, with the flag they set.
+// The flag variable (i.e. GODET_PORT) wins over its aliases.
+var envAliases = map[string]string{
+	"GODET_BROWSER_URL": "port",
+}
+
+const configUsage = `
+Configuration:
+  The options can also be set via environment variables (i.e. GODET_TIMEOUT=10s, GODET_HEADLESS=new,
+  GODET_BROWSER_URL=http://chrome:9222 for -port) and in a configuration file (see -config, or GODET_CONFIG).
+
+  Precedence: command line flags, then environment variables, then the configuration file, then the defaults.
+  GODET_PORT wins over GODET_BROWSER_URL.
+  If the browser address is configured (GODET_PORT, GODET_BROWSER_URL or "port" in the configuration file)
+  but the browser command isn't, no browser is started.
+
+  The configuration file is JSON (an object) if its extension is .json, otherwise YAML limited to
+  top level "key: value" lines and comments (i.e. "timeout: 10s"), where the keys are the flag names.
+  Nested keys, lists and multi-line values are not supported.
+`
+
+// envName returns the environment variable for the flag.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// browserAddress converts a browser URL (i.e. http://chrome:9222/) into the host:port expected by -port.
+func browserAddress(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	return s
+}
+
+// envValues returns the values of the flags set by the environment variables (see envName and envAliases).
+func envValues(names []string) map[string]string {
+	env := map[string]string{}
+
+	for _, name := range names {
+		if v, ok := os.LookupEnv(envName(name)); ok {
+			env[name] = v
+		}
+	}
+
+	var aliases []string
+	for name := range envAliases {
+		aliases = append(aliases, name)
+	}
+
+	sort.Strings(aliases) // if more aliases set the same flag, the first one wins
+
+	for _, name := range aliases {
+		fname := envAliases[name]
+		if _, ok := env[fname]; ok {
+			continue // the flag variable (or a previous alias) wins
+		}
+
+		if v, ok := os.LookupEnv(name); ok {
+			env[fname] = v
+		}
+	}
+
+	if v, ok := env["port"]; ok {
+		env["port"] = browserAddress(v)
+	}
+
+	return env
+}
+
+// configure sets the flags that were not set on the command line from the environment variables
+// and then from the configuration file.
+func configure() error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	portFlag := set["port"]
+
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+
+	for k, v := range envValues(names) {
+		if set[k] {
+			continue // the command line wins
+		}
+
+		if err := flag.Set(k, v); err != nil {
+			return fmt.Errorf("%v: invalid value for %v: %v", envName(k), k, err)
+		}
+
+		set[k] = true
+	}
+
+	if err := loadConfig(flag.Lookup("config").Value.String(), set); err != nil {
+		return err
+	}
+
+	if set["port"] && !portFlag && !set["cmd"] {
+		flag.Set("cmd", "") // connect to the configured browser instead of starting one
+	}
+
+	return nil
+}
+
+// loadConfig reads the configuration file and sets the flags that were not already set.
+// The keys are the flag names (i.e. "cmd", "port", "timeout", "proxy", "device").
+// If path is empty the default configuration files are looked up in the current directory.
+func loadConfig(path string, set map[string]bool) error {
+	if path == "" {
+		for _, name := range configFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+
+		if path == "" {
+			return nil
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	config, err := parseConfig(data, filepath.Ext(path) == ".json")
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+
+	for k, v := range config {
+		if flag.Lookup(k) == nil {
+			return fmt.Errorf("%v: unknown option %q", path, k)
+		}
+
+		if set[k] {
+			continue // the command line and environment win
+		}
+
+		if err := flag.Set(k, v); err != nil {
+			return fmt.Errorf("%v: invalid value for %v: %v", path, k, err)
+		}
+
+		set[k] = true
+	}
+
+	return nil
+}
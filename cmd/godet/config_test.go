@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`---
+# browser
+port: http://chrome:9222 # comment
+timeout: "10s"
+device: 'iPhone X'
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"port": "http://chrome:9222", "timeout": "10s", "device": "iPhone X"}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("config = %q, want %q", config, want)
+	}
+
+	config, err = parseConfig([]byte(`{"port": "chrome:9222", "headless": true, "block": ["a.com", "b.com"]}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = map[string]string{"port": "chrome:9222", "headless": "true", "block": "a.com b.com"}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("config = %q, want %q", config, want)
+	}
+
+	for yaml, msg := range map[string]string{
+		"browser:\n  port: 9222\n": "line 1: missing value for browser",
+		"port: 9222\n  cmd: x\n":   "line 2: nested keys are not supported",
+		"block:\n- a.com\n":        "line 1: missing value for block",
+		"- a.com\n":                "line 1: lists are not supported",
+		"block: [a.com, b.com]\n":  "line 1: unsupported value for block",
+		"script: |\n":              "line 1: unsupported value for script",
+		"port 9222\n":              "line 1: expected key: value",
+	} {
+		if _, err := parseConfig([]byte(yaml), false); err == nil || !strings.HasPrefix(err.Error(), msg) {
+			t.Errorf("parseConfig(%q) = %v, want %q", yaml, err, msg)
+		}
+	}
+}
+
+func TestEnvValues(t *testing.T) {
+	t.Setenv("GODET_BROWSER_URL", "http://chrome:9222/")
+	t.Setenv("GODET_SERVE_TABS", "4")
+
+	want := map[string]string{"port": "chrome:9222", "serve-tabs": "4"}
+	if env := envValues([]string{"port", "serve-tabs", "timeout"}); !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %q, want %q", env, want)
+	}
+
+	t.Setenv("GODET_PORT", "localhost:9333")
+
+	for i := 0; i < 10; i++ { // not dependent on the map order
+		if env := envValues([]string{"port"}); env["port"] != "localhost:9333" {
+			t.Fatalf("port = %q, want GODET_PORT", env["port"])
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	cmd := flag.String("cmd", "chrome", "")
+	port := flag.String("port", "localhost:9222", "")
+	timeout := flag.Duration("timeout", 30*time.Second, "")
+	proxy := flag.String("proxy", "", "")
+	device := flag.String("device", "", "")
+	flag.String("config", "", "")
+
+	config := filepath.Join(t.TempDir(), "godet.yaml")
+	if err := ioutil.WriteFile(config, []byte("port: file:9222\ntimeout: 20s\nproxy: http://proxy:3128\ndevice: iPad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GODET_BROWSER_URL", "http://chrome:9333/")
+	t.Setenv("GODET_TIMEOUT", "10s")
+	t.Setenv("GODET_DEVICE", "iPhone X")
+
+	if err := flag.CommandLine.Parse([]string{"-config", config, "-timeout", "5s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	// command line, then environment, then configuration file
+	if *timeout != 5*time.Second || *port != "chrome:9333" || *device != "iPhone X" || *proxy != "http://proxy:3128" {
+		t.Errorf("timeout = %v, port = %q, device = %q, proxy = %q", *timeout, *port, *device, *proxy)
+	}
+
+	// the browser address is configured: don't start a browser
+	if *cmd != "" {
+		t.Errorf("cmd = %q, want empty", *cmd)
+	}
+}
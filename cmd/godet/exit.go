@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/raff/godet"
+)
+
+// Exit codes, so that pipelines can branch on the failure type.
+const (
+	exitOK         = 0
+	exitError      = 1 // generic error
+	exitUsage      = 2 // invalid flags or configuration
+	exitConnection = 3 // cannot connect to the browser
+	exitNavigation = 4 // navigation failed (network error)
+	exitTimeout    = 5 // navigation or action timeout
+	exitAssertion  = 6 // scenario or assertion failed
+	exitException  = 7 // Javascript exception
+)
+
+// exitKinds are the names of the exit codes, reported by -json-errors.
+var exitKinds = map[int]string{
+	exitError:      "error",
+	exitUsage:      "usage",
+	exitConnection: "connection",
+	exitNavigation: "navigation",
+	exitTimeout:    "timeout",
+	exitAssertion:  "assertion",
+	exitException:  "exception",
+}
+
+const exitUsageText = `
+Exit codes:
+  0 success, 1 generic error, 2 invalid flags or configuration, 3 cannot connect to the browser,
+  4 navigation failed, 5 timeout, 6 scenario or assertion failed, 7 Javascript exception.
+`
+
+// jsonErrors prints the final error as a JSON object on stdout (see -json-errors).
+var jsonErrors bool
+
+// cliError is the error object printed with -json-errors.
+type cliError struct {
+	Kind    string `json:"kind"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+
+	// NetError and Category are set for navigation errors.
+	NetError string `json:"netError,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// classify returns the exit code for the error.
+func classify(err error) int {
+	var navErr godet.NavigationError
+	var evalErr godet.EvaluateError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return exitError
+
+	case err == godet.ErrorTimeout, errors.Is(err, godet.ErrorTimeout), strings.Contains(err.Error(), "timeout"):
+		return exitTimeout
+
+	case errors.As(err, &navErr):
+		return exitNavigation
+
+	case errors.As(err, &evalErr):
+		return exitException
+
+	case errors.As(err, &netErr):
+		return exitConnection
+	}
+
+	return exitError
+}
+
+// fatal reports the error and exits with the code for its type.
+func fatal(msg string, err error) {
+	exit(classify(err), msg, err)
+}
+
+// newCLIError returns the error object for the exit code, message and error.
+func newCLIError(code int, msg string, err error) cliError {
+	ce := cliError{Kind: exitKinds[code], Code: code, Message: msg}
+	if err != nil {
+		ce.Error = err.Error()
+	}
+
+	var navErr godet.NavigationError
+	if errors.As(err, &navErr) {
+		ce.NetError = string(navErr.NetError())
+		ce.Category = string(navErr.Category())
+	}
+
+	return ce
+}
+
+// exit reports the error and exits with the specified code.
+func exit(code int, msg string, err error) {
+	if jsonErrors {
+		json.NewEncoder(os.Stdout).Encode(newCLIError(code, msg, err))
+	}
+
+	if err != nil {
+		log.Println(msg+":", err)
+	} else {
+		log.Println(msg)
+	}
+
+	os.Exit(code)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/raff/godet"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitError},
+		{errors.New("no such file"), exitError},
+		{godet.ErrorTimeout, exitTimeout},
+		{fmt.Errorf("step 2: %w", godet.ErrorTimeout), exitTimeout},
+		{errors.New("timeout waiting for element to be visible"), exitTimeout},
+		{godet.NavigationError("net::ERR_NAME_NOT_RESOLVED"), exitNavigation},
+		{fmt.Errorf("login: %w", godet.NavigationError("net::ERR_CONNECTION_REFUSED")), exitNavigation},
+		{godet.EvaluateError{ErrorDetails: map[string]interface{}{"description": "ReferenceError: x is not defined"}}, exitException},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, exitConnection},
+	}
+
+	for _, tt := range tests {
+		if code := classify(tt.err); code != tt.code {
+			t.Errorf("classify(%v) = %v (%v), want %v (%v)", tt.err, code, exitKinds[code], tt.code, exitKinds[tt.code])
+		}
+	}
+}
+
+func TestCLIError(t *testing.T) {
+	err := fmt.Errorf("home: %w", godet.NavigationError("net::ERR_NAME_NOT_RESOLVED"))
+
+	b, _ := json.Marshal(newCLIError(classify(err), "navigation failed", err))
+
+	want := `{"kind":"navigation","code":4,"message":"navigation failed","error":"home: NavigationError:net::ERR_NAME_NOT_RESOLVED",` +
+		`"netError":"net::ERR_NAME_NOT_RESOLVED","category":"dns"}`
+	if string(b) != want {
+		t.Errorf("error = %s, want %s", b, want)
+	}
+
+	if b, _ := json.Marshal(newCLIError(exitUsage, "invalid -shard", nil)); string(b) != `{"kind":"usage","code":2,"message":"invalid -shard"}` {
+		t.Errorf("usage error = %s", b)
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -15,6 +22,13 @@ import (
 	"github.com/gobs/pretty"
 	"github.com/gobs/simplejson"
 	"github.com/raff/godet"
+	"github.com/raff/godet/monitor"
+	"github.com/raff/godet/notify"
+	"github.com/raff/godet/runner"
+	"github.com/raff/godet/schedule"
+	"github.com/raff/godet/server"
+	"github.com/raff/godet/stealth"
+	"google.golang.org/grpc"
 )
 
 func runCommand(commandString string) error {
@@ -35,7 +49,7 @@ func limit(s string, l int) string {
 func documentNode(remote *godet.RemoteDebugger, verbose bool) int {
 	res, err := remote.GetDocument()
 	if err != nil {
-		log.Fatal("error getting document: ", err)
+		fatal("error getting document", err)
 	}
 
 	if verbose {
@@ -140,8 +154,74 @@ func main() {
 	body := flag.Bool("body", false, "show response body")
 	bypass := flag.Bool("bypass", false, "bypass service workers")
 	download := flag.String("download", "", "download behavour (default,allow,allowAndName,deny)")
+	serve := flag.String("serve", "", "serve the HTTP API (navigate, screenshot, pdf, evaluate, har) on the specified address")
+	serveTabs := flag.Int("serve-tabs", 4, "maximum number of tabs used concurrently by the HTTP API")
+	serveGRPC := flag.String("serve-grpc", "", "serve the API as a gRPC service (with JSON messages) on the specified address")
+	serveEvaluate := flag.Bool("serve-evaluate", false, "enable the evaluate operation of the API (runs arbitrary Javascript in the browser)")
+	xvfb := flag.Bool("xvfb", false, "run the browser (headful) on a virtual Xvfb display (Linux only)")
+	recordLogin := flag.String("record-login", "", "record a login performed in the (headful) browser, saving the session state to {name}.state.json and the actions to {name}.actions.json")
+	scenarios := flag.String("scenarios", "", "run the recorded scenarios matching the pattern (i.e. 'tests/*.actions.json') and exit")
+	results := flag.String("results", "results", "directory for the scenario artifacts and JUnit report")
+	parallel := flag.Int("parallel", 4, "number of scenarios running in parallel")
+	video := flag.String("video", "", "record a video of the scenarios (all, failures)")
+	trace := flag.Bool("trace", false, "record a trace of the scenario actions, with screenshots")
+	notifyWebhook := flag.String("notify-webhook", "", "post the scenario results (as JSON) to the webhook URL")
+	notifySlack := flag.String("notify-slack", "", "post the scenario results to the Slack incoming webhook URL")
+	notifyFailures := flag.Bool("notify-failures", false, "only notify the failures")
+	monitorInterval := flag.Duration("monitor", 0, "run the scenarios as synthetic monitors, on the specified interval")
+	monitorCron := flag.String("monitor-cron", "", "run the scenarios as synthetic monitors, on the specified cron schedule (i.e. '*/5 * * * *')")
+	monitorJitter := flag.Duration("monitor-jitter", 0, "random delay (up to the specified duration) added to the monitors cron schedule")
+	monitorAddr := flag.String("monitor-addr", "localhost:9300", "address serving the monitor metrics (/metrics) and status (/status)")
+	monitorAssertions := flag.String("monitor-assertions", "", "JSON file with the content assertions ([{selector, text}]) checked by the monitors")
+	heal := flag.Bool("heal", false, "replace the selectors of the scenarios that don't match any element with the best candidate (see healed.json in the results)")
+	allowOrigins := flag.String("allow-third-parties", "", "report the requests of the scenarios to the third party domains not in the comma separated allowlist (see thirdparties.json in the results)")
+	blockThirdParties := flag.Bool("block-third-parties", false, "block the requests to the third party domains not in -allow-third-parties")
+	auditCookies := flag.Bool("audit-cookies", false, "save the cookie compliance report of the scenarios (see cookies.json in the results)")
+	shard := flag.String("shard", "", "only run the shard i/n of the scenarios (i is 1 based)")
+	flag.String("config", "", "configuration file (JSON or YAML, with the flag names as keys; default godet.yaml or godet.json, if present)")
+	browserArgs := flag.String("browser-args", "", "additional command line arguments for the browser")
+	proxy := flag.String("proxy", "", "proxy server used by the browser (i.e. http://proxy:3128 or socks5://proxy:1080)")
+	hostRules := flag.String("host-rules", "", "comma separated host=address mappings for the browser DNS resolution (i.e. www.example.com=10.0.0.1)")
+	proxyAuth := flag.String("proxy-auth", "", "proxy credentials (user:password)")
+	hideAutomation := flag.Bool("stealth", false, "hide the most common signs of automation (navigator.webdriver, headless user agent, etc.)")
+	vision := flag.String("vision", "", "simulate a vision deficiency (i.e. protanopia, deuteranopia, tritanopia, achromatopsia, blurredVision)")
+	device := flag.String("device", "", "emulate a device ("+strings.Join(godet.DeviceNames(), ", ")+")")
+	timeout := flag.Duration("timeout", 30*time.Second, "default timeout for navigations and actions")
+	output := flag.String("output", "text", "output format for the results (text, json)")
+	codegenFormat := flag.String("codegen-format", "go", "output format of the codegen command (go, yaml, json)")
+	flag.BoolVar(&jsonErrors, "json-errors", false, "print the final error as a JSON object ({kind, code, message, error}) on stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %v:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), commandsUsage)
+		fmt.Fprint(flag.CommandLine.Output(), configUsage)
+		fmt.Fprint(flag.CommandLine.Output(), exitUsageText)
+	}
 	flag.Parse()
 
+	if err := configure(); err != nil {
+		exit(exitUsage, "cannot load configuration", err)
+	}
+
+	if flag.Arg(0) == "completion" {
+		if err := completionCommand(os.Stdout, os.Args[0], flag.Args()[1:]); err != nil {
+			exit(exitUsage, "completion", err)
+		}
+
+		os.Exit(exitOK)
+	}
+
+	args := flag.Args()
+	pick := flag.Arg(0) == "pick"
+	codegen := flag.Arg(0) == "codegen"
+	if pick || codegen {
+		args = args[1:]
+	}
+
+	if (*recordLogin != "" || pick || codegen) && *headless == "" {
+		*headless = "false"
+	}
+
 	if *cmd != "" {
 		if *headless != "" {
 			hparam := fmt.Sprintf(" --headless=%v ", *headless)
@@ -152,6 +232,44 @@ func main() {
 			*cmd = strings.Replace(*cmd, " --headless ", hparam, -1)
 		}
 
+		if *proxy != "" {
+			*cmd += " --proxy-server=" + *proxy
+		}
+
+		if *hostRules != "" {
+			hosts := map[string]string{}
+
+			for _, m := range strings.Split(*hostRules, ",") {
+				host, target := m, ""
+				if i := strings.Index(m, "="); i >= 0 {
+					host, target = m[:i], m[i+1:]
+				}
+
+				hosts[strings.TrimSpace(host)] = strings.TrimSpace(target)
+			}
+
+			rules, err := godet.HostResolverRules(hosts)
+			if err != nil {
+				exit(exitUsage, "invalid -host-rules", err)
+			}
+
+			*cmd += fmt.Sprintf(" %q", "--host-resolver-rules="+rules)
+		}
+
+		if *browserArgs != "" {
+			*cmd += " " + *browserArgs
+		}
+
+		if *xvfb {
+			x, err := godet.StartXvfb("")
+			if err != nil {
+				fatal("cannot start Xvfb", err)
+			}
+
+			defer x.Stop()
+			os.Setenv("DISPLAY", x.Display)
+		}
+
 		if err := runCommand(*cmd); err != nil {
 			log.Println("cannot start browser", err)
 		}
@@ -174,7 +292,7 @@ func main() {
 	}
 
 	if err != nil {
-		log.Fatal("cannot connect to browser")
+		exit(exitConnection, "cannot connect to browser", err)
 	}
 
 	defer remote.Close()
@@ -186,7 +304,7 @@ func main() {
 
 	v, err := remote.Version()
 	if err != nil {
-		log.Fatal("cannot get version: ", err)
+		fatal("cannot get version", err)
 	}
 
 	if *version {
@@ -195,10 +313,258 @@ func main() {
 		log.Println("connected to", v.Browser, "protocol version", v.ProtocolVersion)
 	}
 
+	if *proxyAuth != "" {
+		user, password := *proxyAuth, ""
+		if i := strings.Index(user, ":"); i >= 0 {
+			user, password = user[:i], user[i+1:]
+		}
+
+		if err := remote.SetProxyCredentials(user, password); err != nil {
+			fatal("cannot set proxy credentials", err)
+		}
+	}
+
+	if *hideAutomation {
+		if err := stealth.Stealth(remote); err != nil {
+			fatal("cannot enable stealth mode", err)
+		}
+	}
+
+	var emulate *godet.Device
+
+	if *device != "" {
+		d, ok := godet.Devices[*device]
+		if !ok {
+			exit(exitUsage, "unknown device "+*device, nil)
+		}
+
+		if err := remote.EmulateDevice(d); err != nil {
+			fatal("cannot emulate device", err)
+		}
+
+		emulate = &d
+	}
+
+	if *vision != "" {
+		if err := remote.EmulateVisionDeficiency(godet.VisionDeficiency(*vision)); err != nil {
+			fatal("cannot emulate vision deficiency", err)
+		}
+	}
+
+	if *serve != "" || *serveGRPC != "" {
+		api := server.New(godet.NewPool(remote, *serveTabs))
+		api.AllowEvaluate = *serveEvaluate
+
+		if *serveGRPC != "" {
+			l, err := net.Listen("tcp", *serveGRPC)
+			if err != nil {
+				fatal("cannot listen", err)
+			}
+
+			g := grpc.NewServer()
+			api.RegisterGRPC(g)
+
+			log.Println("serving gRPC API on", *serveGRPC)
+
+			if *serve == "" {
+				log.Fatal(g.Serve(l))
+			}
+
+			go func() {
+				log.Fatal(g.Serve(l))
+			}()
+		}
+
+		log.Println("serving API on", *serve)
+		log.Fatal(http.ListenAndServe(*serve, api))
+	}
+
+	if *scenarios != "" {
+		files, err := filepath.Glob(*scenarios)
+		if err != nil {
+			exit(exitUsage, "invalid scenarios pattern", err)
+		}
+
+		list, err := runner.LoadScenarios(files...)
+		if err != nil {
+			fatal("cannot load scenarios", err)
+		}
+
+		if *shard != "" {
+			var i, n int
+			if _, err := fmt.Sscanf(*shard, "%d/%d", &i, &n); err != nil || i < 1 || i > n {
+				exit(exitUsage, "invalid shard "+*shard, nil)
+			}
+
+			list = runner.Shard(list, i-1, n)
+		}
+
+		var notifier notify.Notifier
+		var notifiers []notify.Notifier
+		if *notifyWebhook != "" {
+			notifiers = append(notifiers, &notify.Webhook{URL: *notifyWebhook})
+		}
+		if *notifySlack != "" {
+			notifiers = append(notifiers, &notify.Slack{WebhookURL: *notifySlack})
+		}
+		if len(notifiers) > 0 {
+			notifier = notify.Multi(notifiers...)
+			if *notifyFailures {
+				notifier = notify.OnlyFailures(notifier)
+			}
+		}
+
+		if *monitorInterval > 0 || *monitorCron != "" {
+			var assertions []monitor.Assertion
+			if *monitorAssertions != "" {
+				f, err := os.Open(*monitorAssertions)
+				if err != nil {
+					fatal("cannot open assertions", err)
+				}
+
+				assertions, err = monitor.ReadAssertions(f)
+				f.Close()
+				if err != nil {
+					fatal("cannot read assertions", err)
+				}
+			}
+
+			sched := schedule.New(remote, *verbose)
+
+			var monitors []*monitor.Monitor
+			for _, sc := range list {
+				m := monitor.New(remote, sc, *monitorInterval)
+				m.Timeout = *timeout
+				m.Assertions = assertions
+				m.Notifier = notifier
+				monitors = append(monitors, m)
+
+				if *monitorCron == "" {
+					go m.Run(context.Background())
+				} else if _, err := sched.Add(sc.Name, *monitorCron, m.Job(), schedule.Jitter(*monitorJitter)); err != nil {
+					exit(exitUsage, "invalid monitor schedule", err)
+				}
+			}
+
+			if *monitorCron != "" {
+				go sched.Run(context.Background())
+			}
+
+			log.Println("serving monitor metrics and status on", *monitorAddr)
+			log.Fatal(http.ListenAndServe(*monitorAddr, monitor.Handler(monitors...)))
+		}
+
+		r := runner.New(remote, *parallel)
+		r.ResultsDir = *results
+		r.Timeout = *timeout
+		r.Notifier = notifier
+
+		if emulate != nil {
+			r.Collectors = append(r.Collectors, func(tab *godet.RemoteDebugger, dir string) (func(error) error, error) {
+				return func(error) error { return nil }, tab.EmulateDevice(*emulate)
+			})
+		}
+
+		if *trace {
+			r.Collectors = append(r.Collectors, runner.CollectTrace)
+		}
+
+		if *video != "" {
+			r.Collectors = append(r.Collectors, runner.CollectVideo(*video == "failures"))
+		}
+
+		if *allowOrigins != "" || *blockThirdParties {
+			var allowed []string
+			if *allowOrigins != "" {
+				allowed = strings.Split(*allowOrigins, ",")
+			}
+
+			r.Collectors = append(r.Collectors, runner.CollectThirdParties(allowed, *blockThirdParties))
+		}
+
+		if *auditCookies {
+			r.Collectors = append(r.Collectors, runner.CollectCookies)
+		}
+
+		if *heal {
+			r.Collectors = append(r.Collectors, runner.HealSelectors(godet.DefaultHealScore))
+		}
+
+		res, err := r.Run(context.Background(), list)
+		if err != nil {
+			log.Println("cannot write report: ", err)
+		}
+
+		failed := 0
+		for _, sr := range res {
+			if sr.Err != nil {
+				failed++
+			}
+
+			if *output == "json" {
+				out := map[string]interface{}{
+					"scenario":  sr.Scenario,
+					"duration":  sr.Duration.Seconds(),
+					"passed":    sr.Err == nil,
+					"artifacts": sr.Dir,
+				}
+				if sr.Err != nil {
+					out["error"] = sr.Err.Error()
+				}
+
+				json.NewEncoder(os.Stdout).Encode(out)
+				continue
+			}
+
+			status := "ok"
+			if sr.Err != nil {
+				status = "FAIL " + sr.Err.Error()
+			}
+
+			fmt.Printf("%-40v %8.3fs %v\n", sr.Scenario, sr.Duration.Seconds(), status)
+		}
+
+		remote.Close()
+
+		if failed > 0 {
+			exit(exitAssertion, fmt.Sprintf("%v of %v scenarios failed", failed, len(res)), nil)
+		}
+		if err != nil {
+			exit(exitError, "cannot write report", err)
+		}
+
+		os.Exit(exitOK)
+	}
+
+	if flag.Arg(0) == "protocol" {
+		err := protocolCommand(remote, os.Stdout, flag.Args()[1:])
+		remote.Close()
+
+		if err != nil {
+			fatal("protocol", err)
+		}
+
+		os.Exit(exitOK)
+	}
+
+	if flag.Arg(0) == "a11y" {
+		violations, err := a11yCommand(remote, os.Stdout, flag.Args()[1:], *timeout)
+		remote.Close()
+
+		if err != nil {
+			fatal("a11y", err)
+		}
+		if violations > 0 {
+			exit(exitAssertion, fmt.Sprintf("%v accessibility violations", violations), nil)
+		}
+
+		os.Exit(exitOK)
+	}
+
 	if *protocol {
 		p, err := remote.Protocol()
 		if err != nil {
-			log.Fatal("cannot get protocol: ", err)
+			fatal("cannot get protocol", err)
 		}
 
 		pretty.PrettyPrint(p)
@@ -208,7 +574,7 @@ func main() {
 	if *listtabs {
 		tabs, err := remote.TabList(*filter)
 		if err != nil {
-			log.Fatal("cannot get list of tabs: ", err)
+			fatal("cannot get list of tabs", err)
 		}
 
 		pretty.PrettyPrint(tabs)
@@ -218,7 +584,7 @@ func main() {
 	if *listtargets {
 		targets, err := remote.GetTargets()
 		if err != nil {
-			log.Fatal("cannot get list of targets: ", err)
+			fatal("cannot get list of targets", err)
 		}
 
 		pretty.PrettyPrint(targets)
@@ -228,7 +594,7 @@ func main() {
 	if *domains {
 		d, err := remote.GetDomains()
 		if err != nil {
-			log.Fatal("cannot get domains: ", err)
+			fatal("cannot get domains", err)
 		}
 
 		pretty.PrettyPrint(d)
@@ -238,7 +604,7 @@ func main() {
 	if *history {
 		curr, entries, err := remote.GetNavigationHistory()
 		if err != nil {
-			log.Fatal("cannot get history: ", err)
+			fatal("cannot get history", err)
 		}
 
 		fmt.Println("current entry:", curr)
@@ -348,7 +714,7 @@ func main() {
 
 	tabs, err := remote.TabList("page")
 	if err != nil {
-		log.Fatal("cannot get tabs: ", err)
+		fatal("cannot get tabs", err)
 	}
 	if *seltab >= 0 && *seltab < len(tabs) {
 		if err = remote.ActivateTab(tabs[*seltab]); err != nil {
@@ -356,15 +722,15 @@ func main() {
 		}
 	}
 
-	if flag.NArg() > 0 {
-		site = flag.Arg(0)
+	if len(args) > 0 {
+		site = args[0]
 
 		if len(tabs) == 0 || *newtab {
 			_, err = remote.NewTab(site)
 			site = ""
 
 			if err != nil {
-				log.Fatal("error loading page: ", err)
+				fatal("error loading page", err)
 			}
 		}
 	}
@@ -475,11 +841,90 @@ func main() {
 			int(*pause/time.Millisecond))
 	}
 
+	var recorder *godet.ActionRecorder
+
+	if *recordLogin != "" || codegen {
+		recorder, err = remote.RecordActions()
+		if err != nil {
+			fatal("cannot record actions", err)
+		}
+	}
+
 	if len(site) > 0 {
 		_, err = remote.Navigate(site)
 		if err != nil {
-			log.Fatal("error loading page: ", err)
+			fatal("error loading page", err)
+		}
+	}
+
+	if pick {
+		log.Println("Click on an element in the browser...")
+
+		el, err := remote.PickElement(0)
+		if err != nil {
+			fatal("cannot pick element", err)
+		}
+
+		if *output == "json" {
+			json.NewEncoder(os.Stdout).Encode(el)
+		} else {
+			fmt.Println("selector:", el.Selector)
+			fmt.Println("xpath:   ", el.XPath)
+			fmt.Println("element: ", el.Description, limit(el.Text, 80))
+		}
+
+		remote.Close()
+		os.Exit(exitOK)
+	}
+
+	if codegen {
+		fmt.Fprint(os.Stderr, "Perform the actions in the browser, then press Enter here...")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+
+		actions, err := recorder.Stop()
+		if err != nil {
+			log.Println("error stopping recorder: ", err)
+		}
+
+		if err := godet.WriteCode(os.Stdout, godet.CodegenFormat(*codegenFormat), actions); err != nil {
+			fatal("cannot generate code", err)
+		}
+
+		remote.Close()
+		os.Exit(exitOK)
+	}
+
+	if recorder != nil {
+		fmt.Print("Complete the login in the browser, then press Enter here...")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+
+		actions, err := recorder.Stop()
+		if err != nil {
+			log.Println("error stopping recorder: ", err)
+		}
+
+		f, err := os.OpenFile(*recordLogin+".actions.json", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			fatal("cannot save actions", err)
+		}
+
+		err = godet.WriteActions(f, actions)
+		f.Close()
+		if err != nil {
+			fatal("cannot save actions", err)
 		}
+
+		state, err := remote.ExportState()
+		if err != nil {
+			fatal("cannot export session state", err)
+		}
+
+		if err := ioutil.WriteFile(*recordLogin+".state.json", state, 0600); err != nil {
+			fatal("cannot save session state", err)
+		}
+
+		log.Println("recorded", len(actions), "actions to", *recordLogin+".actions.json", "and session state to", *recordLogin+".state.json")
+		shouldWait = false
 	}
 
 	if pwait != nil {
@@ -492,7 +937,7 @@ func main() {
 
 		res, err := remote.QuerySelector(id, *query)
 		if err != nil {
-			log.Fatal("error in querySelector: ", err)
+			fatal("error in querySelector", err)
 		}
 
 		if res == nil {
@@ -501,7 +946,7 @@ func main() {
 			id = int(res["nodeId"].(float64))
 			res, err = remote.ResolveNode(id)
 			if err != nil {
-				log.Fatal("error in resolveNode: ", err)
+				fatal("error in resolveNode", err)
 			}
 
 			pretty.PrettyPrint(res)
@@ -513,7 +958,7 @@ func main() {
 	if *eval != "" {
 		res, err := remote.EvaluateWrap(*eval)
 		if err != nil {
-			log.Fatal("error in evaluate: ", err)
+			fatal("error in evaluate", err)
 		}
 
 		pretty.PrettyPrint(res)
@@ -525,14 +970,14 @@ func main() {
 
 		res, err := remote.QuerySelector(id, "html")
 		if err != nil {
-			log.Fatal("error in querySelector: ", err)
+			fatal("error in querySelector", err)
 		}
 
 		id = int(res["nodeId"].(float64))
 
 		err = remote.SetOuterHTML(id, *setHTML)
 		if err != nil {
-			log.Fatal("error in setOuterHTML: ", err)
+			fatal("error in setOuterHTML", err)
 		}
 
 		shouldWait = false
@@ -543,7 +988,7 @@ func main() {
 
 		res, err := remote.GetOuterHTML(id)
 		if err != nil {
-			log.Fatal("error in getOuterHTML: ", err)
+			fatal("error in getOuterHTML", err)
 		}
 
 		log.Println(res)
@@ -555,14 +1000,14 @@ func main() {
 
 		res, err := remote.QuerySelector(id, "html")
 		if err != nil {
-			log.Fatal("error in querySelector: ", err)
+			fatal("error in querySelector", err)
 		}
 
 		id = int(res["nodeId"].(float64))
 
 		res, err = remote.GetBoxModel(id)
 		if err != nil {
-			log.Fatal("error in getBoxModel: ", err)
+			fatal("error in getBoxModel", err)
 		}
 
 		pretty.PrettyPrint(res)
@@ -574,14 +1019,14 @@ func main() {
 
 		res, err := remote.QuerySelector(id, "html")
 		if err != nil {
-			log.Fatal("error in querySelector: ", err)
+			fatal("error in querySelector", err)
 		}
 
 		id = int(res["nodeId"].(float64))
 
 		res, err = remote.GetComputedStyleForNode(id)
 		if err != nil {
-			log.Fatal("error in getComputedStyleForNode: ", err)
+			fatal("error in getComputedStyleForNode", err)
 		}
 
 		pretty.PrettyPrint(res)
@@ -593,14 +1038,14 @@ func main() {
 
 		res, err := remote.QuerySelector(id, "html")
 		if err != nil {
-			log.Fatal("error in querySelector: ", err)
+			fatal("error in querySelector", err)
 		}
 
 		id = int(res["nodeId"].(float64))
 
 		res, err = remote.GetBoxModel(id)
 		if err != nil {
-			log.Fatal("error in getBoxModel: ", err)
+			fatal("error in getBoxModel", err)
 		}
 
 		if res == nil {
@@ -612,7 +1057,7 @@ func main() {
 
 			err = remote.SetVisibleSize(width, height)
 			if err != nil {
-				log.Fatal("error in setVisibleSize: ", err)
+				fatal("error in setVisibleSize", err)
 			}
 		}
 
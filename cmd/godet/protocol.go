@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/raff/godet"
+)
+
+// protocolItem is a command, event, type or parameter in the protocol description (/json/protocol).
+type protocolItem struct {
+	Name         string         `json:"name"`
+	ID           string         `json:"id"`
+	Description  string         `json:"description"`
+	Type         string         `json:"type"`
+	Ref          string         `json:"$ref"`
+	Items        *protocolItem  `json:"items"`
+	Enum         []string       `json:"enum"`
+	Optional     bool           `json:"optional"`
+	Experimental bool           `json:"experimental"`
+	Deprecated   bool           `json:"deprecated"`
+	Parameters   []protocolItem `json:"parameters"`
+	Returns      []protocolItem `json:"returns"`
+	Properties   []protocolItem `json:"properties"`
+}
+
+type protocolDomain struct {
+	Domain       string         `json:"domain"`
+	Description  string         `json:"description"`
+	Experimental bool           `json:"experimental"`
+	Deprecated   bool           `json:"deprecated"`
+	Commands     []protocolItem `json:"commands"`
+	Events       []protocolItem `json:"events"`
+	Types        []protocolItem `json:"types"`
+}
+
+// loadProtocol returns the domains of the protocol supported by the browser.
+func loadProtocol(remote *godet.RemoteDebugger) ([]protocolDomain, error) {
+	p, err := remote.Protocol()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var proto struct {
+		Domains []protocolDomain `json:"domains"`
+	}
+
+	if err := json.Unmarshal(data, &proto); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(proto.Domains, func(i, j int) bool { return proto.Domains[i].Domain < proto.Domains[j].Domain })
+	return proto.Domains, nil
+}
+
+const protocolUsage = `usage: godet protocol list [domain]
+       godet protocol describe Domain.method|Domain.event|Domain.Type`
+
+// protocolCommand executes the "protocol list" and "protocol describe" commands.
+func protocolCommand(remote *godet.RemoteDebugger, w io.Writer, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "describe") {
+		return errors.New(protocolUsage)
+	}
+
+	domains, err := loadProtocol(remote)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		for _, d := range domains {
+			if len(args) > 1 && !strings.EqualFold(d.Domain, args[1]) {
+				continue
+			}
+
+			for _, c := range d.Commands {
+				fmt.Fprintln(w, d.Domain+"."+c.Name)
+			}
+		}
+
+		return nil
+	}
+
+	if len(args) < 2 {
+		return errors.New(protocolUsage)
+	}
+
+	parts := strings.SplitN(args[1], ".", 2)
+
+	for _, d := range domains {
+		if d.Domain != parts[0] {
+			continue
+		}
+
+		if len(parts) == 1 {
+			describeDomain(w, d)
+			return nil
+		}
+
+		for _, c := range d.Commands {
+			if c.Name == parts[1] {
+				describeItem(w, "command", args[1], c)
+				return nil
+			}
+		}
+
+		for _, e := range d.Events {
+			if e.Name == parts[1] {
+				describeItem(w, "event", args[1], e)
+				return nil
+			}
+		}
+
+		for _, t := range d.Types {
+			if t.ID == parts[1] {
+				describeItem(w, "type", args[1], t)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("unknown method %q", args[1])
+}
+
+// flags returns the experimental/deprecated markers.
+func (p protocolItem) flags() string {
+	var flags string
+	if p.Experimental {
+		flags += " (experimental)"
+	}
+	if p.Deprecated {
+		flags += " (deprecated)"
+	}
+
+	return flags
+}
+
+// typeName returns the type of the parameter or property, as "type", "Domain.Type" or "array of type".
+func (p protocolItem) typeName() string {
+	switch {
+	case p.Ref != "":
+		return p.Ref
+
+	case p.Type == "array" && p.Items != nil:
+		return "array of " + p.Items.typeName()
+
+	case len(p.Enum) > 0:
+		return p.Type + " (" + strings.Join(p.Enum, ", ") + ")"
+	}
+
+	return p.Type
+}
+
+func describeDomain(w io.Writer, d protocolDomain) {
+	var flags string
+	if d.Experimental {
+		flags += " (experimental)"
+	}
+	if d.Deprecated {
+		flags += " (deprecated)"
+	}
+
+	fmt.Fprintf(w, "domain %v%v\n", d.Domain, flags)
+	if d.Description != "" {
+		fmt.Fprintf(w, "\n%v\n", d.Description)
+	}
+
+	list := func(title string, items []protocolItem, name func(protocolItem) string) {
+		if len(items) == 0 {
+			return
+		}
+
+		fmt.Fprintf(w, "\n%v:\n", title)
+		for _, i := range items {
+			fmt.Fprintf(w, "  %v.%v%v\n", d.Domain, name(i), i.flags())
+		}
+	}
+
+	list("Commands", d.Commands, func(i protocolItem) string { return i.Name })
+	list("Events", d.Events, func(i protocolItem) string { return i.Name })
+	list("Types", d.Types, func(i protocolItem) string { return i.ID })
+}
+
+func describeItem(w io.Writer, kind, name string, item protocolItem) {
+	fmt.Fprintf(w, "%v %v%v\n", kind, name, item.flags())
+	if item.Type != "" {
+		fmt.Fprintf(w, "  type: %v\n", item.typeName())
+	}
+	if item.Description != "" {
+		fmt.Fprintf(w, "\n%v\n", item.Description)
+	}
+
+	list := func(title string, items []protocolItem) {
+		if len(items) == 0 {
+			return
+		}
+
+		fmt.Fprintf(w, "\n%v:\n", title)
+		for _, i := range items {
+			optional := ""
+			if i.Optional {
+				optional = " (optional)"
+			}
+
+			fmt.Fprintf(w, "  %v %v%v%v\n", i.Name, i.typeName(), optional, i.flags())
+			if i.Description != "" {
+				fmt.Fprintf(w, "      %v\n", strings.Replace(i.Description, "\n", "\n      ", -1))
+			}
+		}
+	}
+
+	list("Parameters", item.Parameters)
+	list("Returns", item.Returns)
+	list("Properties", item.Properties)
+}
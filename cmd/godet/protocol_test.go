@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/raff/godet"
+)
+
+const testProtocol = `{"domains": [
+	{"domain": "Page", "description": "Actions and events related to the inspected page.",
+	 "commands": [
+		{"name": "navigate", "description": "Navigates current page to the given URL.",
+		 "parameters": [
+			{"name": "url", "type": "string", "description": "URL to navigate the page to."},
+			{"name": "transitionType", "$ref": "TransitionType", "optional": true}
+		 ],
+		 "returns": [{"name": "frameId", "$ref": "FrameId"}]},
+		{"name": "reload", "deprecated": true}
+	 ],
+	 "events": [{"name": "loadEventFired", "parameters": [{"name": "timestamp", "type": "number"}]}],
+	 "types": [{"id": "TransitionType", "type": "string", "enum": ["link", "typed"]}]},
+	{"domain": "Animation", "experimental": true,
+	 "commands": [
+		{"name": "enable"},
+		{"name": "getPlaybackRate", "returns": [{"name": "playbackRate", "type": "array", "items": {"type": "number"}}]}
+	 ]}
+]}`
+
+// fakeBrowser connects to a browser that serves testProtocol and answers Runtime.evaluate
+// with the value returned by evaluate.
+func fakeBrowser(t *testing.T, evaluate func(expr string) interface{}) *godet.RemoteDebugger {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/json/list", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": "page1", "type": "page", "webSocketDebuggerUrl": "ws%v/ws"}]`, strings.TrimPrefix(srv.URL, "http"))
+	})
+
+	mux.HandleFunc("/json/protocol", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testProtocol)
+	})
+
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+
+		for {
+			var cmd struct {
+				ID     int          `json:"id"`
+				Method string       `json:"method"`
+				Params godet.Params `json:"params"`
+			}
+
+			if err := wsjson.Read(r.Context(), c, &cmd); err != nil {
+				return
+			}
+
+			result := godet.Params{}
+			if cmd.Method == "Runtime.evaluate" && evaluate != nil {
+				result["result"] = godet.Params{"type": "object", "value": evaluate(cmd.Params.String("expression"))}
+			}
+
+			if err := wsjson.Write(r.Context(), c, godet.Params{"id": cmd.ID, "result": result}); err != nil {
+				return
+			}
+		}
+	})
+
+	remote, err := godet.Connect(strings.TrimPrefix(srv.URL, "http://"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { remote.Close() })
+	return remote
+}
+
+func TestProtocolCommand(t *testing.T) {
+	remote := fakeBrowser(t, nil)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list"}, "Animation.enable\nAnimation.getPlaybackRate\nPage.navigate\nPage.reload\n"},
+		{[]string{"list", "page"}, "Page.navigate\nPage.reload\n"},
+		{[]string{"describe", "Page.navigate"}, `command Page.navigate
+
+Navigates current page to the given URL.
+
+Parameters:
+  url string
+      URL to navigate the page to.
+  transitionType TransitionType (optional)
+
+Returns:
+  frameId FrameId
+`},
+		{[]string{"describe", "Page.loadEventFired"}, "event Page.loadEventFired\n\nParameters:\n  timestamp number\n"},
+		{[]string{"describe", "Page.TransitionType"}, "type Page.TransitionType\n  type: string (link, typed)\n"},
+		{[]string{"describe", "Animation.getPlaybackRate"}, "command Animation.getPlaybackRate\n\nReturns:\n  playbackRate array of number\n"},
+		{[]string{"describe", "Page"}, `domain Page
+
+Actions and events related to the inspected page.
+
+Commands:
+  Page.navigate
+  Page.reload (deprecated)
+
+Events:
+  Page.loadEventFired
+
+Types:
+  Page.TransitionType
+`},
+		{[]string{"describe", "Animation"}, "domain Animation (experimental)\n\nCommands:\n  Animation.enable\n  Animation.getPlaybackRate\n"},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+
+		if err := protocolCommand(remote, &b, tt.args); err != nil {
+			t.Errorf("protocol %v: %v", tt.args, err)
+		} else if b.String() != tt.want {
+			t.Errorf("protocol %v:\n%v\nwant:\n%v", tt.args, b.String(), tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, protocolUsage},
+		{[]string{"show"}, protocolUsage},
+		{[]string{"describe"}, protocolUsage},
+		{[]string{"describe", "Page.stop"}, `unknown method "Page.stop"`},
+		{[]string{"describe", "Network"}, `unknown method "Network"`},
+	} {
+		if err := protocolCommand(remote, &strings.Builder{}, tt.args); err == nil || err.Error() != tt.err {
+			t.Errorf("protocol %v: unexpected error %v", tt.args, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package godet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CodegenFormat is the output format of WriteCode.
+type CodegenFormat string
+
+const (
+	// CodegenGo generates a Go program reproducing the actions with the Page API.
+	CodegenGo CodegenFormat = "go"
+
+	// CodegenYAML generates a scenario in YAML (see WriteActionsYAML).
+	CodegenYAML CodegenFormat = "yaml"
+
+	// CodegenJSON generates a scenario in JSON (see WriteActions).
+	CodegenJSON CodegenFormat = "json"
+)
+
+// WriteCode writes the recorded actions in the specified format.
+func WriteCode(w io.Writer, format CodegenFormat, actions []RecordedAction) error {
+	switch format {
+	case CodegenGo:
+		return WriteGo(w, actions)
+
+	case CodegenYAML:
+		return WriteActionsYAML(w, actions)
+
+	case CodegenJSON:
+		return WriteActions(w, actions)
+	}
+
+	return fmt.Errorf("unknown codegen format %q", format)
+}
+
+// WriteGo writes a Go program that launches a browser and reproduces the recorded actions.
+// The values typed in password fields are not included: they are read from the GODET_SECRET_{n}
+// environment variables.
+func WriteGo(w io.Writer, actions []RecordedAction) error {
+	var body bytes.Buffer
+	secrets := 0
+
+	for _, a := range actions {
+		value := strconv.Quote(a.Value)
+		if a.Sensitive {
+			secrets++
+			value = fmt.Sprintf("os.Getenv(%q)", secretVar(secrets))
+		}
+
+		selector := strconv.Quote(a.Selector)
+
+		switch a.Type {
+		case "navigate":
+			fmt.Fprintf(&body, "check(page.Goto(%v))\n", value)
+
+		case "click":
+			fmt.Fprintf(&body, "check(page.Click(%v))\n", selector)
+
+		case "fill":
+			fmt.Fprintf(&body, "check(page.Fill(%v, %v))\n", selector, value)
+
+		case "select":
+			fmt.Fprintf(&body, "_, err = page.Select(%v, %v)\ncheck(err)\n", selector, value)
+
+		case "press":
+			fmt.Fprintf(&body, "check(page.PressEnter(%v))\n", selector)
+
+		default:
+			fmt.Fprintf(&body, "// unsupported action %q %v %v\n", a.Type, selector, value)
+		}
+	}
+
+	imports := `"log"`
+	if secrets > 0 {
+		imports += "\n\"os\""
+	}
+
+	src := fmt.Sprintf(`// Code generated by godet codegen.
+
+package main
+
+import (
+%v
+
+"github.com/raff/godet"
+)
+
+func check(err error) {
+if err != nil {
+log.Fatal(err)
+}
+}
+
+func main() {
+browser, err := godet.Launch()
+check(err)
+defer browser.Close()
+
+remote, err := browser.Connect(false)
+check(err)
+defer remote.Close()
+
+page := godet.NewPage(remote)
+
+%v}
+`, imports, body.String())
+
+	code, err := format.Source([]byte(src))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(code)
+	return err
+}
+
+// secretVar returns the name of the environment variable with the n-th sensitive value.
+func secretVar(n int) string {
+	return fmt.Sprintf("GODET_SECRET_%v", n)
+}
+
+// WriteActionsYAML writes the recorded actions as a YAML scenario, for ReadActionsYAML:
+//
+//	# login scenario
+//	- type: navigate
+//	  value: "https://example.com/login"
+//	- type: fill
+//	  selector: "#user"
+//	  value: "joe"
+//	- type: fill
+//	  selector: "#password"
+//	  value: "${GODET_SECRET_1}"
+//	  sensitive: true
+//
+// Like in WriteGo, the values typed in password fields are not included: they are replaced by
+// a reference to the GODET_SECRET_{n} environment variables.
+func WriteActionsYAML(w io.Writer, actions []RecordedAction) error {
+	bw := bufio.NewWriter(w)
+	secrets := 0
+
+	quote := func(s string) string {
+		b, _ := json.Marshal(s) // a JSON string is a valid YAML double quoted scalar
+		return string(b)
+	}
+
+	for _, a := range actions {
+		value := a.Value
+		if a.Sensitive {
+			secrets++
+			value = "${" + secretVar(secrets) + "}"
+		}
+
+		fmt.Fprintf(bw, "- type: %v\n", a.Type)
+		if a.Selector != "" {
+			fmt.Fprintf(bw, "  selector: %v\n", quote(a.Selector))
+		}
+		if value != "" {
+			fmt.Fprintf(bw, "  value: %v\n", quote(value))
+		}
+		if a.Sensitive {
+			fmt.Fprintf(bw, "  sensitive: true\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReadActionsYAML reads a YAML scenario written by WriteActionsYAML: a list of actions with
+// type, selector, value and sensitive keys (plain, single or double quoted scalars).
+// The environment variable references (${VAR}) in the values of the sensitive actions are expanded.
+func ReadActionsYAML(r io.Reader) ([]RecordedAction, error) {
+	var actions []RecordedAction
+	var current *RecordedAction
+
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			actions = append(actions, RecordedAction{})
+			current = &actions[len(actions)-1]
+			line = strings.TrimSpace(strings.TrimPrefix(line, "-"))
+			if line == "" {
+				continue
+			}
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %v: expected a list of actions", n)
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("line %v: expected key: value", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+
+		value, err := yamlScalar(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", n, err)
+		}
+
+		switch key {
+		case "type":
+			current.Type = value
+		case "selector":
+			current.Selector = value
+		case "value":
+			current.Value = value
+		case "sensitive":
+			current.Sensitive = value == "true"
+		default:
+			return nil, fmt.Errorf("line %v: unknown key %q", n, key)
+		}
+	}
+
+	for i, a := range actions {
+		if a.Sensitive {
+			actions[i].Value = os.ExpandEnv(a.Value)
+		}
+	}
+
+	return actions, scanner.Err()
+}
+
+// yamlScalar returns the value of a plain, single or double quoted YAML scalar.
+func yamlScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		var v string
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return "", fmt.Errorf("invalid quoted value %v", s)
+		}
+		return v, nil
+
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("invalid quoted value %v", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+
+	return s, nil
+}
@@ -0,0 +1,110 @@
+package godet
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testActions = []RecordedAction{
+	{Type: "navigate", Value: "https://example.com/login"},
+	{Type: "fill", Selector: "#user", Value: `joe "the user"`},
+	{Type: "fill", Selector: "#password", Value: "s3cret", Sensitive: true},
+	{Type: "click", Selector: "button[type='submit']"},
+}
+
+func TestWriteActionsYAML(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteActionsYAML(&b, testActions); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `- type: navigate
+  value: "https://example.com/login"
+- type: fill
+  selector: "#user"
+  value: "joe \"the user\""
+- type: fill
+  selector: "#password"
+  value: "${GODET_SECRET_1}"
+  sensitive: true
+- type: click
+  selector: "button[type='submit']"
+`
+
+	if got := b.String(); got != want {
+		t.Errorf("YAML:\n%s\nwant:\n%s", got, want)
+	}
+
+	t.Setenv("GODET_SECRET_1", "from-env")
+
+	actions, err := ReadActionsYAML(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := append([]RecordedAction(nil), testActions...)
+	expected[2].Value = "from-env"
+
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("ReadActionsYAML = %+v, want %+v", actions, expected)
+	}
+}
+
+func TestReadActionsYAML(t *testing.T) {
+	t.Setenv("PLAIN", "expanded")
+
+	actions, err := ReadActionsYAML(strings.NewReader(`# scenario
+---
+- type: navigate
+  value: https://example.com/ # comment
+-
+  type: fill
+  selector: 'input[name=''q'']'
+  value: $PLAIN
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []RecordedAction{
+		{Type: "navigate", Value: "https://example.com/"},
+		{Type: "fill", Selector: "input[name='q']", Value: "$PLAIN"}, // only sensitive values are expanded
+	}
+
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("ReadActionsYAML = %+v, want %+v", actions, want)
+	}
+
+	for _, bad := range []string{"type: click", "- type click", "- type: click\n  timeout: 1", `- value: "unterminated`} {
+		if _, err := ReadActionsYAML(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadActionsYAML(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWriteGo(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteGo(&b, testActions); err != nil {
+		t.Fatal(err)
+	}
+
+	code := b.String()
+
+	for _, want := range []string{
+		`check(page.Goto("https://example.com/login"))`,
+		`check(page.Fill("#user", "joe \"the user\""))`,
+		`check(page.Fill("#password", os.Getenv("GODET_SECRET_1")))`,
+		`check(page.Click("button[type='submit']"))`,
+		`"os"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code doesn't contain %s:\n%s", want, code)
+		}
+	}
+
+	if strings.Contains(code, "s3cret") {
+		t.Error("the sensitive value is in the generated code")
+	}
+}
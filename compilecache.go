@@ -0,0 +1,79 @@
+package godet
+
+import (
+	"encoding/base64"
+	"log"
+)
+
+// CompilationCacheCallback is called with the V8 compilation cache produced for a script.
+type CompilationCacheCallback func(url string, data []byte)
+
+// OnCompilationCache calls cb for every Page.compilationCacheProduced event, i.e. for
+// the scripts requested with ProduceCompilationCache once they are compiled.
+//
+// The data can be passed to AddCompilationCache before the next load, to prime the cache.
+// Passing a nil callback stops the notifications. Page events are enabled, if needed.
+func (remote *RemoteDebugger) OnCompilationCache(cb CompilationCacheCallback) error {
+	remote.Lock()
+	stop := remote.stopCompilationCache
+	remote.stopCompilationCache = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	stop = remote.addHook("Page.compilationCacheProduced", func(params Params) bool {
+		data, err := base64.StdEncoding.DecodeString(params.String("data"))
+		if err != nil {
+			if remote.verbose {
+				log.Println("invalid compilation cache for", params.String("url"), err)
+			}
+			return false
+		}
+
+		cb(params.String("url"), data)
+		return false
+	})
+
+	remote.Lock()
+	remote.stopCompilationCache = stop
+	remote.Unlock()
+
+	return remote.ensureDomain("Page")
+}
+
+// ProduceCompilationCache requests the compilation cache for the scripts with the specified URLs,
+// when they are next loaded. If eager is true the scripts are compiled eagerly (i.e. all the
+// functions, not only the ones that are executed).
+func (remote *RemoteDebugger) ProduceCompilationCache(eager bool, urls ...string) error {
+	scripts := make([]Params, 0, len(urls))
+
+	for _, url := range urls {
+		scripts = append(scripts, Params{"url": url, "eager": eager})
+	}
+
+	_, err := remote.SendRequest("Page.produceCompilationCache", Params{
+		"scripts": scripts,
+	})
+	return err
+}
+
+// AddCompilationCache seeds the compilation cache for the script with the specified URL.
+func (remote *RemoteDebugger) AddCompilationCache(url string, data []byte) error {
+	_, err := remote.SendRequest("Page.addCompilationCache", Params{
+		"url":  url,
+		"data": base64.StdEncoding.EncodeToString(data),
+	})
+	return err
+}
+
+// ClearCompilationCache clears the seeded compilation cache.
+func (remote *RemoteDebugger) ClearCompilationCache() error {
+	_, err := remote.SendRequest("Page.clearCompilationCache", nil)
+	return err
+}
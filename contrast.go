@@ -0,0 +1,496 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// MinContrastRatio is the minimum contrast ratio of the normal text (WCAG AA).
+	MinContrastRatio = 4.5
+
+	// MinLargeContrastRatio is the minimum contrast ratio of the large text (WCAG AA):
+	// at least 24px, or 18.66px and bold.
+	MinLargeContrastRatio = 3.0
+
+	// MinTapTargetSize is the default minimum width and height (in CSS pixels) of the tap targets.
+	MinTapTargetSize = 48.0
+)
+
+// snapshotStyles are the computed styles captured by the DOM snapshot, in this order.
+var snapshotStyles = []string{"color", "background-color", "background-image", "font-size", "font-weight", "display", "visibility", "opacity"}
+
+const (
+	styleColor = iota
+	styleBackgroundColor
+	styleBackgroundImage
+	styleFontSize
+	styleFontWeight
+	styleDisplay
+	styleVisibility
+	styleOpacity
+)
+
+// domSnapshot is the reply of DOMSnapshot.captureSnapshot.
+type domSnapshot struct {
+	Documents []struct {
+		Nodes struct {
+			ParentIndex   []int   `json:"parentIndex"`
+			NodeType      []int   `json:"nodeType"`
+			NodeName      []int   `json:"nodeName"`
+			NodeValue     []int   `json:"nodeValue"`
+			BackendNodeID []int   `json:"backendNodeId"`
+			Attributes    [][]int `json:"attributes"`
+			IsClickable   struct {
+				Index []int `json:"index"`
+			} `json:"isClickable"`
+		} `json:"nodes"`
+		Layout struct {
+			NodeIndex []int       `json:"nodeIndex"`
+			Styles    [][]int     `json:"styles"`
+			Bounds    [][]float64 `json:"bounds"`
+		} `json:"layout"`
+	} `json:"documents"`
+	Strings []string `json:"strings"`
+}
+
+// snapshotNode is a node of a snapshot document, with its layout (if rendered).
+type snapshotNode struct {
+	snap   *domSnapshot
+	doc    int
+	index  int
+	layout int // -1 if not rendered
+}
+
+// captureSnapshot captures the DOM snapshot of the current page, with the layout and the snapshotStyles.
+// No script is executed in the page.
+func (remote *RemoteDebugger) captureSnapshot() (*domSnapshot, error) {
+	raw, err := remote.sendRawReplyRequest("DOMSnapshot.captureSnapshot", Params{
+		"computedStyles": snapshotStyles,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var snap domSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
+
+func (s *domSnapshot) str(i int) string {
+	if i < 0 || i >= len(s.Strings) {
+		return ""
+	}
+
+	return s.Strings[i]
+}
+
+// layouts returns the layout index of each node of the document (-1 if not rendered).
+func (s *domSnapshot) layouts(doc int) []int {
+	d := s.Documents[doc]
+
+	layouts := make([]int, len(d.Nodes.ParentIndex))
+	for i := range layouts {
+		layouts[i] = -1
+	}
+
+	for l, n := range d.Layout.NodeIndex {
+		if n < len(layouts) {
+			layouts[n] = l
+		}
+	}
+
+	return layouts
+}
+
+// style returns the computed style (see snapshotStyles) of the node.
+func (n snapshotNode) style(style int) string {
+	if n.layout < 0 {
+		return ""
+	}
+
+	styles := n.snap.Documents[n.doc].Layout.Styles[n.layout]
+	if style >= len(styles) {
+		return ""
+	}
+
+	return n.snap.str(styles[style])
+}
+
+// bounds returns the layout bounds (x, y, width, height) of the node.
+func (n snapshotNode) bounds() []float64 {
+	if n.layout < 0 {
+		return nil
+	}
+
+	if b := n.snap.Documents[n.doc].Layout.Bounds[n.layout]; len(b) == 4 {
+		return b
+	}
+
+	return nil
+}
+
+// describe returns a short description (tag#id.class) of the element.
+func (n snapshotNode) describe() string {
+	desc := n.tag()
+
+	if id := n.attr("id"); id != "" {
+		desc += "#" + id
+	}
+	if c := strings.Fields(n.attr("class")); len(c) > 0 {
+		desc += "." + strings.Join(c, ".")
+	}
+
+	return desc
+}
+
+func (n snapshotNode) backendNodeID() int {
+	return n.snap.Documents[n.doc].Nodes.BackendNodeID[n.index]
+}
+
+// parseColor parses a computed color (rgb() or rgba()).
+func parseColor(s string) (c [4]float64, ok bool) {
+	s = strings.TrimSpace(s)
+
+	i, j := strings.Index(s, "("), strings.LastIndex(s, ")")
+	if i < 0 || j < i || !strings.HasPrefix(s, "rgb") {
+		return c, false
+	}
+
+	parts := strings.FieldsFunc(s[i+1:j], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+	if len(parts) < 3 {
+		return c, false
+	}
+
+	c[3] = 1
+
+	for k := 0; k < len(parts) && k < 4; k++ {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(parts[k], "%"), 64)
+		if err != nil {
+			return c, false
+		}
+
+		if strings.HasSuffix(parts[k], "%") {
+			v = v / 100
+			if k < 3 {
+				v *= 255
+			}
+		}
+
+		c[k] = v
+	}
+
+	return c, true
+}
+
+// blend returns the color c over the opaque background bg.
+func blend(c, bg [4]float64) [4]float64 {
+	a := c[3]
+	return [4]float64{
+		c[0]*a + bg[0]*(1-a),
+		c[1]*a + bg[1]*(1-a),
+		c[2]*a + bg[2]*(1-a),
+		1,
+	}
+}
+
+// luminance returns the relative luminance of the color (WCAG).
+func luminance(c [4]float64) float64 {
+	var l [3]float64
+
+	for i := 0; i < 3; i++ {
+		v := c[i] / 255
+		if v <= 0.03928 {
+			l[i] = v / 12.92
+		} else {
+			l[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2]
+}
+
+// ContrastRatio returns the WCAG contrast ratio (1 to 21) between two opaque colors.
+func ContrastRatio(fg, bg [4]float64) float64 {
+	l1, l2 := luminance(fg), luminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// truncateText truncates s to max characters (not bytes), adding "..." if truncated.
+func truncateText(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max]) + "..."
+}
+
+func colorString(c [4]float64) string {
+	return fmt.Sprintf("rgb(%v, %v, %v)", math.Round(c[0]), math.Round(c[1]), math.Round(c[2]))
+}
+
+// ContrastIssue is an element whose text doesn't have enough contrast with its background.
+type ContrastIssue struct {
+	BackendNodeID int     `json:"backendNodeId"`
+	Node          string  `json:"node"` // tag#id.class
+	Text          string  `json:"text"`
+	Color         string  `json:"color"`
+	Background    string  `json:"background"`
+	FontSize      float64 `json:"fontSize"`
+	Bold          bool    `json:"bold"`
+	Ratio         float64 `json:"ratio"`
+	Required      float64 `json:"required"`
+}
+
+// AuditContrast computes the contrast ratio of the visible text of the current page with its background,
+// from a DOM snapshot (layout and computed styles), and returns the elements below MinContrastRatio
+// (or MinLargeContrastRatio for large text).
+//
+// No script is injected in the page, so it works when the Content-Security-Policy blocks script injection.
+// The background is the closest background color of the element and its ancestors (white if none):
+// the text over a background image is skipped, and opacity and positioned overlapping elements
+// are not taken into account.
+func (remote *RemoteDebugger) AuditContrast() ([]ContrastIssue, error) {
+	snap, err := remote.captureSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ContrastIssue
+
+	for d, doc := range snap.Documents {
+		nodes := doc.Nodes
+		layouts := snap.layouts(d)
+		seen := map[int]bool{}
+
+		for i, t := range nodes.NodeType {
+			if t != 3 || i >= len(nodes.NodeValue) { // text nodes
+				continue
+			}
+
+			text := strings.TrimSpace(snap.str(nodes.NodeValue[i]))
+			parent := nodes.ParentIndex[i]
+			if text == "" || parent < 0 || seen[parent] || layouts[i] < 0 {
+				continue
+			}
+
+			el := snapshotNode{snap: snap, doc: d, index: parent, layout: layouts[parent]}
+			if b := el.bounds(); b == nil || b[2] == 0 || b[3] == 0 || el.style(styleVisibility) == "hidden" {
+				continue
+			}
+
+			if el.style(styleOpacity) == "0" {
+				continue
+			}
+
+			fg, ok := parseColor(el.style(styleColor))
+			if !ok {
+				continue
+			}
+
+			bg, ok := snap.background(d, parent, layouts)
+			if !ok {
+				continue
+			}
+
+			seen[parent] = true
+
+			fg = blend(fg, bg)
+			ratio := ContrastRatio(fg, bg)
+
+			size, _ := strconv.ParseFloat(strings.TrimSuffix(el.style(styleFontSize), "px"), 64)
+			weight, _ := strconv.Atoi(el.style(styleFontWeight))
+			bold := weight >= 700 || el.style(styleFontWeight) == "bold"
+
+			required := MinContrastRatio
+			if size >= 24 || (size >= 18.66 && bold) {
+				required = MinLargeContrastRatio
+			}
+
+			if ratio >= required {
+				continue
+			}
+
+			text = truncateText(text, 80)
+
+			issues = append(issues, ContrastIssue{
+				BackendNodeID: el.backendNodeID(),
+				Node:          el.describe(),
+				Text:          text,
+				Color:         colorString(fg),
+				Background:    colorString(bg),
+				FontSize:      size,
+				Bold:          bold,
+				Ratio:         math.Round(ratio*100) / 100,
+				Required:      required,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// background returns the opaque background color of the element, composing the background colors
+// of the element and its ancestors over white. It returns false if there is a background image.
+func (s *domSnapshot) background(doc, index int, layouts []int) ([4]float64, bool) {
+	var layers [][4]float64
+	nodes := s.Documents[doc].Nodes
+
+	for i := index; i >= 0; i = nodes.ParentIndex[i] {
+		n := snapshotNode{snap: s, doc: doc, index: i, layout: layouts[i]}
+
+		if img := n.style(styleBackgroundImage); img != "" && img != "none" {
+			return [4]float64{}, false
+		}
+
+		c, ok := parseColor(n.style(styleBackgroundColor))
+		if !ok || c[3] == 0 {
+			continue
+		}
+
+		layers = append(layers, c)
+		if c[3] >= 1 {
+			break
+		}
+	}
+
+	bg := [4]float64{255, 255, 255, 1}
+	for i := len(layers) - 1; i >= 0; i-- {
+		bg = blend(layers[i], bg)
+	}
+
+	return bg, true
+}
+
+// TapTargetIssue is a clickable element smaller than the minimum tap target size.
+type TapTargetIssue struct {
+	BackendNodeID int     `json:"backendNodeId"`
+	Node          string  `json:"node"` // tag#id.class
+	Width         float64 `json:"width"`
+	Height        float64 `json:"height"`
+}
+
+// AuditTapTargets returns the visible clickable elements (links, buttons, form controls and elements with
+// click listeners) of the current page that are smaller than minSize (default MinTapTargetSize) CSS pixels
+// in width or height, from a DOM snapshot. Inline links (in a text block) are exempted.
+//
+// No script is injected in the page, so it works when the Content-Security-Policy blocks script injection.
+func (remote *RemoteDebugger) AuditTapTargets(minSize float64) ([]TapTargetIssue, error) {
+	if minSize <= 0 {
+		minSize = MinTapTargetSize
+	}
+
+	snap, err := remote.captureSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []TapTargetIssue
+
+	for d, doc := range snap.Documents {
+		nodes := doc.Nodes
+		layouts := snap.layouts(d)
+
+		clickable := map[int]bool{}
+		for _, i := range nodes.IsClickable.Index {
+			clickable[i] = true
+		}
+
+		for i, t := range nodes.NodeType {
+			if t != 1 || layouts[i] < 0 { // rendered elements
+				continue
+			}
+
+			el := snapshotNode{snap: snap, doc: d, index: i, layout: layouts[i]}
+
+			switch el.tag() {
+			case "a", "button", "select", "textarea", "input", "summary":
+			default:
+				if !clickable[i] {
+					continue
+				}
+			}
+
+			if el.tag() == "input" && el.attr("type") == "hidden" {
+				continue
+			}
+
+			if el.style(styleDisplay) == "inline" && el.tag() == "a" {
+				continue // inline links are exempted
+			}
+
+			b := el.bounds()
+			if b == nil || b[2] == 0 || b[3] == 0 || el.style(styleVisibility) == "hidden" {
+				continue
+			}
+
+			if b[2] >= minSize && b[3] >= minSize {
+				continue
+			}
+
+			if snap.insideClickable(d, i, clickable) {
+				continue // the tap target is the clickable ancestor
+			}
+
+			issues = append(issues, TapTargetIssue{
+				BackendNodeID: el.backendNodeID(),
+				Node:          el.describe(),
+				Width:         b[2],
+				Height:        b[3],
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+func (n snapshotNode) tag() string {
+	return strings.ToLower(n.snap.str(n.snap.Documents[n.doc].Nodes.NodeName[n.index]))
+}
+
+func (n snapshotNode) attr(name string) string {
+	nodes := n.snap.Documents[n.doc].Nodes
+	if n.index >= len(nodes.Attributes) {
+		return ""
+	}
+
+	attrs := nodes.Attributes[n.index]
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if n.snap.str(attrs[i]) == name {
+			return n.snap.str(attrs[i+1])
+		}
+	}
+
+	return ""
+}
+
+// insideClickable returns true if an ancestor of the node is a link, a button or clickable.
+func (s *domSnapshot) insideClickable(doc, index int, clickable map[int]bool) bool {
+	nodes := s.Documents[doc].Nodes
+
+	for i := nodes.ParentIndex[index]; i >= 0; i = nodes.ParentIndex[i] {
+		switch strings.ToLower(s.str(nodes.NodeName[i])) {
+		case "a", "button":
+			return true
+		case "body":
+			return false
+		}
+
+		if clickable[i] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,239 @@
+package godet
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		s  string
+		c  [4]float64
+		ok bool
+	}{
+		{"rgb(255, 0, 10)", [4]float64{255, 0, 10, 1}, true},
+		{"rgba(0, 0, 0, 0.5)", [4]float64{0, 0, 0, 0.5}, true},
+		{"rgb(0 128 255 / 25%)", [4]float64{0, 128, 255, 0.25}, true},
+		{"rgb(100%, 0%, 50%)", [4]float64{255, 0, 127.5, 1}, true},
+		{"#ffffff", [4]float64{}, false},
+		{"rgb(1, 2)", [4]float64{}, false},
+		{"rgb(a, b, c)", [4]float64{}, false},
+		{"", [4]float64{}, false},
+	}
+
+	for _, tt := range tests {
+		c, ok := parseColor(tt.s)
+		if ok != tt.ok || (ok && c != tt.c) {
+			t.Errorf("parseColor(%q) = %v, %v, want %v, %v", tt.s, c, ok, tt.c, tt.ok)
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black := [4]float64{0, 0, 0, 1}
+	white := [4]float64{255, 255, 255, 1}
+
+	tests := []struct {
+		fg, bg [4]float64
+		ratio  float64
+	}{
+		{black, white, 21},
+		{white, black, 21},
+		{white, white, 1},
+		{[4]float64{119, 119, 119, 1}, white, 4.48},
+		{blend([4]float64{0, 0, 0, 0.5}, white), white, 3.98},
+	}
+
+	for _, tt := range tests {
+		if r := ContrastRatio(tt.fg, tt.bg); math.Abs(r-tt.ratio) > 0.01 {
+			t.Errorf("ContrastRatio(%v, %v) = %.2f, want %.2f", tt.fg, tt.bg, r, tt.ratio)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"short", 80, "short"},
+		{"abcdef", 3, "abc..."},
+		{"ééé", 3, "ééé"},
+		{"日本語のテキスト", 3, "日本語..."},
+	}
+
+	for _, tt := range tests {
+		if got := truncateText(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+// snapshotBuilder builds a single document DOMSnapshot.captureSnapshot reply.
+type snapshotBuilder struct {
+	strings   []string
+	index     map[string]int
+	parent    []int
+	nodeType  []int
+	nodeName  []int
+	nodeValue []int
+	attrs     [][]int
+	layoutOf  []int
+	styles    [][]int
+	bounds    [][]float64
+}
+
+func (b *snapshotBuilder) str(s string) int {
+	if b.index == nil {
+		b.index = map[string]int{}
+	}
+
+	if i, ok := b.index[s]; ok {
+		return i
+	}
+
+	b.strings = append(b.strings, s)
+	b.index[s] = len(b.strings) - 1
+	return len(b.strings) - 1
+}
+
+// node adds a node and its layout (if bounds is set), with the styles by name.
+func (b *snapshotBuilder) node(parent, nodeType int, name, value string, attrs []string, styles map[string]string, bounds []float64) int {
+	b.parent = append(b.parent, parent)
+	b.nodeType = append(b.nodeType, nodeType)
+	b.nodeName = append(b.nodeName, b.str(name))
+	b.nodeValue = append(b.nodeValue, b.str(value))
+
+	var a []int
+	for _, s := range attrs {
+		a = append(a, b.str(s))
+	}
+	b.attrs = append(b.attrs, a)
+
+	index := len(b.parent) - 1
+
+	if bounds != nil {
+		var st []int
+		for _, name := range snapshotStyles {
+			st = append(st, b.str(styles[name]))
+		}
+
+		b.layoutOf = append(b.layoutOf, index)
+		b.styles = append(b.styles, st)
+		b.bounds = append(b.bounds, bounds)
+	}
+
+	return index
+}
+
+func (b *snapshotBuilder) reply() Params {
+	return Params{
+		"documents": []Params{{
+			"nodes": Params{
+				"parentIndex":   b.parent,
+				"nodeType":      b.nodeType,
+				"nodeName":      b.nodeName,
+				"nodeValue":     b.nodeValue,
+				"backendNodeId": b.parent, // any value
+				"attributes":    b.attrs,
+			},
+			"layout": Params{
+				"nodeIndex": b.layoutOf,
+				"styles":    b.styles,
+				"bounds":    b.bounds,
+			},
+		}},
+		"strings": b.strings,
+	}
+}
+
+func snapshotBrowser(t *testing.T, b *snapshotBuilder) *RemoteDebugger {
+	return connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		if method == "DOMSnapshot.captureSnapshot" {
+			return b.reply(), nil
+		}
+
+		return nil, &ProtocolError{Code: -32601, Message: "'" + method + "' wasn't found"}
+	}))
+}
+
+func TestAuditContrast(t *testing.T) {
+	var b snapshotBuilder
+
+	box := []float64{0, 0, 100, 20}
+	text := map[string]string{"color": "rgb(0, 0, 0)", "font-size": "16px", "font-weight": "400"}
+	gray := map[string]string{"color": "rgb(170, 170, 170)", "font-size": "16px", "font-weight": "400"}
+	largeGray := map[string]string{"color": "rgb(140, 140, 140)", "font-size": "24px", "font-weight": "400"}
+
+	doc := b.node(-1, 9, "#document", "", nil, nil, nil)
+	body := b.node(doc, 1, "BODY", "", nil, map[string]string{"background-color": "rgb(255, 255, 255)"}, []float64{0, 0, 800, 600})
+
+	p := b.node(body, 1, "P", "", []string{"id", "low", "class", "note small"}, gray, box)
+	b.node(p, 3, "#text", strings.Repeat("é", 100), nil, nil, box)
+
+	ok := b.node(body, 1, "P", "", nil, text, box)
+	b.node(ok, 3, "#text", "readable", nil, nil, box)
+
+	large := b.node(body, 1, "H1", "", nil, largeGray, box)
+	b.node(large, 3, "#text", "large text", nil, nil, box)
+
+	hidden := b.node(body, 1, "P", "", nil, map[string]string{"color": "rgb(250, 250, 250)", "visibility": "hidden"}, box)
+	b.node(hidden, 3, "#text", "hidden", nil, nil, box)
+
+	image := b.node(body, 1, "DIV", "", nil, map[string]string{"color": "rgb(250, 250, 250)", "background-image": "url(x.png)"}, box)
+	b.node(image, 3, "#text", "over an image", nil, nil, box)
+
+	remote := snapshotBrowser(t, &b)
+
+	issues, err := remote.AuditContrast()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("%d issues, want 1: %+v", len(issues), issues)
+	}
+
+	issue := issues[0]
+	if issue.Node != "p#low.note.small" {
+		t.Errorf("Node = %q", issue.Node)
+	}
+	if issue.Text != strings.Repeat("é", 80)+"..." {
+		t.Errorf("Text = %q, want 80 characters", issue.Text)
+	}
+	if issue.Color != "rgb(170, 170, 170)" || issue.Background != "rgb(255, 255, 255)" {
+		t.Errorf("Color = %s, Background = %s", issue.Color, issue.Background)
+	}
+	if issue.Required != MinContrastRatio || math.Abs(issue.Ratio-2.32) > 0.01 {
+		t.Errorf("Ratio = %.2f, Required = %v", issue.Ratio, issue.Required)
+	}
+}
+
+func TestAuditTapTargets(t *testing.T) {
+	var b snapshotBuilder
+
+	doc := b.node(-1, 9, "#document", "", nil, nil, nil)
+	body := b.node(doc, 1, "BODY", "", nil, nil, []float64{0, 0, 800, 600})
+
+	b.node(body, 1, "BUTTON", "", []string{"id", "small"}, map[string]string{"display": "inline-block"}, []float64{0, 0, 20, 20})
+	b.node(body, 1, "BUTTON", "", []string{"id", "big"}, map[string]string{"display": "inline-block"}, []float64{0, 0, 48, 48})
+	b.node(body, 1, "A", "", nil, map[string]string{"display": "inline"}, []float64{0, 0, 20, 10}) // inline link
+	b.node(body, 1, "INPUT", "", []string{"type", "hidden"}, nil, []float64{0, 0, 0, 0})
+	b.node(body, 1, "DIV", "", nil, nil, []float64{0, 0, 10, 10}) // not clickable
+
+	link := b.node(body, 1, "A", "", nil, map[string]string{"display": "block"}, []float64{0, 0, 100, 100})
+	b.node(link, 1, "BUTTON", "", nil, nil, []float64{0, 0, 10, 10}) // inside a link
+
+	remote := snapshotBrowser(t, &b)
+
+	issues, err := remote.AuditTapTargets(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 || issues[0].Node != "button#small" || issues[0].Width != 20 {
+		t.Errorf("issues = %+v, want button#small", issues)
+	}
+}
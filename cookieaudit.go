@@ -0,0 +1,359 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxCookieLifetime is the maximum lifetime of a cookie before CookieReport flags it (13 months,
+// as recommended by the European data protection authorities).
+var MaxCookieLifetime = 396 * 24 * time.Hour
+
+// CookieRecord is a cookie set during the audit (see AuditCookies).
+type CookieRecord struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+
+	// SetBy is "header" for the cookies set by a Set-Cookie response header, "script" for the others.
+	SetBy string `json:"setBy"`
+
+	// URL is the URL of the response that set the cookie (only for SetBy "header").
+	URL  string    `json:"url,omitempty"`
+	Time time.Time `json:"time,omitempty"`
+
+	ThirdParty bool   `json:"thirdParty"`
+	SameSite   string `json:"sameSite"` // Strict, Lax, None or empty if not specified
+	Secure     bool   `json:"secure"`
+	HttpOnly   bool   `json:"httpOnly"`
+	Session    bool   `json:"session"`
+
+	// Expires is the expiration time (zero for session cookies).
+	Expires time.Time `json:"expires,omitempty"`
+
+	// BeforeConsent is true if the cookie was set before MarkConsent was called (or if it wasn't called).
+	BeforeConsent bool `json:"beforeConsent"`
+
+	// BlockedReasons are the reasons the browser rejected the cookie, if it did.
+	BlockedReasons []string `json:"blockedReasons,omitempty"`
+
+	// Problems are the compliance issues of the cookie.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// CookieReport is the result of the cookie audit.
+type CookieReport struct {
+	// Document is the URL of the page loaded in the main frame.
+	Document string `json:"document"`
+
+	// Consent is the time MarkConsent was called (zero if it wasn't).
+	Consent time.Time `json:"consent,omitempty"`
+
+	Cookies []CookieRecord `json:"cookies"`
+
+	FirstParty    int `json:"firstParty"`
+	ThirdParty    int `json:"thirdParty"`
+	BeforeConsent int `json:"beforeConsent"`
+	Blocked       int `json:"blocked"`
+	Problems      int `json:"problems"`
+}
+
+// cookieAudit holds the state of the cookie audit started by AuditCookies.
+type cookieAudit struct {
+	sync.Mutex
+	document      string
+	requests      map[string]string // requestId to URL
+	cookies       []CookieRecord
+	consent       time.Time
+	beforeConsent map[string]bool   // the cookies present when MarkConsent was called
+	existing      map[string]string // the cookies (and their values) present when the audit started
+	stop          []func()
+}
+
+func cookieKey(name, domain, path string) string {
+	return name + ";" + strings.TrimPrefix(domain, ".") + ";" + path
+}
+
+// AuditCookies starts (or stops) recording the cookies set by the responses (via the Set-Cookie headers reported
+// by Network.responseReceivedExtraInfo, including the cookies blocked by the browser), for CookieReport.
+// Network events are enabled, if needed. Starting the audit again resets it.
+//
+// The cookies already stored when the audit starts are not reported, unless they are set again.
+func (remote *RemoteDebugger) AuditCookies(enable bool) error {
+	remote.Lock()
+	audit := remote.cookieAudit
+	remote.cookieAudit = nil
+	remote.Unlock()
+
+	if audit != nil {
+		for _, stop := range audit.stop {
+			stop()
+		}
+	}
+
+	if !enable {
+		return nil
+	}
+
+	if err := remote.ensureDomain("Network"); err != nil {
+		return err
+	}
+
+	stored, err := remote.storageCookies()
+	if err != nil {
+		return err
+	}
+
+	audit = &cookieAudit{requests: map[string]string{}, existing: map[string]string{}}
+
+	for _, c := range stored {
+		audit.existing[cookieKey(c.Name, c.Domain, c.Path)] = c.Value
+	}
+
+	audit.stop = append(audit.stop, remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+		reqURL := Params(params.Map("request")).String("url")
+
+		remote.Lock()
+		current := remote.current
+		remote.Unlock()
+
+		audit.Lock()
+		audit.requests[params.String("requestId")] = reqURL
+		if params.String("type") == string(ResourceTypeDocument) && params.String("frameId") == current {
+			audit.document = reqURL
+		}
+		audit.Unlock()
+		return false
+	}))
+
+	audit.stop = append(audit.stop, remote.addHook("Network.responseReceivedExtraInfo", func(params Params) bool {
+		audit.Lock()
+		defer audit.Unlock()
+
+		audit.addResponse(audit.requests[params.String("requestId")], params)
+		return false
+	}))
+
+	done := func(params Params) bool {
+		audit.Lock()
+		delete(audit.requests, params.String("requestId"))
+		audit.Unlock()
+		return false
+	}
+
+	audit.stop = append(audit.stop, remote.addHook("Network.loadingFinished", done))
+	audit.stop = append(audit.stop, remote.addHook("Network.loadingFailed", done))
+
+	remote.Lock()
+	remote.cookieAudit = audit
+	remote.Unlock()
+	return nil
+}
+
+// addResponse records the cookies set by the response headers (and the blocked ones).
+func (audit *cookieAudit) addResponse(respURL string, params Params) {
+	now := time.Now()
+
+	for _, c := range setCookies(respURL, params, now) {
+		audit.cookies = append(audit.cookies, CookieRecord{
+			Name:           c.Name,
+			Domain:         c.Domain,
+			Path:           c.Path,
+			SetBy:          "header",
+			URL:            respURL,
+			Time:           now,
+			SameSite:       c.SameSite,
+			Secure:         c.Secure,
+			HttpOnly:       c.HttpOnly,
+			Session:        c.Expires.IsZero(),
+			Expires:        c.Expires,
+			BlockedReasons: c.BlockedReasons,
+		})
+	}
+}
+
+// storageCookies returns all the cookies of the browser context.
+func (remote *RemoteDebugger) storageCookies() ([]Cookie, error) {
+	raw, err := remote.sendRawReplyRequest("Storage.getCookies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Cookies []Cookie `json:"cookies"`
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Cookies, nil
+}
+
+// MarkConsent marks the user consent interaction (i.e. after clicking "accept" in the cookie banner):
+// the cookies set until now are reported as set before consent.
+func (remote *RemoteDebugger) MarkConsent() error {
+	remote.Lock()
+	audit := remote.cookieAudit
+	remote.Unlock()
+
+	if audit == nil {
+		return nil
+	}
+
+	cookies, err := remote.storageCookies()
+	if err != nil {
+		return err
+	}
+
+	audit.Lock()
+	defer audit.Unlock()
+
+	audit.consent = time.Now()
+	audit.beforeConsent = map[string]bool{}
+
+	for _, c := range cookies {
+		audit.beforeConsent[cookieKey(c.Name, c.Domain, c.Path)] = true
+	}
+
+	return nil
+}
+
+// CookieReport returns the cookies set since AuditCookies was called: the cookies set by the response
+// headers (including the ones blocked by the browser) and the other cookies currently stored that are
+// new or changed since the audit started (set by scripts), classified as first or third party (relative
+// to the page loaded in the main frame) and set before or after the consent (see MarkConsent),
+// with their compliance problems.
+func (remote *RemoteDebugger) CookieReport() (*CookieReport, error) {
+	remote.Lock()
+	audit := remote.cookieAudit
+	remote.Unlock()
+
+	if audit == nil {
+		return &CookieReport{}, nil
+	}
+
+	stored, err := remote.storageCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Lock()
+	defer audit.Unlock()
+
+	report := &CookieReport{Document: audit.document, Consent: audit.consent}
+
+	var docDomain string
+	if du, err := url.Parse(audit.document); err == nil {
+		docDomain = hostDomain(du.Hostname())
+	}
+
+	seen := map[string]bool{}
+
+	for _, c := range audit.cookies {
+		seen[cookieKey(c.Name, c.Domain, c.Path)] = true
+		c.BeforeConsent = audit.consent.IsZero() || c.Time.Before(audit.consent)
+		report.Cookies = append(report.Cookies, c)
+	}
+
+	for _, c := range stored {
+		key := cookieKey(c.Name, c.Domain, c.Path)
+		if seen[key] {
+			continue
+		}
+
+		if value, ok := audit.existing[key]; ok && value == c.Value {
+			continue // not set during the audit
+		}
+
+		seen[key] = true
+
+		rec := CookieRecord{
+			Name:          c.Name,
+			Domain:        c.Domain,
+			Path:          c.Path,
+			SetBy:         "script",
+			SameSite:      c.SameSite,
+			Secure:        c.Secure,
+			HttpOnly:      c.HttpOnly,
+			Session:       c.Session,
+			BeforeConsent: audit.consent.IsZero() || audit.beforeConsent[key],
+		}
+
+		if !c.Session && c.Expires > 0 {
+			rec.Expires = time.Unix(int64(c.Expires), 0)
+		}
+
+		report.Cookies = append(report.Cookies, rec)
+	}
+
+	for i := range report.Cookies {
+		c := &report.Cookies[i]
+
+		c.ThirdParty = docDomain != "" && hostDomain(strings.TrimPrefix(c.Domain, ".")) != docDomain
+		c.Problems = cookieProblems(c)
+
+		if c.ThirdParty {
+			report.ThirdParty++
+		} else {
+			report.FirstParty++
+		}
+		if c.BeforeConsent {
+			report.BeforeConsent++
+		}
+		if len(c.BlockedReasons) > 0 {
+			report.Blocked++
+		}
+		if len(c.Problems) > 0 {
+			report.Problems++
+		}
+	}
+
+	sort.SliceStable(report.Cookies, func(i, j int) bool {
+		if report.Cookies[i].Domain != report.Cookies[j].Domain {
+			return report.Cookies[i].Domain < report.Cookies[j].Domain
+		}
+
+		return report.Cookies[i].Name < report.Cookies[j].Name
+	})
+
+	return report, nil
+}
+
+// cookieProblems returns the compliance issues of the cookie.
+func cookieProblems(c *CookieRecord) []string {
+	var problems []string
+
+	if c.ThirdParty && c.BeforeConsent {
+		problems = append(problems, "third party cookie set before consent")
+	}
+	if c.SameSite == "None" && !c.Secure {
+		problems = append(problems, "SameSite=None without Secure")
+	}
+	if c.SameSite == "" && c.SetBy == "header" {
+		problems = append(problems, "no SameSite attribute")
+	}
+	if !c.Secure && strings.HasPrefix(c.URL, "https:") {
+		problems = append(problems, "not Secure, set over HTTPS")
+	}
+
+	start := c.Time
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	if !c.Session && !c.Expires.IsZero() && c.Expires.Sub(start) > MaxCookieLifetime {
+		problems = append(problems, fmt.Sprintf("expires in more than %d days", int(MaxCookieLifetime.Hours()/24)))
+	}
+
+	if len(c.BlockedReasons) > 0 {
+		problems = append(problems, "blocked by the browser: "+strings.Join(c.BlockedReasons, ", "))
+	}
+
+	return problems
+}
@@ -0,0 +1,86 @@
+package godet
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCookieReport(t *testing.T) {
+	var lock sync.Mutex
+
+	stored := []Cookie{
+		{Name: "old", Value: "a", Domain: "www.shop.co.uk", Path: "/", Session: true},
+		{Name: "changed", Value: "x", Domain: ".shop.co.uk", Path: "/", Session: true},
+	}
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		if method == "Storage.getCookies" {
+			lock.Lock()
+			defer lock.Unlock()
+			return Params{"cookies": stored}, nil
+		}
+
+		return nil, nil
+	}))
+
+	if err := remote.AuditCookies(true); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeEvent(remote, "Network.requestWillBeSent", Params{
+		"requestId": "1",
+		"frameId":   "fake",
+		"type":      ResourceTypeDocument,
+		"request":   Params{"url": "https://www.shop.co.uk/", "method": "GET"},
+	})
+	fakeEvent(remote, "Network.responseReceivedExtraInfo", Params{
+		"requestId": "1",
+		"headers":   Params{"set-cookie": "sid=1; Path=/; Secure; HttpOnly; SameSite=Lax"},
+	})
+	fakeEvent(remote, "Network.loadingFinished", Params{"requestId": "1"})
+
+	lock.Lock()
+	stored = []Cookie{
+		stored[0],
+		{Name: "changed", Value: "y", Domain: ".shop.co.uk", Path: "/", Session: true},
+		{Name: "sid", Value: "1", Domain: "www.shop.co.uk", Path: "/", Secure: true, HttpOnly: true, SameSite: "Lax", Session: true},
+		{Name: "_ga", Value: "z", Domain: ".other.co.uk", Path: "/", Session: true},
+	}
+	lock.Unlock()
+
+	report, err := remote.CookieReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cookies []string
+	for _, c := range report.Cookies {
+		party := "first"
+		if c.ThirdParty {
+			party = "third"
+		}
+
+		cookies = append(cookies, c.Name+":"+c.SetBy+":"+party)
+	}
+
+	want := []string{"_ga:script:third", "changed:script:first", "sid:header:first"} // sorted by domain
+	if !reflect.DeepEqual(cookies, want) {
+		t.Errorf("cookies = %q, want %q", cookies, want)
+	}
+
+	if report.FirstParty != 2 || report.ThirdParty != 1 || report.Document != "https://www.shop.co.uk/" {
+		t.Errorf("report = %+v", report)
+	}
+
+	remote.Lock()
+	audit := remote.cookieAudit
+	remote.Unlock()
+
+	audit.Lock()
+	defer audit.Unlock()
+
+	if len(audit.requests) != 0 {
+		t.Errorf("requests still tracked after loadingFinished: %v", audit.requests)
+	}
+}
@@ -0,0 +1,229 @@
+package crawl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// Page is a crawled page.
+type Page struct {
+	URL       string    `json:"url"`
+	Status    int       `json:"status"`
+	Title     string    `json:"title"`
+	Canonical string    `json:"canonical,omitempty"`
+	Outlinks  []string  `json:"outlinks"`
+	Depth     int       `json:"depth"`
+	Fetched   time.Time `json:"fetched"`
+	Error     string    `json:"error,omitempty"`
+
+	// LastModified is the Last-Modified header of the page, if sent by the server.
+	LastModified *time.Time `json:"lastModified,omitempty"`
+}
+
+// Crawler visits the pages of a site, following links, with a RemoteDebugger.
+type Crawler struct {
+	Remote *godet.RemoteDebugger
+
+	// Robots, if set, is used to skip the pages disallowed by robots.txt and to wait for the crawl delay.
+	Robots *RobotsCache
+
+	// MaxPages is the maximum number of pages to visit (0 means no limit).
+	MaxPages int
+
+	// MaxDepth is the maximum number of links to follow from the seeds (0 means no limit).
+	MaxDepth int
+
+	// AllOrigins follows links to other origins. By default only links with the same origin of a seed are followed.
+	AllOrigins bool
+
+	// Timeout is the maximum time to wait for each page to load (30 seconds if not set).
+	Timeout time.Duration
+
+	// OnPage, if set, is called for each visited page.
+	OnPage func(page *Page)
+}
+
+// pageInfoJS extracts the crawl information from the current page.
+const pageInfoJS = `var nav = performance.getEntriesByType("navigation")[0];
+	var canonical = document.querySelector("link[rel=canonical]");
+	var links = [];
+	document.querySelectorAll("a[href]").forEach(function(a) { links.push(a.href); });
+	return JSON.stringify({
+		url: location.href,
+		status: nav && nav.responseStatus || 0,
+		title: document.title,
+		canonical: canonical ? canonical.href : "",
+		outlinks: links
+	});`
+
+// normalize returns the URL without fragment, or "" if it's not an http(s) URL.
+func normalize(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	u.Fragment = ""
+	return u.String()
+}
+
+// lastModified returns the Last-Modified header, or nil if missing or invalid.
+func lastModified(headers map[string]string) *time.Time {
+	for k, v := range headers {
+		if strings.EqualFold(k, "Last-Modified") {
+			if t, err := http.ParseTime(v); err == nil {
+				return &t
+			}
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// visit loads the page and extracts title, canonical and links.
+// The page URL is updated to the final URL, after the redirects.
+func (c *Crawler) visit(page *Page) {
+	page.Fetched = time.Now()
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	result, err := c.Remote.NavigateAndWait(page.URL, timeout)
+	if err != nil {
+		page.Error = err.Error()
+		return
+	}
+
+	res, err := c.Remote.EvaluateWrap(pageInfoJS)
+	if err != nil {
+		page.Error = err.Error()
+		return
+	}
+
+	s, _ := res.(string)
+
+	var info Page
+	if err := json.Unmarshal([]byte(s), &info); err != nil {
+		page.Error = err.Error()
+		return
+	}
+
+	if final := normalize(result.URL); final != "" {
+		page.URL = final
+	}
+
+	page.Status, page.Title, page.Canonical = result.Status, info.Title, info.Canonical
+	page.LastModified = lastModified(result.Headers)
+	if page.Status == 0 {
+		page.Status = info.Status
+	}
+
+	seen := map[string]bool{}
+
+	for _, link := range info.Outlinks {
+		if link = normalize(link); link != "" && !seen[link] {
+			seen[link] = true
+			page.Outlinks = append(page.Outlinks, link)
+		}
+	}
+}
+
+// follow returns true if the link is on one of the seed origins, or AllOrigins is set.
+func (c *Crawler) follow(origins map[string]bool, link string) bool {
+	if c.AllOrigins {
+		return true
+	}
+
+	origin, err := Origin(link)
+	return err == nil && origins[origin]
+}
+
+// Crawl visits the seed URLs and the pages they link to (breadth first), and returns the visited pages.
+// Pages disallowed by robots.txt are skipped, as are the pages redirected to a page already visited
+// or to another origin (unless AllOrigins is set).
+//
+// Navigations are subject to the rate limiter of the RemoteDebugger (see godet.NavigationLimit).
+func (c *Crawler) Crawl(ctx context.Context, seeds ...string) ([]*Page, error) {
+	origins := map[string]bool{}
+	queued := map[string]bool{}
+
+	var queue []*Page
+
+	for _, seed := range seeds {
+		seed = normalize(seed)
+		if seed == "" || queued[seed] {
+			continue
+		}
+
+		if origin, err := Origin(seed); err == nil {
+			origins[origin] = true
+		}
+
+		queued[seed] = true
+		queue = append(queue, &Page{URL: seed})
+	}
+
+	var pages []*Page
+
+	for len(queue) > 0 && (c.MaxPages <= 0 || len(pages) < c.MaxPages) {
+		if err := ctx.Err(); err != nil {
+			return pages, err
+		}
+
+		page := queue[0]
+		queue = queue[1:]
+
+		if c.Robots != nil {
+			if allowed, err := c.Robots.Allowed(ctx, page.URL); err != nil || !allowed {
+				continue
+			}
+
+			if err := c.Robots.Wait(ctx, page.URL); err != nil {
+				return pages, err
+			}
+		}
+
+		requested := page.URL
+		c.visit(page)
+
+		if page.URL != requested {
+			// redirected to another site or to a page already visited
+			if queued[page.URL] || !c.follow(origins, page.URL) {
+				continue
+			}
+
+			queued[page.URL] = true
+		}
+
+		pages = append(pages, page)
+
+		if c.OnPage != nil {
+			c.OnPage(page)
+		}
+
+		if c.MaxDepth > 0 && page.Depth >= c.MaxDepth {
+			continue
+		}
+
+		for _, link := range page.Outlinks {
+			if queued[link] || !c.follow(origins, link) {
+				continue
+			}
+
+			queued[link] = true
+			queue = append(queue, &Page{URL: link, Depth: page.Depth + 1})
+		}
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,75 @@
+package crawl
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+// sitemapURL is an entry of sitemap.xml.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// Indexable returns true if the page should be listed in a sitemap: it was loaded successfully
+// and it's not a duplicate of another (canonical) page.
+func (p *Page) Indexable() bool {
+	if p.Error != "" || p.Status < 200 || p.Status > 299 {
+		return false
+	}
+
+	return p.Canonical == "" || normalize(p.Canonical) == p.URL
+}
+
+// WriteSitemap writes the indexable pages (see Page.Indexable) as a sitemap.xml file.
+// The lastmod of a page is only set if the server sent a Last-Modified header: the crawl time is not a modification time.
+func WriteSitemap(w io.Writer, pages []*Page) error {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	for _, p := range pages {
+		if !p.Indexable() {
+			continue
+		}
+
+		entry := sitemapURL{Loc: p.URL}
+		if p.LastModified != nil {
+			entry.LastMod = p.LastModified.UTC().Format("2006-01-02")
+		}
+
+		set.URLs = append(set.URLs, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(set); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteGraph writes the site graph as JSON lines, one page (with its outlinks, status, title and canonical) per line.
+func WriteGraph(w io.Writer, pages []*Page) error {
+	enc := json.NewEncoder(w)
+
+	for _, p := range pages {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package crawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSitemap(t *testing.T) {
+	modified := time.Date(2024, 3, 15, 23, 30, 0, 0, time.FixedZone("PST", -8*3600))
+
+	pages := []*Page{
+		{URL: "https://example.com/", Status: 200, Fetched: time.Now(), LastModified: &modified},
+		{URL: "https://example.com/dynamic", Status: 200, Fetched: time.Now()},
+		{URL: "https://example.com/missing", Status: 404, Fetched: time.Now()},
+		{URL: "https://example.com/failed", Error: "timeout"},
+		{URL: "https://example.com/copy", Status: 200, Canonical: "https://example.com/"},
+		{URL: "https://example.com/self", Status: 200, Canonical: "https://example.com/self#top"},
+	}
+
+	var b bytes.Buffer
+	if err := WriteSitemap(&b, pages); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2024-03-16</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/dynamic</loc>
+  </url>
+  <url>
+    <loc>https://example.com/self</loc>
+  </url>
+</urlset>
+`
+
+	if got := b.String(); got != want {
+		t.Errorf("sitemap:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	tests := []struct {
+		headers map[string]string
+		want    time.Time // zero if nil
+	}{
+		{map[string]string{"Last-Modified": "Wed, 21 Oct 2015 07:28:00 GMT"}, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)},
+		{map[string]string{"last-modified": "Wed, 21 Oct 2015 07:28:00 GMT"}, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)},
+		{map[string]string{"Last-Modified": "yesterday"}, time.Time{}},
+		{map[string]string{"Date": "Wed, 21 Oct 2015 07:28:00 GMT"}, time.Time{}},
+		{nil, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		got := lastModified(tt.headers)
+
+		if tt.want.IsZero() {
+			if got != nil {
+				t.Errorf("lastModified(%v) = %v, want nil", tt.headers, got)
+			}
+		} else if got == nil || !got.Equal(tt.want) {
+			t.Errorf("lastModified(%v) = %v, want %v", tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestPageJSON(t *testing.T) {
+	b, err := json.Marshal(&Page{URL: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "lastModified") {
+		t.Errorf("a page without Last-Modified is encoded as %s", b)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	origins := map[string]bool{"https://example.com": true}
+
+	tests := []struct {
+		link       string
+		allOrigins bool
+		follow     bool
+	}{
+		{"https://example.com/page", false, true},
+		{"https://www.example.com/page", false, false},
+		{"http://example.com/page", false, false},
+		{"https://other.org/page", false, false},
+		{"https://other.org/page", true, true},
+	}
+
+	for _, tt := range tests {
+		c := &Crawler{AllOrigins: tt.allOrigins}
+		if got := c.follow(origins, tt.link); got != tt.follow {
+			t.Errorf("follow(%q) with AllOrigins=%v = %v, want %v", tt.link, tt.allOrigins, got, tt.follow)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+// Package crawl implements the building blocks for crawling sites with a RemoteDebugger:
+// robots.txt rules, per-origin politeness and crawl outputs.
+package crawl
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxRobotsSize is the maximum size of a robots.txt file that is parsed (the rest is ignored).
+var MaxRobotsSize int64 = 500 * 1024
+
+// robotsRule is an allow/disallow rule in a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	path    string
+	pattern *regexp.Regexp
+}
+
+// robotsGroup is a set of rules for one or more user agents.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// Robots holds the rules of a robots.txt file.
+type Robots struct {
+	groups []*robotsGroup
+
+	// Sitemaps lists the sitemap URLs declared in the file.
+	Sitemaps []string
+
+	// disallowAll is set if robots.txt couldn't be retrieved or access to it is forbidden.
+	disallowAll bool
+}
+
+// AllowAll is a Robots that allows everything (i.e. when robots.txt doesn't exist).
+var AllowAll = &Robots{}
+
+// DisallowAll is a Robots that disallows everything (i.e. when robots.txt is unreachable).
+var DisallowAll = &Robots{disallowAll: true}
+
+// DefaultRobotsRetry is the default RobotsCache.RetryDelay.
+var DefaultRobotsRetry = time.Minute
+
+// ParseRobots parses a robots.txt file.
+func ParseRobots(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, MaxRobotsSize))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents || group == nil {
+				group = &robotsGroup{}
+				robots.groups = append(robots.groups, group)
+			}
+
+			group.agents = append(group.agents, strings.ToLower(value))
+			inAgents = true
+
+		case "allow", "disallow":
+			inAgents = false
+
+			if group == nil || (key == "disallow" && value == "") {
+				continue // an empty disallow allows everything
+			}
+
+			group.rules = append(group.rules, robotsRule{
+				allow:   key == "allow",
+				path:    value,
+				pattern: robotsPattern(value),
+			})
+
+		case "crawl-delay":
+			inAgents = false
+
+			if group == nil {
+				continue
+			}
+
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+				group.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+
+		case "sitemap":
+			robots.Sitemaps = append(robots.Sitemaps, value)
+
+		default:
+			inAgents = false
+		}
+	}
+
+	return robots, scanner.Err()
+}
+
+// robotsPattern compiles a path pattern, where '*' matches any sequence and a final '$' anchors the end.
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+
+	expr := "^" + strings.Replace(regexp.QuoteMeta(path), `\*`, ".*", -1)
+	if anchored {
+		expr += "$"
+	}
+
+	return regexp.MustCompile(expr)
+}
+
+// group returns the group that applies to the user agent: the one with the longest matching
+// product token, or the "*" group.
+func (r *Robots) group(userAgent string) *robotsGroup {
+	userAgent = strings.ToLower(userAgent)
+
+	var best, any *robotsGroup
+	bestLen := 0
+
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if any == nil {
+					any = g
+				}
+			} else if strings.Contains(userAgent, agent) && len(agent) > bestLen {
+				best, bestLen = g, len(agent)
+			}
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	return any
+}
+
+// Allowed returns true if the user agent is allowed to fetch the URL (or path).
+// The most specific (longest) matching rule wins, with allow rules winning ties.
+func (r *Robots) Allowed(userAgent, rawurl string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	g := r.group(userAgent)
+	if g == nil {
+		return true
+	}
+
+	path := rawurl
+	if u, err := url.Parse(rawurl); err == nil {
+		path = u.RequestURI()
+	}
+
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, matchLen := true, -1
+
+	for _, rule := range g.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+
+		if l := len(rule.path); l > matchLen || (l == matchLen && rule.allow) {
+			allowed, matchLen = rule.allow, l
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay returns the crawl delay for the user agent, or 0 if not specified.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	if g := r.group(userAgent); g != nil {
+		return g.crawlDelay
+	}
+
+	return 0
+}
+
+// Origin returns the origin (scheme://host[:port]) of the URL.
+func Origin(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// RobotsCache retrieves and caches robots.txt per origin, and schedules the requests
+// according to the crawl delay.
+type RobotsCache struct {
+	// UserAgent is the user agent the rules are evaluated for.
+	UserAgent string
+
+	// Client is the client used to retrieve robots.txt (http.DefaultClient if nil).
+	Client *http.Client
+
+	// MinDelay is the minimum delay between requests to the same origin, if robots.txt
+	// specifies a shorter (or no) crawl delay.
+	MinDelay time.Duration
+
+	// RetryDelay is how long an unreachable robots.txt (server or network errors) disallows the origin,
+	// before it's retrieved again (DefaultRobotsRetry if 0).
+	RetryDelay time.Duration
+
+	sync.Mutex
+	robots map[string]robotsEntry
+	next   map[string]time.Time
+}
+
+// robotsEntry is a cached robots.txt.
+type robotsEntry struct {
+	robots  *Robots
+	expires time.Time // zero if it doesn't expire
+}
+
+// NewRobotsCache returns a RobotsCache for the specified user agent.
+func NewRobotsCache(userAgent string) *RobotsCache {
+	return &RobotsCache{
+		UserAgent: userAgent,
+		robots:    map[string]robotsEntry{},
+		next:      map[string]time.Time{},
+	}
+}
+
+// fetch retrieves robots.txt for the origin. A missing file (404 and the other 4xx) allows everything,
+// while a forbidden one (401 or 403) disallows everything. An unreachable file (5xx or network errors)
+// also disallows everything, but temporarily: the file is retrieved again after RetryDelay.
+// It fails only if the context is done.
+func (c *RobotsCache) fetch(ctx context.Context, origin string) (robots *Robots, temporary bool, err error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return DisallowAll, false, nil // not a valid origin
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+
+		return DisallowAll, true, nil
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return DisallowAll, true, nil
+
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return DisallowAll, false, nil
+
+	case resp.StatusCode >= 400:
+		return AllowAll, false, nil
+	}
+
+	robots, err = ParseRobots(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+
+		return DisallowAll, true, nil // the body couldn't be read
+	}
+
+	return robots, false, nil
+}
+
+// Robots returns the (cached) robots.txt rules for the origin of the URL.
+// It only fails if the URL is invalid or if the context is done while retrieving robots.txt.
+func (c *RobotsCache) Robots(ctx context.Context, rawurl string) (*Robots, error) {
+	origin, err := Origin(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	entry, ok := c.robots[origin]
+	c.Unlock()
+
+	if ok && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		return entry.robots, nil
+	}
+
+	robots, temporary, err := c.fetch(ctx, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	entry = robotsEntry{robots: robots}
+
+	if temporary {
+		retry := c.RetryDelay
+		if retry <= 0 {
+			retry = DefaultRobotsRetry
+		}
+
+		entry.expires = time.Now().Add(retry)
+	}
+
+	c.Lock()
+	c.robots[origin] = entry
+	c.Unlock()
+
+	return robots, nil
+}
+
+// Allowed returns true if robots.txt allows fetching the URL.
+func (c *RobotsCache) Allowed(ctx context.Context, rawurl string) (bool, error) {
+	robots, err := c.Robots(ctx, rawurl)
+	if err != nil {
+		return false, err
+	}
+
+	return robots.Allowed(c.UserAgent, rawurl), nil
+}
+
+// Wait waits until the URL origin can be accessed again, according to the crawl delay,
+// and reserves the next slot. It returns early with an error if the context is done.
+func (c *RobotsCache) Wait(ctx context.Context, rawurl string) error {
+	robots, err := c.Robots(ctx, rawurl)
+	if err != nil {
+		return err
+	}
+
+	origin, _ := Origin(rawurl)
+
+	delay := robots.CrawlDelay(c.UserAgent)
+	if delay < c.MinDelay {
+		delay = c.MinDelay
+	}
+
+	c.Lock()
+	now := time.Now()
+	at := c.next[origin]
+	if at.Before(now) {
+		at = now
+	}
+	c.next[origin] = at.Add(delay)
+	c.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
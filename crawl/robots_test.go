@@ -0,0 +1,240 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRobots = `# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /tmp   # trailing comment
+Crawl-delay: 2.5
+
+User-agent: GoodBot
+User-agent: OtherBot
+Disallow:
+
+User-agent: goodbot-images
+Disallow: /
+
+Sitemap: https://example.com/sitemap.xml
+Sitemap: https://example.com/news.xml
+`
+
+func TestParseRobots(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader(testRobots))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		agent   string
+		url     string
+		allowed bool
+	}{
+		{"somebot", "/", true},
+		{"somebot", "/private/", false},
+		{"somebot", "/private/data", false},
+		{"somebot", "/private/public", true},      // the longest rule wins
+		{"somebot", "/private/public/page", true}, // allow is a prefix too
+		{"somebot", "https://example.com/tmpfile", false},
+		{"somebot", "https://example.com/a/b.pdf", false},
+		{"somebot", "https://example.com/a/b.pdf?x=1", true}, // $ anchors the end
+		{"somebot", "/robots.txt", true},
+		{"Mozilla/5.0 (compatible; GoodBot/1.0)", "/private/", true}, // empty disallow
+		{"otherbot", "/tmp", true},
+		{"GoodBot-Images/2.0", "/", false}, // the longest agent wins
+	}
+
+	for _, tt := range tests {
+		if got := robots.Allowed(tt.agent, tt.url); got != tt.allowed {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.agent, tt.url, got, tt.allowed)
+		}
+	}
+
+	if d := robots.CrawlDelay("somebot"); d != 2500*time.Millisecond {
+		t.Errorf("CrawlDelay = %v, want 2.5s", d)
+	}
+	if d := robots.CrawlDelay("goodbot"); d != 0 {
+		t.Errorf("CrawlDelay(goodbot) = %v, want 0", d)
+	}
+
+	sitemaps := []string{"https://example.com/sitemap.xml", "https://example.com/news.xml"}
+	if !reflect.DeepEqual(robots.Sitemaps, sitemaps) {
+		t.Errorf("Sitemaps = %q, want %q", robots.Sitemaps, sitemaps)
+	}
+}
+
+func TestParseRobotsTies(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader("user-agent: *\ndisallow: /page\nallow: /page\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !robots.Allowed("bot", "/page") {
+		t.Error("allow should win a tie")
+	}
+
+	if !AllowAll.Allowed("bot", "/x") || DisallowAll.Allowed("bot", "/x") {
+		t.Error("AllowAll or DisallowAll wrong")
+	}
+}
+
+// robotsServer serves robots.txt with the current status, counting the requests.
+type robotsServer struct {
+	sync.Mutex
+	status int
+	hits   int
+}
+
+func (s *robotsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.hits++
+	status := s.status
+	s.Unlock()
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}
+}
+
+func (s *robotsServer) set(status int) {
+	s.Lock()
+	s.status = status
+	s.Unlock()
+}
+
+func (s *robotsServer) count() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.hits
+}
+
+func TestRobotsCacheStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		allowed bool
+		cached  bool
+	}{
+		{http.StatusOK, true, true},
+		{http.StatusNotFound, true, true},
+		{http.StatusGone, true, true},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusForbidden, false, true},
+		{http.StatusInternalServerError, false, false},
+		{http.StatusServiceUnavailable, false, false},
+	}
+
+	for _, tt := range tests {
+		rs := &robotsServer{status: tt.status}
+		srv := httptest.NewServer(rs)
+
+		cache := NewRobotsCache("bot")
+		cache.RetryDelay = time.Millisecond
+
+		allowed, err := cache.Allowed(context.Background(), srv.URL+"/page")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != tt.allowed {
+			t.Errorf("status %d: allowed = %v, want %v", tt.status, allowed, tt.allowed)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		cache.Allowed(context.Background(), srv.URL+"/other")
+
+		if hits, want := rs.count(), map[bool]int{true: 1, false: 2}[tt.cached]; hits != want {
+			t.Errorf("status %d: robots.txt retrieved %d times, want %d", tt.status, hits, want)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestRobotsCacheRetry(t *testing.T) {
+	rs := &robotsServer{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	cache := NewRobotsCache("bot")
+	cache.RetryDelay = 50 * time.Millisecond
+
+	ctx := context.Background()
+
+	if allowed, _ := cache.Allowed(ctx, srv.URL+"/page"); allowed {
+		t.Error("unreachable robots.txt should disallow")
+	}
+
+	rs.set(http.StatusOK)
+
+	if allowed, _ := cache.Allowed(ctx, srv.URL+"/page"); allowed {
+		t.Error("unreachable robots.txt should be cached until RetryDelay")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if allowed, _ := cache.Allowed(ctx, srv.URL+"/page"); !allowed {
+		t.Error("robots.txt not retrieved again after RetryDelay")
+	}
+	if allowed, _ := cache.Allowed(ctx, srv.URL+"/private"); allowed {
+		t.Error("/private should be disallowed")
+	}
+
+	if hits := rs.count(); hits != 2 {
+		t.Errorf("robots.txt retrieved %d times, want 2", hits)
+	}
+}
+
+func TestRobotsCacheContext(t *testing.T) {
+	rs := &robotsServer{status: http.StatusOK}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	cache := NewRobotsCache("bot")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := cache.Robots(ctx, srv.URL+"/page"); err != context.Canceled {
+		t.Errorf("Robots with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+
+	// the failure is not cached
+	if allowed, err := cache.Allowed(context.Background(), srv.URL+"/page"); err != nil || !allowed {
+		t.Errorf("Allowed = %v, %v, want true, nil", allowed, err)
+	}
+}
+
+func TestRobotsCacheNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // connection refused
+
+	cache := NewRobotsCache("bot")
+
+	robots, err := cache.Robots(context.Background(), url+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if robots != DisallowAll {
+		t.Error("unreachable robots.txt should disallow")
+	}
+
+	cache.Lock()
+	entry := cache.robots[url]
+	cache.Unlock()
+
+	if entry.expires.IsZero() {
+		t.Error("network errors should be cached temporarily")
+	}
+}
@@ -0,0 +1,174 @@
+package godet
+
+import (
+	"sort"
+)
+
+// Device describes the viewport and user agent of a device to emulate (see EmulateDevice).
+type Device struct {
+	Name        string
+	Width       int
+	Height      int
+	ScaleFactor float64
+	Mobile      bool
+	Touch       bool
+	UserAgent   string
+
+	// Posture is the device posture of a foldable device (DevicePostureContinuous or DevicePostureFolded).
+	Posture DevicePosture
+
+	// Fold is the fold (or hinge) splitting the viewport in segments, for dual-screen and foldable devices.
+	Fold *DisplayFeature
+}
+
+// DevicePosture is the posture of a foldable device, reported by the Device Posture API.
+type DevicePosture string
+
+const (
+	DevicePostureContinuous = DevicePosture("continuous")
+	DevicePostureFolded     = DevicePosture("folded")
+)
+
+// DisplayFeature is a fold or hinge of the screen, splitting the viewport in two segments
+// (reported by the Viewport Segments API and the CSS env(viewport-segment-*) variables).
+type DisplayFeature struct {
+	// Orientation is "vertical" (side by side segments) or "horizontal" (stacked segments).
+	Orientation string `json:"orientation"`
+
+	// Offset is the position of the fold from the left (vertical) or top (horizontal) edge, in CSS pixels.
+	Offset int `json:"offset"`
+
+	// MaskLength is the size of the hinge, in CSS pixels (0 for a seamless fold).
+	MaskLength int `json:"maskLength"`
+}
+
+// Devices are the built-in device presets, by name.
+var Devices = map[string]Device{
+	"desktop": {
+		Name: "desktop", Width: 1920, Height: 1080, ScaleFactor: 1,
+	},
+	"laptop": {
+		Name: "laptop", Width: 1366, Height: 768, ScaleFactor: 1,
+	},
+	"iphone-13": {
+		Name: "iphone-13", Width: 390, Height: 844, ScaleFactor: 3, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+	},
+	"iphone-se": {
+		Name: "iphone-se", Width: 375, Height: 667, ScaleFactor: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+	},
+	"ipad": {
+		Name: "ipad", Width: 810, Height: 1080, ScaleFactor: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+	},
+	"pixel-7": {
+		Name: "pixel-7", Width: 412, Height: 915, ScaleFactor: 2.625, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+	},
+	"galaxy-s9": {
+		Name: "galaxy-s9", Width: 360, Height: 740, ScaleFactor: 4, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 8.0.0; SM-G960F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+	},
+	"surface-duo": {
+		Name: "surface-duo", Width: 540, Height: 720, ScaleFactor: 2.5, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 11; Surface Duo) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+	},
+	"surface-duo-spanned": {
+		Name: "surface-duo-spanned", Width: 1114, Height: 720, ScaleFactor: 2.5, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 11; Surface Duo) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+		Posture:   DevicePostureFolded,
+		Fold:      &DisplayFeature{Orientation: "vertical", Offset: 540, MaskLength: 34},
+	},
+	"galaxy-z-fold-5": {
+		Name: "galaxy-z-fold-5", Width: 344, Height: 882, ScaleFactor: 2.625, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 13; SM-F946B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+	},
+	"galaxy-z-fold-5-unfolded": {
+		Name: "galaxy-z-fold-5-unfolded", Width: 690, Height: 829, ScaleFactor: 2.625, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 13; SM-F946B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+		Posture:   DevicePostureFolded,
+		Fold:      &DisplayFeature{Orientation: "vertical", Offset: 345},
+	},
+}
+
+// DeviceNames returns the names of the built-in device presets, sorted.
+func DeviceNames() []string {
+	var names []string
+	for name := range Devices {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// EmulateDevice emulates the device viewport, scale factor, touch support and user agent (if set,
+// with the matching Client Hints metadata), and the posture and fold of foldable devices (if set).
+func (remote *RemoteDebugger) EmulateDevice(device Device) error {
+	if err := remote.SetDeviceMetricsOverride(device.Width, device.Height, device.ScaleFactor, device.Mobile, false); err != nil {
+		return err
+	}
+
+	if device.Fold != nil {
+		if err := remote.SetDisplayFeatures(*device.Fold); err != nil {
+			return err
+		}
+	}
+
+	if device.Posture != "" {
+		if err := remote.SetDevicePosture(device.Posture); err != nil {
+			return err
+		}
+	}
+
+	params := Params{"enabled": device.Touch}
+	if device.Touch {
+		params["maxTouchPoints"] = 5
+	}
+
+	if _, err := remote.SendRequest("Emulation.setTouchEmulationEnabled", params); err != nil {
+		return err
+	}
+
+	if device.UserAgent == "" {
+		return nil
+	}
+
+	return remote.SetUserAgentOverride(NewUserAgentOverride(device.UserAgent))
+}
+
+// SetDevicePosture emulates the posture of a foldable device.
+func (remote *RemoteDebugger) SetDevicePosture(posture DevicePosture) error {
+	_, err := remote.SendRequest("Emulation.setDevicePostureOverride", Params{
+		"posture": Params{"type": posture},
+	})
+
+	return err
+}
+
+// ClearDevicePosture clears the posture set by SetDevicePosture.
+func (remote *RemoteDebugger) ClearDevicePosture() error {
+	_, err := remote.SendRequest("Emulation.clearDevicePostureOverride", nil)
+	return err
+}
+
+// SetDisplayFeatures emulates the folds (or hinges) of the screen, splitting the viewport
+// (see SetDeviceMetricsOverride) in segments.
+func (remote *RemoteDebugger) SetDisplayFeatures(features ...DisplayFeature) error {
+	if features == nil {
+		features = []DisplayFeature{}
+	}
+
+	_, err := remote.SendRequest("Emulation.setDisplayFeaturesOverride", Params{
+		"features": features,
+	})
+
+	return err
+}
+
+// ClearDisplayFeatures clears the folds set by SetDisplayFeatures.
+func (remote *RemoteDebugger) ClearDisplayFeatures() error {
+	_, err := remote.SendRequest("Emulation.clearDisplayFeaturesOverride", nil)
+	return err
+}
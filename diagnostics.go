@@ -0,0 +1,218 @@
+package godet
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxDiagnosticsEvents is the number of recent console and network events kept for a diagnostics bundle.
+var MaxDiagnosticsEvents = 200
+
+// ErrorTargetCrashed is returned by NavigateWithRetry if the page crashed during the navigation
+var ErrorTargetCrashed = errors.New("target crashed")
+
+// eventLog keeps the most recent lines logged.
+type eventLog struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *eventLog) add(format string, args ...interface{}) {
+	line := time.Now().Format("15:04:05.000 ") + fmt.Sprintf(format, args...)
+
+	l.Lock()
+	l.lines = append(l.lines, line)
+	if len(l.lines) > MaxDiagnosticsEvents {
+		l.lines = l.lines[len(l.lines)-MaxDiagnosticsEvents:]
+	}
+	l.Unlock()
+}
+
+func (l *eventLog) String() string {
+	l.Lock()
+	defer l.Unlock()
+
+	return strings.Join(l.lines, "\n") + "\n"
+}
+
+// ConsoleMessage formats a Runtime.consoleAPICalled event as a log line (i.e. "console.log message 42").
+func ConsoleMessage(params Params) string {
+	args, _ := params["args"].([]interface{})
+	var parts []string
+
+	for _, a := range args {
+		m, _ := a.(map[string]interface{})
+		arg := Params(m)
+
+		if v, ok := arg["value"]; ok {
+			parts = append(parts, fmt.Sprint(v))
+		} else {
+			parts = append(parts, arg.String("description"))
+		}
+	}
+
+	return fmt.Sprintf("console.%v %v", params.String("type"), strings.Join(parts, " "))
+}
+
+// ExceptionMessage formats a Runtime.exceptionThrown event as a log line (i.e. "exception Uncaught TypeError: ...").
+func ExceptionMessage(params Params) string {
+	details := Params(params.Map("exceptionDetails"))
+	return fmt.Sprintf("exception %v %v", details.String("text"), Params(details.Map("exception")).String("description"))
+}
+
+// NavigateOption defines the functional options for NavigateWithRetry.
+type NavigateOption func(nr *navigateRetry)
+
+type navigateRetry struct {
+	timeout time.Duration
+	dir     string
+}
+
+// NavigateTimeout sets the timeout of each navigation attempt (default 30 seconds).
+func NavigateTimeout(timeout time.Duration) NavigateOption {
+	return func(nr *navigateRetry) {
+		nr.timeout = timeout
+	}
+}
+
+// DiagnosticsDir saves a diagnostics bundle in a subdirectory of dir for each failed attempt.
+func DiagnosticsDir(dir string) NavigateOption {
+	return func(nr *navigateRetry) {
+		nr.dir = dir
+	}
+}
+
+// saveDiagnostics writes a screenshot, the console log, the recent network events,
+// the page HTML and the error to a new directory. The artifacts that can't be captured
+// (i.e. if the page crashed) are skipped.
+func (remote *RemoteDebugger) saveDiagnostics(dir string, attempt int, navErr error, console, network *eventLog) error {
+	dir = filepath.Join(dir, fmt.Sprintf("%v-attempt%v", time.Now().Format("20060102-150405"), attempt))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"error.txt":   navErr.Error() + "\n",
+		"console.log": console.String(),
+		"network.log": network.String(),
+	}
+
+	if res, err := remote.Evaluate("document.documentElement ? document.documentElement.outerHTML : ''"); err == nil {
+		html, _ := res.(string)
+		files["page.html"] = html
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if screenshot, err := remote.CaptureScreenshot("png", 0, true); err == nil {
+		return ioutil.WriteFile(filepath.Join(dir, "screenshot.png"), screenshot, 0644)
+	}
+
+	return nil
+}
+
+// NavigateWithRetry navigates to the URL (see NavigateAndWait) up to attempts times, waiting backoff
+// before the first retry and doubling it after each failure.
+//
+// Network errors (net::ERR_*), timeouts and crashes are retried. If DiagnosticsDir is set, a diagnostics
+// bundle (screenshot, console log, recent network events and page HTML) is saved for each failed attempt.
+// Network, Page, Runtime and Inspector (for Inspector.targetCrashed) events are enabled, if needed.
+func (remote *RemoteDebugger) NavigateWithRetry(url string, attempts int, backoff time.Duration, options ...NavigateOption) (result *NavigationResult, err error) {
+	nr := &navigateRetry{timeout: 30 * time.Second}
+
+	for _, opt := range options {
+		opt(nr)
+	}
+
+	for _, domain := range []string{"Network", "Page", "Runtime", "Inspector"} {
+		if err := remote.ensureDomain(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	console, network := &eventLog{}, &eventLog{}
+	crashed := make(chan struct{}, 1)
+
+	removeHooks := []func(){
+		remote.addHook("Runtime.consoleAPICalled", func(params Params) bool {
+			console.add("%v", ConsoleMessage(params))
+			return false
+		}),
+		remote.addHook("Runtime.exceptionThrown", func(params Params) bool {
+			console.add("%v", ExceptionMessage(params))
+			return false
+		}),
+		remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+			req := Params(params.Map("request"))
+			network.add("request %v %v %v", params.String("requestId"), req.String("method"), req.String("url"))
+			return false
+		}),
+		remote.addHook("Network.responseReceived", func(params Params) bool {
+			resp := Params(params.Map("response"))
+			network.add("response %v %v %v", params.String("requestId"), resp.Int("status"), resp.String("url"))
+			return false
+		}),
+		remote.addHook("Network.loadingFailed", func(params Params) bool {
+			network.add("failed %v %v", params.String("requestId"), params.String("errorText"))
+			return false
+		}),
+		remote.addHook("Inspector.targetCrashed", func(params Params) bool {
+			select {
+			case crashed <- struct{}{}:
+			default:
+			}
+			return false
+		}),
+	}
+
+	defer func() {
+		for _, remove := range removeHooks {
+			remove()
+		}
+	}()
+
+	for attempt := 1; ; attempt++ {
+		result, err = remote.NavigateAndWait(url, nr.timeout)
+
+		select {
+		case <-crashed:
+			if err == nil || err == ErrorTimeout {
+				err = ErrorTargetCrashed
+			}
+		default:
+		}
+
+		if err == nil {
+			return result, nil
+		}
+
+		if _, ok := err.(NavigationError); !ok && err != ErrorTimeout && err != ErrorTargetCrashed {
+			return nil, err // not a navigation error (i.e. the connection was closed)
+		}
+
+		if nr.dir != "" {
+			if derr := remote.saveDiagnostics(nr.dir, attempt, err, console, network); derr != nil && remote.verbose {
+				log.Println("cannot save diagnostics", derr)
+			}
+		}
+
+		if attempt >= attempts {
+			return nil, err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
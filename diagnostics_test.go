@@ -0,0 +1,109 @@
+package godet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestConsoleMessage(t *testing.T) {
+	msg := ConsoleMessage(Params{
+		"type": "warning",
+		"args": []interface{}{
+			map[string]interface{}{"type": "string", "value": "count"},
+			map[string]interface{}{"type": "number", "value": 42.0},
+			map[string]interface{}{"type": "object", "description": "Window"},
+		},
+	})
+
+	if want := "console.warning count 42 Window"; msg != want {
+		t.Errorf("ConsoleMessage = %q, want %q", msg, want)
+	}
+
+	msg = ExceptionMessage(Params{
+		"exceptionDetails": map[string]interface{}{
+			"text":      "Uncaught",
+			"exception": map[string]interface{}{"description": "TypeError: x is undefined"},
+		},
+	})
+
+	if want := "exception Uncaught TypeError: x is undefined"; msg != want {
+		t.Errorf("ExceptionMessage = %q, want %q", msg, want)
+	}
+}
+
+func TestNavigateWithRetryCrash(t *testing.T) {
+	var lock sync.Mutex
+	var methods []string
+
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		for {
+			var cmd struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			lock.Lock()
+			methods = append(methods, cmd.Method)
+			lock.Unlock()
+
+			reply := Params{"id": cmd.ID, "result": Params{}}
+
+			switch cmd.Method {
+			case "Page.navigate":
+				reply["result"] = Params{"frameId": "fake", "loaderId": "L"}
+
+			case "Runtime.evaluate", "Page.captureScreenshot":
+				delete(reply, "result")
+				reply["error"] = Params{"code": -32000, "message": "Target crashed"}
+			}
+
+			if err := wsjson.Write(ctx, c, reply); err != nil {
+				return
+			}
+
+			// the page crashes while loading
+			if cmd.Method == "Page.navigate" {
+				if err := wsjson.Write(ctx, c, Params{"method": "Inspector.targetCrashed", "params": Params{}}); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	remote := connectFake(t, tab)
+	dir := t.TempDir()
+
+	_, err := remote.NavigateWithRetry("https://example.com/", 2, time.Millisecond, NavigateTimeout(100*time.Millisecond), DiagnosticsDir(dir))
+	if err != ErrorTargetCrashed {
+		t.Errorf("NavigateWithRetry = %v, want %v", err, ErrorTargetCrashed)
+	}
+
+	lock.Lock()
+	calls := strings.Join(methods, " ")
+	lock.Unlock()
+
+	if !strings.Contains(calls, "Inspector.enable") || strings.Count(calls, "Page.navigate") != 2 {
+		t.Errorf("methods = %s", calls)
+	}
+
+	bundles, _ := filepath.Glob(filepath.Join(dir, "*-attempt*", "error.txt"))
+	if len(bundles) != 2 {
+		t.Fatalf("%d diagnostics bundles, want 2", len(bundles))
+	}
+
+	if data, _ := os.ReadFile(bundles[0]); string(data) != "target crashed\n" {
+		t.Errorf("error.txt = %q", data)
+	}
+}
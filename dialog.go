@@ -0,0 +1,57 @@
+package godet
+
+import (
+	"log"
+)
+
+// BeforeUnloadCallback is called when the page shows a "leave site?" (beforeunload) dialog,
+// with the URL of the frame opening it. It returns true to leave the page, false to stay.
+type BeforeUnloadCallback func(url string) bool
+
+// OnBeforeUnload handles the beforeunload dialogs with cb, instead of leaving them pending
+// (that would block the navigation). Other dialogs are still delivered to the
+// Page.javascriptDialogOpening callback, if any.
+//
+// Passing a nil callback restores the default behaviour. Page events are enabled, if needed.
+func (remote *RemoteDebugger) OnBeforeUnload(cb BeforeUnloadCallback) error {
+	remote.Lock()
+	removeHook := remote.stopBeforeUnload
+	remote.stopBeforeUnload = nil
+	remote.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	stop := remote.addHook("Page.javascriptDialogOpening", func(params Params) bool {
+		if params.String("type") != "beforeunload" {
+			return false
+		}
+
+		if err := remote.HandleJavaScriptDialog(cb(params.String("url")), ""); err != nil && remote.verbose {
+			log.Println("cannot handle beforeunload dialog", err)
+		}
+
+		return true
+	})
+
+	remote.Lock()
+	remote.stopBeforeUnload = stop
+	remote.Unlock()
+
+	return remote.ensureDomain("Page")
+}
+
+// DisableBeforeUnload automatically accepts all beforeunload dialogs if disable is true,
+// so that navigating away or closing the page is never blocked.
+func (remote *RemoteDebugger) DisableBeforeUnload(disable bool) error {
+	if !disable {
+		return remote.OnBeforeUnload(nil)
+	}
+
+	return remote.OnBeforeUnload(func(string) bool { return true })
+}
@@ -0,0 +1,168 @@
+package godet
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gobs/httpclient"
+)
+
+// BrowserKind identifies the type of application exposing the remote debugging interface.
+type BrowserKind string
+
+const (
+	BrowserChrome   = BrowserKind("chrome")
+	BrowserWebView  = BrowserKind("webview")  // Android WebView
+	BrowserElectron = BrowserKind("electron") // Electron app
+	BrowserNode     = BrowserKind("node")     // Node.js inspector
+	BrowserFirefox  = BrowserKind("firefox")  // Firefox (partial CDP support)
+)
+
+// WebViewInfo is the state of an Android WebView, reported in the target description.
+type WebViewInfo struct {
+	Attached bool `json:"attached"`
+	Visible  bool `json:"visible"`
+	Empty    bool `json:"empty"`
+	ScreenX  int  `json:"screenX"`
+	ScreenY  int  `json:"screenY"`
+	Width    int  `json:"width"`
+	Height   int  `json:"height"`
+}
+
+// WebViewInfo returns the WebView state from the target description, or nil if the target is not a WebView.
+func (t *Tab) WebViewInfo() *WebViewInfo {
+	if !strings.HasPrefix(t.Description, "{") {
+		return nil
+	}
+
+	var info WebViewInfo
+	if err := json.Unmarshal([]byte(t.Description), &info); err != nil {
+		return nil
+	}
+
+	return &info
+}
+
+// DetectBrowserKind returns the kind of application from the /json/version information.
+func DetectBrowserKind(v *Version) BrowserKind {
+	switch {
+	case strings.HasPrefix(strings.ToLower(v.Browser), "node.js"):
+		return BrowserNode
+
+	case strings.HasPrefix(v.Browser, "Firefox") || strings.Contains(v.UserAgent, "Firefox/"):
+		return BrowserFirefox
+
+	case strings.Contains(v.UserAgent, "Electron/"):
+		return BrowserElectron
+
+	case (v.AndroidPackage != "" && v.AndroidPackage != "com.android.chrome") || strings.Contains(v.UserAgent, "; wv)"):
+		return BrowserWebView
+	}
+
+	return BrowserChrome
+}
+
+// BrowserKind returns the kind of application the debugger is connected to.
+func (remote *RemoteDebugger) BrowserKind() (BrowserKind, error) {
+	v, err := remote.Version()
+	if err != nil {
+		return "", err
+	}
+
+	return DetectBrowserKind(v), nil
+}
+
+// AppTargets returns the targets that can be automated, according to the kind of application:
+//
+//   - chrome: the pages
+//   - webview: the attached WebViews, visible ones first
+//   - electron: the windows and <webview> tags, excluding the DevTools windows
+//   - node: the Node.js process
+func (remote *RemoteDebugger) AppTargets(kind BrowserKind) ([]*Tab, error) {
+	tabs, err := remote.TabList("")
+	if err != nil {
+		return nil, err
+	}
+
+	var targets, hidden []*Tab
+
+	for _, t := range tabs {
+		if t.WsURL == "" {
+			continue // already attached by another client
+		}
+
+		switch kind {
+		case BrowserNode:
+			targets = append(targets, t)
+
+		case BrowserWebView:
+			if t.Type != "page" {
+				continue
+			}
+
+			if info := t.WebViewInfo(); info != nil && (!info.Attached || !info.Visible || info.Empty) {
+				if info.Attached {
+					hidden = append(hidden, t)
+				}
+				continue
+			}
+
+			targets = append(targets, t)
+
+		case BrowserElectron:
+			if (t.Type == "page" || t.Type == "webview") && !strings.HasPrefix(t.URL, "devtools://") {
+				targets = append(targets, t)
+			}
+
+		default:
+			if t.Type == "page" {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	return append(targets, hidden...), nil
+}
+
+// ConnectApp connects to a Chrome, Android WebView, Electron or Node.js remote debugging interface,
+// detecting the kind of application and attaching to the first of its AppTargets.
+//
+// For WebViews use ADBForward with the socket name returned by ADBDevtoolsSockets (webview_devtools_remote_<pid>),
+// for Electron start the app with --remote-debugging-port.
+func ConnectApp(port string, verbose bool, options ...ConnectOption) (*RemoteDebugger, BrowserKind, error) {
+	remote := newRemoteDebugger(httpclient.NewHttpClient("http://"+port), verbose)
+
+	kind, err := remote.BrowserKind()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if kind == BrowserNode {
+		remote, err := ConnectNode(port, verbose, options...)
+		return remote, kind, err
+	}
+
+	for _, setOption := range options {
+		setOption(remote)
+	}
+
+	if verbose {
+		httpclient.StartLogging(false, true, false)
+	}
+
+	targets, err := remote.AppTargets(kind)
+	if err != nil {
+		return nil, kind, err
+	}
+
+	if len(targets) == 0 {
+		return nil, kind, ErrorNoActiveTab
+	}
+
+	if err := remote.connectWs(targets[0]); err != nil {
+		return nil, kind, err
+	}
+
+	remote.start()
+	return remote, kind, nil
+}
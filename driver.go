@@ -0,0 +1,158 @@
+package godet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Driver is the subset of high-level helpers supported by all the backends: Chrome (and other Chromium
+// based browsers) and Firefox over CDP, and WebDriver BiDi (see the bidi package).
+// It allows the same code to target different browsers.
+type Driver interface {
+	// Navigate navigates to the URL and waits for the page to load.
+	Navigate(url string, timeout time.Duration) error
+
+	// Screenshot captures a screenshot of the viewport (format is "png" or "jpeg").
+	Screenshot(format string, quality int) ([]byte, error)
+
+	// Evaluate evaluates a Javascript expression and returns its (JSON) value.
+	Evaluate(expr string) (interface{}, error)
+
+	// Cookies returns the cookies visible to the current page.
+	Cookies() ([]Cookie, error)
+
+	// SetCookies sets the cookies.
+	SetCookies(cookies []Cookie) error
+
+	// Close closes the connection.
+	Close() error
+}
+
+// cdpDriver implements Driver over CDP, mapping the helpers to the commands supported by the backend.
+type cdpDriver struct {
+	remote *RemoteDebugger
+	kind   BrowserKind
+}
+
+// NewDriver returns a Driver for the connection, detecting the backend (see BrowserKind).
+// Page and Runtime events are enabled, if needed.
+func NewDriver(remote *RemoteDebugger) (Driver, error) {
+	kind, err := remote.BrowserKind()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domain := range []string{"Page", "Runtime"} {
+		if err := remote.ensureDomain(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cdpDriver{remote: remote, kind: kind}, nil
+}
+
+// ConnectDriver connects to a Chrome or Firefox remote debugging port (see Connect) and returns its Driver.
+func ConnectDriver(port string, verbose bool, options ...ConnectOption) (Driver, error) {
+	remote, err := Connect(port, verbose, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := NewDriver(remote)
+	if err != nil {
+		remote.Close()
+		return nil, err
+	}
+
+	return driver, nil
+}
+
+func (d *cdpDriver) Navigate(url string, timeout time.Duration) error {
+	_, err := d.remote.NavigateAndWait(url, timeout)
+	return err
+}
+
+func (d *cdpDriver) Screenshot(format string, quality int) ([]byte, error) {
+	if d.kind != BrowserFirefox {
+		return d.remote.CaptureScreenshot(format, quality, true)
+	}
+
+	// Firefox only supports the format (and no quality or fromSurface)
+	if format == "" {
+		format = "png"
+	}
+
+	res, err := d.remote.SendRequest("Page.captureScreenshot", Params{
+		"format": format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, ErrorNoResponse
+	}
+
+	return base64.StdEncoding.DecodeString(Params(res).String("data"))
+}
+
+func (d *cdpDriver) Evaluate(expr string) (interface{}, error) {
+	return d.remote.Evaluate(expr)
+}
+
+func (d *cdpDriver) Cookies() ([]Cookie, error) {
+	if d.kind != BrowserFirefox {
+		return d.remote.GetCookies(nil)
+	}
+
+	// Firefox requires the URLs for Network.getCookies, but supports Network.getAllCookies
+	rawReply, err := d.remote.sendRawReplyRequest("Network.getAllCookies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var cookies struct {
+		Cookies []Cookie `json:"cookies"`
+	}
+
+	if err := json.Unmarshal(rawReply, &cookies); err != nil {
+		return nil, err
+	}
+
+	return cookies.Cookies, nil
+}
+
+func (d *cdpDriver) SetCookies(cookies []Cookie) error {
+	if d.kind != BrowserFirefox {
+		return d.remote.SetCookies(cookies)
+	}
+
+	// Firefox doesn't support Network.setCookies
+	for _, c := range cookies {
+		params := Params{
+			"name":     c.Name,
+			"value":    c.Value,
+			"domain":   c.Domain,
+			"path":     c.Path,
+			"secure":   c.Secure,
+			"httpOnly": c.HttpOnly,
+		}
+		if c.SameSite != "" {
+			params["sameSite"] = c.SameSite
+		}
+		if c.Expires > 0 {
+			params["expires"] = c.Expires
+		}
+
+		if _, err := d.remote.SendRequest("Network.setCookie", params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *cdpDriver) Close() error {
+	return d.remote.Close()
+}
@@ -0,0 +1,320 @@
+// Package expect implements retrying assertions on godet locators, pages and responses,
+// that report their failures to a *testing.T, so that godet can be used as an end-to-end test framework:
+//
+//	func TestLogin(t *testing.T) {
+//		page := godet.NewPage(remote)
+//		e := expect.New(t)
+//
+//		login := e.ExpectResponse(page, "*/api/login")
+//		page.Locator("#user").Fill("me")
+//		page.Locator("text=Sign in").Click()
+//
+//		login.ToHaveStatus(200)
+//		e.Expect(page.Locator(".welcome")).ToHaveText("Welcome, me")
+//	}
+//
+// The assertions are retried until they pass or the timeout expires, so no explicit waits are needed.
+package expect
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// DefaultTimeout is the default time the assertions are retried for.
+var DefaultTimeout = 5 * time.Second
+
+// PollInterval is the interval between the attempts of a retrying assertion.
+var PollInterval = 100 * time.Millisecond
+
+// TestingT is the subset of testing.TB used to report the failures.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Assertions creates the assertions for a test.
+type Assertions struct {
+	t TestingT
+
+	// Timeout is how long the assertions are retried for (default DefaultTimeout).
+	Timeout time.Duration
+}
+
+// New returns the Assertions reporting to t.
+func New(t TestingT) *Assertions {
+	return &Assertions{t: t, Timeout: DefaultTimeout}
+}
+
+// WithTimeout returns a copy of the assertions with a different timeout.
+func (a *Assertions) WithTimeout(timeout time.Duration) *Assertions {
+	c := *a
+	c.Timeout = timeout
+	return &c
+}
+
+// poll calls check until it returns true or the timeout expires, returning the last
+// observed value (or error) for the failure message.
+func (a *Assertions) poll(check func() (bool, string, error)) (bool, string) {
+	deadline := time.Now().Add(a.Timeout)
+
+	for {
+		ok, actual, err := check()
+		if ok {
+			return true, ""
+		}
+
+		if err != nil {
+			actual = err.Error()
+		}
+
+		if time.Now().After(deadline) {
+			return false, actual
+		}
+
+		time.Sleep(PollInterval)
+	}
+}
+
+// normalizeSpace collapses the sequences of white space, as they are rendered.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// LocatorAssertions are the assertions on the element found by a locator.
+type LocatorAssertions struct {
+	a   *Assertions
+	l   *godet.Locator
+	not bool
+}
+
+// Expect returns the assertions on the element found by the locator.
+func (a *Assertions) Expect(l *godet.Locator) *LocatorAssertions {
+	return &LocatorAssertions{a: a, l: l.WithTimeout(0)}
+}
+
+// Not returns the negated assertions (i.e. Not().ToBeVisible() waits for the element not to be visible).
+func (la *LocatorAssertions) Not() *LocatorAssertions {
+	c := *la
+	c.not = !c.not
+	return &c
+}
+
+// check retries the assertion and reports the failure. It returns true if the assertion passed.
+func (la *LocatorAssertions) check(expected string, check func() (bool, string, error)) bool {
+	la.a.t.Helper()
+
+	ok, actual := la.a.poll(func() (bool, string, error) {
+		ok, actual, err := check()
+		if err != nil {
+			return false, actual, err
+		}
+
+		return ok != la.not, actual, nil
+	})
+
+	if !ok {
+		not := ""
+		if la.not {
+			not = "not "
+		}
+
+		la.a.t.Errorf("%v: expected %v%v, got %v (after %v)", la.l.Selector(), not, expected, actual, la.a.Timeout)
+	}
+
+	return ok
+}
+
+// ToHaveText asserts that the element text content is text (ignoring differences in white space).
+func (la *LocatorAssertions) ToHaveText(text string) bool {
+	la.a.t.Helper()
+
+	return la.check(fmt.Sprintf("text %q", text), func() (bool, string, error) {
+		s, err := la.l.Text()
+		s = normalizeSpace(s)
+		return s == normalizeSpace(text), fmt.Sprintf("%q", s), err
+	})
+}
+
+// ToContainText asserts that the element text content contains text (ignoring differences in white space).
+func (la *LocatorAssertions) ToContainText(text string) bool {
+	la.a.t.Helper()
+
+	return la.check(fmt.Sprintf("text containing %q", text), func() (bool, string, error) {
+		s, err := la.l.Text()
+		s = normalizeSpace(s)
+		return strings.Contains(s, normalizeSpace(text)), fmt.Sprintf("%q", s), err
+	})
+}
+
+// ToHaveAttribute asserts that the element has the attribute, with the specified value.
+func (la *LocatorAssertions) ToHaveAttribute(name, value string) bool {
+	la.a.t.Helper()
+
+	return la.check(fmt.Sprintf("attribute %v=%q", name, value), func() (bool, string, error) {
+		v, set, err := la.l.Attribute(name)
+		if !set {
+			return false, "no attribute", err
+		}
+
+		return v == value, fmt.Sprintf("%v=%q", name, v), err
+	})
+}
+
+// ToBeVisible asserts that the element exists and is visible.
+func (la *LocatorAssertions) ToBeVisible() bool {
+	la.a.t.Helper()
+
+	return la.check("visible", func() (bool, string, error) {
+		visible, err := la.l.IsVisible()
+		return visible, visibility(visible), err
+	})
+}
+
+// ToBeHidden asserts that the element doesn't exist or is not visible.
+func (la *LocatorAssertions) ToBeHidden() bool {
+	la.a.t.Helper()
+
+	return la.check("hidden", func() (bool, string, error) {
+		visible, err := la.l.IsVisible()
+		return !visible, visibility(visible), err
+	})
+}
+
+// ToBeAttached asserts that the element exists.
+func (la *LocatorAssertions) ToBeAttached() bool {
+	la.a.t.Helper()
+
+	return la.check("attached", func() (bool, string, error) {
+		attached, err := la.l.IsAttached()
+		if attached {
+			return true, "attached", err
+		}
+
+		return false, "detached", err
+	})
+}
+
+func visibility(visible bool) string {
+	if visible {
+		return "visible"
+	}
+
+	return "hidden"
+}
+
+// PageAssertions are the assertions on a page.
+type PageAssertions struct {
+	a    *Assertions
+	page *godet.Page
+}
+
+// ExpectPage returns the assertions on the page.
+func (a *Assertions) ExpectPage(page *godet.Page) *PageAssertions {
+	return &PageAssertions{a: a, page: page}
+}
+
+func (pa *PageAssertions) check(what, expected string, get func() (string, error), match func(string) bool) bool {
+	pa.a.t.Helper()
+
+	ok, actual := pa.a.poll(func() (bool, string, error) {
+		s, err := get()
+		return err == nil && match(s), fmt.Sprintf("%q", s), err
+	})
+
+	if !ok {
+		pa.a.t.Errorf("page: expected %v %q, got %v (after %v)", what, expected, actual, pa.a.Timeout)
+	}
+
+	return ok
+}
+
+// ToHaveURL asserts that the page URL is url.
+func (pa *PageAssertions) ToHaveURL(url string) bool {
+	pa.a.t.Helper()
+
+	return pa.check("URL", url, pa.page.URL, func(s string) bool { return s == url })
+}
+
+// ToHaveTitle asserts that the page title is title.
+func (pa *PageAssertions) ToHaveTitle(title string) bool {
+	pa.a.t.Helper()
+
+	return pa.check("title", title, pa.page.Title, func(s string) bool { return s == title })
+}
+
+// ResponseAssertions are the assertions on a response, see Assertions.ExpectResponse.
+type ResponseAssertions struct {
+	a       *Assertions
+	pattern string
+	waiter  *godet.ResponseWaiter
+	matched *godet.MatchedResponse
+	err     error
+}
+
+// ExpectResponse starts waiting for a response whose URL matches urlPattern (see RemoteDebugger.ExpectResponse).
+// Call it before the action that triggers the request, then call the assertions on the result,
+// that wait for the response (up to the timeout).
+func (a *Assertions) ExpectResponse(page *godet.Page, urlPattern string, options ...godet.ResponseWaitOption) *ResponseAssertions {
+	ra := &ResponseAssertions{a: a, pattern: urlPattern}
+	ra.waiter, ra.err = page.Remote().ExpectResponse(urlPattern, options...)
+	return ra
+}
+
+// Response waits for the response and returns it, or nil if it wasn't received (the failure is reported).
+func (ra *ResponseAssertions) Response() *godet.MatchedResponse {
+	ra.a.t.Helper()
+
+	if ra.matched == nil && ra.err == nil {
+		ra.matched, ra.err = ra.waiter.Wait(ra.a.Timeout)
+	}
+
+	if ra.err != nil {
+		ra.a.t.Errorf("%v: expected response, got %v (after %v)", ra.pattern, ra.err, ra.a.Timeout)
+		return nil
+	}
+
+	return ra.matched
+}
+
+// ToHaveStatus asserts that the response is received with the status code.
+func (ra *ResponseAssertions) ToHaveStatus(status int) bool {
+	ra.a.t.Helper()
+
+	resp := ra.Response()
+	if resp == nil {
+		return false
+	}
+
+	if resp.Failed {
+		ra.a.t.Errorf("%v: expected status %v, got %v", resp.URL, status, resp.ErrorText)
+		return false
+	}
+
+	if resp.Status != status {
+		ra.a.t.Errorf("%v: expected status %v, got %v %v", resp.URL, status, resp.Status, resp.StatusText)
+		return false
+	}
+
+	return true
+}
+
+// ToBeOK asserts that the response is received with a 2xx status code.
+func (ra *ResponseAssertions) ToBeOK() bool {
+	ra.a.t.Helper()
+
+	resp := ra.Response()
+	if resp == nil {
+		return false
+	}
+
+	if resp.Failed || resp.Status < 200 || resp.Status > 299 {
+		ra.a.t.Errorf("%v: expected OK status, got %v %v%v", resp.URL, resp.Status, resp.StatusText, resp.ErrorText)
+		return false
+	}
+
+	return true
+}
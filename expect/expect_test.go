@@ -0,0 +1,182 @@
+package expect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/raff/godet"
+)
+
+// fakeT records the failures.
+type fakeT struct {
+	sync.Mutex
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.Lock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	t.Unlock()
+}
+
+func (t *fakeT) failures() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	errors := t.errors
+	t.errors = nil
+	return errors
+}
+
+// fakePage starts a browser with a page where "#welcome" shows "Loading..." for the first two text checks,
+// "#spinner" is hidden and evaluating "login()" gets a 500 response for /api/login.
+func fakePage(t *testing.T) *godet.Page {
+	var texts int
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/json/list", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": "page1", "type": "page", "webSocketDebuggerUrl": "ws%v/ws"}]`, strings.TrimPrefix(srv.URL, "http"))
+	})
+
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+
+		ctx := r.Context()
+
+		for {
+			var cmd struct {
+				ID     int          `json:"id"`
+				Method string       `json:"method"`
+				Params godet.Params `json:"params"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			var value interface{}
+			var events []godet.Params
+
+			expr := cmd.Params.String("expression")
+
+			switch {
+			case cmd.Method != "Runtime.evaluate":
+
+			case expr == "document.title":
+				value = "Home"
+
+			case expr == "login()":
+				value = godet.Params{}
+				events = append(events, godet.Params{"method": "Network.responseReceived", "params": godet.Params{
+					"requestId": "1",
+					"type":      "XHR",
+					"response":  godet.Params{"url": "https://example.com/api/login", "status": 500, "statusText": "Internal Server Error"},
+				}})
+
+			case strings.Contains(expr, "notFound"): // an element evaluation
+				switch {
+				case strings.Contains(expr, "el.textContent"):
+					texts++
+					if texts < 3 {
+						value = godet.Params{"value": "Loading..."}
+					} else {
+						value = godet.Params{"value": "\n  Welcome,\n  me "}
+					}
+
+				case strings.Contains(expr, "getAttribute"):
+					value = godet.Params{"value": "button"}
+				}
+
+			case strings.Contains(expr, "#spinner"): // the element state
+				value = godet.Params{"attached": true, "visible": false}
+
+			default:
+				value = godet.Params{"attached": true, "visible": true, "stable": true, "enabled": true}
+			}
+
+			msg := godet.Params{"id": cmd.ID, "result": godet.Params{}}
+			if value != nil {
+				msg["result"] = godet.Params{"result": godet.Params{"type": "object", "value": value}}
+			}
+
+			for _, ev := range append(events, msg) {
+				if err := wsjson.Write(ctx, c, ev); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	remote, err := godet.Connect(strings.TrimPrefix(srv.URL, "http://"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { remote.Close() })
+
+	return godet.NewPage(remote)
+}
+
+func TestExpect(t *testing.T) {
+	defer func(interval time.Duration) { PollInterval = interval }(PollInterval)
+	PollInterval = 10 * time.Millisecond
+
+	page := fakePage(t)
+	ft := &fakeT{}
+	e := New(ft).WithTimeout(200 * time.Millisecond)
+
+	if !e.Expect(page.Locator("#welcome")).ToHaveText("Welcome, me") {
+		t.Errorf("ToHaveText failed: %q", ft.failures())
+	}
+
+	if !e.Expect(page.Locator("#spinner")).Not().ToBeVisible() || !e.Expect(page.Locator("#spinner")).ToBeHidden() {
+		t.Errorf("Not().ToBeVisible failed: %q", ft.failures())
+	}
+
+	if !e.ExpectPage(page).ToHaveTitle("Home") {
+		t.Errorf("ToHaveTitle failed: %q", ft.failures())
+	}
+
+	login := e.ExpectResponse(page, "*/api/login")
+	if _, err := page.Evaluate("login()"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	e.Expect(page.Locator("#welcome")).ToContainText("Goodbye")
+	e.Expect(page.Locator("#welcome")).ToHaveAttribute("type", "submit")
+	e.ExpectPage(page).ToHaveTitle("Login")
+	login.ToHaveStatus(200)
+
+	if elapsed := time.Since(start); elapsed < 3*200*time.Millisecond {
+		t.Errorf("the failed assertions were not retried (%v)", elapsed)
+	}
+
+	want := []string{
+		`#welcome: expected text containing "Goodbye", got "Welcome, me" (after 200ms)`,
+		`#welcome: expected attribute type="submit", got type="button" (after 200ms)`,
+		`page: expected title "Login", got "Home" (after 200ms)`,
+		`https://example.com/api/login: expected status 200, got 500 Internal Server Error`,
+	}
+
+	failures := ft.failures()
+	if strings.Join(failures, "\n") != strings.Join(want, "\n") {
+		t.Errorf("failures:\n%s\nwant:\n%s", strings.Join(failures, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,67 @@
+package godet
+
+import (
+	"strings"
+	"time"
+)
+
+// ExtensionID returns the id of the extension a target belongs to, or an empty string
+// if the target is not an extension page or worker.
+func ExtensionID(tab *Tab) string {
+	if !strings.HasPrefix(tab.URL, "chrome-extension://") {
+		return ""
+	}
+
+	id := strings.TrimPrefix(tab.URL, "chrome-extension://")
+	if i := strings.Index(id, "/"); i >= 0 {
+		id = id[:i]
+	}
+
+	return id
+}
+
+// ExtensionTargets returns the background targets of the loaded extensions: background pages
+// (type "background_page", manifest v2) and service workers (type "service_worker", manifest v3).
+func (remote *RemoteDebugger) ExtensionTargets() ([]*Tab, error) {
+	tabs, err := remote.TabList("")
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*Tab
+
+	for _, t := range tabs {
+		if (t.Type == "background_page" || t.Type == "service_worker") && ExtensionID(t) != "" {
+			targets = append(targets, t)
+		}
+	}
+
+	return targets, nil
+}
+
+// AttachExtension returns a new connection to the background page or service worker of the extension
+// with the specified id (or to the first extension, if id is empty), waiting up to timeout for it to start.
+//
+// Note that a service worker exposes the Runtime, Network and Debugger domains, but not Page or DOM.
+func (remote *RemoteDebugger) AttachExtension(id string, timeout time.Duration) (*RemoteDebugger, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		targets, err := remote.ExtensionTargets()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range targets {
+			if id == "" || ExtensionID(t) == id {
+				return remote.connectTab(t)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrorTimeout
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
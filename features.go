@@ -0,0 +1,97 @@
+package godet
+
+import (
+	"encoding/json"
+)
+
+// OriginTrialToken is an origin trial token, as found in the page (meta tag or header).
+type OriginTrialToken struct {
+	RawTokenText string `json:"rawTokenText"`
+	Status       string `json:"status"` // Success, NotSupported, Insecure, Expired, WrongOrigin, ...
+
+	// ParsedToken is only available for well-formed tokens.
+	ParsedToken *struct {
+		Origin           string  `json:"origin"`
+		MatchSubDomains  bool    `json:"matchSubDomains"`
+		TrialName        string  `json:"trialName"`
+		ExpiryTime       float64 `json:"expiryTime"`
+		IsThirdParty     bool    `json:"isThirdParty"`
+		UsageRestriction string  `json:"usageRestriction"`
+	} `json:"parsedToken"`
+}
+
+// OriginTrial is the state of an origin trial for the page.
+type OriginTrial struct {
+	TrialName string             `json:"trialName"`
+	Status    string             `json:"status"` // Enabled, ValidTokenNotProvided, OSNotSupported, TrialNotAllowed
+	Tokens    []OriginTrialToken `json:"tokensWithStatus"`
+}
+
+// Enabled returns true if the trial is enabled for the page.
+func (t OriginTrial) Enabled() bool {
+	return t.Status == "Enabled"
+}
+
+// GetOriginTrials returns the origin trials that apply to the top-level frame of the current page.
+func (remote *RemoteDebugger) GetOriginTrials() ([]OriginTrial, error) {
+	frameID, err := remote.mainFrameID()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := remote.sendRawReplyRequest("Page.getOriginTrials", Params{
+		"frameId": frameID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		OriginTrials []OriginTrial `json:"originTrials"`
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return res.OriginTrials, nil
+}
+
+// PermissionsPolicyFeature is the state of a feature controlled by the Permissions Policy.
+type PermissionsPolicyFeature struct {
+	Feature string `json:"feature"`
+	Allowed bool   `json:"allowed"`
+
+	// Locator is only set for the features that are blocked, with the reason (Header, IframeAttribute, ...)
+	// and the frame that blocked it.
+	Locator *struct {
+		FrameID     string `json:"frameId"`
+		BlockReason string `json:"blockReason"`
+	} `json:"locator"`
+}
+
+// GetPermissionsPolicyState returns the state of the features controlled by the Permissions Policy
+// for the top-level frame of the current page.
+func (remote *RemoteDebugger) GetPermissionsPolicyState() ([]PermissionsPolicyFeature, error) {
+	frameID, err := remote.mainFrameID()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := remote.sendRawReplyRequest("Page.getPermissionsPolicyState", Params{
+		"frameId": frameID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		States []PermissionsPolicyFeature `json:"states"`
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return res.States, nil
+}
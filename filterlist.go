@@ -0,0 +1,578 @@
+package godet
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ResourceTypeSubdocument is a document loaded in a frame other than the main frame, matched by the
+// $subdocument filter rules (see FilterList.Match). It's not a protocol resource type: the protocol
+// reports the frame documents as ResourceTypeDocument.
+const ResourceTypeSubdocument = ResourceType("Subdocument")
+
+// filterTypes maps the filter list type options to the resource types.
+var filterTypes = map[string][]ResourceType{
+	"document":       {ResourceTypeDocument},
+	"subdocument":    {ResourceTypeSubdocument},
+	"stylesheet":     {ResourceTypeStylesheet},
+	"image":          {ResourceTypeImage},
+	"media":          {ResourceTypeMedia},
+	"font":           {ResourceTypeFont},
+	"script":         {ResourceTypeScript},
+	"xmlhttprequest": {ResourceTypeXHR, ResourceTypeFetch},
+	"websocket":      {ResourceTypeWebSocket},
+	"ping":           {ResourceTypePing},
+	"other":          {ResourceTypeOther, ResourceTypeTextTrack, ResourceTypeEventSource, ResourceTypeManifest},
+}
+
+// FilterRule is a network filter from a filter list (EasyList/EasyPrivacy syntax).
+type FilterRule struct {
+	// Text is the rule, as found in the list.
+	Text string
+
+	// Exception is true for exception (@@) rules, that allow requests matching blocking rules.
+	Exception bool
+
+	pattern    *regexp.Regexp
+	prefix     string // the plain pattern, for BlockedURLs
+	token      string // a token that all the matching URLs contain (see filterIndex)
+	types      map[ResourceType]bool
+	notTypes   map[ResourceType]bool
+	domains    []string
+	notDomains []string
+	thirdParty int // 1: third party only, -1: first party only
+}
+
+// FilterList is a compiled filter list.
+//
+// Only the network filters are supported, with the most common options: resource types, third-party,
+// domain and match-case. Element hiding rules and rules with unsupported options are skipped.
+type FilterList struct {
+	rules      []*FilterRule
+	exceptions []*FilterRule
+
+	blocking filterIndex
+	allowing filterIndex
+
+	// Skipped is the number of rules that couldn't be parsed or use unsupported features.
+	Skipped int
+}
+
+// Len returns the number of rules in the list (blocking and exceptions).
+func (fl *FilterList) Len() int {
+	return len(fl.rules) + len(fl.exceptions)
+}
+
+// ParseFilterList parses a filter list. Multiple lists can be merged with Add.
+func ParseFilterList(r io.Reader) (*FilterList, error) {
+	fl := &FilterList{}
+	return fl, fl.Add(r)
+}
+
+// Add parses the rules from r and adds them to the list.
+func (fl *FilterList) Add(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '!' || line[0] == '[' {
+			continue // comments and header
+		}
+
+		if strings.Contains(line, "##") || strings.Contains(line, "#@#") || strings.Contains(line, "#?#") || strings.Contains(line, "#$#") {
+			continue // element hiding and scriptlets
+		}
+
+		rule := parseFilterRule(line)
+		if rule == nil {
+			fl.Skipped++
+		} else if rule.Exception {
+			fl.exceptions = append(fl.exceptions, rule)
+			fl.allowing.add(rule)
+		} else {
+			fl.rules = append(fl.rules, rule)
+			fl.blocking.add(rule)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseFilterRule parses a network filter, returning nil if not supported.
+func parseFilterRule(line string) *FilterRule {
+	rule := &FilterRule{Text: line}
+
+	if strings.HasPrefix(line, "@@") {
+		rule.Exception = true
+		line = line[2:]
+	}
+
+	matchCase := false
+
+	if i := strings.LastIndex(line, "$"); i >= 0 && !strings.HasSuffix(line, "/") {
+		options := line[i+1:]
+		line = line[:i]
+
+		for _, opt := range strings.Split(options, ",") {
+			negate := strings.HasPrefix(opt, "~")
+			name := strings.TrimPrefix(opt, "~")
+
+			switch {
+			case name == "third-party":
+				if negate {
+					rule.thirdParty = -1
+				} else {
+					rule.thirdParty = 1
+				}
+
+			case name == "match-case":
+				matchCase = true
+
+			case strings.HasPrefix(opt, "domain="):
+				for _, d := range strings.Split(opt[7:], "|") {
+					if strings.HasPrefix(d, "~") {
+						rule.notDomains = append(rule.notDomains, d[1:])
+					} else {
+						rule.domains = append(rule.domains, d)
+					}
+				}
+
+			case filterTypes[name] != nil:
+				types := &rule.types
+				if negate {
+					types = &rule.notTypes
+				}
+				if *types == nil {
+					*types = map[ResourceType]bool{}
+				}
+				for _, t := range filterTypes[name] {
+					(*types)[t] = true
+				}
+
+			default:
+				return nil
+			}
+		}
+	}
+
+	if len(line) > 1 && line[0] == '/' && line[len(line)-1] == '/' {
+		expr := line[1 : len(line)-1]
+		if !matchCase {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil
+		}
+
+		rule.pattern = re
+		return rule
+	}
+
+	var expr strings.Builder
+
+	if !matchCase {
+		expr.WriteString("(?i)")
+	}
+
+	anchorStart := true
+
+	switch {
+	case strings.HasPrefix(line, "||"):
+		expr.WriteString(`^[a-z][a-z0-9.+-]*://([^/?#]*\.)?`)
+		line = line[2:]
+
+	case strings.HasPrefix(line, "|"):
+		expr.WriteString("^")
+		line = line[1:]
+
+	default:
+		anchorStart = false
+	}
+
+	anchorEnd := strings.HasSuffix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	if line == "" {
+		return nil // this would match everything
+	}
+
+	for _, c := range line {
+		switch c {
+		case '*':
+			expr.WriteString(".*")
+		case '^':
+			expr.WriteString(`(?:[^\w.%-]|$)`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if anchorEnd {
+		expr.WriteString("$")
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil
+	}
+
+	rule.pattern = re
+	rule.token = filterToken(line, anchorStart, anchorEnd)
+
+	if !rule.Exception && !anchorStart && !matchCase && rule.types == nil && rule.notTypes == nil && rule.domains == nil &&
+		rule.notDomains == nil && rule.thirdParty == 0 && !anchorEnd && !strings.ContainsAny(line, "^|") {
+		rule.prefix = line
+	}
+
+	return rule
+}
+
+// filterCommonTokens are the tokens found in most URLs, that are useless to select the rules.
+var filterCommonTokens = map[string]bool{"http": true, "https": true, "www": true, "com": true}
+
+// isFilterTokenChar returns true if the (lowercase) character is part of a token.
+func isFilterTokenChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '%'
+}
+
+// filterToken returns the longest token of the rule pattern (without the anchors) that is a whole token
+// of all the URLs matching the rule, or an empty string if there isn't one: a token next to a wildcard
+// (or to an unanchored end of the pattern) could be part of a longer token in the URL.
+func filterToken(pattern string, anchorStart, anchorEnd bool) string {
+	pattern = strings.ToLower(pattern)
+
+	var best string
+
+	for i := 0; i < len(pattern); {
+		if !isFilterTokenChar(pattern[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(pattern) && isFilterTokenChar(pattern[j]) {
+			j++
+		}
+
+		bounded := (i > 0 && pattern[i-1] != '*') || (i == 0 && anchorStart)
+		bounded = bounded && ((j < len(pattern) && pattern[j] != '*') || (j == len(pattern) && anchorEnd))
+
+		if token := pattern[i:j]; bounded && len(token) > len(best) && !filterCommonTokens[token] {
+			best = token
+		}
+
+		i = j
+	}
+
+	return best
+}
+
+// urlTokens returns the distinct tokens of the URL, in order.
+func urlTokens(reqURL string) []string {
+	reqURL = strings.ToLower(reqURL)
+
+	var tokens []string
+	seen := map[string]bool{}
+
+	for i := 0; i < len(reqURL); {
+		if !isFilterTokenChar(reqURL[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(reqURL) && isFilterTokenChar(reqURL[j]) {
+			j++
+		}
+
+		if token := reqURL[i:j]; !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+
+		i = j
+	}
+
+	return tokens
+}
+
+// filterIndex indexes the rules by token, as the ad blockers do, so that only the few rules
+// with a token found in the URL (and the ones without a token) are evaluated.
+type filterIndex struct {
+	tokens  map[string][]*FilterRule
+	generic []*FilterRule // the rules without a token
+}
+
+func (idx *filterIndex) add(rule *FilterRule) {
+	if rule.token == "" {
+		idx.generic = append(idx.generic, rule)
+		return
+	}
+
+	if idx.tokens == nil {
+		idx.tokens = map[string][]*FilterRule{}
+	}
+
+	idx.tokens[rule.token] = append(idx.tokens[rule.token], rule)
+}
+
+// match returns the first rule matching the request, checking the rules indexed by the URL tokens first.
+func (idx *filterIndex) match(tokens []string, reqURL string, resourceType ResourceType, reqHost, docHost string) *FilterRule {
+	for _, token := range tokens {
+		for _, rule := range idx.tokens[token] {
+			if rule.match(reqURL, resourceType, reqHost, docHost) {
+				return rule
+			}
+		}
+	}
+
+	for _, rule := range idx.generic {
+		if rule.match(reqURL, resourceType, reqHost, docHost) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// hostDomain returns the registrable domain (eTLD+1, i.e. "example.co.uk" for "www.example.co.uk") of the host name.
+// IP addresses and hosts without a registrable domain (i.e. "localhost") are returned unchanged.
+func hostDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+
+	return domain
+}
+
+// matchDomain returns true if host is domain or one of its subdomains.
+func matchDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func (rule *FilterRule) match(reqURL string, resourceType ResourceType, reqHost, docHost string) bool {
+	if rule.types != nil && !rule.types[resourceType] {
+		return false
+	}
+	if rule.notTypes[resourceType] {
+		return false
+	}
+
+	if rule.thirdParty != 0 && docHost != "" {
+		third := hostDomain(reqHost) != hostDomain(docHost)
+		if third != (rule.thirdParty > 0) {
+			return false
+		}
+	}
+
+	if len(rule.domains) > 0 {
+		found := false
+		for _, d := range rule.domains {
+			if matchDomain(docHost, d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, d := range rule.notDomains {
+		if matchDomain(docHost, d) {
+			return false
+		}
+	}
+
+	return rule.pattern.MatchString(reqURL)
+}
+
+// Match returns the blocking rule matching the request, or nil if the request is allowed
+// (no blocking rules match, or an exception rule does).
+//
+// documentURL is the URL of the top-level document, used for the third-party and domain options.
+// The documents loaded in frames (other than the main frame) should be matched as ResourceTypeSubdocument.
+func (fl *FilterList) Match(reqURL string, resourceType ResourceType, documentURL string) *FilterRule {
+	var reqHost, docHost string
+
+	if u, err := url.Parse(reqURL); err == nil {
+		reqHost = u.Hostname()
+	}
+	if u, err := url.Parse(documentURL); err == nil {
+		docHost = u.Hostname()
+	}
+
+	tokens := urlTokens(reqURL)
+
+	rule := fl.blocking.match(tokens, reqURL, resourceType, reqHost, docHost)
+	if rule == nil || fl.allowing.match(tokens, reqURL, resourceType, reqHost, docHost) != nil {
+		return nil
+	}
+
+	return rule
+}
+
+// BlockedURLs returns the rules that can be expressed as simple wildcard patterns
+// (no options, anchors or exceptions), for SetBlockedURLs.
+func (fl *FilterList) BlockedURLs() []string {
+	var urls []string
+
+	for _, rule := range fl.rules {
+		if rule.prefix != "" {
+			urls = append(urls, "*"+rule.prefix+"*")
+		}
+	}
+
+	return urls
+}
+
+// FilterStats are the match statistics for the filter list enforced by EnableFilterList.
+type FilterStats struct {
+	Blocked int
+	Allowed int
+
+	// Rules maps each blocking rule to the number of requests it blocked.
+	Rules map[string]int
+}
+
+// filterState holds the state of the filter list enforced by EnableFilterList.
+type filterState struct {
+	sync.Mutex
+	list     *FilterList
+	document string
+	stats    FilterStats
+}
+
+// userPaused returns true if the paused request should be delivered to the Fetch.requestPaused
+// callback, because it matches the patterns passed to EnableRequestPaused.
+func (remote *RemoteDebugger) userPaused(params Params) bool {
+	remote.Lock()
+	fetchEnabled, fetchPatterns := remote.fetchEnabled, remote.fetchPatterns
+	remote.Unlock()
+
+	reqURL := Params(params.Map("request")).String("url")
+	resourceType := ResourceType(params.String("resourceType"))
+
+	return fetchEnabled && fetchPatternMatch(fetchPatterns, reqURL, resourceType)
+}
+
+// fetchPatternMatch returns true if the request would be paused by one of the Fetch patterns.
+func fetchPatternMatch(patterns []FetchRequestPattern, reqURL string, resourceType ResourceType) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if p.ResourceType != "" && p.ResourceType != resourceType {
+			continue
+		}
+		if p.RequestStage != "" && p.RequestStage != "Request" {
+			continue
+		}
+		if matchURLPattern(p.UrlPattern, reqURL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnableFilterList blocks the requests matching the filter list, via Fetch interception.
+// Passing a nil list disables the filtering.
+//
+// If request interception is also enabled (see EnableRequestPaused) the requests that are not blocked
+// are delivered to the Fetch.requestPaused callback as usual.
+func (remote *RemoteDebugger) EnableFilterList(list *FilterList) error {
+	remote.Lock()
+	state := remote.filters
+	remote.filters = nil
+	if list != nil {
+		remote.filters = &filterState{list: list, stats: FilterStats{Rules: map[string]int{}}}
+	}
+	remote.Unlock()
+
+	if list == nil && state == nil {
+		return nil
+	}
+
+	return remote.updateFetch()
+}
+
+// filterBlocked matches a paused request against the filter list (if enabled), updating the statistics,
+// and fails it if blocked.
+func (remote *RemoteDebugger) filterBlocked(params Params) bool {
+	remote.Lock()
+	state := remote.filters
+	current := remote.current
+	remote.Unlock()
+
+	if state == nil {
+		return false
+	}
+
+	requestID := params.String("requestId")
+	reqURL := Params(params.Map("request")).String("url")
+	resourceType := ResourceType(params.String("resourceType"))
+
+	matchType := resourceType
+	if resourceType == ResourceTypeDocument && params.String("frameId") != current {
+		matchType = ResourceTypeSubdocument
+	}
+
+	state.Lock()
+	if matchType == ResourceTypeDocument {
+		state.document = reqURL
+	}
+	rule := state.list.Match(reqURL, matchType, state.document)
+	if rule != nil {
+		state.stats.Blocked++
+		state.stats.Rules[rule.Text]++
+	} else {
+		state.stats.Allowed++
+	}
+	state.Unlock()
+
+	if rule == nil {
+		return false
+	}
+
+	remote.FailRequest(requestID, ErrorReasonBlockedByClient)
+	return true
+}
+
+// FilterListStats returns the match statistics for the filter list enforced by EnableFilterList.
+func (remote *RemoteDebugger) FilterListStats() FilterStats {
+	remote.Lock()
+	state := remote.filters
+	remote.Unlock()
+
+	if state == nil {
+		return FilterStats{}
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	stats := state.stats
+	stats.Rules = map[string]int{}
+	for k, v := range state.stats.Rules {
+		stats.Rules[k] = v
+	}
+
+	return stats
+}
@@ -0,0 +1,324 @@
+package godet
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testFilterList = `[Adblock Plus 2.0]
+! Title: test list
+||ads.example.com^
+||tracker.net^$third-party
+/banner/*/img^
+|https://cdn.example.org/ad.js|
+&ad_type=
+/\/pixel\d+\.gif/
+||fonts.example.com^$font
+||stats.example.com^$~script
+||widgets.example.com^$domain=news.com|~sports.news.com
+||case.example.com/Path$match-case
+||frames.example.com^$subdocument
+||pages.example.com^$document
+@@||ads.example.com/allowed/
+@@||tracker.net^$domain=tracker-friends.org
+example.com##.ad-banner
+||unsupported.com^$popup
+`
+
+func parseTestList(t *testing.T) *FilterList {
+	t.Helper()
+
+	fl, err := ParseFilterList(strings.NewReader(testFilterList))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fl
+}
+
+func TestParseFilterList(t *testing.T) {
+	fl := parseTestList(t)
+
+	if fl.Len() != 14 {
+		t.Errorf("Len = %d, want 14", fl.Len())
+	}
+	if fl.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", fl.Skipped)
+	}
+
+	want := []string{"*&ad_type=*"}
+	if got := fl.BlockedURLs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BlockedURLs = %q, want %q", got, want)
+	}
+}
+
+func TestFilterListMatch(t *testing.T) {
+	fl := parseTestList(t)
+
+	tests := []struct {
+		url      string
+		rtype    ResourceType
+		document string
+		rule     string // the matching rule, empty if allowed
+	}{
+		{"https://ads.example.com/x.js", ResourceTypeScript, "https://site.org/", "||ads.example.com^"},
+		{"https://sub.ads.example.com/x.js", ResourceTypeScript, "https://site.org/", "||ads.example.com^"},
+		{"https://ads.example.com.evil.org/x.js", ResourceTypeScript, "https://site.org/", ""},
+		{"https://notads.example.com/x.js", ResourceTypeScript, "https://site.org/", ""},
+		{"https://ads.example.com/allowed/x.js", ResourceTypeScript, "https://site.org/", ""}, // exception
+
+		{"https://tracker.net/t.gif", ResourceTypeImage, "https://site.org/", "||tracker.net^$third-party"},
+		{"https://tracker.net/t.gif", ResourceTypeImage, "https://www.tracker.net/", ""}, // first party
+		{"https://tracker.net/t.gif", ResourceTypeImage, "https://tracker-friends.org/", ""},
+
+		{"https://site.org/banner/2024/img?w=300", ResourceTypeImage, "", "/banner/*/img^"},
+		{"https://site.org/banner/2024/img.png", ResourceTypeImage, "", ""}, // "." is not a separator
+		{"https://site.org/banner/2024/imgs", ResourceTypeImage, "", ""},
+		{"https://site.org/banner/2024/img", ResourceTypeImage, "", "/banner/*/img^"},
+
+		{"https://cdn.example.org/ad.js", ResourceTypeScript, "", "|https://cdn.example.org/ad.js|"},
+		{"https://cdn.example.org/ad.js?v=2", ResourceTypeScript, "", ""},
+		{"http://cdn.example.org/ad.js", ResourceTypeScript, "", ""},
+
+		{"https://site.org/q?x=1&ad_type=banner", ResourceTypeXHR, "", "&ad_type="},
+		{"https://site.org/q?x=1&AD_TYPE=banner", ResourceTypeXHR, "", "&ad_type="},
+
+		{"https://site.org/img/pixel42.gif", ResourceTypeImage, "", `/\/pixel\d+\.gif/`},
+		{"https://site.org/img/pixel.gif", ResourceTypeImage, "", ""},
+
+		{"https://fonts.example.com/a.woff", ResourceTypeFont, "", "||fonts.example.com^$font"},
+		{"https://fonts.example.com/a.css", ResourceTypeStylesheet, "", ""},
+
+		{"https://stats.example.com/s.js", ResourceTypeScript, "", ""},
+		{"https://stats.example.com/s.gif", ResourceTypeImage, "", "||stats.example.com^$~script"},
+
+		{"https://widgets.example.com/w.js", ResourceTypeScript, "https://news.com/", "||widgets.example.com^$domain=news.com|~sports.news.com"},
+		{"https://widgets.example.com/w.js", ResourceTypeScript, "https://www.news.com/", "||widgets.example.com^$domain=news.com|~sports.news.com"},
+		{"https://widgets.example.com/w.js", ResourceTypeScript, "https://sports.news.com/", ""},
+		{"https://widgets.example.com/w.js", ResourceTypeScript, "https://other.com/", ""},
+
+		{"https://case.example.com/Path", ResourceTypeOther, "", "||case.example.com/Path$match-case"},
+		{"https://case.example.com/path", ResourceTypeOther, "", ""},
+
+		{"https://frames.example.com/embed", ResourceTypeSubdocument, "https://site.org/", "||frames.example.com^$subdocument"},
+		{"https://frames.example.com/", ResourceTypeDocument, "", ""}, // the main frame
+		{"https://frames.example.com/f.js", ResourceTypeScript, "https://site.org/", ""},
+		{"https://pages.example.com/", ResourceTypeDocument, "", "||pages.example.com^$document"},
+		{"https://pages.example.com/embed", ResourceTypeSubdocument, "https://site.org/", ""},
+
+		{"https://unsupported.com/", ResourceTypeDocument, "", ""},
+	}
+
+	for _, tt := range tests {
+		rule := fl.Match(tt.url, tt.rtype, tt.document)
+
+		var got string
+		if rule != nil {
+			got = rule.Text
+		}
+
+		if got != tt.rule {
+			t.Errorf("Match(%q, %s, %q) = %q, want %q", tt.url, tt.rtype, tt.document, got, tt.rule)
+		}
+	}
+}
+
+func TestFilterToken(t *testing.T) {
+	tests := []struct {
+		pattern                string
+		anchorStart, anchorEnd bool
+		token                  string
+	}{
+		{"ads.example.com^", true, false, "example"},
+		{"ads.example.com^", false, false, "example"}, // "ads" could be the end of "loads"
+		{"adserver.", false, false, ""},               // could be "myadserver."
+		{"/adserver.", false, false, "adserver"},      // bounded by / and .
+		{"/banner/*/img^", false, false, "banner"},    // "img" is next to a wildcard
+		{"/ads*", false, false, ""},                   // a prefix only
+		{"https://cdn.example.org/ad.js", true, true, "example"},
+		{"/Tracker/Pixel/", false, false, "tracker"}, // lowercase
+		{"www.com", true, true, ""},                  // common tokens only
+		{"&ad_type=", false, false, "type"},
+	}
+
+	for _, tt := range tests {
+		if got := filterToken(tt.pattern, tt.anchorStart, tt.anchorEnd); got != tt.token {
+			t.Errorf("filterToken(%q, %v, %v) = %q, want %q", tt.pattern, tt.anchorStart, tt.anchorEnd, got, tt.token)
+		}
+	}
+}
+
+// linearMatch is Match without the index, to check the index doesn't change the results.
+func (fl *FilterList) linearMatch(reqURL string, resourceType ResourceType, reqHost, docHost string) bool {
+	for _, rule := range fl.rules {
+		if rule.match(reqURL, resourceType, reqHost, docHost) {
+			for _, exception := range fl.exceptions {
+				if exception.match(reqURL, resourceType, reqHost, docHost) {
+					return false
+				}
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// generatedFilterList returns a list with n rules of the common shapes.
+func generatedFilterList(n int) string {
+	var b strings.Builder
+
+	for i := 0; i < n; i++ {
+		switch i % 6 {
+		case 0:
+			fmt.Fprintf(&b, "||ads%d.example.com^\n", i)
+		case 1:
+			fmt.Fprintf(&b, "||tracker%d.net^$third-party\n", i)
+		case 2:
+			fmt.Fprintf(&b, "/banner%d/*\n", i)
+		case 3:
+			fmt.Fprintf(&b, "&ad_slot%d=\n", i)
+		case 4:
+			fmt.Fprintf(&b, "@@||ads%d.example.com/ok/\n", i-4)
+		case 5:
+			fmt.Fprintf(&b, "-ad-%dx%d.\n", i, i)
+		}
+	}
+
+	return b.String()
+}
+
+var generatedURLs = []string{
+	"https://ads0.example.com/x.js",
+	"https://ads0.example.com/ok/x.js",
+	"https://ads600.example.com/x.js",
+	"https://ads601.example.com/x.js",
+	"https://tracker7.net/p.gif",
+	"https://site.org/banner8/a.png",
+	"https://site.org/banner8x/a.png",
+	"https://site.org/q?a=1&ad_slot9=3",
+	"https://site.org/img/top-ad-11x11.png",
+	"https://site.org/img/top-ad-11x12.png",
+	"https://site.org/articles/2024/10/some-long-article-title.html?utm_source=feed",
+}
+
+func TestFilterListIndex(t *testing.T) {
+	fl, err := ParseFilterList(strings.NewReader(generatedFilterList(6000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fl.blocking.generic) != 0 {
+		t.Errorf("%d blocking rules not indexed", len(fl.blocking.generic))
+	}
+
+	for _, u := range generatedURLs {
+		for _, doc := range []string{"https://site.org/", "https://tracker7.net/"} {
+			reqHost := strings.SplitN(strings.TrimPrefix(u, "https://"), "/", 2)[0]
+			docHost := strings.SplitN(strings.TrimPrefix(doc, "https://"), "/", 2)[0]
+
+			want := fl.linearMatch(u, ResourceTypeImage, reqHost, docHost)
+			if got := fl.Match(u, ResourceTypeImage, doc) != nil; got != want {
+				t.Errorf("Match(%q, %q) = %v, want %v", u, doc, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkFilterListMatch matches a few URLs against a list of EasyList size,
+// with the token index and with a linear scan of the rules.
+func BenchmarkFilterListMatch(b *testing.B) {
+	fl, err := ParseFilterList(strings.NewReader(generatedFilterList(70000)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, u := range generatedURLs {
+				fl.Match(u, ResourceTypeImage, "https://site.org/")
+			}
+		}
+	})
+
+	b.Run("linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, u := range generatedURLs {
+				fl.linearMatch(u, ResourceTypeImage, "", "site.org")
+			}
+		}
+	})
+}
+
+func TestHostDomain(t *testing.T) {
+	tests := []struct {
+		host, domain string
+	}{
+		{"www.example.com", "example.com"},
+		{"example.com", "example.com"},
+		{"a.b.example.co.uk", "example.co.uk"},
+		{"shop.example.com.au", "example.com.au"},
+		{"user.github.io", "user.github.io"}, // a private public suffix
+		{"localhost", "localhost"},
+		{"192.168.1.10", "192.168.1.10"},
+		{"::1", "::1"},
+	}
+
+	for _, tt := range tests {
+		if got := hostDomain(tt.host); got != tt.domain {
+			t.Errorf("hostDomain(%q) = %q, want %q", tt.host, got, tt.domain)
+		}
+	}
+}
+
+func TestEnableFilterListFrames(t *testing.T) {
+	var lock sync.Mutex
+	var calls []string
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		if method == "Fetch.failRequest" || method == "Fetch.continueRequest" {
+			lock.Lock()
+			calls = append(calls, method+" "+params.String("requestId"))
+			lock.Unlock()
+		}
+
+		return nil, nil
+	}))
+
+	list, err := ParseFilterList(strings.NewReader("||frames.example.com^$subdocument\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.EnableFilterList(list); err != nil {
+		t.Fatal(err)
+	}
+
+	paused := func(id, url, frameID string) {
+		fakeEvent(remote, "Fetch.requestPaused", Params{
+			"requestId":    id,
+			"frameId":      frameID,
+			"resourceType": ResourceTypeDocument,
+			"request":      Params{"url": url, "method": "GET"},
+		})
+	}
+
+	paused("main", "https://frames.example.com/", "fake")
+	paused("frame", "https://frames.example.com/embed", "child")
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	want := []string{"Fetch.continueRequest main", "Fetch.failRequest frame"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	if stats := remote.FilterListStats(); stats.Blocked != 1 || stats.Allowed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,98 @@
+package godet
+
+import (
+	"fmt"
+)
+
+// describeElementJS is a Javascript function returning a short description (tag#id.class) of an element.
+const describeElementJS = `function(e) {
+	if (!e) return "";
+	var d = e.tagName.toLowerCase();
+	if (e.id) d += "#" + e.id;
+	if (typeof e.className === "string" && e.className.trim()) d += "." + e.className.trim().split(/\s+/).join(".");
+	return d;
+}`
+
+// ActiveElement returns the nodeId of the focused element (document.activeElement).
+func (remote *RemoteDebugger) ActiveElement() (int, error) {
+	return remote.expressionNode("document.activeElement")
+}
+
+// ActiveElementDescription returns a short description (tag#id.class) of the focused element.
+func (remote *RemoteDebugger) ActiveElementDescription() (string, error) {
+	res, err := remote.Evaluate(fmt.Sprintf("(%v)(document.activeElement)", describeElementJS))
+	if err != nil {
+		return "", err
+	}
+
+	desc, _ := res.(string)
+	return desc, nil
+}
+
+// FocusSelector sets focus on the first element matching the selector.
+func (remote *RemoteDebugger) FocusSelector(selector string) error {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return err
+	}
+
+	return remote.Focus(id)
+}
+
+// Blur removes focus from the first element matching the selector.
+func (remote *RemoteDebugger) Blur(selector string) error {
+	_, err := remote.evaluateSelector(selector, "el.blur();")
+	return err
+}
+
+// PressTab sends a Tab (or Shift+Tab) key press, moving the focus to the next (or previous) element,
+// and returns the description of the new focused element.
+func (remote *RemoteDebugger) PressTab(shift bool) (string, error) {
+	modifiers := NoModifier
+	if shift {
+		modifiers = ShiftKey
+	}
+
+	for _, t := range []string{"rawKeyDown", "keyUp"} {
+		if _, err := remote.SendRequest("Input.dispatchKeyEvent", Params{
+			"type":                  t,
+			"key":                   "Tab",
+			"code":                  "Tab",
+			"windowsVirtualKeyCode": 9,
+			"nativeVirtualKeyCode":  9,
+			"modifiers":             modifiers,
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	return remote.ActiveElementDescription()
+}
+
+// TabOrder returns the descriptions of the focusable elements of the page, in sequential focus navigation order:
+// elements with a positive tabindex first (in tabindex order), followed by the others in document order.
+// Disabled, hidden and tabindex=-1 elements are skipped.
+func (remote *RemoteDebugger) TabOrder() ([]string, error) {
+	res, err := remote.EvaluateWrap(fmt.Sprintf(`var describe = %v;
+		var focusable = "a[href], area[href], button, input, select, textarea, iframe, summary, [tabindex], [contenteditable]";
+		var list = Array.from(document.querySelectorAll(focusable)).filter(e => {
+			if (e.disabled || e.tabIndex < 0) return false;
+			if (e.tagName === "INPUT" && e.type === "hidden") return false;
+			var s = window.getComputedStyle(e);
+			return s.display !== "none" && s.visibility !== "hidden" && e.getClientRects().length > 0;
+		});
+		var positive = list.filter(e => e.tabIndex > 0).sort((a, b) => a.tabIndex - b.tabIndex);
+		return positive.concat(list.filter(e => e.tabIndex === 0)).map(describe);`, describeElementJS))
+	if err != nil {
+		return nil, err
+	}
+
+	list, _ := res.([]interface{})
+	order := make([]string, 0, len(list))
+
+	for _, d := range list {
+		order = append(order, d.(string))
+	}
+
+	return order, nil
+}
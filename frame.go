@@ -0,0 +1,134 @@
+package godet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorNoSuchFrame is returned by Frame if neither a frame nor an <iframe> element matches the identifier
+var ErrorNoSuchFrame = errors.New("no such frame")
+
+// frameWorldName is the name of the isolated world created to evaluate expressions in a frame.
+const frameWorldName = "godet"
+
+// Frame is a handle to a (same-process) frame in the current page.
+// Its methods operate on the frame document instead of the top-level document.
+//
+// Expressions are evaluated in an isolated world: they have access to the frame DOM
+// but not to the Javascript globals defined by the frame scripts.
+//
+// The handle is bound to the current frame document, so if the frame navigates it
+// should be requested again.
+type Frame struct {
+	remote    *RemoteDebugger
+	ID        string
+	contextID int
+}
+
+// frameIDs returns the ids of all the frames in the frame tree.
+func frameIDs(tree map[string]interface{}, ids map[string]bool) {
+	ids[Params(Params(tree).Map("frame")).String("id")] = true
+
+	children, _ := tree["childFrames"].([]interface{})
+	for _, c := range children {
+		if child, ok := c.(map[string]interface{}); ok {
+			frameIDs(child, ids)
+		}
+	}
+}
+
+// mainFrameID returns the frameId of the top-level frame.
+func (remote *RemoteDebugger) mainFrameID() (string, error) {
+	res, err := remote.SendRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return Params(Params(Params(res).Map("frameTree")).Map("frame")).String("id"), nil
+}
+
+// mainLoaderID returns the loaderId of the document loaded in the top-level frame.
+func (remote *RemoteDebugger) mainLoaderID() (string, error) {
+	res, err := remote.SendRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return Params(Params(Params(res).Map("frameTree")).Map("frame")).String("loaderId"), nil
+}
+
+// Frame returns a handle to the frame identified by frame, that can either be a frameId
+// or a selector matching the <iframe> element (see also DeepSelectorSeparator).
+func (remote *RemoteDebugger) Frame(frame string) (*Frame, error) {
+	res, err := remote.SendRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	frameIDs(Params(res).Map("frameTree"), ids)
+
+	frameID := frame
+
+	if !ids[frameID] {
+		nodeID, err := remote.QuerySelectorNode(frame)
+		if err == ErrorNoSuchNode {
+			return nil, ErrorNoSuchFrame
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := remote.SendRequest("DOM.describeNode", Params{
+			"nodeId": nodeID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		frameID = Params(Params(res).Map("node")).String("frameId")
+		if frameID == "" || !ids[frameID] {
+			return nil, ErrorNoSuchFrame
+		}
+	}
+
+	res, err = remote.SendRequest("Page.createIsolatedWorld", Params{
+		"frameId":   frameID,
+		"worldName": frameWorldName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Frame{
+		remote:    remote,
+		ID:        frameID,
+		contextID: Params(res).Int("executionContextId"),
+	}, nil
+}
+
+// Evaluate evaluates a Javascript expression in the context of the frame.
+func (f *Frame) Evaluate(expr string, options ...EvaluateOption) (interface{}, error) {
+	return f.remote.Evaluate(expr, append(options, ContextID(f.contextID))...)
+}
+
+// EvaluateWrap is like RemoteDebugger.EvaluateWrap, in the context of the frame.
+func (f *Frame) EvaluateWrap(expr string, options ...EvaluateOption) (interface{}, error) {
+	return f.Evaluate(fmt.Sprintf("(function(){%v})()", expr), options...)
+}
+
+// QuerySelector returns the nodeId of the first element in the frame document matching the selector.
+// The selector can traverse shadow roots (see DeepSelectorSeparator).
+func (f *Frame) QuerySelector(selector string) (int, error) {
+	return f.remote.expressionNode(elementExpression(selector), ContextID(f.contextID))
+}
+
+// Click scrolls the first element in the frame document matching the selector into view and clicks on it.
+func (f *Frame) Click(selector string) error {
+	id, err := f.QuerySelector(selector)
+	if err != nil {
+		return err
+	}
+
+	return f.remote.clickNode(id)
+}
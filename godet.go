@@ -4,11 +4,13 @@
 package godet
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
@@ -82,8 +84,19 @@ var (
 	ErrorNoResponse = errors.New("no response")
 	// ErrorClose is returned if a method is called after the connection has been close
 	ErrorClose = errors.New("closed")
-
-	MaxReadBufferSize  int64 = 100 * 1024
+	// ErrorMessageTooLarge is returned if a reply is larger than the maximum message size (see ReadBufferSize)
+	ErrorMessageTooLarge = errors.New("message too large")
+	// ErrorUnhealthy is returned if the browser stopped answering heartbeats (see Heartbeat)
+	ErrorUnhealthy = errors.New("connection unhealthy")
+	// ErrorDisconnected is returned by Run if the connection with the debugger is lost
+	ErrorDisconnected = errors.New("disconnected")
+	// ErrorEventLoop is returned by Run if events are already being dispatched (see ManualRun)
+	ErrorEventLoop = errors.New("event loop already running")
+	// ErrorTimeout is returned by the wait helpers if the condition is not met in time
+	ErrorTimeout = errors.New("timeout")
+
+	InitialReadBufferSize int64 = 4096
+	MaxReadBufferSize     int64 = 100 * 1024
 	MaxWriteBufferSize int64 = 100 * 1024 // this should be large enough to send large scripts
 )
 
@@ -130,6 +143,7 @@ type Version struct {
 	UserAgent       string `json:"User-Agent"`
 	V8Version       string `json:"V8-Version"`
 	WebKitVersion   string `json:"WebKit-Version"`
+	AndroidPackage  string `json:"Android-Package"`
 }
 
 // Domain holds a domain name and version.
@@ -194,6 +208,23 @@ func (err EvaluateError) Error() string {
 	return desc
 }
 
+// ProtocolError is returned when the browser replies to a request with an error.
+// Older versions ignored the error replies, returning a nil result (see the README).
+type ProtocolError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+func (err ProtocolError) Error() string {
+	if err.Data != "" {
+		return fmt.Sprintf("%v (%v): %v", err.Message, err.Code, err.Data)
+	}
+
+	return fmt.Sprintf("%v (%v)", err.Message, err.Code)
+}
+
+// NavigationError is returned when a navigation fails. The failure class is available via NetError and Category.
 type NavigationError string
 
 func (err NavigationError) Error() string {
@@ -205,17 +236,75 @@ type RemoteDebugger struct {
 	http    *httpclient.HttpClient
 	ws      *websocket.Conn
 	current string
+	session string // flat protocol session, when connected to a browser endpoint
 	reqID   int
 	verbose bool
 
 	sync.Mutex
-	closed chan bool
+	closed  chan bool
+	readers sync.WaitGroup // the readMessages goroutines
+
+	readBufferSize int64
+	maxMessageSize int64
+
+	retry *RetryPolicy
+
+	protoLog *protocolLog
+	tag      string
+
+	manualRun bool
+	running   bool
+
+	heartbeat        time.Duration
+	heartbeatTimeout time.Duration
+	unhealthy        bool
+	unhealthyCh      chan struct{}  // closed while unhealthy
+	pinger           sync.WaitGroup // the heartbeats goroutine
 
 	requests  chan Params
-	responses map[int]chan json.RawMessage
-	callbacks map[string]EventCallback
-	domains   map[string]bool
-	events    chan wsMessage
+	responses map[int]chan wsReply
+	callbacks map[string]*subscription
+	hooks     map[string][]*hook
+
+	fetchEnabled  bool
+	fetchPatterns []FetchRequestPattern
+
+	captured    map[string]*CapturedRequest
+	stopCapture func()
+
+	stats    *networkStats
+	security *securityCollector
+
+	stopPopups      func()
+	stopPopupPolicy func()
+
+	stopBeforeUnload     func()
+	stopBackForwardCache func()
+	stopCompilationCache func()
+	stopPreload          func()
+
+	lastNavigation  *navigationRecord
+	stopNavigations func()
+
+	filters      *filterState
+	limiter      *OriginLimiter
+	recorder     *responseRecorder
+	rewrites     []*requestRewrite
+	stopFetch    func()
+	stopGraphQL  func()
+	validation   *validationState
+	stateScript  string
+	battery      string // SetBatteryStatus script
+	trace        *Trace
+	healing      *selectorHealing
+	thirdParties *thirdPartyState
+	cookieAudit  *cookieAudit
+	proxyAuth    *proxyAuth
+	sessions     map[string]*TargetSession
+	eventSeq     uint64
+
+	domains map[string]Params
+	events  chan wsMessage
 }
 
 // Params is a type alias for the event params structure.
@@ -244,39 +333,39 @@ func (p Params) Map(k string) map[string]interface{} {
 // EventCallback represents a callback event, associated with a method.
 type EventCallback func(params Params)
 
-type ConnectOption func(c *httpclient.HttpClient)
+// ConnectOption defines the functional option for Connect
+type ConnectOption func(remote *RemoteDebugger)
 
 // Host set the host header
 func Host(host string) ConnectOption {
-	return func(c *httpclient.HttpClient) {
-		c.Host = host
+	return func(remote *RemoteDebugger) {
+		remote.http.Host = host
 	}
 }
 
 // Headers set specified HTTP headers
 func Headers(headers map[string]string) ConnectOption {
-	return func(c *httpclient.HttpClient) {
-		c.Headers = headers
+	return func(remote *RemoteDebugger) {
+		remote.http.Headers = headers
+	}
+}
+
+// ReadBufferSize sets the initial size of the buffer used to read messages and the maximum message size.
+// Messages larger than max are discarded, and a request waiting for one fails with ErrorMessageTooLarge
+// (the defaults are InitialReadBufferSize and MaxReadBufferSize, a max of 0 disables the limit).
+func ReadBufferSize(initial, max int64) ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.readBufferSize = initial
+		remote.maxMessageSize = max
 	}
 }
 
 // Connect to the remote debugger and return `RemoteDebugger` object.
 func Connect(port string, verbose bool, options ...ConnectOption) (*RemoteDebugger, error) {
-	client := httpclient.NewHttpClient("http://" + port)
+	remote := newRemoteDebugger(httpclient.NewHttpClient("http://"+port), verbose)
 
 	for _, setOption := range options {
-		setOption(client)
-	}
-
-	remote := &RemoteDebugger{
-		http:      client,
-		requests:  make(chan Params),
-		responses: map[int]chan json.RawMessage{},
-		callbacks: map[string]EventCallback{},
-		domains:   map[string]bool{},
-		events:    make(chan wsMessage, 256),
-		closed:    make(chan bool),
-		verbose:   verbose,
+		setOption(remote)
 	}
 
 	// remote.http.Verbose = verbose
@@ -288,11 +377,65 @@ func Connect(port string, verbose bool, options ...ConnectOption) (*RemoteDebugg
 		return nil, err
 	}
 
-	go remote.sendMessages()
-	go remote.processEvents()
+	remote.start()
 	return remote, nil
 }
 
+func newRemoteDebugger(http *httpclient.HttpClient, verbose bool) *RemoteDebugger {
+	return &RemoteDebugger{
+		http:           http,
+		readBufferSize: InitialReadBufferSize,
+		maxMessageSize: MaxReadBufferSize,
+		requests:       make(chan Params),
+		responses:      map[int]chan wsReply{},
+		callbacks:      map[string]*subscription{},
+		hooks:          map[string][]*hook{},
+		domains:        map[string]Params{},
+		events:         make(chan wsMessage, 256),
+		closed:         make(chan bool),
+		unhealthyCh:    make(chan struct{}),
+		verbose:        verbose,
+	}
+}
+
+// start starts the goroutines that process requests and events, once connected.
+func (remote *RemoteDebugger) start() {
+	go remote.sendMessages()
+
+	if !remote.manualRun {
+		go remote.processEvents()
+	}
+
+	if remote.heartbeat > 0 {
+		remote.pinger.Add(1)
+		go remote.heartbeats()
+	}
+}
+
+// connectTab returns a new connection to the specified tab, with the same settings as remote
+// (buffer sizes, retry policy, heartbeat, navigation limiter, protocol log and tag).
+func (remote *RemoteDebugger) connectTab(tab *Tab) (*RemoteDebugger, error) {
+	conn := newRemoteDebugger(remote.http, remote.verbose)
+	conn.readBufferSize = remote.readBufferSize
+	conn.maxMessageSize = remote.maxMessageSize
+	conn.retry = remote.retry
+	conn.heartbeat = remote.heartbeat
+	conn.heartbeatTimeout = remote.heartbeatTimeout
+
+	remote.Lock()
+	conn.limiter = remote.limiter   // the politeness limits apply to all the tabs
+	conn.protoLog = remote.protoLog // and all the tabs are recorded in the same log
+	conn.tag = remote.tag
+	remote.Unlock()
+
+	if err := conn.connectWs(tab); err != nil {
+		return nil, err
+	}
+
+	conn.start()
+	return conn, nil
+}
+
 func (remote *RemoteDebugger) connectWs(tab *Tab) error {
 	if tab == nil || len(tab.WsURL) == 0 {
 		tabs, err := remote.TabList("page")
@@ -353,13 +496,14 @@ func (remote *RemoteDebugger) connectWs(tab *Tab) error {
 		return err
 	}
 
-	ws.SetReadLimit(MaxReadBufferSize)
+	ws.SetReadLimit(-1) // the message size is checked in readMessage, without closing the connection
 
 	remote.Lock()
 	remote.ws = ws
 	remote.current = tab.ID
 	remote.Unlock()
 
+	remote.readers.Add(1)
 	go remote.readMessages(ws)
 	return nil
 }
@@ -373,6 +517,12 @@ func (remote *RemoteDebugger) socket() (ws *websocket.Conn) {
 
 // Close the RemoteDebugger connection.
 func (remote *RemoteDebugger) Close() (err error) {
+	return remote.closeConn(true)
+}
+
+// closeConn closes the connection, with or without the websocket close handshake
+// (that an unresponsive browser would never answer).
+func (remote *RemoteDebugger) closeConn(handshake bool) (err error) {
 	remote.Lock()
 	ws := remote.ws
 	remote.ws = nil
@@ -381,7 +531,12 @@ func (remote *RemoteDebugger) Close() (err error) {
 	if ws != nil { // already closed
 		close(remote.requests)
 		close(remote.closed)
-		err = ws.Close(websocket.StatusNormalClosure, "")
+
+		if handshake {
+			err = ws.Close(websocket.StatusNormalClosure, "")
+		} else {
+			err = ws.CloseNow()
+		}
 	}
 
 	if remote.verbose {
@@ -401,6 +556,17 @@ type wsMessage struct {
 
 	Method string          `json:"Method"`
 	Params json.RawMessage `json:"Params"`
+
+	hooksOnly bool           // the event callback didn't accept this event
+	session   *TargetSession // the event is for this session (see AttachSession)
+	seq       uint64         // the event sequence number
+	received  time.Time      // when the event was received
+}
+
+// wsReply is what a pending request receives: either the method result or an error.
+type wsReply struct {
+	result json.RawMessage
+	err    error
 }
 
 // SendRequest sends a request and returns the reply as a a map.
@@ -413,33 +579,102 @@ func (remote *RemoteDebugger) SendRequest(method string, params Params) (map[str
 }
 
 // sendRawReplyRequest sends a request and returns the reply bytes.
+// Failed requests are retried according to the retry policy, if any.
 func (remote *RemoteDebugger) sendRawReplyRequest(method string, params Params) ([]byte, error) {
+	policy := remote.retry
+	if !policy.applies(method) {
+		return remote.sendRequestOnce(method, params)
+	}
+
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		reply, err := remote.sendRequestOnce(method, params)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return reply, err
+		}
+
+		if remote.verbose {
+			log.Println("retry", method, "attempt", attempt, err)
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// sendRequestOnce sends a request and waits for the reply.
+func (remote *RemoteDebugger) sendRequestOnce(method string, params Params) ([]byte, error) {
+	return remote.sendSessionRequest("", method, params)
+}
+
+// sendSessionRequest sends a request to the specified session (the connection session if empty)
+// and waits for the reply.
+func (remote *RemoteDebugger) sendSessionRequest(sessionID, method string, params Params) ([]byte, error) {
 	remote.Lock()
 	if remote.ws == nil {
 		remote.Unlock()
 		return nil, ErrorClose
 	}
+	if remote.unhealthy {
+		remote.Unlock()
+		return nil, ErrorUnhealthy
+	}
 
-	responseChan := make(chan json.RawMessage, 1)
+	responseChan := make(chan wsReply, 1)
 	reqID := remote.reqID
 	remote.responses[reqID] = responseChan
 	remote.reqID++
+	plog, tag := remote.protoLog, remote.tag
+	session := remote.session
+	unhealthy := remote.unhealthyCh
 	remote.Unlock()
 
+	if sessionID != "" {
+		session = sessionID
+	}
+
 	command := Params{
 		"id":     reqID,
 		"method": method,
 		"params": params,
 	}
 
-	remote.requests <- command
+	if session != "" {
+		command["sessionId"] = session
+	}
+
+	if plog != nil {
+		entry := ProtocolEntry{Type: "command", ID: reqID, Method: method}
+		entry.Params, _ = json.Marshal(params)
+		remote.logProtocol(entry, &tag)
+	}
+
+	select {
+	case remote.requests <- command:
+
+	case <-unhealthy: // the writer is stuck
+		remote.Lock()
+		delete(remote.responses, reqID)
+		remote.Unlock()
+		return nil, ErrorUnhealthy
+	}
+
 	reply := <-responseChan
 
 	remote.Lock()
 	delete(remote.responses, reqID)
 	remote.Unlock()
 
-	return reply, nil
+	if plog != nil {
+		entry := ProtocolEntry{Type: "reply", ID: reqID, Method: method, Result: reply.result}
+		if reply.err != nil {
+			entry.Error = reply.err.Error()
+		}
+		remote.logProtocol(entry, &tag)
+	}
+
+	return reply.result, reply.err
 }
 
 func (remote *RemoteDebugger) sendMessages() {
@@ -476,9 +711,63 @@ func permanentError(err error) bool {
 	return false
 }
 
+// readMessage reads the next message from the websocket into buf, reusing its storage.
+//
+// If the message is larger than maxMessageSize, the rest of it is discarded, buf only contains
+// the beginning of the message and ErrorMessageTooLarge is returned.
+func (remote *RemoteDebugger) readMessage(ws *websocket.Conn, buf *bytes.Buffer) error {
+	buf.Reset()
+
+	_, r, err := ws.Reader(context.Background())
+	if err != nil {
+		return err
+	}
+
+	max := remote.maxMessageSize
+	if max <= 0 {
+		_, err = buf.ReadFrom(r)
+		return err
+	}
+
+	if _, err = buf.ReadFrom(io.LimitReader(r, max+1)); err != nil {
+		return err
+	}
+
+	if int64(buf.Len()) > max {
+		if _, err = io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+
+		return ErrorMessageTooLarge
+	}
+
+	return nil
+}
+
+// reply delivers a reply to the pending request with the specified id, if any.
+func (remote *RemoteDebugger) reply(id int, reply wsReply) {
+	remote.Lock()
+	ch := remote.responses[id]
+	remote.Unlock()
+
+	if ch != nil {
+		select {
+		case ch <- reply:
+		default: // the request already got a reply (or an error)
+		}
+	}
+}
+
 func (remote *RemoteDebugger) readMessages(ws *websocket.Conn) {
+	defer remote.readers.Done()
+
 	remoteClosed := false
 
+	var buf bytes.Buffer
+	var raw rawMessage
+
+	buf.Grow(int(remote.readBufferSize))
+
 loop:
 	for {
 		select {
@@ -491,10 +780,15 @@ loop:
 				break loop
 			}
 
-			var message wsMessage
+			err := remote.readMessage(ws, &buf)
+			if err == ErrorMessageTooLarge {
+				log.Println("read message:", err)
 
-			err := wsjson.Read(context.Background(), ws, &message)
-			if err != nil {
+				// replies start with the id, so we can still fail the pending request
+				if id, ok := scanID(buf.Bytes()); ok {
+					remote.reply(id, wsReply{err: err})
+				}
+			} else if err != nil {
 				if remote.socket() != ws { // this socket is now closed
 					continue // one more check for remote.closed
 				}
@@ -503,17 +797,16 @@ loop:
 				if permanentError(err) {
 					break loop
 				}
-			} else if message.Method != "" {
+			} else if err := scanMessage(buf.Bytes(), &raw); err != nil {
+				log.Println("scan message:", err, buf.Len())
+			} else if raw.method != nil {
 				if remote.verbose {
-					log.Println("EVENT", message.Method, string(message.Params), len(remote.events))
+					log.Println("EVENT", string(raw.method), string(raw.params), len(remote.events))
 				}
 
-				remote.Lock()
-				_, ok := remote.callbacks[message.Method]
-				remote.Unlock()
-
+				message, ok := remote.eventMessage(&raw)
 				if !ok {
-					continue // don't queue unrequested events
+					continue // don't queue (or decode) unrequested, disabled or rate-limited events
 				}
 
 				select {
@@ -528,15 +821,18 @@ loop:
 				// should be a method reply
 				//
 				if remote.verbose {
-					log.Println("REPLY", message.ID, string(message.Result))
+					log.Println("REPLY", raw.id, string(raw.result))
 				}
 
-				remote.Lock()
-				ch := remote.responses[message.ID]
-				remote.Unlock()
+				if raw.error != nil {
+					var perr ProtocolError
+					if err := json.Unmarshal(raw.error, &perr); err != nil {
+						perr.Message = string(raw.error)
+					}
 
-				if ch != nil {
-					ch <- message.Result
+					remote.reply(raw.id, wsReply{err: perr})
+				} else {
+					remote.reply(raw.id, wsReply{result: raw.message().Result})
 				}
 			}
 		}
@@ -545,6 +841,8 @@ loop:
 	// log.Println("exit readMessages", remoteClosed)
 
 	if remoteClosed {
+		remote.pinger.Wait() // the heartbeats may be queueing an event
+
 		remote.events <- wsMessage{Method: EventClosed, Params: []byte("{}")}
 		close(remote.events)
 	} else if remote.socket() == ws { // we should still be connected but something is wrong
@@ -552,20 +850,96 @@ loop:
 	}
 }
 
+// eventMessage numbers the scanned event and routes it to its session, if any.
+// It returns false if the event should not be queued, as there is no callback or hook for it.
+func (remote *RemoteDebugger) eventMessage(raw *rawMessage) (wsMessage, bool) {
+	received := time.Now()
+
+	remote.Lock()
+	remote.eventSeq++ // all the events are numbered, so that the gaps show the dropped ones
+	seq := remote.eventSeq
+	session := remote.sessions[string(raw.sessionID)]
+	var ok, hooked bool
+	if session != nil {
+		ok = session.callbacks[string(raw.method)] != nil
+	} else {
+		ok = remote.callbacks[string(raw.method)].accept()
+		hooked = len(remote.hooks[string(raw.method)]) > 0
+	}
+	remote.Unlock()
+
+	remote.logProtocol(ProtocolEntry{
+		Time:   received,
+		Type:   "event",
+		Seq:    seq,
+		Method: string(raw.method),
+		Params: raw.params,
+	}, nil)
+
+	if !ok && !hooked {
+		return wsMessage{}, false
+	}
+
+	message := raw.message()
+	message.hooksOnly = !ok
+	message.session = session
+	message.seq = seq
+	message.received = received
+
+	return message, true
+}
+
 func (remote *RemoteDebugger) processEvents() {
 	for ev := range remote.events {
-		remote.Lock()
-		cb := remote.callbacks[ev.Method]
-		remote.Unlock()
+		remote.dispatch(ev)
+	}
+}
 
-		if cb != nil {
-			var params Params
-			if err := json.Unmarshal(ev.Params, &params); err != nil {
-				log.Println("unmarshal", string(ev.Params), len(ev.Params), err)
-			} else {
-				cb(params)
+// dispatch decodes the event params and calls the internal hooks and the event callback, if any.
+func (remote *RemoteDebugger) dispatch(ev wsMessage) {
+	remote.Lock()
+	var cb EventCallback
+	var hooks []*hook
+	if ev.session != nil {
+		cb = ev.session.callbacks[ev.Method]
+	} else {
+		if sub := remote.callbacks[ev.Method]; sub != nil && !ev.hooksOnly {
+			cb = sub.cb
+
+			if sub.evcb != nil {
+				evcb := sub.evcb
+				cb = func(params Params) {
+					evcb(Event{
+						Seq:      ev.seq,
+						Received: ev.received,
+						Method:   ev.Method,
+						Params:   params,
+					})
+				}
 			}
 		}
+		hooks = remote.hooks[ev.Method]
+	}
+	remote.Unlock()
+
+	if cb == nil && len(hooks) == 0 {
+		return
+	}
+
+	var params Params
+	if err := json.Unmarshal(ev.Params, &params); err != nil {
+		log.Println("unmarshal", string(ev.Params), len(ev.Params), err)
+		return
+	}
+
+	for _, h := range hooks {
+		if h.cb(params) {
+			return // consumed by the hook
+		}
+	}
+
+	if cb != nil {
+		cb(params)
 	}
 }
 
@@ -641,8 +1015,15 @@ func (remote *RemoteDebugger) ActivateTab(tab *Tab) error {
 		err = remote.connectWs(tab)
 
 		if err == nil {
-			for domain, state := range remote.domains {
-				remote.DomainEvents(domain, state)
+			remote.Lock()
+			domains := make(map[string]Params, len(remote.domains))
+			for domain, params := range remote.domains {
+				domains[domain] = params
+			}
+			remote.Unlock()
+
+			for domain, params := range domains {
+				remote.domainEvents(domain, true, params)
 			}
 		}
 	}
@@ -659,6 +1040,20 @@ func (remote *RemoteDebugger) CloseTab(tab *Tab) error {
 
 // NewTab creates a new tab.
 func (remote *RemoteDebugger) NewTab(url string) (*Tab, error) {
+	tab, err := remote.createTab(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = remote.connectWs(tab); err != nil {
+		return nil, err
+	}
+
+	return tab, nil
+}
+
+// createTab creates a new tab, without connecting to it.
+func (remote *RemoteDebugger) createTab(url string) (*Tab, error) {
 	path := "/json/new"
 	if url != "" {
 		path += "?" + url
@@ -674,10 +1069,6 @@ func (remote *RemoteDebugger) NewTab(url string) (*Tab, error) {
 		return nil, err
 	}
 
-	if err = remote.connectWs(&tab); err != nil {
-		return nil, err
-	}
-
 	return &tab, nil
 }
 
@@ -1248,17 +1639,60 @@ func (remote *RemoteDebugger) ContinueInterceptedRequest(interceptionID string,
 // fetchRequested event and will be paused until clients response.
 // If not set,all requests will be affected.
 func (remote *RemoteDebugger) EnableRequestPaused(enable bool, patterns ...FetchRequestPattern) error {
+	remote.Lock()
+	remote.fetchEnabled = enable
+	remote.fetchPatterns = patterns
+	remote.Unlock()
+
+	return remote.updateFetch()
+}
+
+// updateFetch enables Fetch with the user patterns (see EnableRequestPaused), the extra patterns
+// and, if the filter list, the request rewrites, the third party blocking or the proxy credentials
+// are active, a catch-all pattern. The requests paused by the internal patterns are handled by requestPaused.
+func (remote *RemoteDebugger) updateFetch(extra ...FetchRequestPattern) error {
+	remote.Lock()
+	fetchEnabled, fetchPatterns := remote.fetchEnabled, remote.fetchPatterns
+	internal := remote.filters != nil || len(remote.rewrites) > 0 || (remote.thirdParties != nil && remote.thirdParties.block) ||
+		remote.proxyAuth != nil
+	remote.Unlock()
+
+	remote.fetchHook(internal || len(extra) > 0)
+
+	if !fetchEnabled && !internal && len(extra) == 0 {
+		return remote.enableFetch(false, nil)
+	}
+
+	patterns := extra
+
+	if internal || (fetchEnabled && len(fetchPatterns) == 0) {
+		patterns = append(patterns, FetchRequestPattern{UrlPattern: "*"})
+	}
+	if fetchEnabled {
+		patterns = append(patterns, fetchPatterns...)
+	}
+
+	return remote.enableFetch(true, patterns)
+}
+
+func (remote *RemoteDebugger) enableFetch(enable bool, patterns []FetchRequestPattern) error {
 	if !enable {
 		_, err := remote.SendRequest("Fetch.disable", nil)
 		return err
 	}
 
-	var params Params
+	params := Params{}
 
 	if len(patterns) > 0 {
-		params = Params{"patterns": patterns}
+		params["patterns"] = patterns
 	}
 
+	remote.Lock()
+	if remote.proxyAuth != nil {
+		params["handleAuthRequests"] = true
+	}
+	remote.Unlock()
+
 	_, err := remote.SendRequest("Fetch.enable", params)
 	return err
 }
@@ -1543,6 +1977,188 @@ func (remote *RemoteDebugger) SetVirtualTimePolicy(policy VirtualTimePolicy, bud
 	return err
 }
 
+// SetDefaultBackgroundColor sets the default background color of the frame (used when the page doesn't set one).
+// Use an alpha of 0 for a transparent background.
+func (remote *RemoteDebugger) SetDefaultBackgroundColor(r, g, b int, a float64) error {
+	_, err := remote.SendRequest("Emulation.setDefaultBackgroundColorOverride", Params{
+		"color": Params{"r": r, "g": g, "b": b, "a": a},
+	})
+	return err
+}
+
+// ClearDefaultBackgroundColor restores the default background color.
+func (remote *RemoteDebugger) ClearDefaultBackgroundColor() error {
+	_, err := remote.SendRequest("Emulation.setDefaultBackgroundColorOverride", nil)
+	return err
+}
+
+// SetEmulatedMedia emulates the given media type (i.e. "print" or "screen", empty to disable)
+// and media features (i.e. "prefers-color-scheme": "dark").
+func (remote *RemoteDebugger) SetEmulatedMedia(media string, features map[string]string) error {
+	params := Params{"media": media}
+
+	if len(features) > 0 {
+		var flist = []map[string]string{}
+
+		for k, v := range features {
+			flist = append(flist, map[string]string{"name": k, "value": v})
+		}
+
+		params["features"] = flist
+	}
+
+	_, err := remote.SendRequest("Emulation.setEmulatedMedia", params)
+	return err
+}
+
+// SetColorScheme emulates the prefers-color-scheme media feature ("light" or "dark", empty to disable).
+func (remote *RemoteDebugger) SetColorScheme(scheme string) error {
+	return remote.SetEmulatedMedia("", map[string]string{"prefers-color-scheme": scheme})
+}
+
+// VisionDeficiency is a vision deficiency simulated by EmulateVisionDeficiency.
+type VisionDeficiency string
+
+const (
+	VisionNone            = VisionDeficiency("none")
+	VisionBlurred         = VisionDeficiency("blurredVision")
+	VisionReducedContrast = VisionDeficiency("reducedContrast")
+	VisionAchromatopsia   = VisionDeficiency("achromatopsia")
+	VisionDeuteranopia    = VisionDeficiency("deuteranopia")
+	VisionProtanopia      = VisionDeficiency("protanopia")
+	VisionTritanopia      = VisionDeficiency("tritanopia")
+)
+
+// VisionDeficiencies are the vision deficiencies that can be simulated (excluding VisionNone),
+// i.e. for an accessibility screenshot matrix.
+var VisionDeficiencies = []VisionDeficiency{
+	VisionBlurred,
+	VisionReducedContrast,
+	VisionAchromatopsia,
+	VisionDeuteranopia,
+	VisionProtanopia,
+	VisionTritanopia,
+}
+
+// EmulateVisionDeficiency simulates a vision deficiency (i.e. color blindness) when rendering the page
+// (VisionNone to disable).
+func (remote *RemoteDebugger) EmulateVisionDeficiency(kind VisionDeficiency) error {
+	_, err := remote.SendRequest("Emulation.setEmulatedVisionDeficiency", Params{
+		"type": kind,
+	})
+	return err
+}
+
+// SetIdleState emulates the user idle state reported by the Idle Detection API
+// (the page still needs the "idle-detection" permission).
+func (remote *RemoteDebugger) SetIdleState(userActive, screenUnlocked bool) error {
+	_, err := remote.SendRequest("Emulation.setIdleOverride", Params{
+		"isUserActive":     userActive,
+		"isScreenUnlocked": screenUnlocked,
+	})
+	return err
+}
+
+// ClearIdleState restores the actual user idle state.
+func (remote *RemoteDebugger) ClearIdleState() error {
+	_, err := remote.SendRequest("Emulation.clearIdleOverride", nil)
+	return err
+}
+
+// ActivateUser gives the page a transient user activation, as if the user clicked on it,
+// so that the activation-gated features (i.e. window.open, fullscreen or clipboard) can be used.
+// It returns the value of navigator.userActivation.isActive, when supported.
+func (remote *RemoteDebugger) ActivateUser() (bool, error) {
+	res, err := remote.Evaluate("navigator.userActivation ? navigator.userActivation.isActive : true", UserGesture(true))
+	if err != nil {
+		return false, err
+	}
+
+	active, _ := res.(bool)
+	return active, nil
+}
+
+// FontFamilies defines the generic font families for SetFontFamilies (empty families are not changed).
+type FontFamilies struct {
+	Standard  string `json:"standard,omitempty"`
+	Fixed     string `json:"fixed,omitempty"`
+	Serif     string `json:"serif,omitempty"`
+	SansSerif string `json:"sansSerif,omitempty"`
+	Cursive   string `json:"cursive,omitempty"`
+	Fantasy   string `json:"fantasy,omitempty"`
+	Math      string `json:"math,omitempty"`
+}
+
+// SetFontFamilies sets the generic font families, so that pages render the same on different hosts.
+func (remote *RemoteDebugger) SetFontFamilies(families FontFamilies) error {
+	_, err := remote.SendRequest("Page.setFontFamilies", Params{
+		"fontFamilies": families,
+	})
+	return err
+}
+
+// SetFontSizes sets the default font sizes.
+func (remote *RemoteDebugger) SetFontSizes(standard, fixed int) error {
+	_, err := remote.SendRequest("Page.setFontSizes", Params{
+		"fontSizes": Params{"standard": standard, "fixed": fixed},
+	})
+	return err
+}
+
+// AddScriptToEvaluateOnNewDocument evaluates the script in every frame upon creation (before loading the frame's scripts).
+// It returns an identifier that can be passed to RemoveScriptToEvaluateOnNewDocument.
+func (remote *RemoteDebugger) AddScriptToEvaluateOnNewDocument(source string) (string, error) {
+	res, err := remote.SendRequest("Page.addScriptToEvaluateOnNewDocument", Params{
+		"source": source,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	return res["identifier"].(string), nil
+}
+
+// RemoveScriptToEvaluateOnNewDocument removes the given script from the list.
+func (remote *RemoteDebugger) RemoveScriptToEvaluateOnNewDocument(identifier string) error {
+	_, err := remote.SendRequest("Page.removeScriptToEvaluateOnNewDocument", Params{
+		"identifier": identifier,
+	})
+	return err
+}
+
+// stableRenderingCSS disables font smoothing, animations and the text caret, that are sources of pixel noise.
+const stableRenderingCSS = `*, *::before, *::after {
+	-webkit-font-smoothing: none !important;
+	text-rendering: geometricPrecision !important;
+	caret-color: transparent !important;
+	animation: none !important;
+	transition: none !important;
+}`
+
+// DisableFontSmoothing injects a stylesheet in the current and in all new documents that disables font antialiasing,
+// animations, transitions and the text caret, reducing pixel noise when comparing screenshots.
+func (remote *RemoteDebugger) DisableFontSmoothing() error {
+	script := fmt.Sprintf(`(function() {
+		function add() {
+			var style = document.createElement("style");
+			style.textContent = %q;
+			document.documentElement.appendChild(style);
+		}
+		if (document.documentElement) add(); else document.addEventListener("DOMContentLoaded", add);
+	})()`, stableRenderingCSS)
+
+	if _, err := remote.AddScriptToEvaluateOnNewDocument(script); err != nil {
+		return err
+	}
+
+	_, err := remote.Evaluate(script)
+	return err
+}
+
 // SendRune sends a character as keyboard input.
 func (remote *RemoteDebugger) SendRune(c rune) error {
 	if _, err := remote.SendRequest("Input.dispatchKeyEvent", Params{
@@ -1679,6 +2295,20 @@ func ThrowOnSideEffect(enable bool) EvaluateOption {
 	}
 }
 
+// AwaitPromise waits for the result of an expression returning a promise.
+func AwaitPromise(enable bool) EvaluateOption {
+	return func(params Params) {
+		params["awaitPromise"] = enable
+	}
+}
+
+// ContextID evaluates the expression in the specified execution context (i.e. a frame or an isolated world).
+func ContextID(id int) EvaluateOption {
+	return func(params Params) {
+		params["contextId"] = id
+	}
+}
+
 // Evaluate evalutes a Javascript function in the context of the current page.
 func (remote *RemoteDebugger) Evaluate(expr string, options ...EvaluateOption) (interface{}, error) {
 	params := Params{
@@ -1732,18 +2362,22 @@ func (remote *RemoteDebugger) SetUserAgent(userAgent string) error {
 	return err
 }
 
+// GetCertificate returns the DER-encoded (base64) certificate chain for the specified origin.
 func (remote *RemoteDebugger) GetCertificate(origin string) ([]string, error) {
 	resp, err := remote.SendRequest("Network.getCertificate", Params{
 		"origin": origin,
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	tableNames := resp["tableNames"].([]interface{})
-	certs := make([]string, len(tableNames))
+	tableNames, _ := resp["tableNames"].([]interface{})
+	certs := make([]string, 0, len(tableNames))
 
 	for _, item := range tableNames {
 		certs = append(certs, item.(string))
 	}
-	return certs, err
+	return certs, nil
 }
 
 func (remote *RemoteDebugger) ClearBrowserCache() error {
@@ -1772,10 +2406,138 @@ func (remote *RemoteDebugger) SetBypassServiceWorker(bypass bool) error {
 	return err
 }
 
+// subscription holds an event callback and its delivery settings.
+type subscription struct {
+	cb       EventCallback
+	evcb     func(ev Event)
+	interval time.Duration
+	last     time.Time
+	disabled bool
+}
+
+// accept returns true if the next event should be delivered to the subscription.
+// Must be called with the RemoteDebugger lock held.
+func (sub *subscription) accept() bool {
+	if sub == nil || sub.disabled {
+		return false
+	}
+
+	if sub.interval > 0 {
+		now := time.Now()
+		if now.Sub(sub.last) < sub.interval {
+			return false
+		}
+
+		sub.last = now
+	}
+
+	return true
+}
+
+// hook is an internal event listener, called before the event callback.
+// A hook returns true if it consumed the event, that should not be delivered to the event callback.
+type hook struct {
+	cb func(params Params) bool
+}
+
+// addHook registers an internal listener for the specified event, independent of CallbackEvent.
+// It returns a function that removes the hook.
+func (remote *RemoteDebugger) addHook(method string, cb func(params Params) bool) (remove func()) {
+	h := &hook{cb: cb}
+
+	remote.Lock()
+	hooks := append([]*hook(nil), remote.hooks[method]...) // dispatch may be iterating the old slice
+	remote.hooks[method] = append(hooks, h)
+	remote.Unlock()
+
+	return func() {
+		remote.Lock()
+		defer remote.Unlock()
+
+		var hooks []*hook
+		for _, o := range remote.hooks[method] {
+			if o != h {
+				hooks = append(hooks, o)
+			}
+		}
+
+		if len(hooks) == 0 {
+			delete(remote.hooks, method)
+		} else {
+			remote.hooks[method] = hooks
+		}
+	}
+}
+
+// CallbackOption defines the functional option for CallbackEvent
+type CallbackOption func(sub *subscription)
+
+// RateLimit delivers at most one event every interval, dropping the others before they are decoded.
+// This is useful for high-frequency events like Network.dataReceived.
+func RateLimit(interval time.Duration) CallbackOption {
+	return func(sub *subscription) {
+		sub.interval = interval
+	}
+}
+
+// CallbackDisabled registers the callback in a disabled state (see EnableCallback).
+func CallbackDisabled() CallbackOption {
+	return func(sub *subscription) {
+		sub.disabled = true
+	}
+}
+
 // CallbackEvent sets a callback for the specified event.
-func (remote *RemoteDebugger) CallbackEvent(method string, cb EventCallback) {
+//
+// Events are only decoded and queued if there is an enabled callback for their method,
+// so subscribing to a domain doesn't cost anything for the events nobody is listening to.
+func (remote *RemoteDebugger) CallbackEvent(method string, cb EventCallback, options ...CallbackOption) {
+	sub := &subscription{cb: cb}
+
+	for _, opt := range options {
+		opt(sub)
+	}
+
 	remote.Lock()
-	remote.callbacks[method] = cb
+	remote.callbacks[method] = sub
+	remote.Unlock()
+}
+
+// Event is an event delivered by CallbackEventInfo.
+type Event struct {
+	// Seq is the sequence number of the event, increasing for each event received on the connection
+	// (including the events not delivered to any callback), so that the ordering can be reconstructed
+	// after the events are buffered or processed in parallel.
+	Seq uint64
+
+	// Received is the time the event was read from the connection.
+	Received time.Time
+
+	Method string
+	Params Params
+}
+
+// CallbackEventInfo sets a callback for the specified event, that receives the event sequence number
+// and receive time with the params. It replaces the callback set by CallbackEvent for the same method.
+func (remote *RemoteDebugger) CallbackEventInfo(method string, cb func(ev Event), options ...CallbackOption) {
+	sub := &subscription{evcb: cb}
+
+	for _, opt := range options {
+		opt(sub)
+	}
+
+	remote.Lock()
+	remote.callbacks[method] = sub
+	remote.Unlock()
+}
+
+// EnableCallback enables or disables delivery of the events for the specified method,
+// without removing the registered callback.
+func (remote *RemoteDebugger) EnableCallback(method string, enable bool) {
+	remote.Lock()
+	if sub := remote.callbacks[method]; sub != nil {
+		sub.disabled = !enable
+	}
 	remote.Unlock()
 }
 
@@ -1851,20 +2613,42 @@ func (remote *RemoteDebugger) CloseBrowser() {
 
 // DomainEvents enables event listening in the specified domain.
 func (remote *RemoteDebugger) DomainEvents(domain string, enable bool) error {
+	return remote.domainEvents(domain, enable, nil)
+}
+
+// domainEvents enables or disables event listening in the specified domain.
+// The enable params are remembered, so that the domain can be re-enabled with the same options when switching tabs.
+func (remote *RemoteDebugger) domainEvents(domain string, enable bool, params Params) error {
 	method := domain
 
+	remote.Lock()
 	if enable {
-		remote.domains[method] = true
+		remote.domains[method] = params
 		method += ".enable"
 	} else {
 		delete(remote.domains, method)
 		method += ".disable"
+		params = nil
 	}
+	remote.Unlock()
 
-	_, err := remote.SendRequest(method, nil)
+	_, err := remote.SendRequest(method, params)
 	return err
 }
 
+// ensureDomain enables event listening in the specified domain, if not already enabled.
+func (remote *RemoteDebugger) ensureDomain(domain string) error {
+	remote.Lock()
+	_, ok := remote.domains[domain]
+	remote.Unlock()
+
+	if ok {
+		return nil
+	}
+
+	return remote.DomainEvents(domain, true)
+}
+
 // AllEvents enables event listening for all domains.
 func (remote *RemoteDebugger) AllEvents(enable bool) error {
 	domains, err := remote.GetDomains()
@@ -1891,9 +2675,46 @@ func (remote *RemoteDebugger) PageEvents(enable bool) error {
 	return remote.DomainEvents("Page", enable)
 }
 
+// NetworkOption defines the functional option for NetworkEvents
+type NetworkOption func(p Params)
+
+// MaxTotalBufferSize sets the buffer size in bytes to use when preserving network payloads (XHRs, etc).
+func MaxTotalBufferSize(size int) NetworkOption {
+	return func(p Params) {
+		p["maxTotalBufferSize"] = size
+	}
+}
+
+// MaxResourceBufferSize sets the per-resource buffer size in bytes to use when preserving network payloads (XHRs, etc).
+func MaxResourceBufferSize(size int) NetworkOption {
+	return func(p Params) {
+		p["maxResourceBufferSize"] = size
+	}
+}
+
+// MaxPostDataSize sets the longest post body size (in bytes) that would be included in requestWillBeSent notification.
+func MaxPostDataSize(size int) NetworkOption {
+	return func(p Params) {
+		p["maxPostDataSize"] = size
+	}
+}
+
 // NetworkEvents enables Network events listening.
-func (remote *RemoteDebugger) NetworkEvents(enable bool) error {
-	return remote.DomainEvents("Network", enable)
+//
+// When enabling, options can be used to change the buffer sizes used by the browser,
+// so that post data and large responses are not truncated.
+func (remote *RemoteDebugger) NetworkEvents(enable bool, options ...NetworkOption) error {
+	var params Params
+
+	if enable && len(options) > 0 {
+		params = Params{}
+
+		for _, opt := range options {
+			opt(params)
+		}
+	}
+
+	return remote.domainEvents("Network", enable, params)
 }
 
 // TargetEvents enables Target events listening.
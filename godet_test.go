@@ -0,0 +1,102 @@
+package godet
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestConnectTabSettings(t *testing.T) {
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		c.CloseRead(ctx)
+		<-ctx.Done()
+	})
+
+	limiter := NewOriginLimiter(1, 1)
+
+	remote := connectFake(t, tab,
+		NavigationLimit(limiter),
+		ProtocolLog(io.Discard),
+		Retry(RetryPolicy{MaxAttempts: 3}),
+		Heartbeat(time.Minute, time.Second))
+	defer remote.Close()
+
+	remote.SetTag("step-1")
+
+	conn, err := remote.connectTab(&Tab{ID: "other", WsURL: tab.WsURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if conn.limiter != limiter {
+		t.Error("navigation limiter not copied")
+	}
+	if conn.protoLog == nil || conn.protoLog != remote.protoLog {
+		t.Error("protocol log not shared")
+	}
+	if conn.tag != "step-1" {
+		t.Errorf("tag = %q, want %q", conn.tag, "step-1")
+	}
+	if conn.retry != remote.retry || conn.heartbeat != time.Minute || conn.heartbeatTimeout != time.Second {
+		t.Error("retry policy or heartbeat not copied")
+	}
+}
+
+func TestEnsureDomain(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		mu.Lock()
+		calls[method]++
+		mu.Unlock()
+		return nil, nil
+	}))
+	defer remote.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := remote.ensureDomain("Page"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := remote.ensureDomain("Page"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	enabled := calls["Page.enable"]
+	mu.Unlock()
+
+	if enabled < 1 || enabled > 4 {
+		t.Errorf("Page.enable sent %d times", enabled)
+	}
+
+	if err := remote.PageEvents(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.ensureDomain("Page"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls["Page.enable"] != enabled+1 {
+		t.Error("disabled domain not enabled again")
+	}
+}
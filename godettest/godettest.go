@@ -0,0 +1,214 @@
+// Package godettest provides the fixtures to write browser tests with go test.
+//
+// A browser is launched (once per test binary) the first time it's needed, and each test gets
+// a page in its own isolated browser context. When a test fails a screenshot and the console log
+// are saved in ArtifactsDir. Everything is cleaned up when the test completes:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(godettest.Run(m))
+//	}
+//
+//	func TestHome(t *testing.T) {
+//		page := godettest.NewPage(t)
+//		if err := page.Goto("https://example.com"); err != nil {
+//			t.Fatal(err)
+//		}
+//		...
+//	}
+package godettest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+var (
+	// LaunchOptions are the options used to launch the shared browser (headless by default).
+	LaunchOptions []godet.LaunchOption
+
+	// Port, if set, is the address of an already running browser to use instead of launching one.
+	// It defaults to the GODET_PORT (or GODET_BROWSER_URL) environment variable.
+	Port = browserPort()
+
+	// ArtifactsDir is the directory where the artifacts of the failed tests are saved, in a subdirectory per test.
+	ArtifactsDir = "godet-artifacts"
+
+	// Verbose enables the verbose logging of the connections.
+	Verbose bool
+)
+
+// browserPort returns the browser address from the environment.
+func browserPort() string {
+	if port := os.Getenv("GODET_PORT"); port != "" {
+		return port
+	}
+
+	if u, err := url.Parse(os.Getenv("GODET_BROWSER_URL")); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	return os.Getenv("GODET_BROWSER_URL")
+}
+
+var shared struct {
+	sync.Mutex
+	browser *godet.Browser
+	remote  *godet.RemoteDebugger
+	err     error
+	started bool
+}
+
+// connect returns the connection to the shared browser, launching it if needed.
+func connect() (*godet.RemoteDebugger, error) {
+	shared.Lock()
+	defer shared.Unlock()
+
+	if shared.started {
+		return shared.remote, shared.err
+	}
+
+	shared.started = true
+
+	port := Port
+	if port == "" {
+		shared.browser, shared.err = godet.Launch(LaunchOptions...)
+		if shared.err != nil {
+			return nil, shared.err
+		}
+
+		port = shared.browser.Port
+	}
+
+	shared.remote, shared.err = godet.Connect(port, Verbose)
+	return shared.remote, shared.err
+}
+
+// Close closes the shared browser, if launched. It's called by Run.
+func Close() {
+	shared.Lock()
+	defer shared.Unlock()
+
+	if shared.remote != nil {
+		shared.remote.Close()
+	}
+	if shared.browser != nil {
+		shared.browser.Close()
+	}
+
+	shared.browser, shared.remote, shared.err, shared.started = nil, nil, nil, false
+}
+
+// Run runs the tests and closes the shared browser, returning the exit code for os.Exit.
+// Call it from TestMain.
+func Run(m *testing.M) int {
+	defer Close()
+	return m.Run()
+}
+
+// NewPage returns a page in a new isolated browser context for the test.
+//
+// The test is skipped if no browser is available, and fails if the browser cannot be started.
+// The browser context is closed when the test completes, after saving a screenshot and the console log
+// in ArtifactsDir if the test failed.
+func NewPage(t testing.TB) *godet.Page {
+	t.Helper()
+
+	root, err := connect()
+	if err == godet.ErrorNoBrowser {
+		t.Skip("godettest:", err)
+	}
+	if err != nil {
+		t.Fatal("godettest: cannot start browser:", err)
+	}
+
+	bc, err := root.NewBrowserContext()
+	if err != nil {
+		t.Fatal("godettest: cannot create browser context:", err)
+	}
+
+	remote, err := bc.NewTab("")
+	if err != nil {
+		bc.Close()
+		t.Fatal("godettest: cannot create tab:", err)
+	}
+
+	console := &consoleLog{}
+	remote.CallbackEvent("Runtime.consoleAPICalled", console.consoleAPICalled)
+	remote.CallbackEvent("Runtime.exceptionThrown", console.exceptionThrown)
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			saveArtifacts(t, remote, console)
+		}
+
+		remote.Close()
+		bc.Close()
+	})
+
+	if err := remote.RuntimeEvents(true); err != nil {
+		t.Fatal("godettest: cannot enable runtime events:", err)
+	}
+
+	return godet.NewPage(remote)
+}
+
+// consoleLog collects the console messages and exceptions.
+type consoleLog struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *consoleLog) add(format string, args ...interface{}) {
+	line := time.Now().Format("15:04:05.000 ") + fmt.Sprintf(format, args...)
+
+	l.Lock()
+	l.lines = append(l.lines, line)
+	l.Unlock()
+}
+
+func (l *consoleLog) consoleAPICalled(params godet.Params) {
+	l.add("%v", godet.ConsoleMessage(params))
+}
+
+func (l *consoleLog) exceptionThrown(params godet.Params) {
+	l.add("%v", godet.ExceptionMessage(params))
+}
+
+func (l *consoleLog) String() string {
+	l.Lock()
+	defer l.Unlock()
+
+	return strings.Join(l.lines, "\n") + "\n"
+}
+
+var unsafeChars = regexp.MustCompile(`[^\w.-]+`)
+
+// saveArtifacts saves the screenshot and console log of a failed test.
+func saveArtifacts(t testing.TB, remote *godet.RemoteDebugger, console *consoleLog) {
+	dir := filepath.Join(ArtifactsDir, unsafeChars.ReplaceAllString(t.Name(), "_"))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Log("godettest: cannot save artifacts:", err)
+		return
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "console.log"), []byte(console.String()), 0644); err != nil {
+		t.Log("godettest: cannot save console log:", err)
+	}
+
+	if err := remote.SaveScreenshot(filepath.Join(dir, "screenshot.png"), 0644, 0, true); err != nil {
+		t.Log("godettest: cannot save screenshot:", err)
+	}
+
+	t.Log("godettest: artifacts saved in", dir)
+}
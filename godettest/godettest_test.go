@@ -0,0 +1,66 @@
+package godettest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raff/godet"
+)
+
+func TestBrowserPort(t *testing.T) {
+	tests := []struct {
+		port, browserURL string
+		want             string
+	}{
+		{"", "", ""},
+		{"localhost:9333", "http://other:9222", "localhost:9333"},
+		{"", "http://chrome:9222/json", "chrome:9222"},
+		{"", "chrome:9222", "chrome:9222"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("GODET_PORT", tt.port)
+		t.Setenv("GODET_BROWSER_URL", tt.browserURL)
+
+		if got := browserPort(); got != tt.want {
+			t.Errorf("browserPort with GODET_PORT=%q GODET_BROWSER_URL=%q = %q, want %q", tt.port, tt.browserURL, got, tt.want)
+		}
+	}
+}
+
+func TestConsoleLog(t *testing.T) {
+	var l consoleLog
+
+	l.consoleAPICalled(godet.Params{
+		"type": "log",
+		"args": []interface{}{
+			map[string]interface{}{"type": "string", "value": "count"},
+			map[string]interface{}{"type": "number", "value": 42.0},
+			map[string]interface{}{"type": "object", "description": "Window"},
+		},
+	})
+	l.exceptionThrown(godet.Params{
+		"exceptionDetails": map[string]interface{}{
+			"text":      "Uncaught",
+			"exception": map[string]interface{}{"description": "TypeError: x is undefined"},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSuffix(l.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d lines, want 2:\n%s", len(lines), l.String())
+	}
+
+	for i, want := range []string{"console.log count 42 Window", "exception Uncaught TypeError: x is undefined"} {
+		// the lines start with the time (15:04:05.000)
+		if got := lines[i][13:]; got != want {
+			t.Errorf("line %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestUnsafeChars(t *testing.T) {
+	if got := unsafeChars.ReplaceAllString("TestHome/mobile view #1", "_"); got != "TestHome_mobile_view_1" {
+		t.Errorf("artifacts dir = %q", got)
+	}
+}
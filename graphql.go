@@ -0,0 +1,257 @@
+package godet
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// GraphQLError is an error returned in a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLOperation is a GraphQL request observed on the network, with its result (see OnGraphQL).
+type GraphQLOperation struct {
+	RequestID string
+	URL       string
+
+	OperationName string
+	OperationType string // query, mutation or subscription
+	Query         string // empty for persisted queries
+	Variables     map[string]interface{}
+
+	// Persisted is true for persisted queries (sent as a hash instead of the query).
+	Persisted bool
+
+	Status int
+	Data   json.RawMessage
+	Errors []GraphQLError
+
+	// Failed is true if the request failed, with the reason in ErrorText.
+	Failed    bool
+	ErrorText string
+}
+
+// GraphQLCallback is called for each GraphQL operation, once the response is received.
+type GraphQLCallback func(op *GraphQLOperation)
+
+// graphqlRequest is the standard GraphQL over HTTP request body.
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
+
+var graphqlOperationType = regexp.MustCompile(`^\s*(query|mutation|subscription)\b`)
+
+func (req *graphqlRequest) operation(requestID, reqURL string) *GraphQLOperation {
+	op := &GraphQLOperation{
+		RequestID:     requestID,
+		URL:           reqURL,
+		OperationName: req.OperationName,
+		OperationType: "query", // the shorthand form { ... } is a query
+		Query:         req.Query,
+		Variables:     req.Variables,
+	}
+
+	if m := graphqlOperationType.FindStringSubmatch(req.Query); m != nil {
+		op.OperationType = m[1]
+	}
+
+	if _, ok := req.Extensions["persistedQuery"]; ok {
+		op.Persisted = true
+	}
+
+	return op
+}
+
+// parseGraphQLRequest returns the GraphQL operations in the request, or nil if it's not a GraphQL request.
+// Batched requests (a JSON array of operations) return multiple operations.
+func parseGraphQLRequest(requestID, method, reqURL, contentType, postData string) []*GraphQLOperation {
+	if method == "GET" {
+		u, err := url.Parse(reqURL)
+		if err != nil {
+			return nil
+		}
+
+		q := u.Query()
+		if q.Get("query") == "" && q.Get("extensions") == "" {
+			return nil
+		}
+
+		req := graphqlRequest{Query: q.Get("query"), OperationName: q.Get("operationName")}
+		json.Unmarshal([]byte(q.Get("variables")), &req.Variables)
+		json.Unmarshal([]byte(q.Get("extensions")), &req.Extensions)
+
+		if req.Query == "" && req.Extensions["persistedQuery"] == nil {
+			return nil
+		}
+
+		return []*GraphQLOperation{req.operation(requestID, reqURL)}
+	}
+
+	if strings.HasPrefix(contentType, "application/graphql") {
+		req := graphqlRequest{Query: postData}
+		return []*GraphQLOperation{req.operation(requestID, reqURL)}
+	}
+
+	if !strings.Contains(contentType, "json") {
+		return nil
+	}
+
+	var batch []graphqlRequest
+
+	if strings.HasPrefix(strings.TrimSpace(postData), "[") {
+		if err := json.Unmarshal([]byte(postData), &batch); err != nil {
+			return nil
+		}
+	} else {
+		var req graphqlRequest
+		if err := json.Unmarshal([]byte(postData), &req); err != nil {
+			return nil
+		}
+
+		batch = append(batch, req)
+	}
+
+	var ops []*GraphQLOperation
+
+	for i := range batch {
+		if batch[i].Query == "" && batch[i].Extensions["persistedQuery"] == nil {
+			return nil // not GraphQL
+		}
+
+		ops = append(ops, batch[i].operation(requestID, reqURL))
+	}
+
+	return ops
+}
+
+// graphqlResponse is the standard GraphQL over HTTP response body.
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// OnGraphQL calls cb for every GraphQL operation sent by the page, recognized by the shape of the request
+// (a "query" or persisted query, in a JSON body, an application/graphql body or GET parameters).
+// The operations include the response data and errors.
+//
+// Passing a nil callback stops the notifications. Network events are enabled, if needed.
+func (remote *RemoteDebugger) OnGraphQL(cb GraphQLCallback) error {
+	remote.Lock()
+	stop := remote.stopGraphQL
+	remote.stopGraphQL = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	var lock sync.Mutex
+	pending := map[string][]*GraphQLOperation{}
+
+	complete := func(params Params) []*GraphQLOperation {
+		id := params.String("requestId")
+
+		lock.Lock()
+		ops := pending[id]
+		delete(pending, id)
+		lock.Unlock()
+
+		return ops
+	}
+
+	removeHooks := []func(){
+		remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+			req := Params(params.Map("request"))
+			method := req.String("method")
+			if method != "GET" && method != "POST" {
+				return false
+			}
+
+			contentType, _ := responseHeader(headerMap(req.Map("headers")), "Content-Type")
+
+			postData := ""
+			if method == "POST" {
+				var err error
+				if postData, err = remote.RequestPostData(params); err != nil {
+					return false
+				}
+			}
+
+			id := params.String("requestId")
+			if ops := parseGraphQLRequest(id, method, req.String("url"), contentType, postData); ops != nil {
+				lock.Lock()
+				pending[id] = ops
+				lock.Unlock()
+			}
+			return false
+		}),
+		remote.addHook("Network.responseReceived", func(params Params) bool {
+			id := params.String("requestId")
+
+			lock.Lock()
+			for _, op := range pending[id] {
+				op.Status = Params(params.Map("response")).Int("status")
+			}
+			lock.Unlock()
+			return false
+		}),
+		remote.addHook("Network.loadingFinished", func(params Params) bool {
+			ops := complete(params)
+			if ops == nil {
+				return false
+			}
+
+			if body, err := remote.GetResponseBody(params.String("requestId")); err == nil {
+				var results []graphqlResponse
+
+				if len(ops) > 1 {
+					json.Unmarshal(body, &results)
+				} else {
+					results = make([]graphqlResponse, 1)
+					json.Unmarshal(body, &results[0])
+				}
+
+				for i, res := range results {
+					if i < len(ops) {
+						ops[i].Data, ops[i].Errors = res.Data, res.Errors
+					}
+				}
+			}
+
+			for _, op := range ops {
+				cb(op)
+			}
+			return false
+		}),
+		remote.addHook("Network.loadingFailed", func(params Params) bool {
+			for _, op := range complete(params) {
+				op.Failed, op.ErrorText = true, params.String("errorText")
+				cb(op)
+			}
+			return false
+		}),
+	}
+
+	remote.Lock()
+	remote.stopGraphQL = func() {
+		for _, remove := range removeHooks {
+			remove()
+		}
+	}
+	remote.Unlock()
+
+	return remote.ensureDomain("Network")
+}
@@ -0,0 +1,191 @@
+package godet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gobs/httpclient"
+)
+
+// GridSession is a WebDriver session on a Selenium Grid, controlled via its CDP endpoint.
+type GridSession struct {
+	*RemoteDebugger
+
+	GridURL      string
+	SessionID    string
+	Capabilities map[string]interface{}
+}
+
+// gridRequest sends a WebDriver command to the grid and decodes the "value" of the reply.
+func gridRequest(method, url string, body interface{}, value interface{}) error {
+	var data []byte
+
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var reply struct {
+		Value json.RawMessage `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return fmt.Errorf("%v: %v", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if resp.StatusCode >= 400 {
+		var werr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+
+		json.Unmarshal(reply.Value, &werr)
+		return fmt.Errorf("%v: %v", werr.Error, werr.Message)
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	return json.Unmarshal(reply.Value, value)
+}
+
+// ConnectGrid starts a WebDriver session on a Selenium Grid (i.e. "http://grid:4444"), with the requested
+// capabilities (default: {"browserName": "chrome"}), and returns a RemoteDebugger connected to its
+// CDP endpoint (the "se:cdp" capability), attached to the session page.
+//
+// Call Quit to end the session, since closing the connection leaves it open on the grid.
+func ConnectGrid(gridURL string, capabilities map[string]interface{}, verbose bool, options ...ConnectOption) (*GridSession, error) {
+	gridURL = strings.TrimSuffix(gridURL, "/")
+
+	if capabilities == nil {
+		capabilities = map[string]interface{}{"browserName": "chrome"}
+	}
+
+	var session struct {
+		SessionID    string                 `json:"sessionId"`
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+
+	if err := gridRequest("POST", gridURL+"/session", map[string]interface{}{
+		"capabilities": map[string]interface{}{"alwaysMatch": capabilities},
+	}, &session); err != nil {
+		return nil, err
+	}
+
+	gs := &GridSession{GridURL: gridURL, SessionID: session.SessionID, Capabilities: session.Capabilities}
+
+	cdp, _ := session.Capabilities["se:cdp"].(string)
+	if cdp == "" {
+		gs.Quit()
+		return nil, fmt.Errorf("the grid doesn't expose a CDP endpoint (se:cdp) for %v", session.Capabilities["browserName"])
+	}
+
+	remote := newRemoteDebugger(httpclient.NewHttpClient(gridURL), verbose)
+
+	for _, setOption := range options {
+		setOption(remote)
+	}
+
+	if err := remote.connectWs(&Tab{WsURL: cdp}); err != nil {
+		gs.Quit()
+		return nil, err
+	}
+
+	remote.start()
+	gs.RemoteDebugger = remote
+
+	if err := remote.attachPage(); err != nil {
+		gs.Quit()
+		return nil, err
+	}
+
+	return gs, nil
+}
+
+// attachPage attaches a browser endpoint connection to the first page (using the flat protocol sessions),
+// so that the following commands are sent to the page.
+func (remote *RemoteDebugger) attachPage() error {
+	rawReply, err := remote.sendRawReplyRequest("Target.getTargets", nil)
+	if err != nil {
+		return err
+	}
+
+	var targets struct {
+		TargetInfos []struct {
+			TargetID string `json:"targetId"`
+			Type     string `json:"type"`
+		} `json:"targetInfos"`
+	}
+
+	if err := json.Unmarshal(rawReply, &targets); err != nil {
+		return err
+	}
+
+	var targetID string
+
+	for _, t := range targets.TargetInfos {
+		if t.Type == "page" {
+			targetID = t.TargetID
+			break
+		}
+	}
+
+	if targetID == "" {
+		res, err := remote.SendRequest("Target.createTarget", Params{"url": "about:blank"})
+		if err != nil {
+			return err
+		}
+
+		targetID = Params(res).String("targetId")
+	}
+
+	res, err := remote.SendRequest("Target.attachToTarget", Params{
+		"targetId": targetID,
+		"flatten":  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	remote.Lock()
+	remote.current = targetID
+	remote.session = Params(res).String("sessionId")
+	remote.Unlock()
+
+	return nil
+}
+
+// Quit ends the WebDriver session (closing the browser) and the CDP connection.
+func (gs *GridSession) Quit() error {
+	if gs.RemoteDebugger != nil {
+		gs.RemoteDebugger.Close()
+	}
+
+	return gridRequest("DELETE", gs.GridURL+"/session/"+gs.SessionID, nil, nil)
+}
@@ -0,0 +1,130 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestConnectGrid(t *testing.T) {
+	var lock sync.Mutex
+	var commands, requests []string
+
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		for {
+			var cmd struct {
+				ID        int    `json:"id"`
+				Method    string `json:"method"`
+				SessionID string `json:"sessionId"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			lock.Lock()
+			commands = append(commands, cmd.Method+" "+cmd.SessionID)
+			lock.Unlock()
+
+			result := Params{}
+
+			switch cmd.Method {
+			case "Target.getTargets":
+				result["targetInfos"] = []Params{{"targetId": "sw", "type": "service_worker"}, {"targetId": "page1", "type": "page"}}
+			case "Target.attachToTarget":
+				result["sessionId"] = "cdp-session"
+			}
+
+			if err := wsjson.Write(ctx, c, Params{"id": cmd.ID, "result": result}); err != nil {
+				return
+			}
+		}
+	})
+
+	cdp := tab.WsURL
+
+	grid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/session":
+			caps := Params(Params(body).Map("capabilities")).Map("alwaysMatch")
+
+			switch caps["browserName"] {
+			case "chrome":
+				json.NewEncoder(w).Encode(Params{"value": Params{
+					"sessionId":    "s1",
+					"capabilities": Params{"browserName": "chrome", "se:cdp": cdp},
+				}})
+
+			case "firefox":
+				json.NewEncoder(w).Encode(Params{"value": Params{
+					"sessionId":    "s2",
+					"capabilities": Params{"browserName": "firefox"},
+				}})
+
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(Params{"value": Params{"error": "session not created", "message": "no matching node"}})
+			}
+
+		case r.Method == "DELETE":
+			json.NewEncoder(w).Encode(Params{"value": nil})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer grid.Close()
+
+	gs, err := ConnectGrid(grid.URL+"/", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gs.SessionID != "s1" || gs.current != "page1" || gs.session != "cdp-session" {
+		t.Errorf("session = %v, target = %v, CDP session = %v", gs.SessionID, gs.current, gs.session)
+	}
+
+	if _, err := gs.SendRequest("Page.enable", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gs.Quit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ConnectGrid(grid.URL, map[string]interface{}{"browserName": "firefox"}, false); err == nil || !strings.Contains(err.Error(), "se:cdp") {
+		t.Errorf("firefox error = %v", err)
+	}
+
+	if _, err := ConnectGrid(grid.URL, map[string]interface{}{"browserName": "safari"}, false); err == nil || err.Error() != "session not created: no matching node" {
+		t.Errorf("safari error = %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	wantCommands := []string{"Target.getTargets ", "Target.attachToTarget ", "Page.enable cdp-session"}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("commands = %q, want %q", commands, wantCommands)
+	}
+
+	wantRequests := []string{"POST /session", "DELETE /session/s1", "POST /session", "DELETE /session/s2", "POST /session"}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %q, want %q", requests, wantRequests)
+	}
+}
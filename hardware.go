@@ -0,0 +1,119 @@
+package godet
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// SetHardwareConcurrency overrides the number of logical processors reported by navigator.hardwareConcurrency.
+// The override can't be cleared: set it to the actual value (i.e. runtime.NumCPU() for a local browser) to restore it.
+func (remote *RemoteDebugger) SetHardwareConcurrency(n int) error {
+	_, err := remote.SendRequest("Emulation.setHardwareConcurrencyOverride", Params{
+		"hardwareConcurrency": n,
+	})
+	return err
+}
+
+// BatteryStatus is the battery state reported by the Battery Status API (see SetBatteryStatus).
+type BatteryStatus struct {
+	Charging bool
+
+	// ChargingTime and DischargingTime are in seconds (math.Inf(1) if unknown or not applicable,
+	// as for the discharging time of a charging battery).
+	ChargingTime    float64
+	DischargingTime float64
+
+	// Level is the charge level, between 0 and 1.
+	Level float64
+}
+
+// FullBattery is the status of a fully charged battery, plugged in.
+var FullBattery = BatteryStatus{Charging: true, ChargingTime: 0, DischargingTime: math.Inf(1), Level: 1}
+
+// LowBattery is the status of a battery almost empty, not charging.
+var LowBattery = BatteryStatus{Charging: false, ChargingTime: math.Inf(1), DischargingTime: 600, Level: 0.05}
+
+// jsNumber returns the JavaScript literal for the number (including Infinity).
+func jsNumber(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// batteryJS replaces navigator.getBattery with a stub reporting the status passed as argument.
+// Evaluating it again updates the status, firing the change events for the changed values.
+const batteryJS = `(function(status) {
+	var stub = window.__godetBattery;
+	if (!stub) {
+		var state = {};
+		var battery = new EventTarget();
+		["charging", "chargingTime", "dischargingTime", "level"].forEach(function(k) {
+			Object.defineProperty(battery, k, {get: function() { return state[k]; }, enumerable: true});
+			battery["on" + k.toLowerCase() + "change"] = null;
+		});
+		stub = {
+			set: function(status) {
+				Object.keys(status).forEach(function(k) {
+					if (state[k] === status[k]) return;
+					state[k] = status[k];
+					var type = k.toLowerCase() + "change";
+					var ev = new Event(type);
+					battery.dispatchEvent(ev);
+					if (typeof battery["on" + type] === "function") battery["on" + type](ev);
+				});
+			}
+		};
+		Object.defineProperty(window, "__godetBattery", {value: stub});
+		navigator.getBattery = function() { return Promise.resolve(battery); };
+	}
+	stub.set(status);
+})`
+
+// SetBatteryStatus replaces the Battery Status API (navigator.getBattery) in the current and in all new documents
+// with a stub reporting the status. Calling it again updates the status, firing the change events
+// (i.e. "levelchange") in the current page, so that adaptive code paths can be exercised deterministically.
+//
+// Passing nil stops installing the stub in the new documents (the current page keeps it until reloaded).
+func (remote *RemoteDebugger) SetBatteryStatus(status *BatteryStatus) error {
+	remote.Lock()
+	script := remote.battery
+	remote.battery = ""
+	remote.Unlock()
+
+	if script != "" {
+		if err := remote.RemoveScriptToEvaluateOnNewDocument(script); err != nil {
+			return err
+		}
+	}
+
+	if status == nil {
+		return nil
+	}
+
+	source := fmt.Sprintf("%s({charging: %v, chargingTime: %s, dischargingTime: %s, level: %s})",
+		batteryJS,
+		status.Charging,
+		jsNumber(status.ChargingTime),
+		jsNumber(status.DischargingTime),
+		jsNumber(status.Level))
+
+	script, err := remote.AddScriptToEvaluateOnNewDocument(source)
+	if err != nil {
+		return err
+	}
+
+	remote.Lock()
+	remote.battery = script
+	remote.Unlock()
+
+	_, err = remote.Evaluate(source)
+	return err
+}
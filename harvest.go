@@ -0,0 +1,152 @@
+package godet
+
+import (
+	"strings"
+	"time"
+)
+
+// IdlePolicy defines how long to wait for new content after each scroll (see HarvestScrollingPage).
+type IdlePolicy struct {
+	// Delay is a fixed wait after each scroll.
+	Delay time.Duration
+
+	// NetworkIdle, if set, waits for the network to be idle for this duration after each scroll,
+	// up to Timeout.
+	NetworkIdle time.Duration
+	Timeout     time.Duration
+}
+
+// HarvestResult is the content collected by HarvestScrollingPage.
+type HarvestResult struct {
+	// HTML is the document (outerHTML) after the last scroll.
+	HTML string
+
+	// Lines are the (unique, non-empty) lines of text seen while scrolling, in order of appearance.
+	// This includes content that virtualized lists removed from the document while scrolling.
+	Lines []string
+
+	// Scrolls is the number of scrolls performed.
+	Scrolls int
+
+	// Complete is true if the end of the page was reached (i.e. the page stopped growing).
+	Complete bool
+}
+
+// Text returns the collected text.
+func (r *HarvestResult) Text() string {
+	return strings.Join(r.Lines, "\n")
+}
+
+// HarvestScrollingPage repeatedly scrolls down an "infinite scroll" page, waiting for new content
+// according to the idle policy, and returns the accumulated text and the final document.
+//
+// It stops after maxScrolls scrolls or when the page stops growing. If maxScrolls is 0 and the page is still
+// growing after 1000 scrolls, the result so far is returned with ErrorScrollLimit.
+func (remote *RemoteDebugger) HarvestScrollingPage(maxScrolls int, policy IdlePolicy) (*HarvestResult, error) {
+	wait := func() error {
+		time.Sleep(policy.Delay)
+		return nil
+	}
+
+	if policy.NetworkIdle > 0 {
+		na, stop, err := remote.trackNetwork()
+		if err != nil {
+			return nil, err
+		}
+
+		defer stop()
+
+		wait = func() error {
+			time.Sleep(policy.Delay)
+
+			if err := na.waitIdle(policy.NetworkIdle, policy.Timeout); err != nil && err != ErrorTimeout {
+				return err
+			}
+
+			return nil
+		}
+	}
+
+	result := &HarvestResult{}
+	seen := map[string]bool{}
+
+	collect := func() (bool, error) {
+		res, err := remote.Evaluate("document.body ? document.body.innerText : ''")
+		if err != nil {
+			return false, err
+		}
+
+		text, _ := res.(string)
+		added := false
+
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || seen[line] {
+				continue
+			}
+
+			seen[line] = true
+			result.Lines = append(result.Lines, line)
+			added = true
+		}
+
+		return added, nil
+	}
+
+	if _, err := collect(); err != nil {
+		return nil, err
+	}
+
+	limit := maxScrolls
+	if limit <= 0 {
+		limit = maxScrollSteps
+	}
+
+	pos, err := remote.GetScrollPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	for result.Scrolls < limit {
+		if err := remote.ScrollBy(0, pos.ViewportHeight); err != nil {
+			return nil, err
+		}
+
+		result.Scrolls++
+
+		if err := wait(); err != nil {
+			return nil, err
+		}
+
+		added, err := collect()
+		if err != nil {
+			return nil, err
+		}
+
+		next, err := remote.GetScrollPosition()
+		if err != nil {
+			return nil, err
+		}
+
+		// the position didn't change: either the bottom was reached or the page doesn't scroll
+		if next.Y == pos.Y && next.Height <= pos.Height && !added {
+			result.Complete = pos.AtBottom()
+			break
+		}
+
+		pos = next
+	}
+
+	res, err := remote.Evaluate("document.documentElement.outerHTML")
+	if err != nil {
+		return nil, err
+	}
+
+	result.HTML, _ = res.(string)
+
+	if maxScrolls <= 0 && !result.Complete && result.Scrolls >= limit {
+		return result, ErrorScrollLimit
+	}
+
+	return result, nil
+}
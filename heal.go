@@ -0,0 +1,262 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// DefaultHealScore is the suggested minimum score of the candidate used by HealSelectors.
+var DefaultHealScore = 0.6
+
+// MaxSelectorCandidates is the maximum number of candidates returned by SuggestSelectors.
+var MaxSelectorCandidates = 5
+
+// SelectorCandidate is an element the failed selector was likely meant to match.
+type SelectorCandidate struct {
+	Selector    string  `json:"selector"`
+	Description string  `json:"description"` // tag#id.class
+	Reason      string  `json:"reason"`      // the best matching property (i.e. "id", "text", "aria-label")
+	Score       float64 `json:"score"`       // 0-1
+}
+
+// SelectorError is returned when no element matches a selector, with the candidate alternatives.
+type SelectorError struct {
+	Selector   string
+	Candidates []SelectorCandidate
+}
+
+func (err SelectorError) Error() string {
+	msg := fmt.Sprintf("%v: %v", err.Selector, ErrorNoSuchNode)
+	if len(err.Candidates) == 0 {
+		return msg
+	}
+
+	var alts []string
+	for _, c := range err.Candidates {
+		alts = append(alts, fmt.Sprintf("%v (%v %.2f)", c.Selector, c.Reason, c.Score))
+	}
+
+	return msg + " - candidates: " + strings.Join(alts, ", ")
+}
+
+// Unwrap returns ErrorNoSuchNode.
+func (err SelectorError) Unwrap() error {
+	return ErrorNoSuchNode
+}
+
+// HealedSelector is a selector replaced by the auto-heal mode (see HealSelectors).
+type HealedSelector struct {
+	Selector string            `json:"selector"`
+	Healed   SelectorCandidate `json:"healed"`
+}
+
+type selectorHealing struct {
+	minScore float64
+	healed   []HealedSelector
+}
+
+var (
+	selectorIDs    = regexp.MustCompile(`#([\w-]+)`)
+	selectorClass  = regexp.MustCompile(`\.([A-Za-z_][\w-]*)`)
+	selectorAttrs  = regexp.MustCompile(`\[[\w-]+[~|^$*]?=\s*(?:"([^"]*)"|'([^']*)'|([^\]\s]+))\s*\]`)
+	selectorQuoted = regexp.MustCompile(`"([^"]+)"|'([^']+)'`)
+	selectorTag    = regexp.MustCompile(`^([a-zA-Z][\w-]*)`)
+)
+
+// selectorTokens returns the identifiers (ids, classes, attribute values and texts) in the selector,
+// and the tag of the target element (if any).
+func selectorTokens(selector string) (tokens []string, tag string) {
+	switch {
+	case strings.HasPrefix(selector, "text="):
+		return []string{strings.TrimPrefix(selector, "text=")}, ""
+
+	case strings.HasPrefix(selector, "xpath="), strings.HasPrefix(selector, "//"):
+		for _, m := range selectorQuoted.FindAllStringSubmatch(selector, -1) {
+			tokens = append(tokens, m[1]+m[2])
+		}
+
+		return tokens, ""
+	}
+
+	selector = strings.TrimPrefix(selector, "css=")
+	if i := strings.LastIndex(selector, DeepSelectorSeparator); i >= 0 {
+		selector = selector[i+len(DeepSelectorSeparator):]
+	}
+
+	for _, m := range selectorAttrs.FindAllStringSubmatch(selector, -1) {
+		tokens = append(tokens, m[1]+m[2]+m[3])
+	}
+
+	noattrs := selectorAttrs.ReplaceAllString(selector, "")
+
+	for _, m := range selectorIDs.FindAllStringSubmatch(noattrs, -1) {
+		tokens = append(tokens, m[1])
+	}
+	for _, m := range selectorClass.FindAllStringSubmatch(noattrs, -1) {
+		tokens = append(tokens, m[1])
+	}
+
+	parts := strings.FieldsFunc(noattrs, func(r rune) bool { return r == ' ' || r == '>' || r == '+' || r == '~' })
+	if len(parts) > 0 && strings.HasSuffix(noattrs, parts[len(parts)-1]) { // not "div [name=x]"
+		if m := selectorTag.FindStringSubmatch(parts[len(parts)-1]); m != nil {
+			tag = strings.ToLower(m[1])
+		}
+	}
+
+	return tokens, tag
+}
+
+// suggestSelectorsJS scores the elements of the document by the similarity of their properties
+// (id, name, test id, label, placeholder, classes, role and text) with the tokens of the failed selector.
+const suggestSelectorsJS = `(function(tokens, tag, max) {
+	var selector = ` + elementSelectorJS + `;
+	var describe = ` + describeElementJS + `;
+
+	function words(s) {
+		return s.replace(/([a-z])([A-Z])/g, "$1 $2").toLowerCase().split(/[^a-z0-9]+/).filter(function(w) { return w; });
+	}
+
+	function similarity(token, value) {
+		if (!value) return 0;
+		var t = token.toLowerCase(), v = value.toLowerCase().trim();
+		if (t === v) return 1;
+		if (v.length <= 200 && (v.indexOf(t) >= 0 || t.indexOf(v) >= 0)) return 0.7;
+		var tw = words(token), vw = words(value);
+		if (!tw.length || !vw.length || vw.length > 30) return 0;
+		var common = tw.filter(function(w) { return vw.indexOf(w) >= 0; }).length;
+		return 0.6 * common / Math.max(tw.length, vw.length);
+	}
+
+	var implicitRoles = {a: "link", button: "button", select: "combobox", textarea: "textbox", h1: "heading", h2: "heading", h3: "heading", img: "img", nav: "navigation", form: "form"};
+
+	var candidates = [];
+	var els = document.body ? document.body.querySelectorAll("*") : [];
+
+	for (var i = 0; i < els.length; i++) {
+		var el = els[i];
+		if (/^(SCRIPT|STYLE|NOSCRIPT|TEMPLATE|svg|path)$/.test(el.tagName)) continue;
+
+		var text = el.children.length === 0 || /^(A|BUTTON|LABEL)$/.test(el.tagName) ? (el.innerText || "").trim() : "";
+		var props = {
+			"id": el.id,
+			"name": el.getAttribute("name"),
+			"data-testid": el.getAttribute("data-testid"),
+			"aria-label": el.getAttribute("aria-label"),
+			"placeholder": el.getAttribute("placeholder"),
+			"title": el.getAttribute("title"),
+			"class": typeof el.className === "string" ? el.className : "",
+			"role": el.getAttribute("role") || implicitRoles[el.tagName.toLowerCase()] || (el.tagName === "INPUT" ? el.type : ""),
+			"text": text.length <= 100 ? text : ""
+		};
+
+		var best = 0, reason = "";
+		tokens.forEach(function(t) {
+			for (var p in props) {
+				var s = p === "class" ? Math.max.apply(null, props[p].split(/\s+/).map(function(c) { return similarity(t, c); })) * 0.8 : similarity(t, props[p]);
+				if (s > best) { best = s; reason = p; }
+			}
+		});
+
+		if (best === 0) continue;
+		if (tag && el.tagName.toLowerCase() === tag) best = Math.min(1, best + 0.1);
+
+		candidates.push({el: el, score: Math.round(best * 100) / 100, reason: reason});
+	}
+
+	candidates.sort(function(a, b) { return b.score - a.score; });
+
+	var seen = {};
+	var res = [];
+	for (var i = 0; i < candidates.length && res.length < max; i++) {
+		var c = candidates[i];
+		var s = selector(c.el);
+		if (!s || seen[s]) continue;
+		seen[s] = true;
+		res.push({selector: s, description: describe(c.el), reason: c.reason, score: c.score});
+	}
+
+	return res;
+})(%v, %v, %v)`
+
+// SuggestSelectors returns the elements of the current document the (failed) selector was likely meant
+// to match, best first: the elements are scored by the similarity of their id, name, test id, label,
+// placeholder, classes, role and text with the ids, classes, attribute values and texts in the selector.
+func (remote *RemoteDebugger) SuggestSelectors(selector string) ([]SelectorCandidate, error) {
+	tokens, tag := selectorTokens(selector)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	jtokens, err := json.Marshal(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := remote.Evaluate(fmt.Sprintf(suggestSelectorsJS, string(jtokens), jsString(tag), MaxSelectorCandidates))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []SelectorCandidate
+	if err := decodeParams(res, &candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// selectorError returns a SelectorError for the failed selector, with the candidate alternatives.
+func (remote *RemoteDebugger) selectorError(selector string) error {
+	candidates, _ := remote.SuggestSelectors(selector)
+	return SelectorError{Selector: selector, Candidates: candidates}
+}
+
+// HealSelectors enables the auto-heal mode of ReplayActions: when no element matches the selector of an action,
+// the best candidate (see SuggestSelectors) is used instead, if its score is at least minScore.
+// A minScore of 0 disables the auto-heal mode.
+func (remote *RemoteDebugger) HealSelectors(minScore float64) {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if minScore <= 0 {
+		remote.healing = nil
+	} else {
+		remote.healing = &selectorHealing{minScore: minScore}
+	}
+}
+
+// HealedSelectors returns the selectors replaced by the auto-heal mode, so that the scenarios can be fixed.
+func (remote *RemoteDebugger) HealedSelectors() []HealedSelector {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if remote.healing == nil {
+		return nil
+	}
+
+	return append([]HealedSelector(nil), remote.healing.healed...)
+}
+
+// healSelector returns the selector to use in place of the failed selector, if the auto-heal mode is enabled
+// and a good candidate is found, or a SelectorError.
+func (remote *RemoteDebugger) healSelector(selector string) (string, error) {
+	candidates, _ := remote.SuggestSelectors(selector)
+
+	remote.Lock()
+	defer remote.Unlock()
+
+	if h := remote.healing; h != nil && len(candidates) > 0 && candidates[0].Score >= h.minScore {
+		h.healed = append(h.healed, HealedSelector{Selector: selector, Healed: candidates[0]})
+
+		if remote.verbose {
+			log.Println("healed selector", selector, "with", candidates[0].Selector)
+		}
+
+		return candidates[0].Selector, nil
+	}
+
+	return "", SelectorError{Selector: selector, Candidates: candidates}
+}
@@ -0,0 +1,113 @@
+package godet
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectorTokens(t *testing.T) {
+	tests := []struct {
+		selector string
+		tokens   []string
+		tag      string
+	}{
+		{"#login-button", []string{"login-button"}, ""},
+		{"form.signin > button#submit.btn-primary", []string{"submit", "signin", "btn-primary"}, "button"},
+		{`input[name="email"]`, []string{"email"}, "input"},
+		{`css=div [data-testid='cart.total']`, []string{"cart.total"}, ""},
+		{"my-app >>> Button.save", []string{"save"}, "button"},
+		{"text=Sign in", []string{"Sign in"}, ""},
+		{`//button[text()="Save"]`, []string{"Save"}, ""},
+		{`xpath=//*[@id='main']//a[@title="Home"]`, []string{"main", "Home"}, ""},
+		{"div > span", nil, "span"},
+	}
+
+	for _, tt := range tests {
+		tokens, tag := selectorTokens(tt.selector)
+		if !reflect.DeepEqual(tokens, tt.tokens) || tag != tt.tag {
+			t.Errorf("selectorTokens(%q) = %q, %q, want %q, %q", tt.selector, tokens, tag, tt.tokens, tt.tag)
+		}
+	}
+}
+
+func TestHealSelector(t *testing.T) {
+	var expressions []string
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		expr := params.String("expression")
+		expressions = append(expressions, expr)
+
+		var value interface{} = []interface{}{}
+		if strings.Contains(expr, `(["login"], "button", 5)`) {
+			value = []interface{}{
+				Params{"selector": "#sign-in", "description": "button#sign-in", "reason": "text", "score": 0.7},
+				Params{"selector": "a:nth-of-type(2)", "description": "a", "reason": "class", "score": 0.48},
+			}
+		}
+
+		return Params{"result": Params{"type": "object", "value": value}}, nil
+	}))
+
+	candidates, err := remote.SuggestSelectors("button#login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SelectorCandidate{
+		{Selector: "#sign-in", Description: "button#sign-in", Reason: "text", Score: 0.7},
+		{Selector: "a:nth-of-type(2)", Description: "a", Reason: "class", Score: 0.48},
+	}
+	if !reflect.DeepEqual(candidates, want) {
+		t.Errorf("candidates %+v, want %+v", candidates, want)
+	}
+
+	// no tokens: nothing to look for
+	if candidates, err := remote.SuggestSelectors("div > span"); candidates != nil || err != nil || len(expressions) != 1 {
+		t.Errorf("unexpected suggestion for a selector without tokens: %v %v", candidates, err)
+	}
+
+	// auto-heal disabled: the error lists the candidates
+	_, err = remote.healSelector("button#login")
+	if !errors.Is(err, ErrorNoSuchNode) {
+		t.Errorf("expected a SelectorError, got %v", err)
+	}
+
+	if msg := "button#login: no node matching selector - candidates: #sign-in (text 0.70), a:nth-of-type(2) (class 0.48)"; err.Error() != msg {
+		t.Errorf("error %q, want %q", err, msg)
+	}
+
+	if msg := (SelectorError{Selector: "#menu"}).Error(); msg != "#menu: no node matching selector" {
+		t.Errorf("error without candidates %q", msg)
+	}
+
+	// the best candidate score is below the minimum
+	remote.HealSelectors(0.8)
+
+	if _, err := remote.healSelector("button#login"); err == nil {
+		t.Errorf("healed with a low score candidate")
+	}
+
+	remote.HealSelectors(DefaultHealScore)
+
+	healed, err := remote.healSelector("button#login")
+	if err != nil || healed != "#sign-in" {
+		t.Errorf("healSelector = %q, %v", healed, err)
+	}
+
+	if _, err := remote.healSelector("#cart"); err == nil {
+		t.Errorf("healed a selector without candidates")
+	}
+
+	wantHealed := []HealedSelector{{Selector: "button#login", Healed: want[0]}}
+	if got := remote.HealedSelectors(); !reflect.DeepEqual(got, wantHealed) {
+		t.Errorf("healed selectors %+v, want %+v", got, wantHealed)
+	}
+
+	remote.HealSelectors(0)
+
+	if got := remote.HealedSelectors(); got != nil {
+		t.Errorf("healed selectors not cleared: %+v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package godet
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Heartbeat enables sending a websocket ping every interval. If the browser doesn't answer within timeout
+// (defaults to interval) the connection is marked unhealthy: pending and new requests fail with ErrorUnhealthy
+// and an EventDisconnect event is dispatched, like when the connection is lost (so Run returns ErrorDisconnected,
+// after closing the connection). Without ManualRun, the connection becomes healthy again if a following ping succeeds.
+func Heartbeat(interval, timeout time.Duration) ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.heartbeat = interval
+		remote.heartbeatTimeout = timeout
+	}
+}
+
+// Healthy returns false if the browser stopped answering heartbeats.
+func (remote *RemoteDebugger) Healthy() bool {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return !remote.unhealthy
+}
+
+func (remote *RemoteDebugger) heartbeats() {
+	defer remote.pinger.Done()
+
+	timeout := remote.heartbeatTimeout
+	if timeout <= 0 {
+		timeout = remote.heartbeat
+	}
+
+	ticker := time.NewTicker(remote.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-remote.closed:
+			return
+
+		case <-ticker.C:
+		}
+
+		ws := remote.socket()
+		if ws == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := ws.Ping(ctx)
+		cancel()
+
+		if remote.socket() != ws { // switched tab while pinging
+			continue
+		}
+
+		if err == nil {
+			remote.Lock()
+			if remote.unhealthy {
+				remote.unhealthy = false
+				remote.unhealthyCh = make(chan struct{})
+			}
+			remote.Unlock()
+			continue
+		}
+
+		if remote.verbose {
+			log.Println("heartbeat:", err)
+		}
+
+		remote.Lock()
+		wasUnhealthy := remote.unhealthy
+		if !wasUnhealthy {
+			remote.unhealthy = true
+			close(remote.unhealthyCh) // fail the requests waiting to be sent
+		}
+
+		pending := make([]chan wsReply, 0, len(remote.responses))
+		for _, ch := range remote.responses {
+			pending = append(pending, ch)
+		}
+		remote.Unlock()
+
+		if wasUnhealthy {
+			continue
+		}
+
+		log.Println("connection unhealthy:", err)
+
+		for _, ch := range pending {
+			select {
+			case ch <- wsReply{err: ErrorUnhealthy}:
+			default:
+			}
+		}
+
+		// the events channel is only closed by readMessages after the heartbeats have stopped
+		select {
+		case remote.events <- wsMessage{Method: EventDisconnect, Params: []byte("{}")}:
+
+		case <-remote.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package godet
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// deadBrowser accepts the connection but never reads from it, so the pings are never answered.
+func deadBrowser(t *testing.T) *Tab {
+	return fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		<-ctx.Done()
+	})
+}
+
+// closeNow closes the connection without waiting for the close handshake, that the dead browser never answers.
+func closeNow(remote *RemoteDebugger) {
+	if ws := remote.socket(); ws != nil {
+		ws.CloseNow()
+	}
+
+	remote.Close()
+}
+
+func TestHeartbeatRunReturns(t *testing.T) {
+	remote := connectFake(t, deadBrowser(t), ManualRun(), Heartbeat(20*time.Millisecond, 20*time.Millisecond))
+	defer closeNow(remote)
+
+	disconnected := 0
+	remote.CallbackEvent(EventDisconnect, func(Params) { disconnected++ })
+
+	errc := make(chan error, 1)
+	go func() { errc <- remote.Run(context.Background()) }()
+
+	select {
+	case err := <-errc:
+		if err != ErrorDisconnected {
+			t.Errorf("Run returned %v, want %v", err, ErrorDisconnected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return")
+	}
+
+	if disconnected != 1 {
+		t.Errorf("EventDisconnect callback called %d times, want 1", disconnected)
+	}
+
+	if remote.Healthy() {
+		t.Error("connection still healthy")
+	}
+
+	if remote.socket() != nil {
+		t.Error("connection still open")
+	}
+
+	waitReaders(t, remote, time.Second)
+}
+
+func TestHeartbeatUnblocksSenders(t *testing.T) {
+	remote := newRemoteDebugger(nil, false)
+	remote.heartbeat = 20 * time.Millisecond
+
+	if err := remote.connectWs(deadBrowser(t)); err != nil {
+		t.Fatal(err)
+	}
+	defer closeNow(remote)
+
+	// no sendMessages: the requests are stuck, as if the writer was hung
+	go remote.processEvents()
+	remote.pinger.Add(1)
+	go remote.heartbeats()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = remote.SendRequest("Page.enable", nil)
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("senders still blocked")
+	}
+
+	for i, err := range errs {
+		if err != ErrorUnhealthy {
+			t.Errorf("request %d: got %v, want %v", i, err, ErrorUnhealthy)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package godet
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hostPattern matches a host name, optionally starting with a "*." wildcard.
+var hostPattern = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9_]([a-zA-Z0-9_-]*[a-zA-Z0-9_])?\.)*[a-zA-Z0-9_]([a-zA-Z0-9_-]*[a-zA-Z0-9_])?$`)
+
+// HostResolverRules returns the --host-resolver-rules value mapping each host (i.e. "www.example.com"
+// or "*.example.com") to its target (an IP address or host name, optionally with a port,
+// or "~NOTFOUND" to make the host unresolvable). The rules are sorted by host.
+//
+// An error is returned for the invalid hosts or targets.
+func HostResolverRules(hosts map[string]string) (string, error) {
+	names := make([]string, 0, len(hosts))
+
+	for host, target := range hosts {
+		if !hostPattern.MatchString(host) {
+			return "", fmt.Errorf("invalid host %q", host)
+		}
+
+		if err := validHostTarget(target); err != nil {
+			return "", fmt.Errorf("invalid target %q for %v: %v", target, host, err)
+		}
+
+		names = append(names, host)
+	}
+
+	sort.Strings(names)
+
+	rules := make([]string, len(names))
+
+	for i, host := range names {
+		target := hosts[host]
+
+		if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+			target = "[" + target + "]"
+		}
+
+		rules[i] = "MAP " + host + " " + target
+	}
+
+	return strings.Join(rules, ", "), nil
+}
+
+// validHostTarget checks the target of a host resolver rule.
+func validHostTarget(target string) error {
+	if target == "~NOTFOUND" {
+		return nil
+	}
+
+	if net.ParseIP(target) != nil {
+		return nil
+	}
+
+	host, port := target, ""
+
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		host, port = h, p
+	}
+
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+
+	if net.ParseIP(host) == nil && (strings.HasPrefix(host, "*") || !hostPattern.MatchString(host)) {
+		return fmt.Errorf("invalid address %q", host)
+	}
+
+	return nil
+}
+
+// WithHostResolverRules maps the hosts to the specified targets (see HostResolverRules),
+// i.e. to point the production host names to the staging servers without editing /etc/hosts.
+// Launch fails if the rules are invalid.
+func WithHostResolverRules(hosts map[string]string) LaunchOption {
+	return func(l *launcher) {
+		if l.hosts == nil {
+			l.hosts = map[string]string{}
+		}
+
+		for host, target := range hosts {
+			l.hosts[host] = target
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package godet
+
+import (
+	"sync"
+	"time"
+)
+
+// minIdlePoll is the minimum interval to check for the network idle.
+const minIdlePoll = 10 * time.Millisecond
+
+// networkActivity tracks the requests in flight, to detect when the network is idle.
+type networkActivity struct {
+	sync.Mutex
+	inflight map[string]bool
+	last     time.Time
+}
+
+func (na *networkActivity) start(id string) {
+	na.Lock()
+	na.inflight[id] = true
+	na.last = time.Now()
+	na.Unlock()
+}
+
+func (na *networkActivity) done(id string) {
+	na.Lock()
+	delete(na.inflight, id)
+	na.last = time.Now()
+	na.Unlock()
+}
+
+// idleFor returns true if no requests were in flight for the specified duration.
+func (na *networkActivity) idleFor(idle time.Duration) bool {
+	na.Lock()
+	defer na.Unlock()
+	return len(na.inflight) == 0 && time.Since(na.last) >= idle
+}
+
+// trackNetwork starts tracking the network activity, enabling Network events if needed.
+// The returned function stops tracking.
+func (remote *RemoteDebugger) trackNetwork() (*networkActivity, func(), error) {
+	na := &networkActivity{inflight: map[string]bool{}, last: time.Now()}
+
+	finished := func(params Params) bool {
+		na.done(params.String("requestId"))
+		return false
+	}
+
+	removeHooks := []func(){
+		remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+			na.start(params.String("requestId"))
+			return false
+		}),
+		remote.addHook("Network.loadingFinished", finished),
+		remote.addHook("Network.loadingFailed", finished),
+	}
+
+	stop := func() {
+		for _, remove := range removeHooks {
+			remove()
+		}
+	}
+
+	if err := remote.ensureDomain("Network"); err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return na, stop, nil
+}
+
+// waitIdle waits until the network has been idle for the specified duration, or returns ErrorTimeout.
+func (na *networkActivity) waitIdle(idle, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	poll := idle / 10
+	if poll < minIdlePoll {
+		poll = minIdlePoll
+	}
+
+	for !na.idleFor(idle) {
+		if time.Now().After(deadline) {
+			return ErrorTimeout
+		}
+
+		time.Sleep(poll)
+	}
+
+	return nil
+}
+
+// WaitNetworkIdle waits until there are no requests in flight for the specified idle duration,
+// or returns ErrorTimeout. Network events are enabled, if needed.
+func (remote *RemoteDebugger) WaitNetworkIdle(idle, timeout time.Duration) error {
+	na, stop, err := remote.trackNetwork()
+	if err != nil {
+		return err
+	}
+
+	defer stop()
+	return na.waitIdle(idle, timeout)
+}
@@ -0,0 +1,39 @@
+package godet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitIdle(t *testing.T) {
+	na := &networkActivity{inflight: map[string]bool{}}
+
+	na.start("1")
+	na.start("2")
+	na.done("1")
+
+	if err := na.waitIdle(0, 30*time.Millisecond); err != ErrorTimeout {
+		t.Errorf("waitIdle with a request in flight = %v, want %v", err, ErrorTimeout)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		na.done("2")
+	}()
+
+	start := time.Now()
+	if err := na.waitIdle(0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("idle after %v, before the request was done", elapsed)
+	}
+
+	start = time.Now()
+	if err := na.waitIdle(50*time.Millisecond, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("idle for 50ms after %v", elapsed)
+	}
+}
@@ -0,0 +1,192 @@
+// Package imagediff compares page screenshots against a baseline image, for visual regression testing.
+package imagediff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/raff/godet"
+)
+
+// ErrorSizeMismatch is returned if the images to compare have different sizes
+var ErrorSizeMismatch = errors.New("image size mismatch")
+
+// Options defines how images are compared.
+type Options struct {
+	// Threshold is the maximum difference (0-255) allowed for each color channel before a pixel is considered different
+	Threshold uint8
+	// Ignore lists regions (in image coordinates) that are not compared
+	Ignore []image.Rectangle
+}
+
+// Result holds the result of a comparison.
+type Result struct {
+	// DiffPixels is the number of pixels that are different
+	DiffPixels int
+	// TotalPixels is the number of pixels compared (excluding ignored regions)
+	TotalPixels int
+	// Diff is an annotated image: different pixels are red, the rest is a faded copy of the baseline
+	// and ignored regions are blue.
+	Diff *image.RGBA
+	// NewBaseline is true if CaptureAndCompare didn't find a baseline and saved the screenshot as the new baseline
+	NewBaseline bool
+}
+
+// Ratio returns the fraction of different pixels.
+func (r *Result) Ratio() float64 {
+	if r.TotalPixels == 0 {
+		return 0
+	}
+
+	return float64(r.DiffPixels) / float64(r.TotalPixels)
+}
+
+func absdiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+func ignored(p image.Point, regions []image.Rectangle) bool {
+	for _, r := range regions {
+		if p.In(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compare compares the current image against the baseline.
+func Compare(baseline, current image.Image, opts Options) (*Result, error) {
+	bounds := baseline.Bounds()
+	if bounds.Size() != current.Bounds().Size() {
+		return nil, ErrorSizeMismatch
+	}
+
+	offset := current.Bounds().Min.Sub(bounds.Min)
+	threshold := uint32(opts.Threshold) << 8 // RGBA() returns 16 bit values
+
+	res := &Result{Diff: image.NewRGBA(bounds)}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+
+			if ignored(p, opts.Ignore) {
+				res.Diff.Set(x, y, color.RGBA{0, 0, 255, 255})
+				continue
+			}
+
+			res.TotalPixels++
+
+			r1, g1, b1, a1 := baseline.At(x, y).RGBA()
+			r2, g2, b2, a2 := current.At(x+offset.X, y+offset.Y).RGBA()
+
+			if absdiff(r1, r2) > threshold || absdiff(g1, g2) > threshold ||
+				absdiff(b1, b2) > threshold || absdiff(a1, a2) > threshold {
+				res.DiffPixels++
+				res.Diff.Set(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+
+			gray := color.GrayModel.Convert(baseline.At(x, y)).(color.Gray)
+			faded := 192 + gray.Y/4
+			res.Diff.Set(x, y, color.RGBA{faded, faded, faded, 255})
+		}
+	}
+
+	return res, nil
+}
+
+// selectorRects returns the bounding rectangles (in screenshot pixels) of the elements matching the selectors.
+func selectorRects(remote *godet.RemoteDebugger, selectors []string) ([]image.Rectangle, error) {
+	var rects []image.Rectangle
+
+	for _, sel := range selectors {
+		res, err := remote.EvaluateWrap(fmt.Sprintf(`
+			var r = window.devicePixelRatio || 1;
+			return Array.from(document.querySelectorAll(%q)).map(e => {
+				var b = e.getBoundingClientRect();
+				return [b.left * r, b.top * r, b.right * r, b.bottom * r];
+			});`, sel))
+		if err != nil {
+			return nil, err
+		}
+
+		list, _ := res.([]interface{})
+		for _, l := range list {
+			c := l.([]interface{})
+			rects = append(rects, image.Rect(
+				int(c[0].(float64)), int(c[1].(float64)),
+				int(c[2].(float64)+0.5), int(c[3].(float64)+0.5)))
+		}
+	}
+
+	return rects, nil
+}
+
+// CaptureAndCompare takes a screenshot of the current page and compares it against the PNG baseline file.
+// Elements matching ignoreSelectors are excluded from the comparison.
+//
+// If the baseline file doesn't exist, the screenshot is saved as the new baseline.
+func CaptureAndCompare(remote *godet.RemoteDebugger, baselineFile string, opts Options, ignoreSelectors ...string) (*Result, error) {
+	rects, err := selectorRects(remote, ignoreSelectors)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Ignore = append(opts.Ignore, rects...)
+
+	shot, err := remote.CaptureScreenshot("png", 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := png.Decode(bytes.NewReader(shot))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(baselineFile)
+	if os.IsNotExist(err) {
+		if err := os.WriteFile(baselineFile, shot, 0644); err != nil {
+			return nil, err
+		}
+
+		return &Result{NewBaseline: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	baseline, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(baseline, current, opts)
+}
+
+// SaveDiff saves the annotated diff image as PNG.
+func (r *Result) SaveDiff(filename string) error {
+	if r.Diff == nil {
+		return errors.New("no diff image")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, r.Diff); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
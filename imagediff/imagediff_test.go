@@ -0,0 +1,129 @@
+package imagediff
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testImage returns an image with the bounds r filled with c.
+func testImage(r image.Rectangle, c color.Color) *image.RGBA {
+	img := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	gray := color.RGBA{100, 100, 100, 255}
+	bounds := image.Rect(0, 0, 10, 10)
+
+	baseline := testImage(bounds, gray)
+
+	current := testImage(bounds, gray)
+	current.Set(1, 1, color.RGBA{110, 100, 100, 255}) // within the threshold
+	current.Set(2, 2, color.RGBA{130, 100, 100, 255})
+	current.Set(3, 3, color.RGBA{100, 100, 100, 200}) // alpha
+	current.Set(8, 8, color.RGBA{0, 0, 0, 255})       // ignored
+
+	res, err := Compare(baseline, current, Options{Threshold: 20, Ignore: []image.Rectangle{image.Rect(7, 7, 10, 10)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.DiffPixels != 2 {
+		t.Errorf("DiffPixels = %d, want 2", res.DiffPixels)
+	}
+	if res.TotalPixels != 91 {
+		t.Errorf("TotalPixels = %d, want 91", res.TotalPixels)
+	}
+	if r := res.Ratio(); r != 2.0/91 {
+		t.Errorf("Ratio = %v, want %v", r, 2.0/91)
+	}
+
+	for _, tt := range []struct {
+		x, y int
+		c    color.RGBA
+	}{
+		{2, 2, color.RGBA{255, 0, 0, 255}},
+		{3, 3, color.RGBA{255, 0, 0, 255}},
+		{8, 8, color.RGBA{0, 0, 255, 255}},
+		{1, 1, color.RGBA{217, 217, 217, 255}}, // faded baseline
+	} {
+		if c := res.Diff.RGBAAt(tt.x, tt.y); c != tt.c {
+			t.Errorf("diff at %d,%d = %v, want %v", tt.x, tt.y, c, tt.c)
+		}
+	}
+
+	exact, err := Compare(baseline, current, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exact.DiffPixels != 4 {
+		t.Errorf("DiffPixels with no threshold = %d, want 4", exact.DiffPixels)
+	}
+}
+
+func TestCompareOffset(t *testing.T) {
+	baseline := testImage(image.Rect(0, 0, 4, 4), color.White)
+	current := testImage(image.Rect(10, 20, 14, 24), color.White)
+	current.Set(10, 20, color.Black)
+
+	res, err := Compare(baseline, current, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.DiffPixels != 1 || res.Diff.RGBAAt(0, 0) != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("DiffPixels = %d, want 1 at 0,0", res.DiffPixels)
+	}
+}
+
+func TestCompareSize(t *testing.T) {
+	if _, err := Compare(image.NewRGBA(image.Rect(0, 0, 4, 4)), image.NewRGBA(image.Rect(0, 0, 4, 5)), Options{}); err != ErrorSizeMismatch {
+		t.Errorf("Compare of different sizes = %v, want %v", err, ErrorSizeMismatch)
+	}
+
+	var res Result
+	if res.Ratio() != 0 {
+		t.Error("Ratio of an empty comparison should be 0")
+	}
+}
+
+func TestSaveDiff(t *testing.T) {
+	var empty Result
+	if err := empty.SaveDiff(filepath.Join(t.TempDir(), "none.png")); err == nil {
+		t.Error("SaveDiff without a diff image should fail")
+	}
+
+	res, err := Compare(testImage(image.Rect(0, 0, 3, 3), color.White), testImage(image.Rect(0, 0, 3, 3), color.Black), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "diff.png")
+	if err := res.SaveDiff(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != res.Diff.Bounds() {
+		t.Errorf("saved image bounds = %v, want %v", img.Bounds(), res.Diff.Bounds())
+	}
+}
@@ -0,0 +1,105 @@
+package godet
+
+// CallFrame is a stack entry of a Runtime.StackTrace.
+type CallFrame struct {
+	FunctionName string `json:"functionName"`
+	ScriptID     string `json:"scriptId"`
+	URL          string `json:"url"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+}
+
+// StackTrace is a Javascript call stack, possibly continued by the stack of the async parent.
+type StackTrace struct {
+	Description string      `json:"description"`
+	CallFrames  []CallFrame `json:"callFrames"`
+	Parent      *StackTrace `json:"parent"`
+}
+
+// ScriptURL returns the URL of the innermost script in the stack (including the async parents), if any.
+func (st *StackTrace) ScriptURL() string {
+	for ; st != nil; st = st.Parent {
+		for _, f := range st.CallFrames {
+			if f.URL != "" {
+				return f.URL
+			}
+		}
+	}
+
+	return ""
+}
+
+// Initiator describes what caused a request to be sent (see Network.requestWillBeSent).
+type Initiator struct {
+	// Type is one of parser, script, preload, SignedExchange, preflight or other.
+	Type string `json:"type"`
+
+	// Stack is the script stack, for "script" initiators.
+	Stack *StackTrace `json:"stack"`
+
+	// URL is the document or stylesheet containing the resource, for "parser" and "preload" initiators.
+	URL          string  `json:"url"`
+	LineNumber   float64 `json:"lineNumber"`
+	ColumnNumber float64 `json:"columnNumber"`
+
+	// RequestID is the request being preflighted, for "preflight" initiators.
+	RequestID string `json:"requestId"`
+}
+
+// SourceURL returns the URL of the resource that initiated the request, or "" if not known.
+func (i *Initiator) SourceURL() string {
+	if i == nil {
+		return ""
+	}
+
+	if i.Type == "script" || i.URL == "" {
+		return i.Stack.ScriptURL()
+	}
+
+	return i.URL
+}
+
+// InitiatorChain returns the chain of captured requests that caused the request with the specified
+// requestId, starting from the request itself and walking back (i.e. script that loaded a script
+// that sent an XHR) until the root document or a request that wasn't captured.
+//
+// Requests are recorded with CaptureRequests.
+func (remote *RemoteDebugger) InitiatorChain(requestID string) ([]*CapturedRequest, error) {
+	remote.Lock()
+	defer remote.Unlock()
+
+	req := remote.captured[requestID]
+	if req == nil {
+		return nil, ErrorNoRequest
+	}
+
+	byURL := map[string]*CapturedRequest{}
+	for _, r := range remote.captured {
+		if prev := byURL[r.URL]; prev == nil || r.Type == "Document" {
+			byURL[r.URL] = r
+		}
+	}
+
+	chain := []*CapturedRequest{req}
+	seen := map[*CapturedRequest]bool{req: true}
+
+	for req.Initiator != nil && req.Initiator.Type != "other" {
+		var parent *CapturedRequest
+
+		if req.Initiator.Type == "preflight" {
+			parent = remote.captured[req.Initiator.RequestID]
+		} else {
+			parent = byURL[req.Initiator.SourceURL()]
+		}
+
+		if parent == nil || seen[parent] {
+			break
+		}
+
+		chain = append(chain, parent)
+		seen[parent] = true
+		req = parent
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,398 @@
+package godet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrorNoBrowser is returned by Launch if no browser executable was found
+var ErrorNoBrowser = errors.New("browser not found")
+
+// ErrorXvfb is returned by StartXvfb if it's not available (or not supported on this platform)
+var ErrorXvfb = errors.New("Xvfb not available")
+
+// Xvfb is a virtual X11 display, for running a headful browser without a real display (i.e. on CI).
+type Xvfb struct {
+	// Display is the X11 display name (i.e. ":99"), to set as DISPLAY for the browser.
+	Display string
+
+	cmd *exec.Cmd
+}
+
+// StartXvfb starts Xvfb on the first free display starting at :99, with the specified
+// screen geometry (WIDTHxHEIGHTxDEPTH, default 1920x1080x24). It is only supported on Linux.
+func StartXvfb(screen string) (*Xvfb, error) {
+	if runtime.GOOS != "linux" {
+		return nil, ErrorXvfb
+	}
+
+	path, err := exec.LookPath("Xvfb")
+	if err != nil {
+		return nil, ErrorXvfb
+	}
+
+	if screen == "" {
+		screen = "1920x1080x24"
+	}
+
+	for n := 99; n < 200; n++ {
+		socket := fmt.Sprintf("/tmp/.X11-unix/X%d", n)
+
+		if _, err := os.Stat(fmt.Sprintf("/tmp/.X%d-lock", n)); err == nil {
+			continue
+		}
+		if _, err := os.Stat(socket); err == nil {
+			continue
+		}
+
+		display := fmt.Sprintf(":%d", n)
+
+		cmd := exec.Command(path, display, "-screen", "0", screen, "-nolisten", "tcp")
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+
+		switch waitFile(socket, exited, 10*time.Second) {
+		case nil:
+			return &Xvfb{Display: display, cmd: cmd}, nil
+
+		case ErrorTimeout:
+			cmd.Process.Kill()
+			return nil, ErrorTimeout
+		}
+
+		// Xvfb exited, probably lost a race for the display: try the next one
+	}
+
+	return nil, ErrorXvfb
+}
+
+// waitFile waits until the file exists. It returns ErrorTimeout if it doesn't appear in time,
+// or the process exit error if exited is signaled first.
+func waitFile(path string, exited chan error, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrorTimeout
+		}
+
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("process exited")
+			}
+			return err
+
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Stop terminates Xvfb.
+func (x *Xvfb) Stop() error {
+	return x.cmd.Process.Kill()
+}
+
+// FindBrowser returns the path of the first Chrome, Chromium or Edge executable found, or an empty string.
+// The GODET_CHROMEAPP environment variable, if set, takes precedence.
+func FindBrowser() string {
+	if app := os.Getenv("GODET_CHROMEAPP"); app != "" {
+		return app
+	}
+
+	var candidates []string
+
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []string{
+			"/Applications/Google Chrome Canary.app/Contents/MacOS/Google Chrome Canary",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		}
+
+	case "linux":
+		candidates = []string{
+			"headless_shell",
+			"chromium",
+			"chromium-browser",
+			"google-chrome-beta",
+			"google-chrome-unstable",
+			"google-chrome-stable",
+			"google-chrome",
+		}
+
+	case "windows":
+		candidates = []string{
+			"C:/Program Files/Google/Chrome/Application/chrome.exe",
+			"C:/Program Files (x86)/Google/Chrome/Application/chrome.exe",
+			"C:/Program Files (x86)/Microsoft/Edge/Application/msedge.exe",
+		}
+	}
+
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// LaunchOption defines the functional options for Launch.
+type LaunchOption func(l *launcher)
+
+type launcher struct {
+	path        string
+	port        int
+	headless    bool
+	xvfb        bool
+	screen      string
+	args        []string
+	startupWait time.Duration
+	profileDir  string
+	extensions  []string
+	hosts       map[string]string
+}
+
+// WithBrowserPath sets the browser executable (default: FindBrowser).
+func WithBrowserPath(path string) LaunchOption {
+	return func(l *launcher) {
+		l.path = path
+	}
+}
+
+// WithPort sets the remote debugging port (default: a random free port).
+func WithPort(port int) LaunchOption {
+	return func(l *launcher) {
+		l.port = port
+	}
+}
+
+// WithHeadless starts the browser in headless (default) or headful mode.
+func WithHeadless(headless bool) LaunchOption {
+	return func(l *launcher) {
+		l.headless = headless
+	}
+}
+
+// WithXvfb runs a headful browser on a virtual display (see StartXvfb), if enable is true.
+// The screen geometry is WIDTHxHEIGHTxDEPTH (default 1920x1080x24).
+// It has no effect in headless mode.
+func WithXvfb(enable bool, screen string) LaunchOption {
+	return func(l *launcher) {
+		l.xvfb = enable
+		l.screen = screen
+	}
+}
+
+// WithArgs adds command line arguments (i.e. "--no-sandbox", "--window-size=1280,800").
+func WithArgs(args ...string) LaunchOption {
+	return func(l *launcher) {
+		l.args = append(l.args, args...)
+	}
+}
+
+// WithProfileDir uses dir as the browser profile (user data directory), instead of a new temporary one.
+// The profile is preserved when the browser is closed.
+func WithProfileDir(dir string) LaunchOption {
+	return func(l *launcher) {
+		l.profileDir = dir
+	}
+}
+
+// WithExtensions loads the unpacked extensions in the specified directories (all other extensions are disabled).
+// Extensions require headful or "new" headless mode, so they are not supported by headless_shell.
+// See ExtensionTargets and AttachExtension to access their background pages or service workers.
+func WithExtensions(paths ...string) LaunchOption {
+	return func(l *launcher) {
+		l.extensions = append(l.extensions, paths...)
+	}
+}
+
+// WithStartupWait sets how long to wait for the browser to start (default 30 seconds).
+func WithStartupWait(wait time.Duration) LaunchOption {
+	return func(l *launcher) {
+		l.startupWait = wait
+	}
+}
+
+// Browser is a browser process started by Launch.
+type Browser struct {
+	// Port is the remote debugging address (host:port), to pass to Connect.
+	Port string
+
+	cmd    *exec.Cmd
+	xvfb   *Xvfb
+	tmpDir string // the temporary profile, removed on Close
+	exited chan error
+}
+
+// Launch starts a browser with remote debugging enabled, in a new temporary profile
+// (unless WithProfileDir is used), and waits until it accepts connections.
+func Launch(options ...LaunchOption) (*Browser, error) {
+	l := &launcher{headless: true, startupWait: 30 * time.Second}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	if len(l.hosts) > 0 {
+		rules, err := HostResolverRules(l.hosts)
+		if err != nil {
+			return nil, err
+		}
+
+		l.args = append(l.args, "--host-resolver-rules="+rules)
+	}
+
+	if l.path == "" {
+		if l.path = FindBrowser(); l.path == "" {
+			return nil, ErrorNoBrowser
+		}
+	}
+
+	b := &Browser{exited: make(chan error, 1)}
+
+	dataDir := l.profileDir
+	if dataDir == "" {
+		tmp, err := ioutil.TempDir("", "godet")
+		if err != nil {
+			return nil, err
+		}
+
+		dataDir, b.tmpDir = tmp, tmp
+	} else if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// the browser writes the actual port (useful when it's 0) in DevToolsActivePort
+	portFile := filepath.Join(dataDir, "DevToolsActivePort")
+	os.Remove(portFile) // from a previous run
+
+	args := []string{
+		fmt.Sprintf("--remote-debugging-port=%d", l.port),
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+	}
+
+	if l.headless {
+		if !strings.Contains(filepath.Base(l.path), "headless_shell") {
+			args = append(args, "--headless=new")
+		}
+
+		args = append(args, "--hide-scrollbars")
+	}
+
+	if len(l.extensions) > 0 {
+		var paths []string
+
+		for _, p := range l.extensions {
+			if abs, err := filepath.Abs(p); err == nil {
+				p = abs
+			}
+
+			paths = append(paths, p)
+		}
+
+		list := strings.Join(paths, ",")
+		args = append(args, "--load-extension="+list, "--disable-extensions-except="+list)
+	}
+
+	args = append(append(args, l.args...), "about:blank")
+
+	cmd := exec.Command(l.path, args...)
+
+	if !l.headless && l.xvfb {
+		x, err := StartXvfb(l.screen)
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+
+		b.xvfb = x
+		cmd.Env = append(os.Environ(), "DISPLAY="+x.Display)
+	}
+
+	if err := cmd.Start(); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	b.cmd = cmd
+
+	go func() { b.exited <- b.cmd.Wait() }()
+
+	for deadline := time.Now().Add(l.startupWait); ; {
+		if f, err := os.Open(portFile); err == nil {
+			scanner := bufio.NewScanner(f)
+			if scanner.Scan() && scanner.Text() != "" {
+				b.Port = "localhost:" + scanner.Text()
+			}
+			f.Close()
+
+			if b.Port != "" {
+				return b, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			b.Close()
+			return nil, ErrorTimeout
+		}
+
+		select {
+		case err := <-b.exited:
+			b.exited <- err
+			b.Close()
+			return nil, fmt.Errorf("browser exited: %v", err)
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Connect connects to the browser (see Connect).
+func (b *Browser) Connect(verbose bool, options ...ConnectOption) (*RemoteDebugger, error) {
+	return Connect(b.Port, verbose, options...)
+}
+
+// Close terminates the browser (and Xvfb, if started) and removes the temporary profile, if any.
+func (b *Browser) Close() error {
+	var err error
+
+	if b.cmd != nil {
+		b.cmd.Process.Kill()
+
+		select {
+		case <-b.exited:
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	if b.xvfb != nil {
+		b.xvfb.Stop()
+	}
+
+	if b.tmpDir != "" {
+		err = os.RemoveAll(b.tmpDir)
+	}
+
+	return err
+}
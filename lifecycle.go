@@ -0,0 +1,208 @@
+package godet
+
+import (
+	"errors"
+	"time"
+)
+
+// LifecycleState is the web lifecycle state of the page (see SetWebLifecycleState).
+type LifecycleState string
+
+const (
+	// LifecycleFrozen freezes the page: timers and tasks are suspended, as for a page in the back/forward cache.
+	LifecycleFrozen = LifecycleState("frozen")
+	// LifecycleActive resumes a frozen page.
+	LifecycleActive = LifecycleState("active")
+)
+
+// SetWebLifecycleState freezes or resumes the page, triggering the "freeze" and "resume" document events.
+func (remote *RemoteDebugger) SetWebLifecycleState(state LifecycleState) error {
+	_, err := remote.SendRequest("Page.setWebLifecycleState", Params{
+		"state": state,
+	})
+	return err
+}
+
+// Freeze freezes the current page.
+func (remote *RemoteDebugger) Freeze() error {
+	return remote.SetWebLifecycleState(LifecycleFrozen)
+}
+
+// Resume resumes the current page, after Freeze.
+func (remote *RemoteDebugger) Resume() error {
+	return remote.SetWebLifecycleState(LifecycleActive)
+}
+
+// BackForwardCacheEvent reports whether a back/forward navigation was served by the back/forward cache.
+type BackForwardCacheEvent struct {
+	FrameID  string
+	LoaderID string
+	URL      string
+
+	// Restored is true if the page was restored from the cache.
+	Restored bool
+
+	// Reasons lists why the page couldn't be cached (if Restored is false).
+	Reasons []BackForwardCacheReason
+}
+
+// BackForwardCacheReason is one of the reasons preventing a page from entering the back/forward cache.
+type BackForwardCacheReason struct {
+	Type    string `json:"type"` // SupportPending, PageSupportNeeded or Circumstantial
+	Reason  string `json:"reason"`
+	Context string `json:"context"`
+}
+
+// BackForwardCacheCallback is called for each BackForwardCacheEvent.
+type BackForwardCacheCallback func(ev BackForwardCacheEvent)
+
+// OnBackForwardCache calls cb when a page is restored from the back/forward cache
+// (Page.frameNavigated with type BackForwardCacheRestore) or when it could not be
+// (Page.backForwardCacheNotUsed).
+//
+// Passing a nil callback stops the notifications. Page events are enabled, if needed.
+func (remote *RemoteDebugger) OnBackForwardCache(cb BackForwardCacheCallback) error {
+	remote.Lock()
+	stop := remote.stopBackForwardCache
+	remote.stopBackForwardCache = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	stop = remote.backForwardCacheHooks(cb)
+
+	remote.Lock()
+	remote.stopBackForwardCache = stop
+	remote.Unlock()
+
+	return remote.ensureDomain("Page")
+}
+
+// backForwardCacheHooks calls cb for the back/forward cache events, until the returned function is called.
+func (remote *RemoteDebugger) backForwardCacheHooks(cb BackForwardCacheCallback) func() {
+	removeRestored := remote.addHook("Page.frameNavigated", func(params Params) bool {
+		frame := Params(params.Map("frame"))
+
+		if params.String("type") == "BackForwardCacheRestore" {
+			cb(BackForwardCacheEvent{
+				FrameID:  frame.String("id"),
+				LoaderID: frame.String("loaderId"),
+				URL:      frame.String("url"),
+				Restored: true,
+			})
+		}
+
+		return false
+	})
+
+	removeNotUsed := remote.addHook("Page.backForwardCacheNotUsed", func(params Params) bool {
+		var ev struct {
+			FrameID  string                   `json:"frameId"`
+			LoaderID string                   `json:"loaderId"`
+			Reasons  []BackForwardCacheReason `json:"notRestoredExplanations"`
+		}
+
+		decodeParams(params, &ev)
+
+		cb(BackForwardCacheEvent{
+			FrameID:  ev.FrameID,
+			LoaderID: ev.LoaderID,
+			Reasons:  ev.Reasons,
+		})
+
+		return false
+	})
+
+	return func() {
+		removeRestored()
+		removeNotUsed()
+	}
+}
+
+// BFCacheAwayURL is the page BFCacheReport navigates to, before going back.
+var BFCacheAwayURL = "chrome://terms"
+
+// BFCacheReport is the result of a back/forward cache test (see BFCacheReport).
+type BFCacheReport struct {
+	URL string `json:"url"`
+
+	// Restored is true if the page was restored from the back/forward cache.
+	Restored bool `json:"restored"`
+
+	// Reasons lists why the page couldn't be cached (if Restored is false).
+	Reasons []BackForwardCacheReason `json:"reasons"`
+}
+
+// Actionable returns the reasons that the page can fix (of type PageSupportNeeded).
+func (r *BFCacheReport) Actionable() []BackForwardCacheReason {
+	var reasons []BackForwardCacheReason
+
+	for _, reason := range r.Reasons {
+		if reason.Type == "PageSupportNeeded" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	return reasons
+}
+
+// BFCacheReport tests whether the current page can be served by the back/forward cache,
+// as the DevTools Application panel does: it navigates to BFCacheAwayURL, goes back and
+// returns the reasons that prevented the page from being restored (if any).
+//
+// ErrorTimeout is returned if the back navigation doesn't complete within the timeout.
+func (remote *RemoteDebugger) BFCacheReport(timeout time.Duration) (*BFCacheReport, error) {
+	current, entries, err := remote.GetNavigationHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	if current < 0 || current >= len(entries) {
+		return nil, errors.New("no navigation history")
+	}
+
+	entry := entries[current]
+
+	if _, err := remote.NavigateAndWait(BFCacheAwayURL, timeout); err != nil {
+		return nil, err
+	}
+
+	events := make(chan BackForwardCacheEvent, 1)
+
+	stop := remote.backForwardCacheHooks(func(ev BackForwardCacheEvent) {
+		remote.Lock()
+		mainFrame := ev.FrameID == remote.current
+		remote.Unlock()
+
+		if !mainFrame && ev.FrameID != "" {
+			return
+		}
+
+		select {
+		case events <- ev:
+		default:
+		}
+	})
+
+	defer stop()
+
+	if _, err := remote.SendRequest("Page.navigateToHistoryEntry", Params{
+		"entryId": entry.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case ev := <-events:
+		return &BFCacheReport{URL: entry.URL, Restored: ev.Restored, Reasons: ev.Reasons}, nil
+
+	case <-time.After(timeout):
+		return nil, ErrorTimeout
+	}
+}
@@ -0,0 +1,196 @@
+package godet
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkStatus is the result of checking a link (see CheckLinks).
+type LinkStatus struct {
+	URL string
+
+	// Status is the final HTTP status (0 if the request failed, see Error).
+	Status int
+
+	// Redirects lists the URLs the link redirected to, in order. The last one is the final URL.
+	Redirects []string
+
+	// MixedContent is true if the page is loaded over https and the link (or a redirect) is http.
+	MixedContent bool
+
+	Error string
+}
+
+// Broken returns true if the link couldn't be loaded or returned an error status.
+func (ls *LinkStatus) Broken() bool {
+	return ls.Error != "" || ls.Status >= 400
+}
+
+// linkChecker holds the CheckLinks options.
+type linkChecker struct {
+	concurrency int
+	timeout     time.Duration
+	sameOrigin  bool
+	client      *http.Client
+}
+
+// LinkOption defines the functional options for CheckLinks.
+type LinkOption func(lc *linkChecker)
+
+// LinkConcurrency sets the maximum number of links checked in parallel (default 4).
+func LinkConcurrency(n int) LinkOption {
+	return func(lc *linkChecker) {
+		lc.concurrency = n
+	}
+}
+
+// LinkTimeout sets the timeout for each link (default 10 seconds).
+func LinkTimeout(timeout time.Duration) LinkOption {
+	return func(lc *linkChecker) {
+		lc.timeout = timeout
+	}
+}
+
+// LinkSameOrigin only checks the links with the same origin of the page.
+func LinkSameOrigin() LinkOption {
+	return func(lc *linkChecker) {
+		lc.sameOrigin = true
+	}
+}
+
+// LinkClient sets the HTTP client used to check the links (i.e. to set a proxy or cookies).
+// The client CheckRedirect function is replaced to record the redirects.
+func LinkClient(client *http.Client) LinkOption {
+	return func(lc *linkChecker) {
+		lc.client = client
+	}
+}
+
+// check requests the link with HEAD, falling back to GET if HEAD is not supported.
+func (lc *linkChecker) check(link string, https bool) LinkStatus {
+	status := LinkStatus{URL: link}
+
+	client := *lc.client
+	client.Timeout = lc.timeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return http.ErrUseLastResponse
+		}
+
+		status.Redirects = append(status.Redirects, req.URL.String())
+		return nil
+	}
+
+	for _, method := range []string{"HEAD", "GET"} {
+		status.Redirects = nil
+
+		req, err := http.NewRequest(method, link, nil)
+		if err != nil {
+			status.Error = err.Error()
+			break
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			status.Error = err.Error()
+			break
+		}
+
+		resp.Body.Close()
+		status.Status, status.Error = resp.StatusCode, ""
+
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if https {
+		for _, u := range append([]string{link}, status.Redirects...) {
+			if strings.HasPrefix(u, "http:") {
+				status.MixedContent = true
+			}
+		}
+	}
+
+	return status
+}
+
+// CheckLinks collects the http(s) links (anchors) in the current page and checks them, reporting
+// the status code, the redirects and mixed content (http links in an https page).
+//
+// The links are requested from the client (not the browser), with HEAD or GET if HEAD is not supported.
+func (remote *RemoteDebugger) CheckLinks(options ...LinkOption) ([]LinkStatus, error) {
+	lc := &linkChecker{
+		concurrency: 4,
+		timeout:     10 * time.Second,
+		client:      http.DefaultClient,
+	}
+
+	for _, opt := range options {
+		opt(lc)
+	}
+
+	if lc.concurrency < 1 {
+		lc.concurrency = 1
+	}
+
+	res, err := remote.EvaluateWrap(`var links = [];
+		document.querySelectorAll("a[href]").forEach(function(a) { links.push(a.href); });
+		return {page: location.href, links: links};`)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := res.(map[string]interface{})
+	page := Params(m).String("page")
+	origin := requestOrigin(page)
+	https := strings.HasPrefix(page, "https:")
+
+	var links []string
+	seen := map[string]bool{}
+
+	list, _ := m["links"].([]interface{})
+	for _, l := range list {
+		link, _ := l.(string)
+
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+
+		u.Fragment = ""
+		link = u.String()
+
+		if seen[link] || (lc.sameOrigin && requestOrigin(link) != origin) {
+			continue
+		}
+
+		seen[link] = true
+		links = append(links, link)
+	}
+
+	results := make([]LinkStatus, len(links))
+	sem := make(chan struct{}, lc.concurrency)
+
+	var wg sync.WaitGroup
+
+	for i, link := range links {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, link string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i] = lc.check(link, https)
+		}(i, link)
+	}
+
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,273 @@
+package godet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LocatorState is the element state a Locator can wait for.
+type LocatorState string
+
+const (
+	LocatorAttached = LocatorState("attached") // the element exists
+	LocatorDetached = LocatorState("detached") // the element doesn't exist
+	LocatorVisible  = LocatorState("visible")  // the element exists and has a non-empty box, and is not hidden
+	LocatorHidden   = LocatorState("hidden")   // the element doesn't exist or it's not visible
+)
+
+// locatorStateJS returns the state of the element the expression evaluates to. The element is stable
+// if its bounding box doesn't change across two animation frames (i.e. it's not animating).
+const locatorStateJS = `(async function() {
+	var el = %v;
+	if (!el) return {attached: false};
+
+	var r1 = el.getBoundingClientRect();
+	await Promise.race([
+		new Promise(function(r) { requestAnimationFrame(function() { requestAnimationFrame(r); }); }),
+		new Promise(function(r) { setTimeout(r, 100); })
+	]);
+	if (!el.isConnected) return {attached: false};
+
+	var r2 = el.getBoundingClientRect();
+	var style = getComputedStyle(el);
+
+	return {
+		attached: true,
+		visible: r2.width > 0 && r2.height > 0 && style.visibility !== "hidden" && style.display !== "none",
+		stable: r1.x === r2.x && r1.y === r2.y && r1.width === r2.width && r1.height === r2.height,
+		enabled: !el.disabled && !el.closest("fieldset[disabled]")
+	};
+})()`
+
+// textElementJS returns the innermost element whose text contains the given text.
+const textElementJS = `(function(text) {
+	var found = null;
+	var walk = function(el) {
+		for (var c = el.firstElementChild; c; c = c.nextElementSibling) {
+			if (c.textContent.indexOf(text) >= 0) { found = c; walk(c); return; }
+		}
+	};
+	if (document.body && document.body.textContent.indexOf(text) >= 0) { found = document.body; walk(document.body); }
+	return found;
+})(%s)`
+
+// Locator finds an element in the page when an action is performed on it, waiting for
+// the element to be actionable. See Page.Locator.
+type Locator struct {
+	page     *Page
+	selector string
+	expr     string
+
+	// Timeout is how long the actions wait for the element (default Page.Timeout).
+	Timeout time.Duration
+}
+
+// Locator returns a Locator for the selector, that is a CSS selector (optionally prefixed by "css=",
+// see also DeepSelectorSeparator), an XPath expression (prefixed by "xpath=" or starting with "//")
+// or a text contained in the element (prefixed by "text=").
+//
+// The actions (Click, Fill, Text...) wait for the element to be attached, visible, stable and enabled as needed,
+// so no explicit waits are required.
+func (p *Page) Locator(selector string) *Locator {
+	l := &Locator{page: p, selector: selector, Timeout: p.Timeout}
+
+	switch {
+	case strings.HasPrefix(selector, "xpath="), strings.HasPrefix(selector, "//"):
+		l.expr = fmt.Sprintf("document.evaluate(%s, document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue",
+			jsString(strings.TrimPrefix(selector, "xpath=")))
+
+	case strings.HasPrefix(selector, "text="):
+		l.expr = fmt.Sprintf(textElementJS, jsString(strings.TrimPrefix(selector, "text=")))
+
+	default:
+		l.expr = elementExpression(strings.TrimPrefix(selector, "css="))
+	}
+
+	return l
+}
+
+// Selector returns the selector of the locator.
+func (l *Locator) Selector() string {
+	return l.selector
+}
+
+// WithTimeout returns a copy of the locator with a different timeout.
+func (l *Locator) WithTimeout(timeout time.Duration) *Locator {
+	c := *l
+	c.Timeout = timeout
+	return &c
+}
+
+// elementState is the state returned by locatorStateJS.
+type elementState struct {
+	Attached bool `json:"attached"`
+	Visible  bool `json:"visible"`
+	Stable   bool `json:"stable"`
+	Enabled  bool `json:"enabled"`
+}
+
+func (l *Locator) state() (*elementState, error) {
+	res, err := l.page.remote.Evaluate(fmt.Sprintf(locatorStateJS, l.expr), AwaitPromise(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var st elementState
+	if err := decodeParams(res, &st); err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+// wait polls the element state until check returns true or the timeout expires.
+func (l *Locator) wait(what string, check func(st *elementState) bool) error {
+	deadline := time.Now().Add(l.Timeout)
+
+	for {
+		st, err := l.state()
+		if err != nil {
+			return err
+		}
+
+		if check(st) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if !st.Attached {
+				if l.Timeout <= 0 { // a probe (i.e. polled by expect), skip the suggestions
+					return fmt.Errorf("%v: %v", l.selector, ErrorNoSuchNode)
+				}
+
+				return l.page.remote.selectorError(l.selector)
+			}
+
+			return fmt.Errorf("%v: timeout waiting for element to be %v", l.selector, what)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// WaitFor waits for the element to reach the state.
+func (l *Locator) WaitFor(state LocatorState) error {
+	return l.page.remote.traceStep("wait", l.selector, string(state), func() error {
+		return l.waitFor(state)
+	})
+}
+
+func (l *Locator) waitFor(state LocatorState) error {
+	return l.wait(string(state), func(st *elementState) bool {
+		switch state {
+		case LocatorAttached:
+			return st.Attached
+		case LocatorDetached:
+			return !st.Attached
+		case LocatorHidden:
+			return !st.Attached || !st.Visible
+		default:
+			return st.Attached && st.Visible
+		}
+	})
+}
+
+// waitActionable waits for the element to be visible, stable and enabled.
+func (l *Locator) waitActionable() error {
+	return l.wait("visible, stable and enabled", func(st *elementState) bool {
+		return st.Attached && st.Visible && st.Stable && st.Enabled
+	})
+}
+
+// Click waits for the element to be actionable, scrolls it into view and clicks on it.
+func (l *Locator) Click() error {
+	return l.page.remote.traceStep("click", l.selector, "", func() error {
+		if err := l.waitActionable(); err != nil {
+			return err
+		}
+
+		id, err := l.page.remote.expressionNode(l.expr)
+		if err != nil {
+			return err
+		}
+
+		return l.page.remote.clickNode(id)
+	})
+}
+
+// Fill waits for the element to be actionable and sets its value (see RemoteDebugger.Fill).
+func (l *Locator) Fill(value string) error {
+	return l.page.remote.traceInput("fill", l.selector, l.expr, value, func() error {
+		if err := l.waitActionable(); err != nil {
+			return err
+		}
+
+		return l.page.remote.fillElement(l.expr, value)
+	})
+}
+
+// Text waits for the element to be attached and returns its text content.
+func (l *Locator) Text() (string, error) {
+	if err := l.waitFor(LocatorAttached); err != nil {
+		return "", err
+	}
+
+	res, err := l.page.remote.evaluateElement(l.expr, "return el.textContent;")
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := res.(string)
+	return s, nil
+}
+
+// InnerText waits for the element to be visible and returns its rendered text.
+func (l *Locator) InnerText() (string, error) {
+	if err := l.waitFor(LocatorVisible); err != nil {
+		return "", err
+	}
+
+	res, err := l.page.remote.evaluateElement(l.expr, "return el.innerText;")
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := res.(string)
+	return s, nil
+}
+
+// Attribute waits for the element to be attached and returns the attribute value (and if it's set).
+func (l *Locator) Attribute(name string) (string, bool, error) {
+	if err := l.waitFor(LocatorAttached); err != nil {
+		return "", false, err
+	}
+
+	res, err := l.page.remote.evaluateElement(l.expr, fmt.Sprintf("return el.getAttribute(%s);", jsString(name)))
+	if err != nil {
+		return "", false, err
+	}
+
+	s, ok := res.(string)
+	return s, ok, nil
+}
+
+// IsVisible returns true if the element exists and is visible, without waiting.
+func (l *Locator) IsVisible() (bool, error) {
+	st, err := l.state()
+	if err != nil {
+		return false, err
+	}
+
+	return st.Attached && st.Visible, nil
+}
+
+// IsAttached returns true if the element exists, without waiting.
+func (l *Locator) IsAttached() (bool, error) {
+	st, err := l.state()
+	if err != nil {
+		return false, err
+	}
+
+	return st.Attached, nil
+}
@@ -0,0 +1,128 @@
+package godet
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocatorExpression(t *testing.T) {
+	p := &Page{}
+
+	tests := []struct {
+		selector string
+		expr     string
+	}{
+		{"button.ok", `document.querySelector("button.ok")`},
+		{"css=a[title=\"\U0001F600\"]", `document.querySelector("a[title=\"😀\"]")`},
+		{"//div[@id='x']", `document.evaluate("//div[@id='x']", document`},
+		{"xpath=//p", `document.evaluate("//p", document`},
+		{"text=Sign in \u2028", `})("Sign in \u2028")`}, // a line terminator in Javascript strings
+	}
+
+	for _, tt := range tests {
+		if l := p.Locator(tt.selector); !strings.Contains(l.expr, tt.expr) {
+			t.Errorf("Locator(%q) expression = %s, want %s", tt.selector, l.expr, tt.expr)
+		}
+	}
+}
+
+// locatorBrowser answers the element state evaluations with the states in order (the last one repeated),
+// and the element evaluations with value.
+type locatorBrowser struct {
+	sync.Mutex
+	states []elementState
+	value  interface{}
+	checks int
+	exprs  []string
+}
+
+func (b *locatorBrowser) reply(method string, params Params) (interface{}, *ProtocolError) {
+	if method != "Runtime.evaluate" {
+		return nil, nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	expr := params.String("expression")
+	b.exprs = append(b.exprs, expr)
+
+	if strings.Contains(expr, "notFound") {
+		return Params{"result": Params{"type": "object", "value": Params{"value": b.value}}}, nil
+	}
+
+	st := b.states[len(b.states)-1]
+	if b.checks < len(b.states) {
+		st = b.states[b.checks]
+	}
+	b.checks++
+
+	return Params{"result": Params{"type": "object", "value": st}}, nil
+}
+
+func (b *locatorBrowser) count() int {
+	b.Lock()
+	defer b.Unlock()
+	return b.checks
+}
+
+func TestLocatorWait(t *testing.T) {
+	b := &locatorBrowser{states: []elementState{
+		{},
+		{Attached: true},
+		{Attached: true, Visible: true},
+	}}
+
+	page := NewPage(connectFake(t, fakeCDP(t, b.reply)))
+	page.Timeout = time.Second
+
+	if err := page.Locator("#x").WaitFor(LocatorVisible); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.count(); n != 3 {
+		t.Errorf("state checked %d times, want 3", n)
+	}
+}
+
+func TestLocatorTimeout(t *testing.T) {
+	b := &locatorBrowser{states: []elementState{{Attached: true, Visible: true, Stable: true}}}
+	page := NewPage(connectFake(t, fakeCDP(t, b.reply)))
+
+	err := page.Locator("#disabled").WithTimeout(100 * time.Millisecond).Click()
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for element to be visible, stable and enabled") {
+		t.Errorf("Click on a disabled element = %v", err)
+	}
+
+	b.states = []elementState{{}}
+
+	err = page.Locator("#missing").WithTimeout(0).WaitFor(LocatorAttached)
+	if err == nil || !strings.Contains(err.Error(), ErrorNoSuchNode.Error()) {
+		t.Errorf("WaitFor a missing element = %v", err)
+	}
+
+	if err := page.Locator("#missing").WithTimeout(0).WaitFor(LocatorDetached); err != nil {
+		t.Errorf("WaitFor detached = %v", err)
+	}
+}
+
+func TestLocatorAttribute(t *testing.T) {
+	b := &locatorBrowser{states: []elementState{{Attached: true}}, value: "submit"}
+	page := NewPage(connectFake(t, fakeCDP(t, b.reply)))
+
+	value, ok, err := page.Locator("button").Attribute("data-\U0001F600")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "submit" || !ok {
+		t.Errorf("Attribute = %q, %v", value, ok)
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if last := b.exprs[len(b.exprs)-1]; !strings.Contains(last, `el.getAttribute("data-😀")`) {
+		t.Errorf("attribute expression = %s", last)
+	}
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// labelValue escapes a Prometheus label value.
+func labelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// WriteMetrics writes the metrics of the monitors in the Prometheus text exposition format.
+func WriteMetrics(w io.Writer, monitors ...*Monitor) {
+	type metric struct {
+		name, help, typ string
+		value           func(m *Monitor, st Status) string
+	}
+
+	metrics := []metric{
+		{"godet_monitor_up", "Whether the last check passed.", "gauge",
+			func(m *Monitor, st Status) string { return fmt.Sprint(boolValue(st.Up)) }},
+		{"godet_monitor_checks_total", "Number of checks executed.", "counter",
+			func(m *Monitor, st Status) string { return fmt.Sprint(st.Checks) }},
+		{"godet_monitor_failures_total", "Number of failed checks.", "counter",
+			func(m *Monitor, st Status) string { return fmt.Sprint(st.Failures) }},
+		{"godet_monitor_uptime_ratio", "Ratio of successful checks.", "gauge",
+			func(m *Monitor, st Status) string { return fmt.Sprint(st.Uptime) }},
+	}
+
+	statuses := make([]Status, len(monitors))
+	for i, m := range monitors {
+		statuses[i] = m.Status()
+	}
+
+	for _, metric := range metrics {
+		fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", metric.name, metric.help, metric.name, metric.typ)
+
+		for i, m := range monitors {
+			fmt.Fprintf(w, "%v{monitor=\"%v\"} %v\n", metric.name, labelValue(m.Name), metric.value(m, statuses[i]))
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP godet_monitor_load_seconds Load time of the successful checks.\n# TYPE godet_monitor_load_seconds summary\n")
+
+	for i, m := range monitors {
+		st := statuses[i]
+		name := labelValue(m.Name)
+
+		for _, q := range []struct {
+			quantile, key string
+		}{{"0.5", "p50"}, {"0.9", "p90"}, {"0.99", "p99"}} {
+			fmt.Fprintf(w, "godet_monitor_load_seconds{monitor=\"%v\",quantile=\"%v\"} %v\n", name, q.quantile, st.Percentiles[q.key])
+		}
+
+		m.Lock()
+		sum := m.sumTime.Seconds()
+		m.Unlock()
+
+		fmt.Fprintf(w, "godet_monitor_load_seconds_sum{monitor=\"%v\"} %v\n", name, sum)
+		fmt.Fprintf(w, "godet_monitor_load_seconds_count{monitor=\"%v\"} %v\n", name, st.Checks-st.Failures)
+	}
+}
+
+// Handler returns an HTTP handler exposing the monitors metrics (GET /metrics)
+// and their status as JSON (GET /status).
+func Handler(monitors ...*Monitor) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		WriteMetrics(w, monitors...)
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		statuses := make([]Status, len(monitors))
+		for i, m := range monitors {
+			statuses[i] = m.Status()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statuses)
+	})
+
+	return mux
+}
@@ -0,0 +1,281 @@
+// Package monitor implements synthetic monitoring: a scenario is executed repeatedly on an interval,
+// recording the uptime, the load time percentiles and the result of content assertions,
+// that are exposed as Prometheus metrics and as a JSON status (see Handler).
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raff/godet"
+	"github.com/raff/godet/notify"
+	"github.com/raff/godet/runner"
+	"github.com/raff/godet/schedule"
+)
+
+// DefaultHistory is the default number of checks kept to compute the load time percentiles.
+var DefaultHistory = 100
+
+// Assertion checks the page content at the end of the scenario: an element matching Selector
+// must exist and (if Text is set) contain Text.
+type Assertion struct {
+	Selector string `json:"selector"`
+	Text     string `json:"text,omitempty"`
+}
+
+// ReadAssertions reads a JSON list of assertions.
+func ReadAssertions(r io.Reader) ([]Assertion, error) {
+	var assertions []Assertion
+	err := json.NewDecoder(r).Decode(&assertions)
+	return assertions, err
+}
+
+// CheckResult is the result of an execution of the scenario.
+type CheckResult struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Status is the current status of a monitor.
+type Status struct {
+	Name     string `json:"name"`
+	Up       bool   `json:"up"`
+	Checks   int    `json:"checks"`
+	Failures int    `json:"failures"`
+
+	// Uptime is the ratio of successful checks (0-1).
+	Uptime float64 `json:"uptime"`
+
+	// Percentiles maps "p50", "p90", "p99" to the load time (in seconds) of the recent successful checks.
+	Percentiles map[string]float64 `json:"percentiles"`
+
+	Last *CheckResult `json:"last,omitempty"`
+}
+
+// Monitor executes a scenario repeatedly, each time in a new browser context.
+type Monitor struct {
+	Name     string
+	Scenario runner.Scenario
+
+	// Remote is the connection to the browser, used to create the browser contexts.
+	Remote *godet.RemoteDebugger
+
+	Assertions []Assertion
+
+	// Interval is the time between the start of two checks.
+	Interval time.Duration
+
+	// Timeout is the timeout of each action and assertion.
+	Timeout time.Duration
+
+	// History is the number of checks kept to compute the load time percentiles (default DefaultHistory).
+	History int
+
+	// Notifier, if set, is notified of the failed checks and of the first successful check after a failure.
+	Notifier notify.Notifier
+
+	sync.Mutex
+	checks    int
+	failures  int
+	durations []time.Duration
+	last      *CheckResult
+	sumTime   time.Duration
+}
+
+// New returns a monitor for the scenario, running every interval.
+func New(remote *godet.RemoteDebugger, scenario runner.Scenario, interval time.Duration) *Monitor {
+	return &Monitor{
+		Name:     scenario.Name,
+		Scenario: scenario,
+		Remote:   remote,
+		Interval: interval,
+		Timeout:  30 * time.Second,
+		History:  DefaultHistory,
+	}
+}
+
+// Run executes the checks until the context is done.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Check executes the scenario and the assertions once, in a new browser context, recording the result.
+func (m *Monitor) Check(ctx context.Context) CheckResult {
+	return m.record(ctx, m.check)
+}
+
+// Job returns a scheduled job executing the check, so that the monitor can run on a cron schedule
+// (see schedule.Scheduler) instead of an interval.
+func (m *Monitor) Job() schedule.Job {
+	return func(ctx context.Context, remote *godet.RemoteDebugger) error {
+		res := m.record(ctx, func() error { return m.checkTab(remote) })
+		if !res.Passed {
+			return errors.New(res.Error)
+		}
+
+		return nil
+	}
+}
+
+// record executes the check, recording the result.
+func (m *Monitor) record(ctx context.Context, check func() error) CheckResult {
+	res := CheckResult{Time: time.Now()}
+
+	err := check()
+	res.Duration = time.Since(res.Time)
+	res.Passed = err == nil
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	m.Lock()
+	wasUp := m.last == nil || m.last.Passed
+	m.checks++
+	if !res.Passed {
+		m.failures++
+	} else {
+		m.durations = append(m.durations, res.Duration)
+		if history := m.history(); len(m.durations) > history {
+			m.durations = m.durations[len(m.durations)-history:]
+		}
+		m.sumTime += res.Duration
+	}
+	m.last = &res
+	m.Unlock()
+
+	if m.Notifier != nil && (!res.Passed || !wasUp) {
+		ev := notify.Event{
+			Type:     notify.ScenarioCompleted,
+			Time:     res.Time,
+			Name:     m.Name,
+			Passed:   res.Passed,
+			Error:    res.Error,
+			Duration: res.Duration.Seconds(),
+		}
+
+		if err := m.Notifier.Notify(ctx, ev); err != nil {
+			log.Println("notify", m.Name, err)
+		}
+	}
+
+	return res
+}
+
+func (m *Monitor) history() int {
+	if m.History > 0 {
+		return m.History
+	}
+
+	return DefaultHistory
+}
+
+// check runs the scenario in a new browser context and verifies the assertions.
+func (m *Monitor) check() error {
+	bc, err := m.Remote.NewBrowserContext()
+	if err != nil {
+		return err
+	}
+
+	defer bc.Close()
+
+	remote, err := bc.NewTab("")
+	if err != nil {
+		return err
+	}
+
+	defer remote.Close()
+	return m.checkTab(remote)
+}
+
+// checkTab runs the scenario in the tab and verifies the assertions.
+func (m *Monitor) checkTab(remote *godet.RemoteDebugger) error {
+	if err := remote.ReplayActions(m.Scenario.Actions, m.Timeout); err != nil {
+		return err
+	}
+
+	page := godet.NewPage(remote)
+	page.Timeout = m.Timeout
+
+	for _, a := range m.Assertions {
+		text, err := page.Locator(a.Selector).Text()
+		if err != nil {
+			return err
+		}
+
+		if !strings.Contains(text, a.Text) {
+			return fmt.Errorf("%v: expected text %q", a.Selector, a.Text)
+		}
+	}
+
+	return nil
+}
+
+// percentile returns the p-th percentile of the sorted durations, in seconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(p*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i].Seconds()
+}
+
+// Status returns the current status of the monitor.
+func (m *Monitor) Status() Status {
+	m.Lock()
+	defer m.Unlock()
+
+	st := Status{
+		Name:        m.Name,
+		Up:          m.last != nil && m.last.Passed,
+		Checks:      m.checks,
+		Failures:    m.failures,
+		Percentiles: map[string]float64{},
+	}
+
+	if m.checks > 0 {
+		st.Uptime = float64(m.checks-m.failures) / float64(m.checks)
+	}
+
+	if m.last != nil {
+		last := *m.last
+		st.Last = &last
+	}
+
+	sorted := append([]time.Duration(nil), m.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	st.Percentiles["p50"] = percentile(sorted, 0.5)
+	st.Percentiles["p90"] = percentile(sorted, 0.9)
+	st.Percentiles["p99"] = percentile(sorted, 0.99)
+
+	return st
+}
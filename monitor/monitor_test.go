@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raff/godet/notify"
+	"github.com/raff/godet/runner"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Second)
+	}
+
+	for _, tt := range []struct {
+		p    float64
+		want float64
+	}{{0.5, 5}, {0.9, 9}, {0.99, 10}, {0, 1}} {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile of no durations = %v", got)
+	}
+}
+
+func TestMonitorRecord(t *testing.T) {
+	var events []string
+
+	m := New(nil, runner.Scenario{Name: `home "eu"`}, time.Minute)
+	m.History = 2
+	m.Notifier = notify.NotifierFunc(func(ctx context.Context, ev notify.Event) error {
+		events = append(events, fmt.Sprintf("%v passed=%v %v", ev.Name, ev.Passed, ev.Error))
+		return nil
+	})
+
+	ctx := context.Background()
+
+	for _, err := range []error{nil, errors.New("timeout"), errors.New("timeout"), nil, nil, nil} {
+		m.record(ctx, func() error { return err })
+	}
+
+	// only the failures and the recovery are notified
+	want := []string{`home "eu" passed=false timeout`, `home "eu" passed=false timeout`, `home "eu" passed=true `}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+
+	st := m.Status()
+	if st.Name != `home "eu"` || !st.Up || st.Checks != 6 || st.Failures != 2 || st.Uptime != 4.0/6 || st.Last == nil || !st.Last.Passed {
+		t.Errorf("status = %+v", st)
+	}
+
+	m.Lock()
+	kept := len(m.durations)
+	m.Unlock()
+
+	if kept != 2 {
+		t.Errorf("%d durations kept, want the History (2)", kept)
+	}
+
+	w := httptest.NewRecorder()
+	Handler(m).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, line := range []string{
+		"# TYPE godet_monitor_up gauge",
+		`godet_monitor_up{monitor="home \"eu\""} 1`,
+		`godet_monitor_checks_total{monitor="home \"eu\""} 6`,
+		`godet_monitor_failures_total{monitor="home \"eu\""} 2`,
+		`godet_monitor_load_seconds_count{monitor="home \"eu\""} 4`,
+		`godet_monitor_load_seconds{monitor="home \"eu\"",quantile="0.99"} `,
+	} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("metrics don't contain %q:\n%s", line, w.Body)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	Handler(m).ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+
+	var statuses []Status
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Checks != 6 || statuses[0].Failures != 2 {
+		t.Errorf("statuses = %+v", statuses)
+	}
+}
@@ -0,0 +1,248 @@
+package godet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NavigationType is the type of a navigation, as reported by the Navigation Timing API.
+type NavigationType string
+
+const (
+	NavigationNavigate    = NavigationType("navigate")
+	NavigationReload      = NavigationType("reload")
+	NavigationBackForward = NavigationType("back_forward")
+	NavigationPrerender   = NavigationType("prerender")
+)
+
+// NavigationInfo describes the last top-level navigation (see LastNavigation).
+type NavigationInfo struct {
+	Type NavigationType
+	URL  string
+
+	// FromCache is true if the page was restored from the back/forward cache.
+	// It is only reported if TrackNavigations is enabled.
+	FromCache bool
+
+	// Navigation timing, relative to the start of the navigation.
+	// For pages restored from the back/forward cache they refer to the original load.
+	DOMContentLoaded time.Duration
+	Load             time.Duration
+	Duration         time.Duration
+}
+
+// navigationRecord is the last top-level Page.frameNavigated event.
+type navigationRecord struct {
+	url      string
+	restored bool
+}
+
+// lastNavigationJS returns the navigation timing entry for the current document.
+const lastNavigationJS = `var nav = performance.getEntriesByType("navigation")[0];
+	if (!nav) return {type: "navigate", url: location.href};
+	return {
+		type: nav.type,
+		url: location.href,
+		domContentLoaded: nav.domContentLoadedEventEnd,
+		load: nav.loadEventEnd,
+		duration: nav.duration
+	};`
+
+// TrackNavigations records the top-level Page.frameNavigated events, so that LastNavigation can
+// report the pages restored from the back/forward cache. Page events are enabled, if needed.
+func (remote *RemoteDebugger) TrackNavigations(enable bool) error {
+	remote.Lock()
+	stop := remote.stopNavigations
+	remote.stopNavigations = nil
+	remote.lastNavigation = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if !enable {
+		return nil
+	}
+
+	stop = remote.addHook("Page.frameNavigated", func(params Params) bool {
+		frame := Params(params.Map("frame"))
+		if frame.String("parentId") != "" {
+			return false
+		}
+
+		remote.Lock()
+		remote.lastNavigation = &navigationRecord{
+			url:      frame.String("url"),
+			restored: params.String("type") == "BackForwardCacheRestore",
+		}
+		remote.Unlock()
+		return false
+	})
+
+	remote.Lock()
+	remote.stopNavigations = stop
+	remote.Unlock()
+
+	return remote.ensureDomain("Page")
+}
+
+// LastNavigation returns the type and timing of the navigation that loaded the current page.
+func (remote *RemoteDebugger) LastNavigation() (*NavigationInfo, error) {
+	res, err := remote.EvaluateWrap(lastNavigationJS)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := res.(map[string]interface{})
+	nav := Params(m)
+
+	ms := func(k string) time.Duration {
+		v, _ := nav[k].(float64)
+		return time.Duration(v * float64(time.Millisecond))
+	}
+
+	info := &NavigationInfo{
+		Type:             NavigationType(nav.String("type")),
+		URL:              nav.String("url"),
+		DOMContentLoaded: ms("domContentLoaded"),
+		Load:             ms("load"),
+		Duration:         ms("duration"),
+	}
+
+	remote.Lock()
+	last := remote.lastNavigation
+	remote.Unlock()
+
+	if last != nil && last.restored && last.url == info.URL {
+		// the navigation entry still refers to the original load
+		info.Type = NavigationBackForward
+		info.FromCache = true
+	}
+
+	return info, nil
+}
+
+// LastNavigationType returns the type of the navigation that loaded the current page
+// (navigate, reload, back_forward or prerender).
+func (remote *RemoteDebugger) LastNavigationType() (NavigationType, error) {
+	info, err := remote.LastNavigation()
+	if err != nil {
+		return "", err
+	}
+
+	return info.Type, nil
+}
+
+// NavigationResult is the result of NavigateAndWait: the main document response.
+type NavigationResult struct {
+	FrameID  string
+	LoaderID string
+
+	// URL is the final URL, after the redirects.
+	URL string
+
+	// Status is the HTTP status of the main document (0 if not known, i.e. for same-document navigations).
+	Status     int
+	StatusText string
+	MimeType   string
+	Headers    map[string]string
+
+	// FromCache is true if the document came from the disk or prefetch cache.
+	FromCache         bool
+	FromServiceWorker bool
+}
+
+// NavigateAndWait navigates to the URL and waits for the page load event, up to timeout.
+// It returns the main document response (correlated with the navigation via the Network events),
+// so that i.e. a 404 page can be told from a 200. On timeout, the result so far is returned with ErrorTimeout.
+//
+// If a navigation rate limiter is set (see NavigationLimit) it waits for the origin to be available
+// before navigating (the wait counts towards the timeout). Page and Network events are enabled, if needed.
+func (remote *RemoteDebugger) NavigateAndWait(url string, timeout time.Duration) (*NavigationResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := remote.WaitNavigationLimit(ctx, url); err != nil {
+		return nil, ErrorTimeout
+	}
+
+	for _, domain := range []string{"Page", "Network"} {
+		if err := remote.ensureDomain(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded := make(chan struct{}, 1)
+
+	removeHook := remote.addHook("Page.loadEventFired", func(params Params) bool {
+		select {
+		case loaded <- struct{}{}:
+		default:
+		}
+		return false
+	})
+
+	defer removeHook()
+
+	// the document response can be received before the Page.navigate reply (that has the loaderId)
+	var lock sync.Mutex
+	documents := map[string]map[string]interface{}{}
+
+	removeResponse := remote.addHook("Network.responseReceived", func(params Params) bool {
+		if params.String("type") == string(ResourceTypeDocument) {
+			lock.Lock()
+			documents[params.String("loaderId")] = params.Map("response")
+			lock.Unlock()
+		}
+		return false
+	})
+
+	defer removeResponse()
+
+	res, err := remote.SendRequest("Page.navigate", Params{
+		"url": url,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if errorText, ok := res["errorText"].(string); ok && errorText != "" {
+		return nil, NavigationError(errorText)
+	}
+
+	result := &NavigationResult{
+		FrameID:  Params(res).String("frameId"),
+		LoaderID: Params(res).String("loaderId"),
+		URL:      url,
+	}
+
+	if result.LoaderID == "" {
+		return result, nil // same-document navigation, there is no load event
+	}
+
+	var done error
+
+	select {
+	case <-loaded:
+	case <-ctx.Done():
+		done = ErrorTimeout
+	}
+
+	lock.Lock()
+	response := Params(documents[result.LoaderID])
+	lock.Unlock()
+
+	if response != nil {
+		result.URL = response.String("url")
+		result.Status = response.Int("status")
+		result.StatusText = response.String("statusText")
+		result.MimeType = response.String("mimeType")
+		result.Headers = headerMap(response.Map("headers"))
+		result.FromCache = response.Bool("fromDiskCache") || response.Bool("fromPrefetchCache")
+		result.FromServiceWorker = response.Bool("fromServiceWorker")
+	}
+
+	return result, done
+}
@@ -0,0 +1,139 @@
+package godet
+
+import (
+	"strings"
+)
+
+// NetError is a Chrome network error code, as reported in the errorText of Page.navigate
+// and Network.loadingFailed (i.e. "net::ERR_NAME_NOT_RESOLVED").
+type NetError string
+
+const (
+	NetErrorNone                  = NetError("")
+	NetErrorAborted               = NetError("net::ERR_ABORTED")
+	NetErrorFailed                = NetError("net::ERR_FAILED")
+	NetErrorTimedOut              = NetError("net::ERR_TIMED_OUT")
+	NetErrorNameNotResolved       = NetError("net::ERR_NAME_NOT_RESOLVED")
+	NetErrorNameResolutionFailed  = NetError("net::ERR_NAME_RESOLUTION_FAILED")
+	NetErrorConnectionRefused     = NetError("net::ERR_CONNECTION_REFUSED")
+	NetErrorConnectionReset       = NetError("net::ERR_CONNECTION_RESET")
+	NetErrorConnectionClosed      = NetError("net::ERR_CONNECTION_CLOSED")
+	NetErrorConnectionTimedOut    = NetError("net::ERR_CONNECTION_TIMED_OUT")
+	NetErrorAddressUnreachable    = NetError("net::ERR_ADDRESS_UNREACHABLE")
+	NetErrorInternetDisconnected  = NetError("net::ERR_INTERNET_DISCONNECTED")
+	NetErrorEmptyResponse         = NetError("net::ERR_EMPTY_RESPONSE")
+	NetErrorCertAuthorityInvalid  = NetError("net::ERR_CERT_AUTHORITY_INVALID")
+	NetErrorCertCommonNameInvalid = NetError("net::ERR_CERT_COMMON_NAME_INVALID")
+	NetErrorCertDateInvalid       = NetError("net::ERR_CERT_DATE_INVALID")
+	NetErrorSSLProtocolError      = NetError("net::ERR_SSL_PROTOCOL_ERROR")
+	NetErrorBlockedByClient       = NetError("net::ERR_BLOCKED_BY_CLIENT")
+	NetErrorBlockedByResponse     = NetError("net::ERR_BLOCKED_BY_RESPONSE")
+	NetErrorBlockedByAdmin        = NetError("net::ERR_BLOCKED_BY_ADMINISTRATOR")
+	NetErrorUnsafePort            = NetError("net::ERR_UNSAFE_PORT")
+	NetErrorTooManyRedirects      = NetError("net::ERR_TOO_MANY_REDIRECTS")
+)
+
+// NetErrorCategory is the failure class of a NetError.
+type NetErrorCategory string
+
+const (
+	NetErrorCategoryNone       = NetErrorCategory("")
+	NetErrorCategoryDNS        = NetErrorCategory("dns")
+	NetErrorCategoryTLS        = NetErrorCategory("tls")
+	NetErrorCategoryConnection = NetErrorCategory("connection")
+	NetErrorCategoryBlocked    = NetErrorCategory("blocked")
+	NetErrorCategoryAborted    = NetErrorCategory("aborted")
+	NetErrorCategoryOther      = NetErrorCategory("other")
+)
+
+// netErrorPrefixes maps the error code prefixes to their category. Exact codes are listed before prefixes.
+var netErrorPrefixes = []struct {
+	prefix   string
+	category NetErrorCategory
+}{
+	{"ERR_NAME_NOT_RESOLVED", NetErrorCategoryDNS},
+	{"ERR_NAME_RESOLUTION_FAILED", NetErrorCategoryDNS},
+	{"ERR_DNS_", NetErrorCategoryDNS},
+	{"ERR_ICANN_NAME_COLLISION", NetErrorCategoryDNS},
+
+	{"ERR_CERT_", NetErrorCategoryTLS},
+	{"ERR_SSL_", NetErrorCategoryTLS},
+	{"ERR_BAD_SSL_", NetErrorCategoryTLS},
+	{"ERR_TLS_", NetErrorCategoryTLS},
+	{"ERR_ECH_", NetErrorCategoryTLS},
+
+	{"ERR_BLOCKED_", NetErrorCategoryBlocked},
+	{"ERR_UNSAFE_PORT", NetErrorCategoryBlocked},
+	{"ERR_UNSAFE_REDIRECT", NetErrorCategoryBlocked},
+	{"ERR_ACCESS_DENIED", NetErrorCategoryBlocked},
+	{"ERR_NETWORK_ACCESS_DENIED", NetErrorCategoryBlocked},
+
+	{"ERR_CONNECTION_", NetErrorCategoryConnection},
+	{"ERR_ADDRESS_", NetErrorCategoryConnection},
+	{"ERR_INTERNET_DISCONNECTED", NetErrorCategoryConnection},
+	{"ERR_NETWORK_", NetErrorCategoryConnection},
+	{"ERR_TIMED_OUT", NetErrorCategoryConnection},
+	{"ERR_EMPTY_RESPONSE", NetErrorCategoryConnection},
+	{"ERR_PROXY_", NetErrorCategoryConnection},
+	{"ERR_TUNNEL_CONNECTION_FAILED", NetErrorCategoryConnection},
+	{"ERR_SOCKS_", NetErrorCategoryConnection},
+	{"ERR_HTTP2_", NetErrorCategoryConnection},
+	{"ERR_QUIC_", NetErrorCategoryConnection},
+
+	{"ERR_ABORTED", NetErrorCategoryAborted},
+}
+
+// ParseNetError extracts the network error code from an error text, returning NetErrorNone
+// if the text doesn't contain one.
+func ParseNetError(text string) NetError {
+	i := strings.Index(text, "net::ERR_")
+	if i < 0 {
+		return NetErrorNone
+	}
+
+	text = text[i:]
+	if j := strings.IndexFunc(text[5:], func(c rune) bool {
+		return !(c == '_' || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+	}); j >= 0 {
+		text = text[:j+5]
+	}
+
+	return NetError(text)
+}
+
+// Code returns the error code without the "net::" prefix (i.e. "ERR_NAME_NOT_RESOLVED").
+func (e NetError) Code() string {
+	return strings.TrimPrefix(string(e), "net::")
+}
+
+// Category returns the failure class of the error.
+func (e NetError) Category() NetErrorCategory {
+	if e == NetErrorNone {
+		return NetErrorCategoryNone
+	}
+
+	code := e.Code()
+
+	for _, p := range netErrorPrefixes {
+		if strings.HasPrefix(code, p.prefix) {
+			return p.category
+		}
+	}
+
+	return NetErrorCategoryOther
+}
+
+// NetError returns the network error code of a failed navigation.
+func (err NavigationError) NetError() NetError {
+	return ParseNetError(string(err))
+}
+
+// Category returns the failure class of a failed navigation.
+func (err NavigationError) Category() NetErrorCategory {
+	return err.NetError().Category()
+}
+
+// NetError returns the network error code of a failed request.
+func (r ObservedResponse) NetError() NetError {
+	return ParseNetError(r.ErrorText)
+}
@@ -0,0 +1,95 @@
+package godet
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gobs/httpclient"
+)
+
+// nodeTarget returns the first Node.js target listed by the inspector.
+func (remote *RemoteDebugger) nodeTarget() (*Tab, error) {
+	tabs, err := remote.TabList("")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tabs {
+		if t.Type == "node" && t.WsURL != "" {
+			return t, nil
+		}
+	}
+
+	// older versions don't set the type
+	for _, t := range tabs {
+		if t.WsURL != "" {
+			return t, nil
+		}
+	}
+
+	return nil, ErrorNoActiveTab
+}
+
+// ConnectNode connects to a Node.js inspector (node --inspect), where addr is either the inspector
+// address (host:port, default port 9229) or the websocket URL printed by node ("ws://127.0.0.1:9229/<id>").
+//
+// Node.js exposes the Runtime, Debugger, Profiler and HeapProfiler domains, but no pages: the Runtime,
+// Debugger and Profiler events are enabled, while the Page and DOM methods are not available.
+// If node was started with --inspect-brk, call RunIfWaitingForDebugger to start the script.
+func ConnectNode(addr string, verbose bool, options ...ConnectOption) (*RemoteDebugger, error) {
+	tab := &Tab{Type: "node"}
+
+	if strings.HasPrefix(addr, "ws://") || strings.HasPrefix(addr, "wss://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		tab.WsURL = addr
+		tab.ID = strings.TrimPrefix(u.Path, "/")
+		addr = u.Host
+	} else if !strings.Contains(addr, ":") {
+		addr += ":9229"
+	}
+
+	remote := newRemoteDebugger(httpclient.NewHttpClient("http://"+addr), verbose)
+
+	for _, setOption := range options {
+		setOption(remote)
+	}
+
+	if verbose {
+		httpclient.StartLogging(false, true, false)
+	}
+
+	if tab.WsURL == "" {
+		t, err := remote.nodeTarget()
+		if err != nil {
+			return nil, err
+		}
+
+		tab = t
+	}
+
+	if err := remote.connectWs(tab); err != nil {
+		return nil, err
+	}
+
+	remote.start()
+
+	for _, enable := range []func(bool) error{remote.RuntimeEvents, remote.DebuggerEvents, remote.ProfilerEvents} {
+		if err := enable(true); err != nil {
+			remote.Close()
+			return nil, err
+		}
+	}
+
+	return remote, nil
+}
+
+// RunIfWaitingForDebugger tells a Node.js process started with --inspect-brk
+// (or a target paused on start) to start running.
+func (remote *RemoteDebugger) RunIfWaitingForDebugger() error {
+	_, err := remote.SendRequest("Runtime.runIfWaitingForDebugger", nil)
+	return err
+}
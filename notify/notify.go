@@ -0,0 +1,195 @@
+// Package notify implements the notifications sent when scenarios or monitors complete or fail,
+// with built-in webhook and Slack notifiers.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EventType is the type of a notification event.
+type EventType string
+
+const (
+	// ScenarioCompleted is sent when a scenario (or monitor check) completes, successfully or not.
+	ScenarioCompleted = EventType("scenario")
+	// RunCompleted is sent when all the scenarios of a run completed.
+	RunCompleted = EventType("run")
+)
+
+// Event is the summary payload of a notification.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Name is the name of the scenario (or of the run).
+	Name string `json:"name"`
+
+	Passed   bool    `json:"passed"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"` // in seconds
+
+	// Artifacts is the location of the artifacts (i.e. the results directory).
+	Artifacts string `json:"artifacts,omitempty"`
+
+	// Total and Failed are the number of scenarios in the run (RunCompleted only).
+	Total  int `json:"total,omitempty"`
+	Failed int `json:"failed,omitempty"`
+}
+
+// Text returns a one line description of the event.
+func (ev Event) Text() string {
+	status := "passed"
+	if !ev.Passed {
+		status = "FAILED"
+	}
+
+	var text string
+
+	if ev.Type == RunCompleted {
+		text = fmt.Sprintf("%v %v: %v/%v scenarios failed in %.1fs", ev.Name, status, ev.Failed, ev.Total, ev.Duration)
+	} else {
+		text = fmt.Sprintf("%v %v in %.1fs", ev.Name, status, ev.Duration)
+		if ev.Error != "" {
+			text += ": " + ev.Error
+		}
+	}
+
+	if ev.Artifacts != "" {
+		text += " (" + ev.Artifacts + ")"
+	}
+
+	return text
+}
+
+// Notifier sends the notifications.
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// NotifierFunc is a function implementing Notifier.
+type NotifierFunc func(ctx context.Context, ev Event) error
+
+// Notify calls f.
+func (f NotifierFunc) Notify(ctx context.Context, ev Event) error {
+	return f(ctx, ev)
+}
+
+// OnlyFailures wraps a notifier so that it's only called for the failures.
+func OnlyFailures(n Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, ev Event) error {
+		if ev.Passed {
+			return nil
+		}
+
+		return n.Notify(ctx, ev)
+	})
+}
+
+// Multi sends the notifications to all the notifiers, returning the first error.
+func Multi(notifiers ...Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, ev Event) error {
+		var err error
+
+		for _, n := range notifiers {
+			if nerr := n.Notify(ctx, ev); nerr != nil && err == nil {
+				err = nerr
+			}
+		}
+
+		return err
+	})
+}
+
+// postJSON posts the payload to url, failing if the response status is not 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%v: %v %v", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// Webhook posts the events, as JSON, to a URL.
+type Webhook struct {
+	URL string
+
+	// Headers are additional request headers (i.e. Authorization).
+	Headers map[string]string
+
+	// Client is the client used to send the requests (http.DefaultClient if nil).
+	Client *http.Client
+}
+
+// Notify posts the event to the webhook URL.
+func (w *Webhook) Notify(ctx context.Context, ev Event) error {
+	return postJSON(ctx, w.Client, w.URL, w.Headers, ev)
+}
+
+// Slack posts the events to a Slack incoming webhook.
+type Slack struct {
+	// WebhookURL is the URL of the incoming webhook.
+	WebhookURL string
+
+	// Channel and Username override the webhook defaults, if set.
+	Channel  string
+	Username string
+
+	// Client is the client used to send the requests (http.DefaultClient if nil).
+	Client *http.Client
+}
+
+// Notify posts the event to Slack.
+func (s *Slack) Notify(ctx context.Context, ev Event) error {
+	icon := ":white_check_mark:"
+	if !ev.Passed {
+		icon = ":x:"
+	}
+
+	payload := map[string]interface{}{
+		"text": icon + " " + ev.Text(),
+	}
+
+	if s.Channel != "" {
+		payload["channel"] = s.Channel
+	}
+	if s.Username != "" {
+		payload["username"] = s.Username
+	}
+
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, payload)
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventText(t *testing.T) {
+	tests := []struct {
+		ev   Event
+		want string
+	}{
+		{Event{Type: ScenarioCompleted, Name: "login", Passed: true, Duration: 1.25}, "login passed in 1.2s"},
+		{Event{Type: ScenarioCompleted, Name: "login", Error: "timeout", Duration: 30, Artifacts: "results/login"}, "login FAILED in 30.0s: timeout (results/login)"},
+		{Event{Type: RunCompleted, Name: "nightly", Total: 10, Failed: 2, Duration: 62.5}, "nightly FAILED: 2/10 scenarios failed in 62.5s"},
+	}
+
+	for _, tt := range tests {
+		if text := tt.ev.Text(); text != tt.want {
+			t.Errorf("Text() = %q, want %q", text, tt.want)
+		}
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	var received []map[string]interface{}
+	var auth []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "invalid_token", http.StatusForbidden)
+			return
+		}
+
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		received = append(received, payload)
+		auth = append(auth, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	var called []string
+
+	n := Multi(
+		&Webhook{URL: srv.URL + "/hook", Headers: map[string]string{"Authorization": "Bearer xyz"}},
+		OnlyFailures(&Slack{WebhookURL: srv.URL + "/slack", Channel: "#alerts"}),
+		NotifierFunc(func(ctx context.Context, ev Event) error {
+			called = append(called, ev.Name)
+			return errors.New("func failed")
+		}),
+		&Webhook{URL: srv.URL + "/broken"},
+	)
+
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := n.Notify(ctx, Event{Type: ScenarioCompleted, Time: now, Name: "home", Passed: true, Duration: 1}); err == nil || err.Error() != "func failed" {
+		t.Errorf("Notify error = %v, want the first error", err)
+	}
+
+	err := n.Notify(ctx, Event{Type: ScenarioCompleted, Time: now, Name: "checkout", Error: "no element", Duration: 2})
+	if err == nil || err.Error() != "func failed" {
+		t.Errorf("Notify error = %v", err)
+	}
+
+	if err := (&Webhook{URL: srv.URL + "/broken"}).Notify(ctx, Event{}); err == nil || !strings.HasSuffix(err.Error(), "/broken: 403 Forbidden invalid_token") {
+		t.Errorf("webhook error = %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{"type": "scenario", "time": "2024-01-02T03:04:05Z", "name": "home", "passed": true, "duration": 1.0},
+		{"type": "scenario", "time": "2024-01-02T03:04:05Z", "name": "checkout", "passed": false, "error": "no element", "duration": 2.0},
+		{"text": ":x: checkout FAILED in 2.0s: no element", "channel": "#alerts"},
+	}
+
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("received = %v, want %v", received, want)
+	}
+
+	if wantAuth := []string{"Bearer xyz", "Bearer xyz", ""}; !reflect.DeepEqual(auth, wantAuth) {
+		t.Errorf("authorization = %q, want %q", auth, wantAuth)
+	}
+
+	if want := []string{"home", "checkout"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("called = %q, want %q", called, want)
+	}
+}
@@ -0,0 +1,262 @@
+package godet
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// SetOffline emulates (or stops emulating) a disconnected network.
+func (remote *RemoteDebugger) SetOffline(offline bool) error {
+	_, err := remote.SendRequest("Network.emulateNetworkConditions", Params{
+		"offline":            offline,
+		"latency":            0,
+		"downloadThroughput": -1,
+		"uploadThroughput":   -1,
+	})
+	return err
+}
+
+// OfflineFailure is a request that failed while offline.
+type OfflineFailure struct {
+	URL   string
+	Error string
+}
+
+// OfflineReport is the result of TestOffline.
+type OfflineReport struct {
+	URL string
+
+	// Controlled is true if the page was controlled by a service worker.
+	Controlled bool
+
+	// Loaded is true if the document itself was loaded while offline.
+	Loaded bool
+
+	// ServiceWorker lists the resources served by the service worker.
+	ServiceWorker []string
+
+	// HTTPCache lists the resources served from the browser cache.
+	HTTPCache []string
+
+	// Failed lists the resources that couldn't be loaded.
+	Failed []OfflineFailure
+
+	// Caches maps the names of the page origin CacheStorage caches to the URLs they contain.
+	Caches map[string][]string
+}
+
+// serviceWorkerReadyJS waits (up to 10 seconds) for a service worker to be active and returns true if it controls the page.
+const serviceWorkerReadyJS = `new Promise(function(resolve) {
+	if (!navigator.serviceWorker) return resolve(false);
+	setTimeout(function() { resolve(!!navigator.serviceWorker.controller); }, 10000);
+	navigator.serviceWorker.ready.then(function() { resolve(!!navigator.serviceWorker.controller); });
+})`
+
+// reloadAndWait reloads the page (using the cache and the service worker) and waits for the load event.
+func (remote *RemoteDebugger) reloadAndWait(timeout time.Duration) error {
+	loaded := make(chan struct{}, 1)
+
+	removeHook := remote.addHook("Page.loadEventFired", func(params Params) bool {
+		select {
+		case loaded <- struct{}{}:
+		default:
+		}
+		return false
+	})
+
+	defer removeHook()
+
+	if _, err := remote.SendRequest("Page.reload", Params{"ignoreCache": false}); err != nil {
+		return err
+	}
+
+	select {
+	case <-loaded:
+		return nil
+	case <-time.After(timeout):
+		return ErrorTimeout
+	}
+}
+
+// cacheStorage returns the CacheStorage caches for the origin, with the URLs they contain.
+func (remote *RemoteDebugger) cacheStorage(origin string) (map[string][]string, error) {
+	raw, err := remote.sendRawReplyRequest("CacheStorage.requestCacheNames", Params{
+		"securityOrigin": origin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names struct {
+		Caches []struct {
+			CacheID   string `json:"cacheId"`
+			CacheName string `json:"cacheName"`
+		} `json:"caches"`
+	}
+
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return nil, err
+	}
+
+	caches := map[string][]string{}
+
+	for _, c := range names.Caches {
+		raw, err := remote.sendRawReplyRequest("CacheStorage.requestEntries", Params{
+			"cacheId":   c.CacheID,
+			"skipCount": 0,
+			"pageSize":  1000,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var entries struct {
+			Entries []struct {
+				RequestURL string `json:"requestURL"`
+			} `json:"cacheDataEntries"`
+		}
+
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, err
+		}
+
+		urls := []string{}
+		for _, e := range entries.Entries {
+			urls = append(urls, e.RequestURL)
+		}
+
+		caches[c.CacheName] = urls
+	}
+
+	return caches, nil
+}
+
+// TestOffline loads the page, waits for its service worker (if any) to take control, then goes
+// offline and reloads it, reporting which resources were served by the service worker, by the
+// browser cache or failed. The network is back online when TestOffline returns.
+//
+// The timeout applies to each page load. Network and Page events are enabled, if needed.
+func (remote *RemoteDebugger) TestOffline(url string, timeout time.Duration) (*OfflineReport, error) {
+	if err := remote.ensureDomain("Network"); err != nil {
+		return nil, err
+	}
+
+	if _, err := remote.NavigateAndWait(url, timeout); err != nil {
+		return nil, err
+	}
+
+	report := &OfflineReport{URL: url}
+
+	controlled, err := remote.Evaluate(serviceWorkerReadyJS, AwaitPromise(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if controlled != true {
+		// a new service worker only controls the page after a reload (unless it claims its clients)
+		if err := remote.reloadAndWait(timeout); err != nil {
+			return nil, err
+		}
+
+		controlled, _ = remote.Evaluate("!!(navigator.serviceWorker && navigator.serviceWorker.controller)")
+	}
+
+	report.Controlled = controlled == true
+
+	type resource struct {
+		url, errorText string
+		document       bool
+		sw, cache      bool
+		failed         bool
+	}
+
+	var order []string
+	resources := map[string]*resource{}
+	var lock sync.Mutex
+
+	update := func(id string, f func(r *resource)) {
+		lock.Lock()
+		if r := resources[id]; r != nil {
+			f(r)
+		}
+		lock.Unlock()
+	}
+
+	removeHooks := []func(){
+		remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+			id := params.String("requestId")
+
+			lock.Lock()
+			if resources[id] == nil {
+				order = append(order, id)
+			}
+			resources[id] = &resource{
+				url:      Params(params.Map("request")).String("url"),
+				document: params.String("type") == string(ResourceTypeDocument),
+			}
+			lock.Unlock()
+			return false
+		}),
+		remote.addHook("Network.responseReceived", func(params Params) bool {
+			resp := Params(params.Map("response"))
+			update(params.String("requestId"), func(r *resource) {
+				r.sw = resp.Bool("fromServiceWorker")
+				r.cache = resp.Bool("fromDiskCache")
+			})
+			return false
+		}),
+		remote.addHook("Network.requestServedFromCache", func(params Params) bool {
+			update(params.String("requestId"), func(r *resource) { r.cache = true })
+			return false
+		}),
+		remote.addHook("Network.loadingFailed", func(params Params) bool {
+			update(params.String("requestId"), func(r *resource) {
+				r.failed, r.errorText = true, params.String("errorText")
+			})
+			return false
+		}),
+	}
+
+	defer func() {
+		for _, remove := range removeHooks {
+			remove()
+		}
+	}()
+
+	if err := remote.SetOffline(true); err != nil {
+		return nil, err
+	}
+
+	defer remote.SetOffline(false)
+
+	if err := remote.reloadAndWait(timeout); err != nil && err != ErrorTimeout {
+		return nil, err
+	}
+
+	lock.Lock()
+	for _, id := range order {
+		r := resources[id]
+
+		switch {
+		case r.failed:
+			report.Failed = append(report.Failed, OfflineFailure{URL: r.url, Error: r.errorText})
+		case r.sw:
+			report.ServiceWorker = append(report.ServiceWorker, r.url)
+		case r.cache:
+			report.HTTPCache = append(report.HTTPCache, r.url)
+		}
+
+		if r.document && !r.failed {
+			report.Loaded = true
+		}
+	}
+	lock.Unlock()
+
+	report.Caches, err = remote.cacheStorage(requestOrigin(url))
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,213 @@
+package godet
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultPageTimeout is the default timeout for the Page navigations and waits.
+var DefaultPageTimeout = 30 * time.Second
+
+// Page is a high-level facade over a RemoteDebugger, in the style of the Playwright and Puppeteer page objects:
+// navigations wait for the page to load and the element actions wait for the element to exist.
+//
+//	page := godet.NewPage(remote)
+//	if err := page.Goto("https://example.com/login"); err != nil { ... }
+//	page.Fill("#user", "me")
+//	page.Click("button[type=submit]")
+//	img, err := page.Screenshot()
+type Page struct {
+	remote *RemoteDebugger
+
+	// Timeout is the timeout for navigations and waits (default DefaultPageTimeout).
+	Timeout time.Duration
+
+	response *NavigationResult
+}
+
+// NewPage returns a Page for the tab remote is connected to.
+func NewPage(remote *RemoteDebugger) *Page {
+	return &Page{remote: remote, Timeout: DefaultPageTimeout}
+}
+
+// Remote returns the underlying RemoteDebugger, for the operations not covered by Page.
+func (p *Page) Remote() *RemoteDebugger {
+	return p.remote
+}
+
+// Goto navigates to the URL and waits for the page to load. See Response for the document status.
+func (p *Page) Goto(url string) error {
+	return p.remote.traceStep("navigate", "", url, func() error {
+		res, err := p.remote.NavigateAndWait(url, p.Timeout)
+		p.response = res
+		return err
+	})
+}
+
+// Response returns the main document response of the last Goto (nil if it failed).
+func (p *Page) Response() *NavigationResult {
+	return p.response
+}
+
+// Reload reloads the page and waits for it to load.
+func (p *Page) Reload() error {
+	return p.remote.traceStep("reload", "", "", func() error {
+		if err := p.remote.ensureDomain("Page"); err != nil {
+			return err
+		}
+
+		return p.remote.reloadAndWait(p.Timeout)
+	})
+}
+
+// evaluateString evaluates an expression returning a string.
+func (p *Page) evaluateString(expr string) (string, error) {
+	res, err := p.remote.Evaluate(expr)
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := res.(string)
+	return s, nil
+}
+
+// URL returns the URL of the page.
+func (p *Page) URL() (string, error) {
+	return p.evaluateString("location.href")
+}
+
+// Title returns the title of the page.
+func (p *Page) Title() (string, error) {
+	return p.evaluateString("document.title")
+}
+
+// Content returns the HTML of the page.
+func (p *Page) Content() (string, error) {
+	return p.evaluateString("document.documentElement ? document.documentElement.outerHTML : ''")
+}
+
+// Evaluate evaluates a Javascript expression in the page and returns its value.
+func (p *Page) Evaluate(expr string) (interface{}, error) {
+	return p.remote.Evaluate(expr, AwaitPromise(true))
+}
+
+// WaitForSelector waits until an element matches the selector.
+func (p *Page) WaitForSelector(selector string) error {
+	return p.remote.traceStep("wait", selector, "", func() error {
+		return p.remote.waitSelector(selector, p.Timeout)
+	})
+}
+
+// WaitForNetworkIdle waits until there are no network requests for the idle duration.
+func (p *Page) WaitForNetworkIdle(idle time.Duration) error {
+	return p.remote.traceStep("wait-network-idle", "", idle.String(), func() error {
+		return p.remote.WaitNetworkIdle(idle, p.Timeout)
+	})
+}
+
+// Click waits for the element matching the selector, scrolls it into view and clicks on it.
+func (p *Page) Click(selector string) error {
+	return p.remote.traceStep("click", selector, "", func() error {
+		if err := p.WaitForSelector(selector); err != nil {
+			return err
+		}
+
+		return p.remote.Click(selector)
+	})
+}
+
+// Fill waits for the input element matching the selector and sets its value.
+func (p *Page) Fill(selector, value string) error {
+	return p.remote.traceInput("fill", selector, elementExpression(selector), value, func() error {
+		if err := p.WaitForSelector(selector); err != nil {
+			return err
+		}
+
+		return p.remote.Fill(selector, value)
+	})
+}
+
+// Type waits for the element matching the selector, focuses it and types the text, one key at a time.
+func (p *Page) Type(selector, text string) error {
+	return p.remote.traceInput("type", selector, elementExpression(selector), text, func() error {
+		if err := p.WaitForSelector(selector); err != nil {
+			return err
+		}
+
+		if err := p.remote.FocusSelector(selector); err != nil {
+			return err
+		}
+
+		for _, c := range text {
+			if err := p.remote.SendRune(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// PressEnter waits for the element matching the selector, focuses it and presses Enter.
+func (p *Page) PressEnter(selector string) error {
+	return p.remote.traceStep("press", selector, "Enter", func() error {
+		if err := p.WaitForSelector(selector); err != nil {
+			return err
+		}
+
+		if err := p.remote.FocusSelector(selector); err != nil {
+			return err
+		}
+
+		return p.remote.pressEnter()
+	})
+}
+
+// Select waits for the <select> element matching the selector and selects the options with the given values.
+func (p *Page) Select(selector string, values ...string) (selected []string, err error) {
+	err = p.remote.traceStep("select", selector, strings.Join(values, ","), func() error {
+		if err := p.WaitForSelector(selector); err != nil {
+			return err
+		}
+
+		selected, err = p.remote.SelectOption(selector, values...)
+		return err
+	})
+
+	return
+}
+
+// TextContent waits for the element matching the selector and returns its text content.
+func (p *Page) TextContent(selector string) (string, error) {
+	if err := p.remote.waitSelector(selector, p.Timeout); err != nil {
+		return "", err
+	}
+
+	res, err := p.remote.evaluateSelector(selector, "return el.textContent;")
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := res.(string)
+	return s, nil
+}
+
+// IsVisible returns true if an element matching the selector exists and is visible. It doesn't wait.
+func (p *Page) IsVisible(selector string) (bool, error) {
+	return p.remote.IsVisible(selector)
+}
+
+// SetViewport sets the size of the page viewport, in CSS pixels.
+func (p *Page) SetViewport(width, height int) error {
+	return p.remote.SetDeviceMetricsOverride(width, height, 0, false, false)
+}
+
+// Screenshot captures a PNG screenshot of the viewport.
+func (p *Page) Screenshot() ([]byte, error) {
+	return p.remote.CaptureScreenshot("png", 0, true)
+}
+
+// PDF prints the page as PDF.
+func (p *Page) PDF(options ...PrintToPDFOption) ([]byte, error) {
+	return p.remote.PrintToPDF(options...)
+}
@@ -0,0 +1,121 @@
+package godet
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestPage(t *testing.T) {
+	var lock sync.Mutex
+	var queries int
+	var evaluated []string
+
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		for {
+			var cmd struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+				Params Params `json:"params"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			result := Params{}
+			var events []Params
+
+			lock.Lock()
+			switch cmd.Method {
+			case "Page.navigate":
+				result = Params{"frameId": "fake", "loaderId": "L1"}
+				events = []Params{
+					{"method": "Network.responseReceived", "params": Params{"loaderId": "L0", "type": "Document", "response": Params{"url": "https://example.com/old", "status": 200}}},
+					{"method": "Network.responseReceived", "params": Params{"loaderId": "L1", "type": "Document", "response": Params{"url": cmd.Params.String("url"), "status": 404, "statusText": "Not Found"}}},
+					{"method": "Page.loadEventFired", "params": Params{"timestamp": 1}},
+				}
+
+			case "DOM.getDocument":
+				result = Params{"root": Params{"nodeId": 1}}
+
+			case "DOM.querySelector":
+				if cmd.Params.String("selector") == "#user" {
+					queries++
+					if queries > 2 { // added by a script
+						result = Params{"nodeId": 5}
+					}
+				}
+
+			case "Runtime.evaluate":
+				expr := cmd.Params.String("expression")
+				evaluated = append(evaluated, expr)
+
+				switch {
+				case expr == "document.title":
+					result = Params{"result": Params{"type": "string", "value": "Not Found"}}
+				case strings.Contains(expr, "el.textContent;"):
+					result = Params{"result": Params{"type": "object", "value": Params{"value": "Hello"}}}
+				default:
+					result = Params{"result": Params{"type": "object", "value": Params{"value": Params{}}}}
+				}
+			}
+			lock.Unlock()
+
+			for _, ev := range events {
+				if err := wsjson.Write(ctx, c, ev); err != nil {
+					return
+				}
+			}
+
+			if err := wsjson.Write(ctx, c, Params{"id": cmd.ID, "result": result}); err != nil {
+				return
+			}
+		}
+	})
+
+	page := NewPage(connectFake(t, tab))
+	page.Timeout = time.Second
+
+	if err := page.Goto("https://example.com/missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := page.Response(); res == nil || res.Status != 404 || res.StatusText != "Not Found" || res.URL != "https://example.com/missing" {
+		t.Errorf("response = %+v", res)
+	}
+
+	if title, err := page.Title(); err != nil || title != "Not Found" {
+		t.Errorf("title = %q, %v", title, err)
+	}
+
+	if err := page.Fill("#user", "me"); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, err := page.TextContent("#user"); err != nil || text != "Hello" {
+		t.Errorf("text = %q, %v", text, err)
+	}
+
+	page.Timeout = 200 * time.Millisecond
+
+	if err := page.Click("#missing"); err != ErrorNoSuchNode {
+		t.Errorf("click error = %v, want ErrorNoSuchNode", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if queries != 4 {
+		t.Errorf("queries = %v, want 4 (until found, then once for TextContent)", queries)
+	}
+
+	if len(evaluated) != 3 || !strings.Contains(evaluated[1], `var value = "me";`) {
+		t.Errorf("evaluated = %q", evaluated)
+	}
+}
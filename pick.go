@@ -0,0 +1,188 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// elementSelectorJS is a Javascript function returning a CSS selector for an element:
+// the closest unique id, data-testid, name or aria-label attribute, followed by the nth-of-type path.
+const elementSelectorJS = `function(el) {
+    function cssEscape(s) {
+      return window.CSS && CSS.escape ? CSS.escape(s) : s.replace(/([^\w-])/g, "\\$1");
+    }
+
+    function unique(s) {
+      try { return document.querySelectorAll(s).length === 1; } catch (e) { return false; }
+    }
+
+    var parts = [];
+    for (; el && el.nodeType === 1 && el !== document.documentElement; el = el.parentElement) {
+      var tag = el.tagName.toLowerCase();
+      if (el.id && unique("#" + cssEscape(el.id))) { parts.unshift("#" + cssEscape(el.id)); break; }
+      var attrs = ["data-testid", "name", "aria-label"];
+      var found = false;
+      for (var i = 0; i < attrs.length; i++) {
+        var v = el.getAttribute(attrs[i]);
+        if (v) {
+          var s = tag + "[" + attrs[i] + "=" + JSON.stringify(v) + "]";
+          if (unique(s)) { parts.unshift(s); found = true; break; }
+        }
+      }
+      if (found) break;
+      var n = 1;
+      for (var sib = el.previousElementSibling; sib; sib = sib.previousElementSibling) {
+        if (sib.tagName === el.tagName) n++;
+      }
+      parts.unshift(tag + ":nth-of-type(" + n + ")");
+    }
+    return parts.join(" > ");
+  }`
+
+// elementXPathJS is a Javascript function returning an XPath expression for an element:
+// the closest unique id, followed by the indexed path.
+const elementXPathJS = `function(el) {
+    var parts = [];
+    for (; el && el.nodeType === 1; el = el.parentNode) {
+      if (el.id && document.querySelectorAll("[id=" + JSON.stringify(el.id) + "]").length === 1) {
+        parts.unshift("//*[@id=" + JSON.stringify(el.id) + "]");
+        return parts.join("/");
+      }
+      var n = 1;
+      for (var sib = el.previousElementSibling; sib; sib = sib.previousElementSibling) {
+        if (sib.tagName === el.tagName) n++;
+      }
+      parts.unshift(el.tagName.toLowerCase() + "[" + n + "]");
+    }
+    return "/" + parts.join("/");
+  }`
+
+// pickedElementJS returns the selector, XPath, description and text of the element (this).
+var pickedElementJS = fmt.Sprintf(`function() {
+  var text = (this.innerText || this.value || "").trim().replace(/\s+/g, " ");
+  return {
+    selector: (%v)(this),
+    xpath: (%v)(this),
+    description: (%v)(this),
+    text: text.length > 80 ? text.substring(0, 80) + "..." : text
+  };
+}`, elementSelectorJS, elementXPathJS, describeElementJS)
+
+// PickedElement is the element selected by the user with PickElement.
+type PickedElement struct {
+	Selector    string `json:"selector"`
+	XPath       string `json:"xpath"`
+	Description string `json:"description"` // tag#id.class
+	Text        string `json:"text,omitempty"`
+}
+
+// pickHighlight is the highlight configuration used for the hovered elements.
+var pickHighlight = Params{
+	"showInfo":     true,
+	"contentColor": Params{"r": 111, "g": 168, "b": 220, "a": 0.66},
+	"paddingColor": Params{"r": 147, "g": 196, "b": 125, "a": 0.55},
+	"borderColor":  Params{"r": 255, "g": 229, "b": 153, "a": 0.66},
+	"marginColor":  Params{"r": 246, "g": 178, "b": 107, "a": 0.66},
+}
+
+// PickElement enables the inspect mode, waits for the user to click on an element in the (headful) browser
+// and returns a selector and an XPath expression for it.
+// DOM and Overlay events are enabled, if needed. If timeout is not 0, ErrorTimeout is returned if
+// no element is picked before it expires.
+func (remote *RemoteDebugger) PickElement(timeout time.Duration) (*PickedElement, error) {
+	for _, domain := range []string{"DOM", "Overlay"} {
+		if err := remote.ensureDomain(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := remote.GetDocument(); err != nil {
+		return nil, err
+	}
+
+	picked := make(chan int, 1)
+
+	remove := remote.addHook("Overlay.inspectNodeRequested", func(params Params) bool {
+		select {
+		case picked <- params.Int("backendNodeId"):
+		default:
+		}
+
+		return false
+	})
+
+	defer remove()
+
+	if _, err := remote.SendRequest("Overlay.setInspectMode", Params{
+		"mode":            "searchForNode",
+		"highlightConfig": pickHighlight,
+	}); err != nil {
+		return nil, err
+	}
+
+	defer remote.SendRequest("Overlay.setInspectMode", Params{"mode": "none"})
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var id int
+
+	select {
+	case id = <-picked:
+	case <-expired:
+		return nil, ErrorTimeout
+	}
+
+	remote.SendRequest("Overlay.hideHighlight", nil)
+	return remote.describePicked(id)
+}
+
+// describePicked returns the selector and XPath of the node with the given backendNodeId.
+func (remote *RemoteDebugger) describePicked(backendNodeID int) (*PickedElement, error) {
+	res, err := remote.SendRequest("DOM.resolveNode", Params{
+		"backendNodeId": backendNodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objectID := Params(Params(res).Map("object")).String("objectId")
+	if objectID == "" {
+		return nil, ErrorNoSuchNode
+	}
+
+	defer remote.ReleaseObject(objectID)
+
+	raw, err := remote.sendRawReplyRequest("Runtime.callFunctionOn", Params{
+		"objectId":            objectID,
+		"functionDeclaration": pickedElementJS,
+		"returnByValue":       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Result struct {
+			Value PickedElement `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		return nil, err
+	}
+
+	if reply.ExceptionDetails != nil {
+		return nil, fmt.Errorf("cannot describe element: %v", reply.ExceptionDetails.Text)
+	}
+
+	return &reply.Result.Value, nil
+}
@@ -0,0 +1,98 @@
+package godet
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPickElement(t *testing.T) {
+	var lock sync.Mutex
+	var calls []string
+
+	inspect := make(chan struct{}, 1)
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch method {
+		case "Overlay.setInspectMode":
+			calls = append(calls, method+" "+params.String("mode"))
+			if params.String("mode") == "searchForNode" {
+				inspect <- struct{}{}
+			}
+
+		case "DOM.resolveNode":
+			calls = append(calls, method)
+			if params.Int("backendNodeId") != 42 {
+				return nil, &ProtocolError{Code: -32000, Message: "No node with given id found"}
+			}
+
+			return Params{"object": Params{"objectId": "obj-42"}}, nil
+
+		case "Runtime.callFunctionOn":
+			calls = append(calls, method+" "+params.String("objectId"))
+			return Params{"result": Params{"type": "object", "value": Params{
+				"selector":    "#login",
+				"xpath":       `//*[@id="login"]`,
+				"description": "button#login.primary",
+				"text":        "Sign in",
+			}}}, nil
+
+		case "Overlay.hideHighlight", "Runtime.releaseObject":
+			calls = append(calls, method)
+		}
+
+		return Params{}, nil
+	}))
+
+	go func() {
+		<-inspect
+		fakeEvent(remote, "Overlay.inspectNodeRequested", Params{"backendNodeId": 42})
+	}()
+
+	picked, err := remote.PickElement(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := PickedElement{Selector: "#login", XPath: `//*[@id="login"]`, Description: "button#login.primary", Text: "Sign in"}
+	if *picked != want {
+		t.Errorf("picked %+v, want %+v", *picked, want)
+	}
+
+	lock.Lock()
+	wantCalls := []string{
+		"Overlay.setInspectMode searchForNode",
+		"Overlay.hideHighlight",
+		"DOM.resolveNode",
+		"Runtime.callFunctionOn obj-42",
+		"Runtime.releaseObject",
+		"Overlay.setInspectMode none",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("calls %q, want %q", calls, wantCalls)
+	}
+	calls = nil
+	lock.Unlock()
+
+	// nothing picked: the inspect mode is disabled when the timeout expires
+	go func() { <-inspect }()
+
+	if _, err := remote.PickElement(100 * time.Millisecond); err != ErrorTimeout {
+		t.Errorf("expected ErrorTimeout, got %v", err)
+	}
+
+	lock.Lock()
+	wantCalls = []string{"Overlay.setInspectMode searchForNode", "Overlay.setInspectMode none"}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("calls %q, want %q", calls, wantCalls)
+	}
+	lock.Unlock()
+
+	if _, err := remote.describePicked(7); err == nil {
+		t.Errorf("expected an error for a missing node")
+	}
+}
@@ -0,0 +1,67 @@
+package godet
+
+import (
+	"context"
+)
+
+// TabAllocator allocates tabs for concurrent jobs (see Pool and Cluster).
+type TabAllocator interface {
+	// Acquire opens a new tab and returns a connection to it.
+	Acquire(ctx context.Context) (*RemoteDebugger, error)
+
+	// Release closes the tab and its connection.
+	Release(conn *RemoteDebugger) error
+}
+
+// Pool allocates tabs for concurrent jobs: each job gets its own tab (and connection),
+// that is closed when released. Size limits the number of tabs open at the same time.
+type Pool struct {
+	remote *RemoteDebugger
+	slots  chan struct{}
+}
+
+// NewPool returns a pool opening up to size tabs in the browser remote is connected to.
+func NewPool(remote *RemoteDebugger, size int) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &Pool{remote: remote, slots: make(chan struct{}, size)}
+}
+
+// Acquire opens a new tab, waiting for a free slot if the pool is full, and returns a connection to it.
+// The tab must be released with Release.
+func (p *Pool) Acquire(ctx context.Context) (*RemoteDebugger, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	tab, err := p.remote.createTab("about:blank")
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	conn, err := p.remote.connectTab(tab)
+	if err != nil {
+		p.remote.CloseTab(tab)
+		<-p.slots
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Release closes the tab acquired with Acquire, and its connection.
+func (p *Pool) Release(conn *RemoteDebugger) error {
+	defer func() { <-p.slots }()
+
+	conn.Lock()
+	id := conn.current
+	conn.Unlock()
+
+	conn.Close()
+	return p.remote.CloseTab(&Tab{ID: id})
+}
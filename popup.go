@@ -0,0 +1,203 @@
+package godet
+
+import (
+	"log"
+	"strings"
+)
+
+// Session is a popup window (or tab) opened by the page, i.e. with window.open or a target=_blank link.
+//
+// If the session was attached (see OnPopup and Attach) the embedded RemoteDebugger is connected
+// to the popup page and can be used like any other connection. It should be closed with Close
+// when done (this doesn't close the popup, use CloseTab for that).
+type Session struct {
+	*RemoteDebugger
+
+	Tab      *Tab
+	OpenerID string
+
+	opener *RemoteDebugger
+}
+
+// Attach connects the session to the popup page, if not already connected.
+func (s *Session) Attach() error {
+	if s.RemoteDebugger != nil {
+		return nil
+	}
+
+	conn, err := s.opener.connectTab(&Tab{ID: s.Tab.ID})
+	if err != nil {
+		return err
+	}
+
+	s.RemoteDebugger = conn
+	return nil
+}
+
+// PopupCallback is called with a new popup opened by the current page.
+type PopupCallback func(session *Session)
+
+// popupTab returns the Tab for a new popup opened by the current page, or nil.
+func (remote *RemoteDebugger) popupTab(params Params) *Tab {
+	info := Params(params.Map("targetInfo"))
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	if info.String("type") != "page" || info.String("openerId") == "" || info.String("openerId") != current {
+		return nil
+	}
+
+	return &Tab{
+		ID:    info.String("targetId"),
+		Type:  info.String("type"),
+		Title: info.String("title"),
+		URL:   info.String("url"),
+	}
+}
+
+// OnPopup calls cb for every popup window opened by the current page (via Target.targetCreated).
+// If autoAttach is true the session is connected to the popup before calling cb,
+// otherwise use Session.Attach to connect to it.
+//
+// Passing a nil callback stops the notifications.
+func (remote *RemoteDebugger) OnPopup(cb PopupCallback, autoAttach bool) error {
+	remote.Lock()
+	removeHook := remote.stopPopups
+	remote.stopPopups = nil
+	remote.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	stop := remote.addHook("Target.targetCreated", func(params Params) bool {
+		tab := remote.popupTab(params)
+		if tab == nil {
+			return false
+		}
+
+		session := &Session{
+			Tab:      tab,
+			OpenerID: Params(params.Map("targetInfo")).String("openerId"),
+			opener:   remote,
+		}
+
+		if autoAttach {
+			if err := session.Attach(); err != nil {
+				if remote.verbose {
+					log.Println("cannot attach to popup", tab.ID, err)
+				}
+			}
+		}
+
+		cb(session)
+		return false
+	})
+
+	remote.Lock()
+	remote.stopPopups = stop
+	remote.Unlock()
+
+	return remote.SetDiscoverTargets(true)
+}
+
+// PopupPolicy controls what happens to windows opened by the page (see SetPopupPolicy).
+type PopupPolicy int
+
+const (
+	// PopupAllow lets the page open new windows (the default).
+	PopupAllow PopupPolicy = iota
+	// PopupBlock closes any window opened by the page.
+	PopupBlock
+	// PopupRedirect loads the popup URL in the current tab instead of a new window.
+	PopupRedirect
+)
+
+// redirectPopupsJS makes window.open and target=_blank links navigate the current window.
+const redirectPopupsJS = `(function() {
+	window.open = function(url) {
+		if (url) location.href = url;
+		return window;
+	};
+	document.addEventListener("click", function(ev) {
+		var a = ev.target && ev.target.closest && ev.target.closest("a[target]");
+		if (a && ["_self", "_parent", "_top"].indexOf(a.target) < 0) a.target = "_self";
+	}, true);
+})()`
+
+// SetPopupPolicy sets the policy for windows opened by the current page.
+//
+// With PopupBlock and PopupRedirect, popups that are opened anyway (i.e. by a form with target=_blank)
+// are closed as soon as they are created and, with PopupRedirect, their URL (if known) is loaded
+// in the current tab.
+//
+// Note that OnPopup callbacks are still called for the popups that are closed.
+func (remote *RemoteDebugger) SetPopupPolicy(policy PopupPolicy) error {
+	remote.Lock()
+	stop := remote.stopPopupPolicy
+	remote.stopPopupPolicy = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if policy == PopupAllow {
+		return nil
+	}
+
+	var scriptID string
+
+	if policy == PopupRedirect {
+		id, err := remote.AddScriptToEvaluateOnNewDocument(redirectPopupsJS)
+		if err != nil {
+			return err
+		}
+
+		if _, err := remote.Evaluate(redirectPopupsJS); err != nil {
+			remote.RemoveScriptToEvaluateOnNewDocument(id)
+			return err
+		}
+
+		scriptID = id
+	}
+
+	removeHook := remote.addHook("Target.targetCreated", func(params Params) bool {
+		tab := remote.popupTab(params)
+		if tab == nil {
+			return false
+		}
+
+		if _, err := remote.SendRequest("Target.closeTarget", Params{
+			"targetId": tab.ID,
+		}); err != nil && remote.verbose {
+			log.Println("cannot close popup", tab.ID, err)
+		}
+
+		if policy == PopupRedirect && tab.URL != "" && !strings.HasPrefix(tab.URL, "about:") {
+			if _, err := remote.Navigate(tab.URL); err != nil && remote.verbose {
+				log.Println("cannot redirect popup", tab.URL, err)
+			}
+		}
+
+		return false
+	})
+
+	remote.Lock()
+	remote.stopPopupPolicy = func() {
+		removeHook()
+
+		if scriptID != "" {
+			remote.RemoveScriptToEvaluateOnNewDocument(scriptID)
+		}
+	}
+	remote.Unlock()
+
+	return remote.SetDiscoverTargets(true)
+}
@@ -0,0 +1,131 @@
+package godet
+
+// Preload statuses (see PreloadEvent).
+const (
+	PreloadPending      = "Pending"
+	PreloadRunning      = "Running"
+	PreloadReady        = "Ready"
+	PreloadSuccess      = "Success"
+	PreloadFailure      = "Failure"
+	PreloadNotSupported = "NotSupported"
+)
+
+// SpeculationRuleSet is a set of speculation rules (a <script type="speculationrules"> or a
+// Speculation-Rules header) found in the page.
+type SpeculationRuleSet struct {
+	ID         string `json:"id"`
+	LoaderID   string `json:"loaderId"`
+	SourceText string `json:"sourceText"`
+
+	// URL is the URL of the rules, for the rules loaded via Speculation-Rules header.
+	URL string `json:"url"`
+
+	// ErrorType and ErrorMessage report why the rules are invalid (empty if they are valid).
+	ErrorType    string `json:"errorType"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// PreloadEvent is a Preload domain event: a rule set added, updated or removed,
+// or a status change of a prefetch or prerender attempt.
+type PreloadEvent struct {
+	// Method is the protocol event (i.e. "Preload.prerenderStatusUpdated").
+	Method string
+
+	// RuleSet is the updated rule set (for Preload.ruleSetUpdated).
+	RuleSet *SpeculationRuleSet
+
+	// RuleSetID is the id of the removed rule set (for Preload.ruleSetRemoved).
+	RuleSetID string
+
+	// Action is Prefetch or Prerender, for the status updates.
+	Action     string
+	URL        string
+	LoaderID   string
+	PipelineID string
+
+	// Status is one of the Preload* statuses.
+	Status string
+
+	// Reason is the detailed prefetch or prerender status (i.e. "PrefetchFailedNon2XX" or "MainFrameNavigation").
+	Reason string
+}
+
+// PreloadCallback is called for each PreloadEvent.
+type PreloadCallback func(ev PreloadEvent)
+
+// OnPreload calls cb for the speculation rules found in the page (Preload.ruleSetUpdated and Preload.ruleSetRemoved)
+// and for the status changes of the resulting prefetch and prerender attempts (Preload.prefetchStatusUpdated and
+// Preload.prerenderStatusUpdated), to validate the Speculation Rules API.
+//
+// Passing a nil callback stops the notifications. Preload events are enabled, if needed.
+func (remote *RemoteDebugger) OnPreload(cb PreloadCallback) error {
+	remote.Lock()
+	stop := remote.stopPreload
+	remote.stopPreload = nil
+	remote.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	if cb == nil {
+		return nil
+	}
+
+	removeRuleSet := remote.addHook("Preload.ruleSetUpdated", func(params Params) bool {
+		var ev struct {
+			RuleSet SpeculationRuleSet `json:"ruleSet"`
+		}
+
+		decodeParams(params, &ev)
+
+		cb(PreloadEvent{
+			Method:   "Preload.ruleSetUpdated",
+			RuleSet:  &ev.RuleSet,
+			LoaderID: ev.RuleSet.LoaderID,
+		})
+
+		return false
+	})
+
+	removeRemoved := remote.addHook("Preload.ruleSetRemoved", func(params Params) bool {
+		cb(PreloadEvent{
+			Method:    "Preload.ruleSetRemoved",
+			RuleSetID: params.String("id"),
+		})
+
+		return false
+	})
+
+	status := func(method, reasonKey string) func(Params) bool {
+		return func(params Params) bool {
+			key := Params(params.Map("key"))
+
+			cb(PreloadEvent{
+				Method:     method,
+				Action:     key.String("action"),
+				URL:        key.String("url"),
+				LoaderID:   key.String("loaderId"),
+				PipelineID: params.String("pipelineId"),
+				Status:     params.String("status"),
+				Reason:     params.String(reasonKey),
+			})
+
+			return false
+		}
+	}
+
+	removePrefetch := remote.addHook("Preload.prefetchStatusUpdated", status("Preload.prefetchStatusUpdated", "prefetchStatus"))
+	removePrerender := remote.addHook("Preload.prerenderStatusUpdated", status("Preload.prerenderStatusUpdated", "prerenderStatus"))
+
+	remote.Lock()
+	remote.stopPreload = func() {
+		removeRuleSet()
+		removeRemoved()
+		removePrefetch()
+		removePrerender()
+	}
+	remote.Unlock()
+
+	return remote.ensureDomain("Preload")
+}
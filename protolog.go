@@ -0,0 +1,96 @@
+package godet
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProtocolEntry is a command, reply or event recorded in the protocol log.
+type ProtocolEntry struct {
+	Time   time.Time       `json:"time"`
+	Tag    string          `json:"tag,omitempty"`
+	Type   string          `json:"type"` // command, reply or event
+	ID     int             `json:"id,omitempty"`
+	Seq    uint64          `json:"seq,omitempty"` // event sequence number (see Event)
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// protocolLog writes the protocol entries as JSON lines.
+type protocolLog struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+func (l *protocolLog) write(entry ProtocolEntry) {
+	if entry.Time.IsZero() { // the events are logged with their receive time
+		entry.Time = time.Now()
+	}
+
+	l.Lock()
+	l.enc.Encode(entry)
+	l.Unlock()
+}
+
+// ProtocolLog records all the commands, replies and events to w, as JSON lines (see ProtocolEntry).
+func ProtocolLog(w io.Writer) ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.protoLog = &protocolLog{enc: json.NewEncoder(w)}
+	}
+}
+
+// SetProtocolLog starts recording the commands, replies and events to w, as JSON lines.
+// Passing a nil writer stops the recording.
+func (remote *RemoteDebugger) SetProtocolLog(w io.Writer) {
+	remote.Lock()
+	if w == nil {
+		remote.protoLog = nil
+	} else {
+		remote.protoLog = &protocolLog{enc: json.NewEncoder(w)}
+	}
+	remote.Unlock()
+}
+
+// SetTag sets the correlation ID recorded in the protocol log with all the commands sent
+// (and their replies) and the events received from now on, and returns the previous one.
+// An empty tag clears it.
+func (remote *RemoteDebugger) SetTag(tag string) (previous string) {
+	remote.Lock()
+	previous, remote.tag = remote.tag, tag
+	remote.Unlock()
+
+	return
+}
+
+// Tagged runs f with the correlation ID set to tag (see SetTag), restoring the previous one after.
+//
+// Since the tag is per connection, steps tagged concurrently on the same RemoteDebugger can't be told apart.
+func (remote *RemoteDebugger) Tagged(tag string, f func() error) error {
+	previous := remote.SetTag(tag)
+	defer remote.SetTag(previous)
+
+	return f()
+}
+
+// logProtocol records an entry in the protocol log, if enabled, with the current tag.
+// If tag is not nil, it's used instead of the current one.
+func (remote *RemoteDebugger) logProtocol(entry ProtocolEntry, tag *string) {
+	remote.Lock()
+	plog := remote.protoLog
+	entry.Tag = remote.tag
+	remote.Unlock()
+
+	if plog == nil {
+		return
+	}
+
+	if tag != nil {
+		entry.Tag = *tag
+	}
+
+	plog.write(entry)
+}
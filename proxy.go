@@ -0,0 +1,121 @@
+package godet
+
+import "strings"
+
+// maxProxyChallenges limits the number of answered challenges remembered by SetProxyCredentials.
+const maxProxyChallenges = 1000
+
+// proxyAuth holds the proxy credentials set by SetProxyCredentials,
+// and the requests that already got the credentials (protected by the RemoteDebugger lock).
+type proxyAuth struct {
+	username string
+	password string
+	answered map[string]bool
+	stop     []func()
+}
+
+// WithProxy sets the proxy server used by the browser (i.e. "http://proxy:3128" or "socks5://proxy:1080"),
+// optionally bypassed for the specified hosts (i.e. "localhost", "*.internal").
+// Use NewBrowserContext with ContextProxy for a different proxy per browser context.
+func WithProxy(server string, bypass ...string) LaunchOption {
+	return func(l *launcher) {
+		l.args = append(l.args, "--proxy-server="+server)
+
+		if len(bypass) > 0 {
+			l.args = append(l.args, "--proxy-bypass-list="+strings.Join(bypass, ";"))
+		}
+	}
+}
+
+// SetProxyCredentials answers the proxy authentication challenges of the requests of the page
+// with the specified credentials (via Fetch interception). An empty username removes the credentials.
+//
+// If the proxy rejects the credentials (i.e. it challenges the same request again) the authentication is cancelled.
+// The other authentication challenges (i.e. HTTP basic authentication) get the default browser behavior.
+func (remote *RemoteDebugger) SetProxyCredentials(username, password string) error {
+	remote.Lock()
+	auth := remote.proxyAuth
+	remote.proxyAuth = nil
+	remote.Unlock()
+
+	if auth != nil {
+		for _, stop := range auth.stop {
+			stop()
+		}
+	}
+
+	if username == "" {
+		if auth == nil {
+			return nil
+		}
+
+		return remote.updateFetch()
+	}
+
+	auth = &proxyAuth{username: username, password: password, answered: map[string]bool{}}
+
+	auth.stop = append(auth.stop, remote.addHook("Fetch.authRequired", func(params Params) bool {
+		requestID := params.String("requestId")
+		response := Params{"response": "Default"}
+
+		if Params(params.Map("authChallenge")).String("source") == "Proxy" {
+			remote.Lock()
+			retry := auth.answered[requestID]
+			if retry {
+				delete(auth.answered, requestID)
+			} else {
+				if len(auth.answered) >= maxProxyChallenges {
+					auth.answered = map[string]bool{}
+				}
+
+				auth.answered[requestID] = true
+			}
+			remote.Unlock()
+
+			if retry {
+				// the credentials were rejected: don't send them again
+				response = Params{"response": "CancelAuth"}
+			} else {
+				response = Params{
+					"response": "ProvideCredentials",
+					"username": auth.username,
+					"password": auth.password,
+				}
+			}
+		}
+
+		remote.SendRequest("Fetch.continueWithAuth", Params{
+			"requestId":             requestID,
+			"authChallengeResponse": response,
+		})
+
+		return true
+	}))
+
+	remote.Lock()
+	remote.proxyAuth = auth
+	remote.Unlock()
+
+	return remote.updateFetch()
+}
+
+// BrowserContextOption defines the functional options for NewBrowserContext.
+type BrowserContextOption func(p Params)
+
+// ContextProxy sets the proxy server used by the browser context (i.e. "http://proxy:3128" or "socks5://proxy:1080"),
+// optionally bypassed for the specified hosts. Use BrowserContext.SetProxyCredentials if the proxy requires authentication.
+func ContextProxy(server string, bypass ...string) BrowserContextOption {
+	return func(p Params) {
+		p["proxyServer"] = server
+
+		if len(bypass) > 0 {
+			p["proxyBypassList"] = strings.Join(bypass, ";")
+		}
+	}
+}
+
+// SetProxyCredentials sets the proxy credentials for the tabs created (after the call) by NewTab
+// (see RemoteDebugger.SetProxyCredentials).
+func (bc *BrowserContext) SetProxyCredentials(username, password string) {
+	bc.proxyUsername, bc.proxyPassword = username, password
+}
@@ -0,0 +1,53 @@
+package godet
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSetProxyCredentials(t *testing.T) {
+	var lock sync.Mutex
+	var responses []string
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		if method == "Fetch.continueWithAuth" {
+			response := Params(params.Map("authChallengeResponse"))
+
+			lock.Lock()
+			responses = append(responses, params.String("requestId")+":"+response.String("response")+":"+response.String("username"))
+			lock.Unlock()
+		}
+
+		return nil, nil
+	}))
+
+	if err := remote.SetProxyCredentials("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := func(id, source string) {
+		fakeEvent(remote, "Fetch.authRequired", Params{"requestId": id, "authChallenge": Params{"source": source, "origin": "http://proxy:3128"}})
+	}
+
+	challenge("1", "Proxy")
+	challenge("2", "Server")
+	challenge("3", "Proxy")
+	challenge("1", "Proxy") // wrong credentials
+	challenge("3", "Proxy")
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	want := []string{
+		"1:ProvideCredentials:user",
+		"2:Default:",
+		"3:ProvideCredentials:user",
+		"1:CancelAuth:",
+		"3:CancelAuth:",
+	}
+
+	if !reflect.DeepEqual(responses, want) {
+		t.Errorf("responses = %q, want %q", responses, want)
+	}
+}
@@ -0,0 +1,83 @@
+package godet
+
+import (
+	"encoding/json"
+)
+
+// AppManifestError is an error found parsing the web app manifest.
+type AppManifestError struct {
+	Message  string `json:"message"`
+	Critical int    `json:"critical"` // 1 if the error prevents using the manifest
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// InstallabilityError is one of the reasons the page can't be installed as an app.
+type InstallabilityError struct {
+	ErrorID   string `json:"errorId"` // i.e. "no-manifest", "manifest-missing-suitable-icon", ...
+	Arguments []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"errorArguments"`
+}
+
+// PWAReport is the result of CheckPWA.
+type PWAReport struct {
+	// ManifestURL is the URL of the manifest, or "" if the page has no manifest.
+	ManifestURL string
+
+	// Manifest is the parsed manifest (nil if missing or not valid JSON).
+	Manifest map[string]interface{}
+
+	ManifestErrors       []AppManifestError
+	InstallabilityErrors []InstallabilityError
+}
+
+// Installable returns true if no installability errors were reported.
+func (r *PWAReport) Installable() bool {
+	return len(r.InstallabilityErrors) == 0
+}
+
+// CheckPWA returns the web app manifest of the current page, with the manifest and installability errors.
+func (remote *RemoteDebugger) CheckPWA() (*PWAReport, error) {
+	raw, err := remote.sendRawReplyRequest("Page.getAppManifest", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		URL    string             `json:"url"`
+		Errors []AppManifestError `json:"errors"`
+		Data   string             `json:"data"`
+	}
+
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, err
+	}
+
+	raw, err = remote.sendRawReplyRequest("Page.getInstallabilityErrors", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var installability struct {
+		Errors []InstallabilityError `json:"installabilityErrors"`
+	}
+
+	if err := json.Unmarshal(raw, &installability); err != nil {
+		return nil, err
+	}
+
+	report := &PWAReport{
+		ManifestURL:          manifest.URL,
+		ManifestErrors:       manifest.Errors,
+		InstallabilityErrors: installability.Errors,
+	}
+
+	if manifest.Data != "" {
+		// a manifest that is not valid JSON is already reported in ManifestErrors
+		json.Unmarshal([]byte(manifest.Data), &report.Manifest)
+	}
+
+	return report, nil
+}
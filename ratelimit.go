@@ -0,0 +1,117 @@
+package godet
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// tokenBucket is the rate limiter state for one origin.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// OriginLimiter is a per-origin token bucket rate limiter: each origin can be accessed at most
+// rate times per second on average, with bursts of up to burst requests.
+//
+// The same limiter can be shared by multiple connections, to limit the overall traffic to an origin.
+type OriginLimiter struct {
+	rate  float64
+	burst float64
+
+	sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewOriginLimiter returns an OriginLimiter allowing rate requests per second per origin, with bursts of up to burst requests.
+func NewOriginLimiter(rate float64, burst int) *OriginLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &OriginLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// reserve takes a token for the origin and returns how long to wait before using it.
+func (l *OriginLimiter) reserve(origin string) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+
+	b := l.buckets[origin]
+	if b == nil {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[origin] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// Wait waits until the origin of the URL can be accessed, or the context is done.
+// URLs without a host (i.e. about:blank or data: URLs) are never limited.
+func (l *OriginLimiter) Wait(ctx context.Context, rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	delay := l.reserve(u.Scheme + "://" + u.Host)
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NavigationLimit sets the per-origin rate limiter used by NavigateAndWait (and the crawl helpers).
+func NavigationLimit(limiter *OriginLimiter) ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.limiter = limiter
+	}
+}
+
+// SetNavigationLimit sets (or removes, if nil) the per-origin rate limiter used by NavigateAndWait.
+func (remote *RemoteDebugger) SetNavigationLimit(limiter *OriginLimiter) {
+	remote.Lock()
+	remote.limiter = limiter
+	remote.Unlock()
+}
+
+// WaitNavigationLimit waits until the rate limiter (if any) allows accessing the URL.
+func (remote *RemoteDebugger) WaitNavigationLimit(ctx context.Context, url string) error {
+	remote.Lock()
+	limiter := remote.limiter
+	remote.Unlock()
+
+	if limiter == nil {
+		return nil
+	}
+
+	return limiter.Wait(ctx, url)
+}
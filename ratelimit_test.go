@@ -0,0 +1,90 @@
+package godet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOriginLimiterReserve(t *testing.T) {
+	l := NewOriginLimiter(10, 2)
+
+	for i := 0; i < 2; i++ {
+		if d := l.reserve("https://a.com"); d != 0 {
+			t.Errorf("request %d within the burst waits %v", i+1, d)
+		}
+	}
+
+	if d := l.reserve("https://a.com"); d < 90*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("request over the burst waits %v, want 100ms", d)
+	}
+	if d := l.reserve("https://a.com"); d < 190*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("second request over the burst waits %v, want 200ms", d)
+	}
+
+	// the origins are independent
+	if d := l.reserve("https://b.com"); d != 0 {
+		t.Errorf("another origin waits %v", d)
+	}
+}
+
+func TestOriginLimiterRefill(t *testing.T) {
+	l := NewOriginLimiter(100, 1)
+
+	l.reserve("https://a.com")
+	time.Sleep(20 * time.Millisecond)
+
+	if d := l.reserve("https://a.com"); d != 0 {
+		t.Errorf("request after the refill waits %v", d)
+	}
+
+	// the bucket doesn't fill over the burst
+	time.Sleep(50 * time.Millisecond)
+	l.reserve("https://a.com")
+
+	if d := l.reserve("https://a.com"); d == 0 {
+		t.Error("the tokens exceeded the burst")
+	}
+}
+
+func TestOriginLimiterNoRate(t *testing.T) {
+	l := NewOriginLimiter(0, 0)
+
+	for i := 0; i < 3; i++ {
+		if d := l.reserve("https://a.com"); d != 0 {
+			t.Errorf("request %d waits %v with no rate", i+1, d)
+		}
+	}
+}
+
+func TestOriginLimiterWait(t *testing.T) {
+	l := NewOriginLimiter(20, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, u := range []string{"https://a.com/1", "https://a.com/2?x=1", "https://a.com/3"} {
+		if err := l.Wait(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v, want at least 100ms", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "about:blank"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("URLs without a host were limited (%v)", elapsed)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := l.Wait(cctx, "https://a.com/4"); err != context.Canceled {
+		t.Errorf("Wait with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
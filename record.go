@@ -0,0 +1,307 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// recordBinding is the name of the binding called by the recorder script.
+const recordBinding = "__godetRecord"
+
+// recordActionsJS reports the user clicks, input changes and Enter key presses to the recorder binding.
+const recordActionsJS = `(function() {
+  if (window.__godetRecorder || typeof __godetRecord !== "function") return;
+  window.__godetRecorder = true;
+
+  var selector = ` + elementSelectorJS + `;
+
+  function send(type, el, value, sensitive) {
+    try {
+      __godetRecord(JSON.stringify({type: type, selector: selector(el), value: value || "", sensitive: !!sensitive}));
+    } catch (e) {}
+  }
+
+  function isText(el) {
+    return el.isContentEditable || el.tagName === "TEXTAREA" ||
+      (el.tagName === "INPUT" && !/^(checkbox|radio|submit|button|reset|image|file)$/.test(el.type));
+  }
+
+  function value(el) {
+    return el.isContentEditable ? el.textContent : el.value;
+  }
+
+  document.addEventListener("click", function(e) {
+    var el = e.target.closest && e.target.closest("a,button,input,select,textarea,label,[role=button],[onclick]") || e.target;
+    if (isText(el) || el.tagName === "SELECT") return;
+    send("click", el);
+  }, true);
+
+  document.addEventListener("change", function(e) {
+    var el = e.target;
+    if (el.tagName === "SELECT") send("select", el, el.value);
+    else if (isText(el)) send("fill", el, value(el), el.type === "password");
+  }, true);
+
+  document.addEventListener("keydown", function(e) {
+    var el = e.target;
+    if (e.key === "Enter" && isText(el) && el.tagName !== "TEXTAREA" && !el.isContentEditable) {
+      send("fill", el, value(el), el.type === "password");
+      send("press", el, "Enter");
+    }
+  }, true);
+})()`
+
+// RecordedAction is a user action captured by ActionRecorder.
+type RecordedAction struct {
+	Type     string    `json:"type"` // navigate, click, fill, select or press
+	Selector string    `json:"selector,omitempty"`
+	Value    string    `json:"value,omitempty"` // the URL for navigate, the key for press
+	Time     time.Time `json:"time"`
+
+	// Sensitive is true if the value was typed in a password field.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// ActionRecorder records the meaningful actions (navigations, clicks, text input, selections and Enter key presses)
+// performed by a user in a headful browser, so that they can be replayed with ReplayActions.
+type ActionRecorder struct {
+	sync.Mutex
+	remote   *RemoteDebugger
+	actions  []RecordedAction
+	script   string
+	stop     func()
+	redirect bool // the next main frame navigation was requested by the page
+}
+
+func (rec *ActionRecorder) add(action RecordedAction) {
+	rec.Lock()
+	defer rec.Unlock()
+
+	action.Time = time.Now()
+
+	if n := len(rec.actions); n > 0 && action.Type == "fill" {
+		last := rec.actions[n-1]
+		if last.Type == "fill" && last.Selector == action.Selector && last.Value == action.Value {
+			return // change event after Enter
+		}
+	}
+
+	rec.actions = append(rec.actions, action)
+}
+
+// RecordActions starts recording the user actions in the current tab, until Stop is called.
+// Runtime and Page events are enabled, if needed.
+//
+// Note that the values typed in password fields are recorded (and marked as Sensitive),
+// so the recorded actions should be stored accordingly.
+func (remote *RemoteDebugger) RecordActions() (*ActionRecorder, error) {
+	for _, domain := range []string{"Page", "Runtime"} {
+		if err := remote.ensureDomain(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	rec := &ActionRecorder{remote: remote}
+
+	removeBinding := remote.addHook("Runtime.bindingCalled", func(params Params) bool {
+		if params.String("name") != recordBinding {
+			return false
+		}
+
+		var action RecordedAction
+		if err := json.Unmarshal([]byte(params.String("payload")), &action); err == nil {
+			rec.add(action)
+		}
+
+		return true
+	})
+
+	removeRequested := remote.addHook("Page.frameRequestedNavigation", func(params Params) bool {
+		remote.Lock()
+		current := remote.current
+		remote.Unlock()
+
+		if params.String("frameId") == current {
+			rec.Lock()
+			rec.redirect = true
+			rec.Unlock()
+		}
+
+		return false
+	})
+
+	removeNavigated := remote.addHook("Page.frameNavigated", func(params Params) bool {
+		frame := Params(params.Map("frame"))
+		if frame.String("parentId") != "" {
+			return false
+		}
+
+		rec.Lock()
+		requested := rec.redirect
+		rec.redirect = false
+		rec.Unlock()
+
+		if !requested { // typed in the address bar or sent via Page.navigate
+			rec.add(RecordedAction{Type: "navigate", Value: frame.String("url")})
+		}
+
+		return false
+	})
+
+	rec.stop = func() {
+		removeBinding()
+		removeRequested()
+		removeNavigated()
+	}
+
+	if _, err := remote.SendRequest("Runtime.addBinding", Params{"name": recordBinding}); err != nil {
+		rec.stop()
+		return nil, err
+	}
+
+	script, err := remote.AddScriptToEvaluateOnNewDocument(recordActionsJS)
+	if err != nil {
+		rec.stop()
+		return nil, err
+	}
+
+	rec.script = script
+
+	if _, err := remote.Evaluate(recordActionsJS); err != nil {
+		rec.Stop()
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// Actions returns the actions recorded so far.
+func (rec *ActionRecorder) Actions() []RecordedAction {
+	rec.Lock()
+	defer rec.Unlock()
+
+	return append([]RecordedAction(nil), rec.actions...)
+}
+
+// Stop stops recording and returns the recorded actions.
+func (rec *ActionRecorder) Stop() ([]RecordedAction, error) {
+	rec.stop()
+
+	err := rec.remote.RemoveScriptToEvaluateOnNewDocument(rec.script)
+
+	if _, rerr := rec.remote.SendRequest("Runtime.removeBinding", Params{"name": recordBinding}); err == nil {
+		err = rerr
+	}
+
+	return rec.Actions(), err
+}
+
+// WriteActions writes the recorded actions as JSON, for ReadActions.
+func WriteActions(w io.Writer, actions []RecordedAction) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(actions)
+}
+
+// ReadActions reads the actions written by WriteActions.
+func ReadActions(r io.Reader) ([]RecordedAction, error) {
+	var actions []RecordedAction
+	err := json.NewDecoder(r).Decode(&actions)
+	return actions, err
+}
+
+// pressEnter sends an Enter key press to the focused element.
+func (remote *RemoteDebugger) pressEnter() error {
+	for _, t := range []string{"keyDown", "keyUp"} {
+		params := Params{
+			"type":                  t,
+			"key":                   "Enter",
+			"code":                  "Enter",
+			"windowsVirtualKeyCode": 13,
+			"nativeVirtualKeyCode":  13,
+		}
+		if t == "keyDown" {
+			params["text"] = "\r"
+		}
+
+		if _, err := remote.SendRequest("Input.dispatchKeyEvent", params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitSelector waits until an element matches the selector, or the timeout expires.
+func (remote *RemoteDebugger) waitSelector(selector string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		_, err := remote.QuerySelectorNode(selector)
+		if err != ErrorNoSuchNode || time.Now().After(deadline) {
+			return err
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// ReplayActions performs the recorded actions in the current tab. Navigations wait for the page to load
+// and the other actions wait for the target element to exist, up to timeout.
+func (remote *RemoteDebugger) ReplayActions(actions []RecordedAction, timeout time.Duration) error {
+	for i, action := range actions {
+		value := action.Value
+		if action.Sensitive {
+			value = maskedValue
+		}
+
+		selector := action.Selector
+
+		err := remote.traceStep(action.Type, action.Selector, value, func() error {
+			if action.Type != "navigate" {
+				err := remote.waitSelector(selector, timeout)
+				if err == ErrorNoSuchNode {
+					selector, err = remote.healSelector(selector)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			switch action.Type {
+			case "navigate":
+				_, err := remote.NavigateAndWait(action.Value, timeout)
+				return err
+
+			case "click":
+				return remote.Click(selector)
+
+			case "fill":
+				return remote.Fill(selector, action.Value)
+
+			case "select":
+				_, err := remote.SelectOption(selector, action.Value)
+				return err
+
+			case "press":
+				if err := remote.FocusSelector(selector); err != nil {
+					return err
+				}
+
+				return remote.pressEnter()
+
+			default:
+				return fmt.Errorf("unknown action type %q", action.Type)
+			}
+		})
+
+		if err != nil {
+			return fmt.Errorf("action %v (%v %v): %v", i+1, action.Type, action.Selector, err)
+		}
+	}
+
+	return nil
+}
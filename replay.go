@@ -0,0 +1,281 @@
+package godet
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// replayFragment marks the URLs of the requests issued by ReplayRequest, so that they can be modified via Fetch.
+// The fragment is not sent to the server, and unlike a custom header it doesn't trigger a CORS preflight.
+const replayFragment = "#godet-replay-"
+
+var replayID int64
+
+// ErrorNoRequest is returned by ReplayRequest if the request was not captured (see CaptureRequests)
+var ErrorNoRequest = errors.New("request not captured")
+
+// replayMarker returns the marker of the paused request issued by ReplayRequest, or an empty string.
+func replayMarker(params Params) string {
+	fragment := Params(params.Map("request")).String("urlFragment")
+	if !strings.HasPrefix(fragment, replayFragment) {
+		return ""
+	}
+
+	return strings.TrimPrefix(fragment, replayFragment)
+}
+
+// isReplay returns true if the paused request was issued by ReplayRequest.
+func isReplay(params Params) bool {
+	return replayMarker(params) != ""
+}
+
+// CapturedRequest holds a request observed in Network.requestWillBeSent.
+type CapturedRequest struct {
+	ID          string
+	URL         string
+	Method      string
+	Headers     map[string]string
+	PostData    string
+	HasPostData bool
+
+	Type      string // resource type (Document, Script, XHR, ...)
+	Initiator *Initiator
+}
+
+// GetRequestPostData returns the post data sent with the request (from the Network.requestWillBeSent payload).
+// This is needed when the post data is too large to be included in the event.
+func (remote *RemoteDebugger) GetRequestPostData(requestID string) (string, error) {
+	res, err := remote.SendRequest("Network.getRequestPostData", Params{
+		"requestId": requestID,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	postData, _ := res["postData"].(string)
+	return postData, nil
+}
+
+// RequestPostData returns the post data for a Network.requestWillBeSent or Fetch.requestPaused event,
+// requesting it from the browser if it was not included in the event.
+func (remote *RemoteDebugger) RequestPostData(params Params) (string, error) {
+	req := Params(params.Map("request"))
+
+	if postData, ok := req["postData"].(string); ok || !req.Bool("hasPostData") {
+		return postData, nil
+	}
+
+	requestID := params.String("networkId") // Fetch.requestPaused
+	if requestID == "" {
+		requestID = params.String("requestId")
+	}
+
+	return remote.GetRequestPostData(requestID)
+}
+
+// CaptureRequests starts (or stops) recording the requests sent by the page, so that they can be replayed.
+// Stopping the capture discards the recorded requests. Requires Network events (see NetworkEvents).
+func (remote *RemoteDebugger) CaptureRequests(enable bool) {
+	remote.Lock()
+	removeHook := remote.stopCapture
+	remote.stopCapture = nil
+	remote.captured = nil
+	remote.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+
+	if !enable {
+		return
+	}
+
+	remote.Lock()
+	remote.captured = map[string]*CapturedRequest{}
+	remote.Unlock()
+
+	stop := remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+		req := Params(params.Map("request"))
+
+		captured := &CapturedRequest{
+			ID:          params.String("requestId"),
+			URL:         req.String("url"),
+			Method:      req.String("method"),
+			Headers:     map[string]string{},
+			PostData:    req.String("postData"),
+			HasPostData: req.Bool("hasPostData"),
+			Type:        params.String("type"),
+		}
+
+		if initiator, ok := params["initiator"]; ok {
+			captured.Initiator = &Initiator{}
+			decodeParams(initiator, captured.Initiator)
+		}
+
+		for k, v := range req.Map("headers") {
+			captured.Headers[k] = fmt.Sprint(v)
+		}
+
+		remote.Lock()
+		if remote.captured != nil {
+			remote.captured[params.String("requestId")] = captured
+		}
+		remote.Unlock()
+		return false
+	})
+
+	remote.Lock()
+	remote.stopCapture = stop
+	remote.Unlock()
+}
+
+// CapturedRequest returns the captured request with the specified requestId, or nil.
+func (remote *RemoteDebugger) CapturedRequest(requestID string) *CapturedRequest {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return remote.captured[requestID]
+}
+
+// RequestMutation modifies a request before it's replayed.
+type RequestMutation func(req *CapturedRequest)
+
+// ReplayURL changes the request URL.
+func ReplayURL(url string) RequestMutation {
+	return func(req *CapturedRequest) {
+		req.URL = url
+	}
+}
+
+// ReplayMethod changes the request method.
+func ReplayMethod(method string) RequestMutation {
+	return func(req *CapturedRequest) {
+		req.Method = method
+	}
+}
+
+// ReplayHeader sets a request header (an empty value removes the header).
+func ReplayHeader(name, value string) RequestMutation {
+	return func(req *CapturedRequest) {
+		for k := range req.Headers {
+			if strings.EqualFold(k, name) {
+				delete(req.Headers, k)
+			}
+		}
+
+		if value != "" {
+			req.Headers[name] = value
+		}
+	}
+}
+
+// ReplayPostData changes the request post data.
+func ReplayPostData(postData string) RequestMutation {
+	return func(req *CapturedRequest) {
+		req.PostData = postData
+		req.HasPostData = postData != ""
+	}
+}
+
+// ReplayResponse is the response to a replayed request.
+type ReplayResponse struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// ReplayRequest re-issues a captured request (see CaptureRequests) from the current page, after applying the mutations.
+//
+// The request is sent via the page fetch API and rewritten via Fetch.requestPaused, so that method, headers
+// and post data are exactly the ones of the captured request. The replayed requests are told from the page
+// requests by a URL fragment (that is not sent to the server).
+func (remote *RemoteDebugger) ReplayRequest(requestID string, mutations ...RequestMutation) (*ReplayResponse, error) {
+	captured := remote.CapturedRequest(requestID)
+	if captured == nil {
+		return nil, ErrorNoRequest
+	}
+
+	req := *captured
+	req.Headers = map[string]string{}
+	for k, v := range captured.Headers {
+		req.Headers[k] = v
+	}
+
+	if req.HasPostData && req.PostData == "" {
+		postData, err := remote.GetRequestPostData(requestID)
+		if err != nil {
+			return nil, err
+		}
+
+		req.PostData = postData
+	}
+
+	for _, mutate := range mutations {
+		mutate(&req)
+	}
+
+	marker := strconv.FormatInt(atomic.AddInt64(&replayID, 1), 10)
+
+	fetchURL := req.URL
+	if i := strings.Index(fetchURL, "#"); i >= 0 {
+		fetchURL = fetchURL[:i]
+	}
+
+	removeHook := remote.addHook("Fetch.requestPaused", func(params Params) bool {
+		if replayMarker(params) != marker {
+			return false // another request, continued by requestPaused
+		}
+
+		headers := []map[string]string{}
+		for k, v := range req.Headers {
+			headers = append(headers, map[string]string{"name": k, "value": v})
+		}
+
+		remote.SendRequest("Fetch.continueRequest", Params{
+			"requestId": params.String("requestId"),
+			"method":    req.Method,
+			"headers":   headers,
+			"postData":  base64.StdEncoding.EncodeToString([]byte(req.PostData)),
+		})
+		return true
+	})
+
+	defer removeHook()
+
+	if err := remote.updateFetch(FetchRequestPattern{UrlPattern: fetchURL}); err != nil {
+		return nil, err
+	}
+
+	defer remote.updateFetch()
+
+	// the real method, headers and body are set when continuing the request: the page sends
+	// a simple request (without custom headers), that doesn't need a CORS preflight.
+	method, body := "GET", "null"
+	if req.PostData != "" {
+		method, body = "POST", `"replay"`
+	}
+
+	res, err := remote.Evaluate(fmt.Sprintf(`fetch(%s, {method: %s, body: %s, credentials: "include"})
+		.then(r => r.text().then(body => ({status: r.status, body: body})))`,
+		jsString(fetchURL+replayFragment+marker), jsString(method), body), AwaitPromise(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ReplayResponse
+
+	if m, ok := res.(map[string]interface{}); ok {
+		resp.Status = Params(m).Int("status")
+		resp.Body = Params(m).String("body")
+	}
+
+	return &resp, nil
+}
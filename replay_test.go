@@ -0,0 +1,121 @@
+package godet
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestReplayRequest(t *testing.T) {
+	var lock sync.Mutex
+	var expression string
+	var continued []Params
+
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		evaluateID := 0
+
+		for {
+			var cmd struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+				Params Params `json:"params"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			switch cmd.Method {
+			case "Runtime.evaluate":
+				lock.Lock()
+				expression = cmd.Params.String("expression")
+				lock.Unlock()
+
+				// the page fetch, and another request of the page, are paused
+				evaluateID = cmd.ID
+				fragment := expression[strings.Index(expression, "#godet-replay-"):strings.Index(expression, `", {`)]
+
+				for _, paused := range []Params{
+					{"requestId": "page", "request": Params{"url": "https://example.com/api/items", "method": "GET"}},
+					{"requestId": "replay", "request": Params{"url": "https://example.com/api/items", "urlFragment": fragment, "method": "POST"}},
+				} {
+					if err := wsjson.Write(ctx, c, Params{"method": "Fetch.requestPaused", "params": paused}); err != nil {
+						return
+					}
+				}
+
+				continue
+
+			case "Fetch.continueRequest":
+				lock.Lock()
+				continued = append(continued, cmd.Params)
+				done := len(continued) == 2
+				lock.Unlock()
+
+				if done {
+					reply := Params{"id": evaluateID, "result": Params{"result": Params{"type": "object", "value": Params{"status": 201, "body": "created"}}}}
+					if err := wsjson.Write(ctx, c, reply); err != nil {
+						return
+					}
+				}
+			}
+
+			if err := wsjson.Write(ctx, c, Params{"id": cmd.ID, "result": Params{}}); err != nil {
+				return
+			}
+		}
+	})
+
+	remote := connectFake(t, tab)
+	remote.CaptureRequests(true)
+
+	fakeEvent(remote, "Network.requestWillBeSent", Params{
+		"requestId": "1",
+		"type":      ResourceTypeXHR,
+		"request": Params{
+			"url":      "https://example.com/api/items",
+			"method":   "PUT",
+			"headers":  Params{"Content-Type": "application/json", "X-Token": "t"},
+			"postData": `{"name": "a"}`,
+		},
+	})
+
+	resp, err := remote.ReplayRequest("1", ReplayHeader("x-token", "u"), ReplayPostData(`{"name": "b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Status != 201 || resp.Body != "created" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// no custom headers in the page request, so no CORS preflight
+	if strings.Contains(expression, "headers") || !strings.Contains(expression, `fetch("https://example.com/api/items#godet-replay-`) {
+		t.Errorf("fetch expression = %s", expression)
+	}
+
+	if len(continued) != 2 || continued[0].String("requestId") != "page" || continued[0]["method"] != nil {
+		t.Fatalf("continued = %v", continued)
+	}
+
+	replay := continued[1]
+	postData, _ := base64.StdEncoding.DecodeString(replay.String("postData"))
+
+	headers := map[string]string{}
+	for _, h := range replay["headers"].([]interface{}) {
+		m := Params(h.(map[string]interface{}))
+		headers[m.String("name")] = m.String("value")
+	}
+
+	if replay.String("method") != "PUT" || string(postData) != `{"name": "b"}` || headers["x-token"] != "u" || len(headers) != 2 {
+		t.Errorf("replayed request = %v %s %v", replay.String("method"), postData, headers)
+	}
+}
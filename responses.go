@@ -0,0 +1,490 @@
+package godet
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ObservedResponse is a request/response pair recorded by RecordResponses.
+type ObservedResponse struct {
+	RequestID string
+	URL       string
+	Method    string
+	Type      ResourceType
+
+	// LoaderID identifies the document load the request belongs to (a document and its subresources have the same).
+	LoaderID string
+
+	// Timestamp is the time the request was sent (in seconds, from an arbitrary point in the past).
+	Timestamp float64
+
+	Status     int
+	StatusText string
+	Headers    map[string]string
+	MimeType   string
+
+	FromCache         bool
+	FromServiceWorker bool
+
+	// Protocol is the network protocol negotiated for the response (i.e. "http/1.1", "h2" or "h3").
+	Protocol string
+
+	// AlternateProtocolUsage reports why HTTP/3 (QUIC) was or wasn't used (i.e. "alternativeJobWonRace").
+	AlternateProtocolUsage string
+
+	// EarlyHints are the headers of the 103 Early Hints response, if the server sent one.
+	EarlyHints map[string]string
+
+	// Failed is true if the request failed (see ErrorText), as opposed to a response with an error status.
+	Failed    bool
+	ErrorText string
+
+	// RequestHeaders are the request headers, as sent on the wire if reported by Network.requestWillBeSentExtraInfo.
+	RequestHeaders map[string]string
+
+	// Cookies are the cookies the browser considered for the request, including the blocked ones
+	// (from Network.requestWillBeSentExtraInfo).
+	Cookies []ObservedCookie
+
+	// SetCookies are the cookies set by the response, including the blocked ones
+	// (from Network.responseReceivedExtraInfo).
+	SetCookies []ObservedCookie
+
+	requestExtra  bool // requestWillBeSentExtraInfo received
+	responseExtra bool // responseReceivedExtraInfo received
+}
+
+// ObservedCookie is a cookie sent with a request or set by a response (see ObservedResponse).
+type ObservedCookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	Path     string
+	Expires  time.Time // zero for session cookies
+	Secure   bool
+	HttpOnly bool
+	SameSite string // Strict, Lax, None or empty if not specified
+
+	// BlockedReasons are the reasons the browser didn't send (or store) the cookie. Empty if it did.
+	BlockedReasons []string
+}
+
+// Blocked returns true if the browser didn't send (or store) the cookie.
+func (c ObservedCookie) Blocked() bool {
+	return len(c.BlockedReasons) > 0
+}
+
+func (r *ObservedResponse) setResponse(response map[string]interface{}) {
+	resp := Params(response)
+
+	r.Status = resp.Int("status")
+	r.StatusText = resp.String("statusText")
+	r.Headers = mergeHeaders(headerMap(resp.Map("headers")), r.Headers)
+	if r.RequestHeaders == nil {
+		r.RequestHeaders = headerMap(resp.Map("requestHeaders"))
+	}
+	r.MimeType = resp.String("mimeType")
+	r.FromCache = resp.Bool("fromDiskCache")
+	r.FromServiceWorker = resp.Bool("fromServiceWorker")
+	r.Protocol = resp.String("protocol")
+	r.AlternateProtocolUsage = resp.String("alternateProtocolUsage")
+}
+
+// setRequestExtra merges the Network.requestWillBeSentExtraInfo event.
+func (r *ObservedResponse) setRequestExtra(params Params) {
+	r.requestExtra = true
+	r.RequestHeaders = headerMap(params.Map("headers"))
+	r.Cookies = nil
+
+	list, _ := params["associatedCookies"].([]interface{})
+
+	for _, v := range list {
+		ac, _ := v.(map[string]interface{})
+		c := Params(Params(ac).Map("cookie"))
+
+		cookie := ObservedCookie{
+			Name:           c.String("name"),
+			Value:          c.String("value"),
+			Domain:         c.String("domain"),
+			Path:           c.String("path"),
+			Secure:         c.Bool("secure"),
+			HttpOnly:       c.Bool("httpOnly"),
+			SameSite:       c.String("sameSite"),
+			BlockedReasons: stringList(ac["blockedReasons"]),
+		}
+
+		if expires, _ := c["expires"].(float64); expires > 0 && !c.Bool("session") {
+			cookie.Expires = time.Unix(int64(expires), 0)
+		}
+
+		r.Cookies = append(r.Cookies, cookie)
+	}
+}
+
+// setResponseExtra merges the Network.responseReceivedExtraInfo event.
+func (r *ObservedResponse) setResponseExtra(params Params) {
+	r.responseExtra = true
+	r.Headers = mergeHeaders(headerMap(params.Map("headers")), r.Headers)
+	r.SetCookies = setCookies(r.URL, params, time.Now())
+}
+
+// setCookies returns the cookies set by the raw response headers of the Network.responseReceivedExtraInfo event,
+// with the reasons the browser blocked them (if it did).
+func setCookies(respURL string, params Params, now time.Time) []ObservedCookie {
+	blocked := map[string][]string{}
+
+	list, _ := params["blockedCookies"].([]interface{})
+
+	for _, v := range list {
+		bc, _ := v.(map[string]interface{})
+		line := Params(bc).String("cookieLine")
+		blocked[line] = append(blocked[line], stringList(bc["blockedReasons"])...)
+	}
+
+	var host string
+	if u, err := url.Parse(respURL); err == nil {
+		host = u.Hostname()
+	}
+
+	var cookies []ObservedCookie
+
+	for k, v := range params.Map("headers") {
+		if !strings.EqualFold(k, "set-cookie") {
+			continue
+		}
+
+		s, _ := v.(string)
+
+		for _, line := range strings.Split(s, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			resp := http.Response{Header: http.Header{"Set-Cookie": {line}}}
+			parsed := resp.Cookies()
+			if len(parsed) == 0 {
+				continue
+			}
+
+			c := parsed[0]
+
+			cookie := ObservedCookie{
+				Name:           c.Name,
+				Value:          c.Value,
+				Domain:         c.Domain,
+				Path:           c.Path,
+				Secure:         c.Secure,
+				HttpOnly:       c.HttpOnly,
+				BlockedReasons: blocked[line],
+			}
+
+			if cookie.Domain == "" {
+				cookie.Domain = host
+			}
+			if cookie.Path == "" {
+				cookie.Path = "/"
+			}
+
+			if c.MaxAge > 0 {
+				cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+			} else if !c.Expires.IsZero() {
+				cookie.Expires = c.Expires
+			}
+
+			switch c.SameSite {
+			case http.SameSiteStrictMode:
+				cookie.SameSite = "Strict"
+			case http.SameSiteLaxMode:
+				cookie.SameSite = "Lax"
+			case http.SameSiteNoneMode:
+				cookie.SameSite = "None"
+			}
+
+			cookies = append(cookies, cookie)
+		}
+	}
+
+	return cookies
+}
+
+// stringList converts a protocol array of strings.
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+
+	var strs []string
+	for _, s := range list {
+		if s, ok := s.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs
+}
+
+// mergeHeaders adds to dst the headers in src it doesn't have (case insensitive) and returns it.
+func mergeHeaders(dst, src map[string]string) map[string]string {
+	if dst == nil {
+		dst = map[string]string{}
+	}
+
+	for k, v := range src {
+		if _, ok := responseHeader(dst, k); !ok {
+			dst[k] = v
+		}
+	}
+
+	return dst
+}
+
+// responseRecorder holds the responses recorded by RecordResponses.
+type responseRecorder struct {
+	responses []*ObservedResponse
+	requests  map[string]*ObservedResponse
+
+	// the ExtraInfo events received before the primary events
+	requestExtra  map[string]Params
+	responseExtra map[string]Params
+
+	stop []func()
+}
+
+// headerMap converts the protocol headers object to a map of strings.
+func headerMap(headers map[string]interface{}) map[string]string {
+	m := map[string]string{}
+
+	for k, v := range headers {
+		m[k] = fmt.Sprint(v)
+	}
+
+	return m
+}
+
+// RecordResponses starts (or stops) recording the responses received by the page, in order.
+// Stopping discards the recorded responses. Requires Network events (see NetworkEvents).
+//
+// The Network.requestWillBeSentExtraInfo and Network.responseReceivedExtraInfo events are merged
+// into the records, since the raw headers and the (blocked) cookies are only reported there.
+func (remote *RemoteDebugger) RecordResponses(enable bool) {
+	remote.Lock()
+	rr := remote.recorder
+	if !enable {
+		remote.recorder = nil
+	}
+	remote.Unlock()
+
+	if !enable {
+		if rr != nil {
+			for _, stop := range rr.stop {
+				stop()
+			}
+		}
+
+		return
+	}
+
+	if rr != nil { // already recording
+		return
+	}
+
+	rr = &responseRecorder{
+		requests:      map[string]*ObservedResponse{},
+		requestExtra:  map[string]Params{},
+		responseExtra: map[string]Params{},
+	}
+
+	rr.stop = append(rr.stop, remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+		req := Params(params.Map("request"))
+
+		remote.Lock()
+		if redirect, ok := params["redirectResponse"].(map[string]interface{}); ok {
+			// the previous hop is complete and a new one starts with the same requestId
+			if prev := rr.requests[params.String("requestId")]; prev != nil {
+				prev.setResponse(redirect)
+			}
+		}
+
+		timestamp, _ := params["timestamp"].(float64)
+
+		r := &ObservedResponse{
+			RequestID: params.String("requestId"),
+			URL:       req.String("url"),
+			Method:    req.String("method"),
+			Type:      ResourceType(params.String("type")),
+			LoaderID:  params.String("loaderId"),
+			Timestamp: timestamp,
+		}
+
+		if extra, ok := rr.requestExtra[r.RequestID]; ok {
+			r.setRequestExtra(extra)
+			delete(rr.requestExtra, r.RequestID)
+		}
+		if extra, ok := rr.responseExtra[r.RequestID]; ok {
+			r.setResponseExtra(extra)
+			delete(rr.responseExtra, r.RequestID)
+		}
+
+		rr.requests[r.RequestID] = r
+		rr.responses = append(rr.responses, r)
+		remote.Unlock()
+		return false
+	}))
+
+	// the ExtraInfo events can be received before or after the primary events:
+	// if the current hop already has them, they belong to the next one.
+
+	rr.stop = append(rr.stop, remote.addHook("Network.requestWillBeSentExtraInfo", func(params Params) bool {
+		id := params.String("requestId")
+
+		remote.Lock()
+		if r := rr.requests[id]; r != nil && !r.requestExtra {
+			r.setRequestExtra(params)
+		} else {
+			rr.requestExtra[id] = params
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	rr.stop = append(rr.stop, remote.addHook("Network.responseReceivedExtraInfo", func(params Params) bool {
+		id := params.String("requestId")
+
+		remote.Lock()
+		if r := rr.requests[id]; r != nil && !r.responseExtra {
+			r.setResponseExtra(params)
+		} else {
+			rr.responseExtra[id] = params
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	rr.stop = append(rr.stop, remote.addHook("Network.responseReceived", func(params Params) bool {
+		resp := params.Map("response")
+
+		remote.Lock()
+		if r := rr.requests[params.String("requestId")]; r != nil {
+			r.setResponse(resp)
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	rr.stop = append(rr.stop, remote.addHook("Network.responseReceivedEarlyHints", func(params Params) bool {
+		remote.Lock()
+		if r := rr.requests[params.String("requestId")]; r != nil {
+			r.EarlyHints = headerMap(params.Map("headers"))
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	rr.stop = append(rr.stop, remote.addHook("Network.loadingFailed", func(params Params) bool {
+		remote.Lock()
+		if r := rr.requests[params.String("requestId")]; r != nil {
+			r.Failed = true
+			r.ErrorText = params.String("errorText")
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	remote.Lock()
+	remote.recorder = rr
+	remote.Unlock()
+}
+
+// Responses returns the responses recorded so far (see RecordResponses).
+func (remote *RemoteDebugger) Responses() []ObservedResponse {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if remote.recorder == nil {
+		return nil
+	}
+
+	responses := make([]ObservedResponse, len(remote.recorder.responses))
+	for i, r := range remote.recorder.responses {
+		responses[i] = *r
+	}
+
+	return responses
+}
+
+// ClearResponses discards the responses recorded so far.
+func (remote *RemoteDebugger) ClearResponses() {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if remote.recorder != nil {
+		remote.recorder.responses = nil
+		remote.recorder.requests = map[string]*ObservedResponse{}
+		remote.recorder.requestExtra = map[string]Params{}
+		remote.recorder.responseExtra = map[string]Params{}
+	}
+}
+
+// RedirectHop is a step of a redirect chain (see RedirectChain).
+type RedirectHop struct {
+	URL        string
+	Status     int
+	StatusText string
+	Headers    map[string]string
+
+	// Duration is the time from this request to the next hop (or 0 for the last hop).
+	Duration time.Duration
+}
+
+// RedirectChain returns the hops the browser followed for the last request to url (as recorded
+// by RecordResponses), starting from url and including the final response.
+func (remote *RemoteDebugger) RedirectChain(url string) ([]RedirectHop, error) {
+	responses := remote.Responses()
+
+	start := -1
+	for i, r := range responses {
+		if r.URL == url && (i == 0 || responses[i-1].RequestID != r.RequestID || !isRedirect(responses[i-1].Status)) {
+			start = i
+		}
+	}
+
+	if start < 0 {
+		return nil, ErrorNoRequest
+	}
+
+	var hops []RedirectHop
+	var last float64
+
+	for i := start; i < len(responses); i++ {
+		r := responses[i]
+		if r.RequestID != responses[start].RequestID {
+			continue
+		}
+
+		if n := len(hops); n > 0 {
+			hops[n-1].Duration = time.Duration((r.Timestamp - last) * float64(time.Second))
+		}
+
+		last = r.Timestamp
+
+		hops = append(hops, RedirectHop{
+			URL:        r.URL,
+			Status:     r.Status,
+			StatusText: r.StatusText,
+			Headers:    r.Headers,
+		})
+
+		if !isRedirect(r.Status) {
+			break
+		}
+	}
+
+	return hops, nil
+}
+
+// isRedirect returns true for the HTTP redirect status codes.
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400 && status != 304
+}
@@ -0,0 +1,76 @@
+package godet
+
+import (
+	"strings"
+	"time"
+)
+
+// TransientErrors lists the (partial) protocol error messages that are usually caused by racing a navigation
+// and are worth retrying.
+var TransientErrors = []string{
+	"Target closed",
+	"Cannot find context with specified id",
+	"Execution context was destroyed",
+	"Inspected target navigated or closed",
+}
+
+// IsTransientError returns true if err is a ProtocolError matching one of TransientErrors.
+func IsTransientError(err error) bool {
+	perr, ok := err.(ProtocolError)
+	if !ok {
+		return false
+	}
+
+	for _, msg := range TransientErrors {
+		if strings.Contains(perr.Message, msg) || strings.Contains(perr.Data, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryPolicy defines how failed requests are retried (see Retry).
+type RetryPolicy struct {
+	// Methods to retry (i.e. "Runtime.evaluate"). If empty all methods are retried.
+	Methods []string
+	// MaxAttempts is the maximum number of times a request is sent.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each following attempt.
+	Backoff time.Duration
+	// Retryable decides if an error should be retried, the default is IsTransientError.
+	Retryable func(err error) bool
+}
+
+func (policy *RetryPolicy) applies(method string) bool {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return false
+	}
+
+	if len(policy.Methods) == 0 {
+		return true
+	}
+
+	for _, m := range policy.Methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (policy *RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+
+	return IsTransientError(err)
+}
+
+// Retry sets the policy used to retry requests failing with transient protocol errors.
+func Retry(policy RetryPolicy) ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.retry = &policy
+	}
+}
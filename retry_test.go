@@ -0,0 +1,125 @@
+package godet
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{ProtocolError{Code: -32000, Message: "Execution context was destroyed."}, true},
+		{ProtocolError{Code: -32000, Message: "Internal error", Data: "Target closed"}, true},
+		{ProtocolError{Code: -32000, Message: "Cannot find context with specified id"}, true},
+		{ProtocolError{Code: -32601, Message: "'Foo.bar' wasn't found"}, false},
+		{ErrorClose, false},
+		{errors.New("Target closed"), false}, // not a protocol error
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientError(tt.err); got != tt.transient {
+			t.Errorf("IsTransientError(%v) = %v, want %v", tt.err, got, tt.transient)
+		}
+	}
+}
+
+// flakyBrowser fails the first commands (up to failures) with err, and counts the commands.
+type flakyBrowser struct {
+	sync.Mutex
+	failures int
+	err      ProtocolError
+	calls    int
+}
+
+func (b *flakyBrowser) reply(method string, params Params) (interface{}, *ProtocolError) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.calls++
+	if b.calls <= b.failures {
+		return nil, &b.err
+	}
+
+	return Params{"ok": true}, nil
+}
+
+func (b *flakyBrowser) count() int {
+	b.Lock()
+	defer b.Unlock()
+	return b.calls
+}
+
+func TestRetry(t *testing.T) {
+	transient := ProtocolError{Code: -32000, Message: "Execution context was destroyed."}
+	permanent := ProtocolError{Code: -32602, Message: "Invalid parameters"}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		method   string
+		failures int
+		err      ProtocolError
+		calls    int
+		ok       bool
+	}{
+		{"recovers", RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}, "Runtime.evaluate", 2, transient, 3, true},
+		{"gives up", RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, "Runtime.evaluate", 5, transient, 3, false},
+		{"permanent error", RetryPolicy{MaxAttempts: 3}, "Runtime.evaluate", 1, permanent, 1, false},
+		{"other method", RetryPolicy{Methods: []string{"DOM.getDocument"}, MaxAttempts: 3}, "Runtime.evaluate", 1, transient, 1, false},
+		{"listed method", RetryPolicy{Methods: []string{"Runtime.evaluate"}, MaxAttempts: 3}, "Runtime.evaluate", 1, transient, 2, true},
+		{"single attempt", RetryPolicy{MaxAttempts: 1}, "Runtime.evaluate", 1, transient, 1, false},
+		{"custom retryable", RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return true }}, "Runtime.evaluate", 2, permanent, 3, true},
+	}
+
+	for _, tt := range tests {
+		b := &flakyBrowser{failures: tt.failures, err: tt.err}
+		remote := connectFake(t, fakeCDP(t, b.reply), Retry(tt.policy))
+
+		start := time.Now()
+		_, err := remote.SendRequest(tt.method, nil)
+		elapsed := time.Since(start)
+
+		remote.Close()
+
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+		if calls := b.count(); calls != tt.calls {
+			t.Errorf("%s: %d attempts, want %d", tt.name, calls, tt.calls)
+		}
+
+		// the backoff doubles at each attempt
+		var wait time.Duration
+		for i := 0; i < tt.calls-1; i++ {
+			wait += tt.policy.Backoff << i
+		}
+		if elapsed < wait {
+			t.Errorf("%s: retried after %v, want at least %v", tt.name, elapsed, wait)
+		}
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	b := &flakyBrowser{failures: 1, err: ProtocolError{Code: -32000, Message: "No node with given id found"}}
+	remote := connectFake(t, fakeCDP(t, b.reply))
+	defer remote.Close()
+
+	res, err := remote.SendRequest("DOM.describeNode", Params{"nodeId": 42})
+	if res != nil {
+		t.Errorf("result = %v, want nil", res)
+	}
+
+	var perr ProtocolError
+	if !errors.As(err, &perr) || perr.Code != -32000 || err.Error() != "No node with given id found (-32000)" {
+		t.Errorf("SendRequest error = %#v", err)
+	}
+
+	if _, err := remote.SendRequest("DOM.describeNode", Params{"nodeId": 42}); err != nil {
+		t.Errorf("SendRequest after the failure = %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package godet
+
+import (
+	"log"
+	"net/url"
+	"strings"
+)
+
+// requestRewrite modifies the requests matching pattern (see InjectHeader and RewriteHost).
+type requestRewrite struct {
+	pattern string
+	apply   func(u *url.URL, headers map[string]string) bool // returns true if the request was modified
+}
+
+// continuePaused continues a paused request, after applying the request rewrites
+// (or fails it, if blocked by the third party allowlist).
+func (remote *RemoteDebugger) continuePaused(params Params) {
+	requestID := params.String("requestId")
+	req := Params(params.Map("request"))
+	reqURL := req.String("url")
+
+	if remote.blockedThirdParty(params) {
+		remote.FailRequest(requestID, ErrorReasonBlockedByClient)
+		return
+	}
+
+	remote.Lock()
+	rewrites := remote.rewrites
+	remote.Unlock()
+
+	u, err := url.Parse(reqURL)
+	if err != nil || len(rewrites) == 0 {
+		remote.ContinueRequest(requestID, "", "", "", nil)
+		return
+	}
+
+	headers := headerMap(req.Map("headers"))
+	modified := false
+
+	for _, rw := range rewrites {
+		if matchURLPattern(rw.pattern, reqURL) && rw.apply(u, headers) {
+			modified = true
+		}
+	}
+
+	if !modified {
+		remote.ContinueRequest(requestID, "", "", "", nil)
+		return
+	}
+
+	hlist := []map[string]string{}
+	for k, v := range headers {
+		hlist = append(hlist, map[string]string{"name": k, "value": v})
+	}
+
+	continueParams := Params{
+		"requestId": requestID,
+		"headers":   hlist,
+	}
+
+	if newURL := u.String(); newURL != reqURL {
+		continueParams["url"] = newURL
+	}
+
+	if _, err := remote.SendRequest("Fetch.continueRequest", continueParams); err != nil && remote.verbose {
+		log.Println("cannot continue request", reqURL, err)
+	}
+}
+
+// requestPaused handles the requests paused by the internal Fetch patterns (see updateFetch): it fails the
+// requests blocked by the filter list, delivers the requests matching the EnableRequestPaused patterns to
+// the callback and continues the others (see continuePaused).
+func (remote *RemoteDebugger) requestPaused(params Params) bool {
+	if _, ok := params["responseStatusCode"]; ok || isReplay(params) {
+		return false // response stage, or handled by ReplayRequest
+	}
+
+	if remote.filterBlocked(params) {
+		return true
+	}
+
+	if remote.userPaused(params) {
+		return false
+	}
+
+	remote.continuePaused(params)
+	return true
+}
+
+// fetchHook installs (or removes) the Fetch.requestPaused hook that calls requestPaused.
+func (remote *RemoteDebugger) fetchHook(enable bool) {
+	remote.Lock()
+	stop := remote.stopFetch
+	if !enable {
+		remote.stopFetch = nil
+	}
+	remote.Unlock()
+
+	if !enable {
+		if stop != nil {
+			stop()
+		}
+		return
+	}
+
+	if stop != nil {
+		return
+	}
+
+	stop = remote.addHook("Fetch.requestPaused", remote.requestPaused)
+
+	remote.Lock()
+	if remote.stopFetch == nil {
+		remote.stopFetch, stop = stop, nil
+	}
+	remote.Unlock()
+
+	if stop != nil {
+		stop() // installed by a concurrent call
+	}
+}
+
+// addRewrite adds a request rewrite.
+func (remote *RemoteDebugger) addRewrite(rw *requestRewrite) error {
+	remote.Lock()
+	rewrites := append([]*requestRewrite(nil), remote.rewrites...) // continuePaused may be iterating the old slice
+	remote.rewrites = append(rewrites, rw)
+	remote.Unlock()
+
+	return remote.updateFetch()
+}
+
+// InjectHeader adds (or replaces) a header in the requests whose URL matches urlPattern
+// (with '*' and '?' wildcards), via Fetch interception.
+//
+// Requests delivered to the Fetch.requestPaused callback (see EnableRequestPaused) are not modified.
+func (remote *RemoteDebugger) InjectHeader(urlPattern, key, value string) error {
+	return remote.addRewrite(&requestRewrite{
+		pattern: urlPattern,
+		apply: func(_ *url.URL, headers map[string]string) bool {
+			for k := range headers {
+				if strings.EqualFold(k, key) {
+					delete(headers, k)
+				}
+			}
+
+			headers[key] = value
+			return true
+		},
+	})
+}
+
+// RewriteHost sends the requests for host from (i.e. "api.example.com") to host to, that can
+// also specify a different scheme (i.e. "http://localhost:8080"). The page still sees the original URLs.
+//
+// Requests delivered to the Fetch.requestPaused callback (see EnableRequestPaused) are not modified.
+func (remote *RemoteDebugger) RewriteHost(from, to string) error {
+	scheme, host := "", to
+	if i := strings.Index(to, "://"); i >= 0 {
+		scheme, host = to[:i], strings.TrimSuffix(to[i+3:], "/")
+	}
+
+	return remote.addRewrite(&requestRewrite{
+		pattern: "*",
+		apply: func(u *url.URL, _ map[string]string) bool {
+			if u.Host != from && u.Hostname() != from {
+				return false
+			}
+
+			if scheme != "" {
+				u.Scheme = scheme
+			}
+
+			u.Host = host
+			return true
+		},
+	})
+}
+
+// ClearRewrites removes all the request rewrites (see InjectHeader and RewriteHost).
+func (remote *RemoteDebugger) ClearRewrites() error {
+	remote.Lock()
+	active := len(remote.rewrites) > 0
+	remote.rewrites = nil
+	remote.Unlock()
+
+	if !active {
+		return nil
+	}
+
+	return remote.updateFetch()
+}
@@ -0,0 +1,112 @@
+package godet
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRequestPaused(t *testing.T) {
+	var lock sync.Mutex
+	var calls []string
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch method {
+		case "Fetch.enable", "Fetch.disable":
+			calls = append(calls, method)
+
+		case "Fetch.failRequest", "Fetch.continueRequest":
+			call := method + " " + params.String("requestId")
+			if headers, ok := params["headers"].([]interface{}); ok {
+				for _, h := range headers {
+					if m, _ := h.(map[string]interface{}); m["name"] == "X-Test" {
+						call += " X-Test"
+					}
+				}
+			}
+
+			calls = append(calls, call)
+		}
+
+		return nil, nil
+	}))
+
+	var delivered []string
+
+	remote.CallbackEvent("Fetch.requestPaused", func(params Params) {
+		delivered = append(delivered, params.String("requestId"))
+	})
+
+	paused := func(id, url string, resourceType ResourceType) {
+		fakeEvent(remote, "Fetch.requestPaused", Params{
+			"requestId":    id,
+			"frameId":      "fake",
+			"resourceType": resourceType,
+			"request":      Params{"url": url, "method": "GET", "headers": Params{}},
+		})
+	}
+
+	list, err := ParseFilterList(strings.NewReader("/pixel.gif\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []func() error{
+		func() error { return remote.SetProxyCredentials("user", "secret") },
+		func() error { return remote.EnableThirdPartyAllowlist(nil, true) },
+		func() error { return remote.InjectHeader("*/api*", "X-Test", "1") },
+		func() error { return remote.EnableFilterList(list) },
+		func() error { return remote.EnableRequestPaused(true, FetchRequestPattern{UrlPattern: "*/user*"}) },
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paused("doc", "https://www.shop.example/", ResourceTypeDocument)
+	paused("ad", "https://ads.example/ad.js", ResourceTypeScript)            // third party
+	paused("api", "https://www.shop.example/api/items", ResourceTypeXHR)     // rewritten
+	paused("pixel", "https://www.shop.example/pixel.gif", ResourceTypeImage) // filtered
+	paused("user", "https://www.shop.example/user", ResourceTypeXHR)         // delivered to the callback
+	fakeEvent(remote, "Fetch.requestPaused", Params{"requestId": "stage", "responseStatusCode": 200, "request": Params{"url": "https://www.shop.example/"}})
+
+	remote.EnableRequestPaused(false)
+	remote.EnableFilterList(nil)
+	remote.ClearRewrites()
+	remote.DisableThirdPartyAllowlist()
+	remote.SetProxyCredentials("", "")
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	want := []string{
+		"Fetch.enable", "Fetch.enable", "Fetch.enable", "Fetch.enable", "Fetch.enable",
+		"Fetch.continueRequest doc",
+		"Fetch.failRequest ad",
+		"Fetch.continueRequest api X-Test",
+		"Fetch.failRequest pixel",
+		"Fetch.enable", "Fetch.enable", "Fetch.enable", "Fetch.enable", "Fetch.disable",
+	}
+
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	if want := []string{"user", "stage"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered = %q, want %q", delivered, want)
+	}
+
+	remote.Lock()
+	installed := remote.stopFetch != nil
+	remote.Unlock()
+
+	if installed {
+		t.Error("the requestPaused hook is still installed")
+	}
+}
@@ -0,0 +1,92 @@
+package godet
+
+import (
+	"context"
+)
+
+// ManualRun disables the background goroutine that dispatches events to the callbacks.
+// Events are instead dispatched by Run, in the calling goroutine.
+func ManualRun() ConnectOption {
+	return func(remote *RemoteDebugger) {
+		remote.manualRun = true
+	}
+}
+
+// Run dispatches events to the registered callbacks until ctx is done or the connection terminates.
+// It requires the ManualRun option and is designed to be launched inside an errgroup:
+//
+//	remote, err := godet.Connect("localhost:9222", false, godet.ManualRun())
+//	...
+//	g, ctx := errgroup.WithContext(ctx)
+//	g.Go(func() error { return remote.Run(ctx) })
+//
+// When ctx is done the connection is closed, the pending events are discarded and Run returns ctx.Err().
+// Run returns nil if the connection is closed (see Close) and ErrorDisconnected if the connection is lost
+// or unhealthy (see Heartbeat): in this case the connection is closed and the pending events are discarded too.
+// All callbacks have returned by the time Run returns.
+func (remote *RemoteDebugger) Run(ctx context.Context) error {
+	remote.Lock()
+	if !remote.manualRun || remote.running {
+		remote.Unlock()
+		return ErrorEventLoop
+	}
+	remote.running = true
+	remote.Unlock()
+
+	defer func() {
+		remote.Lock()
+		remote.running = false
+		remote.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			remote.Close()
+			remote.drainEvents()
+			return ctx.Err()
+
+		case ev, ok := <-remote.events:
+			if !ok {
+				return nil
+			}
+
+			remote.dispatch(ev)
+
+			switch ev.Method {
+			case EventClosed:
+				return nil
+
+			case EventDisconnect:
+				// nobody reads the events after Run returns: don't leave the connection open
+				remote.closeConn(false)
+				remote.drainEvents()
+				return ErrorDisconnected
+			}
+		}
+	}
+}
+
+// drainEvents discards the queued events until the connection readers have exited,
+// so that they are not blocked forever sending to the events channel.
+func (remote *RemoteDebugger) drainEvents() {
+	done := make(chan struct{})
+
+	go func() {
+		remote.readers.Wait()
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case _, ok := <-remote.events:
+			if !ok {
+				<-done
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// fakeBrowser starts a websocket server that runs serve for each connection,
+// and returns a tab to connect to it.
+func fakeBrowser(t *testing.T, serve func(ctx context.Context, c *websocket.Conn)) *Tab {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+
+		serve(r.Context(), c)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Tab{ID: "fake", WsURL: "ws" + strings.TrimPrefix(srv.URL, "http")}
+}
+
+// fakeCDP returns a tab for a fake browser that answers each command with the result
+// (or the error) returned by reply.
+func fakeCDP(t *testing.T, reply func(method string, params Params) (interface{}, *ProtocolError)) *Tab {
+	t.Helper()
+
+	return fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		for {
+			var cmd struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+				Params Params `json:"params"`
+			}
+
+			if err := wsjson.Read(ctx, c, &cmd); err != nil {
+				return
+			}
+
+			msg := Params{"id": cmd.ID}
+
+			if result, perr := reply(cmd.Method, cmd.Params); perr != nil {
+				msg["error"] = perr
+			} else if result != nil {
+				msg["result"] = result
+			} else {
+				msg["result"] = Params{}
+			}
+
+			if err := wsjson.Write(ctx, c, msg); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// connectFake connects to the fake browser, without the HTTP endpoints.
+func connectFake(t *testing.T, tab *Tab, options ...ConnectOption) *RemoteDebugger {
+	t.Helper()
+
+	remote := newRemoteDebugger(nil, false)
+	for _, setOption := range options {
+		setOption(remote)
+	}
+
+	if err := remote.connectWs(tab); err != nil {
+		t.Fatal(err)
+	}
+
+	remote.start()
+	return remote
+}
+
+// fakeEvent runs the hooks of an event, as if it was received from the browser.
+func fakeEvent(remote *RemoteDebugger, method string, params Params) {
+	b, _ := json.Marshal(params)
+	remote.dispatch(wsMessage{Method: method, Params: b})
+}
+
+// waitReaders fails the test if the readMessages goroutines don't exit within timeout.
+func waitReaders(t *testing.T, remote *RemoteDebugger, timeout time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		remote.readers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("readMessages didn't exit")
+	}
+}
+
+func TestRunCancelDrainsEvents(t *testing.T) {
+	tab := fakeBrowser(t, func(ctx context.Context, c *websocket.Conn) {
+		ctx = c.CloseRead(ctx) // answer the close handshake
+
+		for i := 0; ctx.Err() == nil; i++ {
+			msg := fmt.Sprintf(`{"method":"Network.dataReceived","params":{"n":%d}}`, i)
+			if err := c.Write(ctx, websocket.MessageText, []byte(msg)); err != nil {
+				return
+			}
+		}
+	})
+
+	remote := connectFake(t, tab, ManualRun())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	remote.CallbackEvent("Network.dataReceived", func(Params) {
+		cancel()
+		time.Sleep(50 * time.Millisecond) // let the events queue fill up
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- remote.Run(ctx) }()
+
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Errorf("Run returned %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return")
+	}
+
+	waitReaders(t, remote, 5*time.Second)
+}
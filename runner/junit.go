@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the results as a JUnit XML report, with a test suite named suite.
+// The artifacts directory of each scenario is reported in its system-out.
+func WriteJUnit(w io.Writer, suite string, results []Result) error {
+	s := junitSuite{Name: suite, Tests: len(results)}
+
+	var start, end time.Time
+
+	for _, res := range results {
+		c := junitCase{
+			Name:      res.Scenario,
+			ClassName: suite,
+			Time:      seconds(res.Duration),
+			SystemOut: "artifacts: " + res.Dir,
+		}
+
+		if res.Err != nil {
+			s.Failures++
+			c.Failure = &junitFailure{Message: res.Err.Error(), Text: res.Err.Error()}
+		}
+
+		if !res.Start.IsZero() {
+			if start.IsZero() || res.Start.Before(start) {
+				start = res.Start
+			}
+			if e := res.Start.Add(res.Duration); e.After(end) {
+				end = e
+			}
+		}
+
+		s.Cases = append(s.Cases, c)
+	}
+
+	s.Time = seconds(end.Sub(start))
+	if !start.IsZero() {
+		s.Timestamp = start.Format("2006-01-02T15:04:05")
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{s}}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,247 @@
+package runner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raff/godet"
+	"github.com/raff/godet/server"
+)
+
+// MaxReportConsoleLines is the number of (last) console log lines included for each scenario in the HTML report.
+var MaxReportConsoleLines = 100
+
+// NetworkSummary summarizes the requests of a scenario (from its HAR).
+type NetworkSummary struct {
+	Requests int
+	Failed   int
+	Bytes    int
+
+	// Status maps the status classes ("2xx", "3xx"...) to the number of responses.
+	Status map[string]int
+
+	// Hosts maps the host names to the number of requests.
+	Hosts map[string]int
+}
+
+// reportStep is a trace step with the screenshots inlined.
+type reportStep struct {
+	godet.TraceStep
+	BeforeImage template.URL
+	AfterImage  template.URL
+}
+
+// reportScenario is the data of a scenario in the HTML report.
+type reportScenario struct {
+	Result
+	Error      string
+	Steps      []reportStep
+	Screenshot template.URL
+	Console    []string
+	Network    *NetworkSummary
+}
+
+// dataURL returns the file as a data: URL, or "" if it cannot be read.
+func dataURL(file, mimeType string) template.URL {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	return template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// networkSummary summarizes the HAR file, returning nil if it cannot be read.
+func networkSummary(file string) *NetworkSummary {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var har server.HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil
+	}
+
+	ns := &NetworkSummary{Status: map[string]int{}, Hosts: map[string]int{}}
+
+	for _, e := range har.Log.Entries {
+		ns.Requests++
+
+		if e.Response.Status == 0 {
+			ns.Failed++
+		} else {
+			ns.Status[fmt.Sprintf("%dxx", e.Response.Status/100)]++
+		}
+
+		if e.Response.Content.Size > 0 {
+			ns.Bytes += e.Response.Content.Size
+		}
+
+		if u, err := url.Parse(e.Request.URL); err == nil && u.Host != "" {
+			ns.Hosts[u.Host]++
+		}
+	}
+
+	return ns
+}
+
+// loadScenario collects the artifacts of a scenario for the report.
+func loadScenario(res Result) reportScenario {
+	rs := reportScenario{Result: res}
+
+	if res.Err != nil {
+		rs.Error = res.Err.Error()
+	}
+
+	if trace, err := godet.ReadTrace(filepath.Join(res.Dir, "trace")); err == nil {
+		for _, s := range trace.Steps {
+			step := reportStep{TraceStep: s}
+			if s.Before != "" {
+				step.BeforeImage = dataURL(filepath.Join(trace.Dir, s.Before), "image/jpeg")
+			}
+			if s.After != "" {
+				step.AfterImage = dataURL(filepath.Join(trace.Dir, s.After), "image/jpeg")
+			}
+
+			rs.Steps = append(rs.Steps, step)
+		}
+	}
+
+	rs.Screenshot = dataURL(filepath.Join(res.Dir, "screenshot.png"), "image/png")
+
+	if data, err := ioutil.ReadFile(filepath.Join(res.Dir, "console.log")); err == nil {
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) > MaxReportConsoleLines {
+			lines = lines[len(lines)-MaxReportConsoleLines:]
+		}
+		if len(lines) == 1 && lines[0] == "" {
+			lines = nil
+		}
+
+		rs.Console = lines
+	}
+
+	rs.Network = networkSummary(filepath.Join(res.Dir, "har.json"))
+	return rs
+}
+
+// WriteHTMLReport writes a standalone HTML report of the results (with the screenshots inlined),
+// including the trace steps, console log and network summary of each scenario, from its artifacts.
+func WriteHTMLReport(w io.Writer, title string, results []Result) error {
+	data := struct {
+		Title     string
+		Generated time.Time
+		Passed    int
+		Failed    int
+		Duration  time.Duration
+		Scenarios []reportScenario
+	}{
+		Title:     title,
+		Generated: time.Now(),
+	}
+
+	for _, res := range results {
+		if res.Err != nil {
+			data.Failed++
+		} else {
+			data.Passed++
+		}
+
+		data.Duration += res.Duration
+		data.Scenarios = append(data.Scenarios, loadScenario(res))
+	}
+
+	// failures first
+	sort.SliceStable(data.Scenarios, func(i, j int) bool {
+		return data.Scenarios[i].Err != nil && data.Scenarios[j].Err == nil
+	})
+
+	return reportTemplate.Execute(w, data)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms":  func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.summary span { margin-right: 1em; }
+.passed { color: #080; }
+.failed { color: #c00; }
+details { border: 1px solid #ccc; border-radius: 4px; margin-bottom: 0.5em; padding: 0.5em; }
+details.failed { border-color: #c00; }
+summary { cursor: pointer; font-weight: bold; }
+.error { color: #c00; white-space: pre-wrap; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+td, th { border: 1px solid #ddd; padding: 2px 6px; text-align: left; vertical-align: top; font-size: 0.9em; }
+img { max-width: 320px; border: 1px solid #ddd; }
+img.final { max-width: 640px; }
+pre { background: #f6f6f6; padding: 0.5em; overflow: auto; max-height: 20em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="summary">
+<span>{{.Generated.Format "2006-01-02 15:04:05"}}</span>
+<span class="passed">{{.Passed}} passed</span>
+<span class="failed">{{.Failed}} failed</span>
+<span>total time {{ms .Duration}}</span>
+</div>
+{{range .Scenarios}}
+<details class="{{if .Error}}failed{{else}}passed{{end}}"{{if .Error}} open{{end}}>
+<summary><span class="{{if .Error}}failed{{else}}passed{{end}}">{{if .Error}}&#x2717;{{else}}&#x2713;{{end}}</span> {{.Scenario}} ({{ms .Duration}})</summary>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .Steps}}
+<h3>Steps</h3>
+<table>
+<tr><th>#</th><th>action</th><th>time</th><th>before</th><th>after</th></tr>
+{{range $i, $s := .Steps}}
+<tr{{if $s.Error}} class="failed"{{end}}>
+<td>{{inc $i}}</td>
+<td>{{$s.Action}} {{$s.Selector}} {{$s.Value}}{{if $s.URL}}<br><small>{{$s.URL}}</small>{{end}}{{if $s.Error}}<div class="error">{{$s.Error}}</div>{{end}}</td>
+<td>{{ms $s.Duration}}</td>
+<td>{{if $s.BeforeImage}}<img src="{{$s.BeforeImage}}">{{end}}</td>
+<td>{{if $s.AfterImage}}<img src="{{$s.AfterImage}}">{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+{{if .Screenshot}}<h3>Final screenshot</h3><img class="final" src="{{.Screenshot}}">{{end}}
+{{with .Network}}
+<h3>Network</h3>
+<table>
+<tr><th>requests</th><td>{{.Requests}}</td></tr>
+<tr><th>failed</th><td>{{.Failed}}</td></tr>
+<tr><th>bytes</th><td>{{.Bytes}}</td></tr>
+{{range $k, $v := .Status}}<tr><th>{{$k}}</th><td>{{$v}}</td></tr>{{end}}
+</table>
+<table>
+<tr><th>host</th><th>requests</th></tr>
+{{range $k, $v := .Hosts}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}
+</table>
+{{end}}
+{{if .Console}}
+<h3>Console</h3>
+<pre>{{range .Console}}{{.}}
+{{end}}</pre>
+{{end}}
+<p><small>artifacts: {{.Dir}}</small></p>
+</details>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	passed, failed := t.TempDir(), t.TempDir()
+
+	var console strings.Builder
+	for i := 1; i <= 150; i++ {
+		fmt.Fprintf(&console, "console.log line %d\n", i)
+	}
+
+	files := map[string]string{
+		filepath.Join(failed, "console.log"):    console.String(),
+		filepath.Join(failed, "screenshot.png"): "PNG",
+		filepath.Join(failed, "har.json"): `{"log": {"entries": [
+			{"request": {"url": "https://example.com/"}, "response": {"status": 200, "content": {"size": 1000}}},
+			{"request": {"url": "https://example.com/missing"}, "response": {"status": 404, "content": {"size": 10}}},
+			{"request": {"url": "https://cdn.example.net/app.js"}, "response": {"status": 0, "content": {"size": -1}}}
+		]}}`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results := []Result{
+		{Scenario: "home", Duration: 1500 * time.Millisecond, Dir: passed},
+		{Scenario: "checkout", Duration: 2 * time.Second, Dir: failed, Err: errors.New("no element <button id=pay>")},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, "Nightly <run>", results); err != nil {
+		t.Fatal(err)
+	}
+
+	report := buf.String()
+
+	for _, want := range []string{
+		"<title>Nightly &lt;run&gt;</title>",
+		`<span class="passed">1 passed</span>`,
+		`<span class="failed">1 failed</span>`,
+		"total time 3.5s",
+		`<p class="error">no element &lt;button id=pay&gt;</p>`,
+		`<img class="final" src="data:image/png;base64,UE5H">`,
+		"<tr><th>requests</th><td>3</td></tr>",
+		"<tr><th>failed</th><td>1</td></tr>",
+		"<tr><th>bytes</th><td>1010</td></tr>",
+		"<tr><th>2xx</th><td>1</td></tr>",
+		"<tr><th>4xx</th><td>1</td></tr>",
+		"<tr><td>example.com</td><td>2</td></tr>",
+		"console.log line 51\n",
+		"console.log line 150\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("the report doesn't contain %q", want)
+		}
+	}
+
+	if strings.Contains(report, "console.log line 50\n") {
+		t.Errorf("the report contains more than %d console lines", MaxReportConsoleLines)
+	}
+
+	// failures first
+	if c, h := strings.Index(report, "checkout ("), strings.Index(report, "home ("); c < 0 || h < 0 || c > h {
+		t.Errorf("checkout at %d, home at %d", c, h)
+	}
+
+	if n := strings.Count(report, "<h3>Network</h3>"); n != 1 {
+		t.Errorf("%d network summaries, want 1 (the passed scenario has no HAR)", n)
+	}
+}
@@ -0,0 +1,492 @@
+// Package runner runs scenarios (recorded action scripts, see godet.RecordActions) in parallel,
+// each one in its own browser context, collecting the artifacts of each scenario in a results directory
+// and writing a JUnit XML summary for CI and a standalone HTML report.
+//
+// The results directory is structured as:
+//
+//	results/
+//		junit.xml
+//		report.html
+//		<scenario>/
+//			har.json
+//			screenshot.png
+//			console.log
+//			*.webm (with CollectVideo)
+//			trace/ (with CollectTrace)
+//			error.txt (if the scenario failed)
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raff/godet"
+	"github.com/raff/godet/notify"
+	"github.com/raff/godet/server"
+)
+
+// Scenario is a named script of recorded actions.
+type Scenario struct {
+	Name    string
+	Actions []godet.RecordedAction
+}
+
+// LoadScenarios reads the scenarios from the action files (as written by godet.WriteActions,
+// or godet.WriteActionsYAML for the ".yaml" and ".yml" files).
+// The scenario name is the file name, without the ".actions.json" or ".json" (or YAML) extension.
+func LoadScenarios(files ...string) ([]Scenario, error) {
+	var scenarios []Scenario
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		ext := filepath.Ext(file)
+
+		var actions []godet.RecordedAction
+		if ext == ".yaml" || ext == ".yml" {
+			actions, err = godet.ReadActionsYAML(f)
+		} else {
+			actions, err = godet.ReadActions(f)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+
+		name := filepath.Base(file)
+		name = strings.TrimSuffix(strings.TrimSuffix(name, ext), ".actions")
+
+		scenarios = append(scenarios, Scenario{Name: name, Actions: actions})
+	}
+
+	return scenarios, nil
+}
+
+// Shard returns the scenarios assigned to shard index (0 based) of total, so that the scenarios
+// can be split across multiple CI jobs. The scenarios are sorted by name, so that each job
+// computes the same assignment.
+func Shard(scenarios []Scenario, index, total int) []Scenario {
+	if total <= 1 {
+		return scenarios
+	}
+
+	sorted := append([]Scenario(nil), scenarios...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var shard []Scenario
+	for i, s := range sorted {
+		if i%total == index {
+			shard = append(shard, s)
+		}
+	}
+
+	return shard
+}
+
+// Collector starts collecting an artifact for a scenario, running in remote, to be saved in dir.
+// The returned function is called when the scenario completes (with its error, if any)
+// to stop collecting and save the artifact.
+type Collector func(remote *godet.RemoteDebugger, dir string) (finish func(err error) error, err error)
+
+// Result is the result of a scenario.
+type Result struct {
+	Scenario string
+	Start    time.Time
+	Duration time.Duration
+
+	// Dir is the directory with the scenario artifacts.
+	Dir string
+
+	// Err is the reason the scenario failed, or nil.
+	Err error
+}
+
+// Runner runs the scenarios.
+type Runner struct {
+	// Remote is the connection to the browser, used to create the browser contexts.
+	Remote *godet.RemoteDebugger
+
+	// Parallel is the number of scenarios running at the same time (default 1).
+	Parallel int
+
+	// Timeout is the timeout of each action (default 30 seconds).
+	Timeout time.Duration
+
+	// ResultsDir is the directory where the artifacts and the JUnit summary are saved (default "results").
+	ResultsDir string
+
+	// Collectors are the artifact collectors started for each scenario, in addition to
+	// the HAR, console log and final screenshot.
+	Collectors []Collector
+
+	// Notifier, if set, is notified when each scenario and the run complete.
+	Notifier notify.Notifier
+}
+
+// New returns a Runner creating the browser contexts via remote.
+func New(remote *godet.RemoteDebugger, parallel int) *Runner {
+	return &Runner{
+		Remote:     remote,
+		Parallel:   parallel,
+		Timeout:    30 * time.Second,
+		ResultsDir: "results",
+	}
+}
+
+var unsafeChars = regexp.MustCompile(`[^\w.-]+`)
+
+// Run runs the scenarios, returning their results (in the same order) after writing
+// the JUnit summary in ResultsDir/junit.xml and the HTML report in ResultsDir/report.html. No new scenarios are started after ctx is done.
+func (r *Runner) Run(ctx context.Context, scenarios []Scenario) ([]Result, error) {
+	if err := os.MkdirAll(r.ResultsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	parallel := r.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]Result, len(scenarios))
+	sem := make(chan struct{}, parallel)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+
+	for i, s := range scenarios {
+		results[i] = Result{Scenario: s.Name, Dir: filepath.Join(r.ResultsDir, unsafeChars.ReplaceAllString(s.Name, "_"))}
+
+		// select picks at random when a slot is free and ctx is done
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(s Scenario, res *Result) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res.Start = time.Now()
+			res.Err = r.runScenario(s, res.Dir)
+			res.Duration = time.Since(res.Start)
+
+			ev := notify.Event{
+				Type:      notify.ScenarioCompleted,
+				Time:      time.Now(),
+				Name:      s.Name,
+				Passed:    res.Err == nil,
+				Duration:  res.Duration.Seconds(),
+				Artifacts: res.Dir,
+			}
+			if res.Err != nil {
+				ev.Error = res.Err.Error()
+			}
+
+			r.notify(ctx, ev)
+		}(s, &results[i])
+	}
+
+	wg.Wait()
+
+	ev := notify.Event{
+		Type:      notify.RunCompleted,
+		Time:      time.Now(),
+		Name:      "godet scenarios",
+		Duration:  time.Since(start).Seconds(),
+		Artifacts: r.ResultsDir,
+		Total:     len(results),
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			ev.Failed++
+		}
+	}
+	ev.Passed = ev.Failed == 0
+
+	r.notify(ctx, ev)
+
+	if err := r.writeReport("junit.xml", func(w io.Writer) error { return WriteJUnit(w, "godet", results) }); err != nil {
+		return results, err
+	}
+
+	return results, r.writeReport("report.html", func(w io.Writer) error { return WriteHTMLReport(w, "godet scenarios", results) })
+}
+
+// notify sends the event to the notifier, if any. The errors are logged.
+func (r *Runner) notify(ctx context.Context, ev notify.Event) {
+	if r.Notifier == nil {
+		return
+	}
+
+	if err := r.Notifier.Notify(ctx, ev); err != nil {
+		log.Println("notify", ev.Name, err)
+	}
+}
+
+// writeReport creates the report file in the results directory.
+func (r *Runner) writeReport(name string, write func(w io.Writer) error) error {
+	f, err := os.Create(filepath.Join(r.ResultsDir, name))
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// runScenario runs a scenario in a new browser context, saving the artifacts in dir.
+func (r *Runner) runScenario(s Scenario, dir string) (err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			ioutil.WriteFile(filepath.Join(dir, "error.txt"), []byte(err.Error()+"\n"), 0644)
+		}
+	}()
+
+	bc, err := r.Remote.NewBrowserContext()
+	if err != nil {
+		return err
+	}
+
+	defer bc.Close()
+
+	remote, err := bc.NewTab("")
+	if err != nil {
+		return err
+	}
+
+	defer remote.Close()
+
+	finish, err := startCollectors(remote, dir, append([]Collector{collectHAR, collectConsole}, r.Collectors...))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if ferr := finish(err); ferr != nil && err == nil {
+			err = ferr
+		}
+	}()
+
+	err = remote.ReplayActions(s.Actions, r.Timeout)
+
+	if serr := remote.SaveScreenshot(filepath.Join(dir, "screenshot.png"), 0644, 0, true); serr != nil && err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+// startCollectors starts the collectors, returning a function that finishes all of them (with the scenario error)
+// and returns the first error. If a collector fails to start, the ones already started are finished.
+func startCollectors(remote *godet.RemoteDebugger, dir string, collectors []Collector) (func(error) error, error) {
+	var started []func(error) error
+
+	finish := func(err error) (ferr error) {
+		for _, f := range started {
+			if err := f(err); err != nil && ferr == nil {
+				ferr = err
+			}
+		}
+
+		return
+	}
+
+	for _, collect := range collectors {
+		f, err := collect(remote, dir)
+		if err != nil {
+			finish(err)
+			return nil, err
+		}
+
+		started = append(started, f)
+	}
+
+	return finish, nil
+}
+
+// CollectVideo is a Collector recording a video of the scenario (see godet.RecordVideo).
+// If onlyFailures is true the video is removed when the scenario passes.
+func CollectVideo(onlyFailures bool) Collector {
+	return func(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+		vr, err := remote.RecordVideo(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(serr error) error {
+			file, err := vr.Stop()
+			if err == nil && serr == nil && onlyFailures {
+				err = os.Remove(file)
+			}
+
+			return err
+		}, nil
+	}
+}
+
+// CollectTrace is a Collector recording the trace of the scenario actions (see godet.StartTrace)
+// in the trace subdirectory, with screenshots before and after each action.
+func CollectTrace(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+	if _, err := remote.StartTrace(filepath.Join(dir, "trace"), true); err != nil {
+		return nil, err
+	}
+
+	return func(error) error {
+		_, err := remote.StopTrace()
+		return err
+	}, nil
+}
+
+// HealSelectors is a Collector enabling the auto-heal mode of the selectors (see godet.HealSelectors),
+// saving the healed selectors in healed.json so that the scenario can be fixed.
+func HealSelectors(minScore float64) Collector {
+	return func(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+		remote.HealSelectors(minScore)
+
+		return func(error) error {
+			healed := remote.HealedSelectors()
+			if len(healed) == 0 {
+				return nil
+			}
+
+			data, err := json.MarshalIndent(healed, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			return ioutil.WriteFile(filepath.Join(dir, "healed.json"), data, 0644)
+		}, nil
+	}
+}
+
+// CollectThirdParties is a Collector reporting the requests to the third party origins not in the allowlist
+// (see godet.EnableThirdPartyAllowlist) in thirdparties.json, and blocking them if block is true.
+func CollectThirdParties(allowed []string, block bool) Collector {
+	return func(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+		if err := remote.EnableThirdPartyAllowlist(allowed, block); err != nil {
+			return nil, err
+		}
+
+		return func(error) error {
+			remote.DisableThirdPartyAllowlist()
+
+			unexpected := remote.UnexpectedThirdParties()
+			if len(unexpected) == 0 {
+				return nil
+			}
+
+			data, err := json.MarshalIndent(unexpected, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			return ioutil.WriteFile(filepath.Join(dir, "thirdparties.json"), data, 0644)
+		}, nil
+	}
+}
+
+// CollectCookies is a Collector saving the cookie compliance report (see godet.CookieReport) in cookies.json.
+func CollectCookies(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+	if err := remote.AuditCookies(true); err != nil {
+		return nil, err
+	}
+
+	return func(error) error {
+		defer remote.AuditCookies(false)
+
+		report, err := remote.CookieReport()
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dir, "cookies.json"), data, 0644)
+	}, nil
+}
+
+// collectHAR records the responses and saves them in har.json.
+func collectHAR(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+	if err := remote.NetworkEvents(true); err != nil {
+		return nil, err
+	}
+
+	remote.RecordResponses(true)
+	start := time.Now()
+
+	return func(error) error {
+		data, err := json.MarshalIndent(server.BuildHAR(remote.Responses(), start), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dir, "har.json"), data, 0644)
+	}, nil
+}
+
+// collectConsole records the console messages and exceptions and saves them in console.log.
+func collectConsole(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+	var lock sync.Mutex
+	var lines []string
+
+	add := func(format string, args ...interface{}) {
+		lock.Lock()
+		lines = append(lines, time.Now().Format("15:04:05.000 ")+fmt.Sprintf(format, args...))
+		lock.Unlock()
+	}
+
+	remote.CallbackEvent("Runtime.consoleAPICalled", func(params godet.Params) {
+		add("%v", godet.ConsoleMessage(params))
+	})
+
+	remote.CallbackEvent("Runtime.exceptionThrown", func(params godet.Params) {
+		add("%v", godet.ExceptionMessage(params))
+	})
+
+	if err := remote.RuntimeEvents(true); err != nil {
+		return nil, err
+	}
+
+	return func(error) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return ioutil.WriteFile(filepath.Join(dir, "console.log"), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	}, nil
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/raff/godet"
+)
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// no browser: the scenarios must not start
+	r := New(nil, 4)
+	r.ResultsDir = t.TempDir()
+
+	results, err := r.Run(ctx, []Scenario{{Name: "one"}, {Name: "two"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range results {
+		if res.Err != context.Canceled || !res.Start.IsZero() {
+			t.Errorf("%s: %+v", res.Scenario, res)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(r.ResultsDir, "junit.xml")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStartCollectors(t *testing.T) {
+	var finished []string
+
+	collector := func(name string, startErr, finishErr error) Collector {
+		return func(remote *godet.RemoteDebugger, dir string) (func(error) error, error) {
+			if startErr != nil {
+				return nil, startErr
+			}
+
+			return func(err error) error {
+				finished = append(finished, name)
+				return finishErr
+			}, nil
+		}
+	}
+
+	errStart, errFinish := errors.New("start"), errors.New("finish")
+
+	_, err := startCollectors(nil, "", []Collector{collector("har", nil, nil), collector("console", nil, nil), collector("video", errStart, nil)})
+	if err != errStart {
+		t.Errorf("startCollectors = %v, want %v", err, errStart)
+	}
+	if want := []string{"har", "console"}; !reflect.DeepEqual(finished, want) {
+		t.Errorf("finished = %q, want %q", finished, want)
+	}
+
+	finished = nil
+
+	finish, err := startCollectors(nil, "", []Collector{collector("har", nil, nil), collector("trace", nil, errFinish), collector("cookies", nil, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := finish(nil); err != errFinish {
+		t.Errorf("finish = %v, want %v", err, errFinish)
+	}
+	if want := []string{"har", "trace", "cookies"}; !reflect.DeepEqual(finished, want) {
+		t.Errorf("finished = %q, want %q", finished, want)
+	}
+}
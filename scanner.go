@@ -0,0 +1,276 @@
+package godet
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// errMalformedMessage is returned by scanMessage if the payload is not a JSON object.
+var errMalformedMessage = errors.New("malformed message")
+
+// rawMessage holds the top-level fields of a protocol message, as found by scanMessage.
+//
+// All the fields refer to the scanned buffer, so they are only valid until the buffer is reused.
+type rawMessage struct {
+	id        int
+	method    []byte
+	result    []byte
+	params    []byte
+	error     []byte
+	sessionID []byte
+}
+
+// message copies the scanned fields into a wsMessage that can outlive the read buffer.
+func (raw *rawMessage) message() wsMessage {
+	msg := wsMessage{ID: raw.id, Method: string(raw.method)}
+
+	if raw.result != nil {
+		msg.Result = append(json.RawMessage(nil), raw.result...)
+	}
+	if raw.params != nil {
+		msg.Params = append(json.RawMessage(nil), raw.params...)
+	}
+
+	return msg
+}
+
+// scanMessage extracts "id", "method", "result" and "params" from a protocol message
+// without decoding the payload, so that we can route (or drop) a message before paying
+// for a full json.Unmarshal.
+func scanMessage(data []byte, raw *rawMessage) error {
+	*raw = rawMessage{}
+
+	s := scanner{data: data}
+
+	if !s.consume('{') {
+		return errMalformedMessage
+	}
+	if s.consume('}') {
+		return nil
+	}
+
+	for {
+		s.skipSpace()
+		start := s.pos
+		if !s.skipString() {
+			return errMalformedMessage
+		}
+		key := data[start+1 : s.pos-1]
+
+		if !s.consume(':') {
+			return errMalformedMessage
+		}
+
+		s.skipSpace()
+		start = s.pos
+		if !s.skipValue() {
+			return errMalformedMessage
+		}
+		value := data[start:s.pos]
+
+		switch string(key) {
+		case "id":
+			id, ok := parseInt(value)
+			if !ok {
+				return errMalformedMessage
+			}
+			raw.id = id
+
+		case "method":
+			if len(value) < 2 || value[0] != '"' {
+				return errMalformedMessage
+			}
+			raw.method = value[1 : len(value)-1]
+
+			for _, c := range raw.method {
+				if c == '\\' { // unlikely, but let the json package deal with escapes
+					var method string
+					if err := json.Unmarshal(value, &method); err != nil {
+						return err
+					}
+					raw.method = []byte(method)
+					break
+				}
+			}
+
+		case "result":
+			raw.result = value
+
+		case "params":
+			raw.params = value
+
+		case "error":
+			raw.error = value
+
+		case "sessionId":
+			if len(value) >= 2 && value[0] == '"' {
+				raw.sessionID = value[1 : len(value)-1]
+			}
+		}
+
+		if s.consume(',') {
+			continue
+		}
+		if s.consume('}') {
+			return nil
+		}
+
+		return errMalformedMessage
+	}
+}
+
+// scanID returns the value of "id", if it's the first field of a (possibly truncated) message.
+func scanID(data []byte) (int, bool) {
+	s := scanner{data: data}
+
+	if !s.consume('{') {
+		return 0, false
+	}
+
+	s.skipSpace()
+	start := s.pos
+	if !s.skipString() || string(data[start+1:s.pos-1]) != "id" || !s.consume(':') {
+		return 0, false
+	}
+
+	s.skipSpace()
+	start = s.pos
+	if !s.skipValue() || s.pos == len(data) { // the value must be terminated
+		return 0, false
+	}
+
+	return parseInt(data[start:s.pos])
+}
+
+// parseInt parses a JSON integer without allocating.
+func parseInt(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+
+	neg := b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+
+	if len(b) == 0 {
+		return 0, false
+	}
+
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+
+	if neg {
+		n = -n
+	}
+
+	return n, true
+}
+
+// scanner is a minimal JSON tokenizer that can only skip over values.
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips any whitespace and the next character, if it matches c.
+func (s *scanner) consume(c byte) bool {
+	s.skipSpace()
+
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+
+	return false
+}
+
+// skipString skips a quoted string, including the quotes.
+func (s *scanner) skipString() bool {
+	s.skipSpace()
+
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return false
+	}
+
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipValue skips the next value (string, number, literal, object or array).
+func (s *scanner) skipValue() bool {
+	if s.pos >= len(s.data) {
+		return false
+	}
+
+	switch s.data[s.pos] {
+	case '"':
+		return s.skipString()
+
+	case '{', '[':
+		depth := 0
+
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case '"':
+				if !s.skipString() {
+					return false
+				}
+				continue
+
+			case '{', '[':
+				depth++
+
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					s.pos++
+					return true
+				}
+			}
+
+			s.pos++
+		}
+
+		return false
+
+	default:
+		start := s.pos
+
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return s.pos > start
+			}
+
+			s.pos++
+		}
+
+		return s.pos > start
+	}
+}
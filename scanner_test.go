@@ -0,0 +1,244 @@
+package godet
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestScanMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		id        int
+		method    string
+		result    string
+		params    string
+		error     string
+		sessionID string
+	}{
+		{
+			name: "empty",
+			data: `{}`,
+		},
+		{
+			name:   "reply",
+			data:   `{"id":42,"result":{"frameId":"F1"}}`,
+			id:     42,
+			result: `{"frameId":"F1"}`,
+		},
+		{
+			name:   "negative id and whitespace",
+			data:   " {\n\t\"id\" : -7 ,\r\n \"result\" : {} }",
+			id:     -7,
+			result: `{}`,
+		},
+		{
+			name:   "event",
+			data:   `{"method":"Page.loadEventFired","params":{"timestamp":1.5}}`,
+			method: "Page.loadEventFired",
+			params: `{"timestamp":1.5}`,
+		},
+		{
+			name:   "escaped quote and brace in string",
+			data:   `{"method":"Runtime.consoleAPICalled","params":{"s":"x\"}]y"}}`,
+			method: "Runtime.consoleAPICalled",
+			params: `{"s":"x\"}]y"}`,
+		},
+		{
+			name:   "escaped backslash before closing quote",
+			data:   `{"id":1,"result":{"path":"C:\\"},"sessionId":"S1"}`,
+			id:     1,
+			result: `{"path":"C:\\"}`,
+
+			sessionID: "S1",
+		},
+		{
+			name:   "escaped method",
+			data:   `{"method":"Page.\u006coadEventFired","params":{}}`,
+			method: "Page.loadEventFired",
+			params: `{}`,
+		},
+		{
+			name:   "nested objects and arrays",
+			data:   `{"id":3,"result":{"a":[1,{"b":[2,[3]]},"]}",{}],"c":{"d":{"e":null}}},"extra":[true,false]}`,
+			id:     3,
+			result: `{"a":[1,{"b":[2,[3]]},"]}",{}],"c":{"d":{"e":null}}}`,
+		},
+		{
+			name:   "unknown fields are skipped",
+			data:   `{"other":{"id":9,"method":"X"},"list":["method"],"method":"Network.dataReceived","params":{}}`,
+			method: "Network.dataReceived",
+			params: `{}`,
+		},
+		{
+			name:  "error reply",
+			data:  `{"id":5,"error":{"code":-32000,"message":"No node with given id found"}}`,
+			id:    5,
+			error: `{"code":-32000,"message":"No node with given id found"}`,
+		},
+		{
+			name:      "session event",
+			data:      `{"method":"Page.frameNavigated","params":{"frame":{}},"sessionId":"AB12"}`,
+			method:    "Page.frameNavigated",
+			params:    `{"frame":{}}`,
+			sessionID: "AB12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw rawMessage
+			if err := scanMessage([]byte(tt.data), &raw); err != nil {
+				t.Fatalf("scanMessage(%s): %v", tt.data, err)
+			}
+
+			if raw.id != tt.id {
+				t.Errorf("id = %d, want %d", raw.id, tt.id)
+			}
+			if string(raw.method) != tt.method {
+				t.Errorf("method = %q, want %q", raw.method, tt.method)
+			}
+			if string(raw.result) != tt.result {
+				t.Errorf("result = %s, want %s", raw.result, tt.result)
+			}
+			if string(raw.params) != tt.params {
+				t.Errorf("params = %s, want %s", raw.params, tt.params)
+			}
+			if string(raw.error) != tt.error {
+				t.Errorf("error = %s, want %s", raw.error, tt.error)
+			}
+			if string(raw.sessionID) != tt.sessionID {
+				t.Errorf("sessionId = %q, want %q", raw.sessionID, tt.sessionID)
+			}
+		})
+	}
+}
+
+func TestScanMessageMalformed(t *testing.T) {
+	for _, data := range []string{
+		``,
+		`   `,
+		`[]`,
+		`"id"`,
+		`{`,
+		`{"id"`,
+		`{"id":`,
+		`{"id":}`,
+		`{"id":1`,
+		`{"id":1,}`,
+		`{"id":1 "result":{}}`,
+		`{"id":"1"}`,
+		`{"id":1.5}`,
+		`{id:1}`,
+		`{"method":1}`,
+		`{"method":"Page.load`,
+		`{"method":"Page.loadEventFired","params":{"a":1}`,
+		`{"method":"Page.loadEventFired","params":{"a":[1,2}`,
+		`{"method":"Page.loadEventFired","params":{"s":"x}`,
+		`{"method":"Page.loadEventFired","params":{"s":"x\"}}`,
+	} {
+		var raw rawMessage
+		if err := scanMessage([]byte(data), &raw); err == nil {
+			t.Errorf("scanMessage(%s): expected an error, got %+v", data, raw)
+		}
+	}
+}
+
+func TestScanMessageResets(t *testing.T) {
+	var raw rawMessage
+
+	if err := scanMessage([]byte(`{"method":"A","params":{},"sessionId":"S"}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := scanMessage([]byte(`{"id":2,"result":{}}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if raw.method != nil || raw.params != nil || raw.sessionID != nil {
+		t.Errorf("fields of the previous message not reset: %+v", raw)
+	}
+}
+
+func TestScanID(t *testing.T) {
+	tests := []struct {
+		data string
+		id   int
+		ok   bool
+	}{
+		{`{"id":12,"result":{"data":"truncat`, 12, true},
+		{`{ "id" : 7 }`, 7, true},
+		{`{"id":12`, 0, false},
+		{`{"id":`, 0, false},
+		{`{"method":"Network.dataReceived","id":3}`, 0, false},
+		{`{"id":"x",`, 0, false},
+		{``, 0, false},
+	}
+
+	for _, tt := range tests {
+		id, ok := scanID([]byte(tt.data))
+		if id != tt.id || ok != tt.ok {
+			t.Errorf("scanID(%s) = %d, %v, want %d, %v", tt.data, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
+// benchmarkMessages are a typical mix of messages: a large reply, frequent events
+// (most of them dropped, as nobody listens to them) and a small reply.
+var benchmarkMessages = func() [][]byte {
+	body := strings.Repeat(`{"nodeId":12,"backendNodeId":34,"nodeName":"DIV","attributes":["class","a \"b\" c"],"children":[]},`, 200)
+
+	return [][]byte{
+		[]byte(`{"id":1,"result":{"root":{"nodeId":1,"children":[` + body + `{}]}}}`),
+		[]byte(`{"method":"Network.dataReceived","params":{"requestId":"1000.1","timestamp":1234.5678,"dataLength":65536,"encodedDataLength":1024}}`),
+		[]byte(`{"method":"Network.requestWillBeSent","params":{"requestId":"1000.2","loaderId":"L1","documentURL":"https://example.com/","request":{"url":"https://example.com/app.js","method":"GET","headers":{"Accept":"*/*","User-Agent":"Mozilla/5.0"}},"timestamp":1234.5679,"wallTime":1700000000.123,"initiator":{"type":"parser"},"type":"Script","frameId":"F1"}}`),
+		[]byte(`{"method":"Page.loadEventFired","params":{"timestamp":1235.0001},"sessionId":"S1"}`),
+		[]byte(`{"id":2,"result":{}}`),
+	}
+}()
+
+// BenchmarkReadMessages compares the routing of the incoming messages with scanMessage
+// (that only copies the fields of the messages that are used) with the previous implementation,
+// that decoded every message with json.Unmarshal.
+func BenchmarkReadMessages(b *testing.B) {
+	var size int64
+	for _, m := range benchmarkMessages {
+		size += int64(len(m))
+	}
+
+	b.Run("scan", func(b *testing.B) {
+		var raw rawMessage
+
+		b.SetBytes(size)
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			for _, m := range benchmarkMessages {
+				if err := scanMessage(m, &raw); err != nil {
+					b.Fatal(err)
+				}
+
+				if raw.method == nil {
+					_ = raw.message() // the reply is copied for the pending request
+				} else if bytes.Equal(raw.method, []byte("Page.loadEventFired")) {
+					_ = raw.message() // only the events with a callback are queued
+				}
+			}
+		}
+	})
+
+	b.Run("unmarshal", func(b *testing.B) {
+		b.SetBytes(size)
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			for _, m := range benchmarkMessages {
+				var msg wsMessage
+				if err := json.Unmarshal(m, &msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,208 @@
+// Package schedule runs jobs on cron schedules, each run in its own browser context,
+// preventing overlapping runs and optionally adding a random jitter to the start time.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidSpec is returned by ParseCron for invalid cron expressions
+var ErrorInvalidSpec = errors.New("invalid cron expression")
+
+// Cron is a parsed cron expression.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// day of month and day of week are ORed if both are restricted
+	domAny, dowAny bool
+
+	// every is set for "@every <duration>"
+	every time.Duration
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a standard 5 fields cron expression (minute, hour, day of month, month, day of week),
+// with lists, ranges, steps and month/day names, or one of the macros @yearly, @monthly, @weekly, @daily,
+// @hourly and "@every <duration>".
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[7:]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%v: %q", ErrorInvalidSpec, spec)
+		}
+
+		return &Cron{every: d}, nil
+	}
+
+	if m, ok := cronMacros[spec]; ok {
+		spec = m
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%v: %q (expected 5 fields)", ErrorInvalidSpec, spec)
+	}
+
+	c := &Cron{}
+
+	var err error
+
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+
+	if c.dow&(1<<7) != 0 { // 7 is also Sunday
+		c.dow |= 1
+	}
+
+	c.domAny = fields[2] == "*" || fields[2] == "?"
+	c.dowAny = fields[4] == "*" || fields[4] == "?"
+	return c, nil
+}
+
+// parseValue parses a number or a name.
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
+// parseField parses a cron field into a bitset.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("%v: invalid step in %q", ErrorInvalidSpec, field)
+			}
+
+			step, part = s, part[:i]
+		}
+
+		lo, hi := min, max
+
+		switch {
+		case part == "*" || part == "?":
+
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+
+			var err1, err2 error
+			lo, err1 = parseValue(part[:i], names)
+			hi, err2 = parseValue(part[i+1:], names)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("%v: invalid range in %q", ErrorInvalidSpec, field)
+			}
+
+		default:
+			v, err := parseValue(part, names)
+			if err != nil {
+				return 0, fmt.Errorf("%v: invalid value in %q", ErrorInvalidSpec, field)
+			}
+
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%v: value out of range in %q", ErrorInvalidSpec, field)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// Next returns the first activation time after t (or the zero time if there is none in the next 5 years).
+func (c *Cron) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	start := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"* * * * *", "2024-01-31 10:08"},
+		{"*/15 * * * *", "2024-01-31 10:15"},
+		{"5/20 9-17 * * *", "2024-01-31 10:25"},
+		{"0 0 * * *", "2024-02-01 00:00"},
+		{"@hourly", "2024-01-31 11:00"},
+		{"@monthly", "2024-02-01 00:00"},
+		{"@yearly", "2025-01-01 00:00"},
+		{"30 8 * * mon-fri", "2024-02-01 08:30"},
+		{"0 12 * * 7", "2024-02-04 12:00"},         // 7 is Sunday
+		{"0 0 30 * *", "2024-03-30 00:00"},         // no February 30
+		{"0 0 29 feb *", "2024-02-29 00:00"},       // leap year
+		{"0 0 13 * fri", "2024-02-02 00:00"},       // day of month OR day of week
+		{"0 0 1,15 jan,jul *", "2024-07-01 00:00"}, // lists
+		{"@every 90s", "2024-01-31 10:09"},         // from the start time, not truncated
+		{"0 0 31 4 *", "0001-01-01 00:00"},         // never
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.spec, err)
+			continue
+		}
+
+		if got := c.Next(start).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%q next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@every -1m",
+		"@every soon",
+	} {
+		if _, err := ParseCron(spec); err == nil || !strings.HasPrefix(err.Error(), ErrorInvalidSpec.Error()) {
+			t.Errorf("ParseCron(%q) = %v, want ErrorInvalidSpec", spec, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package schedule
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// Job is a scheduled job. It runs in remote, connected to a tab in a new browser context
+// that is closed when the job returns.
+type Job func(ctx context.Context, remote *godet.RemoteDebugger) error
+
+// JobOption defines the functional options for Scheduler.Add.
+type JobOption func(e *Entry)
+
+// Jitter delays each run by a random duration up to max, to avoid starting many jobs at the same time.
+func Jitter(max time.Duration) JobOption {
+	return func(e *Entry) {
+		e.jitter = max
+	}
+}
+
+// Timeout cancels the job context after timeout.
+func Timeout(timeout time.Duration) JobOption {
+	return func(e *Entry) {
+		e.timeout = timeout
+	}
+}
+
+// Entry is a job registered with the scheduler.
+type Entry struct {
+	Name string
+	Cron *Cron
+
+	job     Job
+	jitter  time.Duration
+	timeout time.Duration
+
+	sync.Mutex
+	running bool
+	next    time.Time
+	last    time.Time
+	lastErr error
+	runs    int
+	skipped int
+}
+
+// EntryStatus is the status of a scheduled job.
+type EntryStatus struct {
+	Name    string
+	Next    time.Time
+	Last    time.Time
+	LastErr error
+	Running bool
+	Runs    int
+
+	// Skipped is the number of runs skipped because the previous run was still running.
+	Skipped int
+}
+
+// Scheduler runs the jobs on their schedule.
+type Scheduler struct {
+	// Remote is the connection to the browser, used to create the browser contexts for the jobs.
+	Remote *godet.RemoteDebugger
+
+	verbose bool
+
+	sync.Mutex
+	entries []*Entry
+	wake    chan struct{}
+}
+
+// New returns a Scheduler creating the browser contexts via remote.
+func New(remote *godet.RemoteDebugger, verbose bool) *Scheduler {
+	return &Scheduler{Remote: remote, verbose: verbose, wake: make(chan struct{}, 1)}
+}
+
+// Add schedules the job with a cron expression (see ParseCron).
+func (s *Scheduler) Add(name, spec string, job Job, options ...JobOption) (*Entry, error) {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entry{Name: name, Cron: c, job: job}
+	for _, opt := range options {
+		opt(e)
+	}
+
+	e.next = c.Next(time.Now())
+
+	s.Lock()
+	s.entries = append(s.entries, e)
+	s.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return e, nil
+}
+
+// Entries returns the status of the scheduled jobs.
+func (s *Scheduler) Entries() []EntryStatus {
+	s.Lock()
+	entries := append([]*Entry(nil), s.entries...)
+	s.Unlock()
+
+	var list []EntryStatus
+
+	for _, e := range entries {
+		e.Lock()
+		list = append(list, EntryStatus{
+			Name:    e.Name,
+			Next:    e.next,
+			Last:    e.last,
+			LastErr: e.lastErr,
+			Running: e.running,
+			Runs:    e.runs,
+			Skipped: e.skipped,
+		})
+		e.Unlock()
+	}
+
+	return list
+}
+
+// Run runs the scheduled jobs until the context is done. It waits for the running jobs before returning.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		now := time.Now()
+		wait := time.Hour
+
+		s.Lock()
+		entries := append([]*Entry(nil), s.entries...)
+		s.Unlock()
+
+		for _, e := range entries {
+			e.Lock()
+			next := e.next
+			due := !next.IsZero() && !next.After(now)
+			if due {
+				e.next = e.Cron.Next(now)
+				next = e.next
+			}
+			e.Unlock()
+
+			if due {
+				wg.Add(1)
+				go func(e *Entry) {
+					defer wg.Done()
+					s.run(ctx, e)
+				}(e)
+			}
+
+			if !next.IsZero() && next.Sub(now) < wait {
+				wait = next.Sub(now)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// run runs the job once (after the jitter), unless the previous run is still running.
+func (s *Scheduler) run(ctx context.Context, e *Entry) {
+	e.Lock()
+	if e.running {
+		e.skipped++
+		e.Unlock()
+
+		if s.verbose {
+			log.Println("schedule", e.Name, "skipped, still running")
+		}
+		return
+	}
+	e.running = true
+	e.Unlock()
+
+	defer func() {
+		e.Lock()
+		e.running = false
+		e.Unlock()
+	}()
+
+	if e.jitter > 0 {
+		select {
+		case <-time.After(time.Duration(rand.Int63n(int64(e.jitter)))):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := s.runIsolated(ctx, e.job)
+
+	e.Lock()
+	e.last, e.lastErr = start, err
+	e.runs++
+	e.Unlock()
+
+	if s.verbose {
+		log.Println("schedule", e.Name, "completed in", time.Since(start), err)
+	}
+}
+
+// runIsolated runs the job in a tab in a new browser context.
+func (s *Scheduler) runIsolated(ctx context.Context, job Job) error {
+	bc, err := s.Remote.NewBrowserContext()
+	if err != nil {
+		return err
+	}
+
+	defer bc.Close()
+
+	remote, err := bc.NewTab("")
+	if err != nil {
+		return err
+	}
+
+	defer remote.Close()
+	return job(ctx, remote)
+}
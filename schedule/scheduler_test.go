@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+func TestSchedulerAdd(t *testing.T) {
+	s := New(nil, false)
+
+	if _, err := s.Add("bad", "* * *", nil); err == nil {
+		t.Error("Add with an invalid spec didn't fail")
+	}
+
+	e, err := s.Add("report", "@every 1h", func(ctx context.Context, remote *godet.RemoteDebugger) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a run while the previous one is still running is skipped (without creating a browser context)
+	e.Lock()
+	e.running = true
+	e.Unlock()
+
+	s.run(context.Background(), e)
+
+	entries := s.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	st := entries[0]
+	if st.Name != "report" || !st.Running || st.Skipped != 1 || st.Runs != 0 || time.Until(st.Next) < 59*time.Minute {
+		t.Errorf("status = %+v", st)
+	}
+}
@@ -0,0 +1,142 @@
+package godet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxScrollSteps limits the number of scrolls on pages that never stop growing.
+const maxScrollSteps = 1000
+
+// ErrorScrollLimit is returned if the page is still growing after maxScrollSteps scrolls.
+var ErrorScrollLimit = errors.New("too many scrolls")
+
+// ScrollPosition is the scroll position of the page, with the document and viewport sizes.
+type ScrollPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+
+	ViewportWidth  float64 `json:"viewportWidth"`
+	ViewportHeight float64 `json:"viewportHeight"`
+}
+
+// AtBottom returns true if the viewport is at the bottom of the document.
+func (p *ScrollPosition) AtBottom() bool {
+	return p.Y+p.ViewportHeight >= p.Height-1
+}
+
+const scrollPositionJS = `var el = document.scrollingElement || document.documentElement;
+	return JSON.stringify({
+		x: window.scrollX,
+		y: window.scrollY,
+		width: el.scrollWidth,
+		height: el.scrollHeight,
+		viewportWidth: window.innerWidth,
+		viewportHeight: window.innerHeight
+	});`
+
+// GetScrollPosition returns the current scroll position of the page.
+func (remote *RemoteDebugger) GetScrollPosition() (*ScrollPosition, error) {
+	res, err := remote.EvaluateWrap(scrollPositionJS)
+	if err != nil {
+		return nil, err
+	}
+
+	s, _ := res.(string)
+
+	var pos ScrollPosition
+	if err := json.Unmarshal([]byte(s), &pos); err != nil {
+		return nil, err
+	}
+
+	return &pos, nil
+}
+
+// ScrollTo scrolls the page to the specified position.
+func (remote *RemoteDebugger) ScrollTo(x, y float64) error {
+	_, err := remote.Evaluate(fmt.Sprintf("window.scrollTo(%v, %v)", x, y))
+	return err
+}
+
+// ScrollBy scrolls the page by the specified amount.
+func (remote *RemoteDebugger) ScrollBy(dx, dy float64) error {
+	_, err := remote.Evaluate(fmt.Sprintf("window.scrollBy(%v, %v)", dx, dy))
+	return err
+}
+
+// ScrollToBottom scrolls down by step pixels (or by a viewport if step is 0), waiting delay
+// after each scroll, until the bottom of the page is reached and the page stops growing.
+//
+// This works for "infinite scroll" pages, as long as they eventually stop loading content.
+func (remote *RemoteDebugger) ScrollToBottom(step float64, delay time.Duration) error {
+	return remote.scrollToBottom(step, func() error {
+		time.Sleep(delay)
+		return nil
+	})
+}
+
+// ScrollUntilIdle is like ScrollToBottom but after each scroll it waits for the network to be idle
+// for the specified duration (i.e. for lazy loaded content), up to timeout per scroll.
+// Network events are enabled, if needed.
+func (remote *RemoteDebugger) ScrollUntilIdle(step float64, idle, timeout time.Duration) error {
+	na, stop, err := remote.trackNetwork()
+	if err != nil {
+		return err
+	}
+
+	defer stop()
+
+	return remote.scrollToBottom(step, func() error {
+		if err := na.waitIdle(idle, timeout); err != nil && err != ErrorTimeout {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// scrollToBottom scrolls until the bottom of the page, calling wait after each scroll.
+// It stops if the scroll position doesn't change (i.e. the page cannot be scrolled further)
+// and returns ErrorScrollLimit if the page keeps growing after maxScrollSteps scrolls.
+func (remote *RemoteDebugger) scrollToBottom(step float64, wait func() error) error {
+	pos, err := remote.GetScrollPosition()
+	if err != nil {
+		return err
+	}
+
+	for scrolls := 0; scrolls < maxScrollSteps; scrolls++ {
+		if !pos.AtBottom() {
+			dy := step
+			if dy <= 0 {
+				dy = pos.ViewportHeight
+			}
+
+			if err := remote.ScrollBy(0, dy); err != nil {
+				return err
+			}
+		}
+
+		// at the bottom, this gives the page a chance to load more content
+		if err := wait(); err != nil {
+			return err
+		}
+
+		next, err := remote.GetScrollPosition()
+		if err != nil {
+			return err
+		}
+
+		if next.Y == pos.Y && next.Height <= pos.Height {
+			return nil
+		}
+
+		pos = next
+	}
+
+	return ErrorScrollLimit
+}
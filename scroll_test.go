@@ -0,0 +1,154 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scrollingPage is a virtualized list of 100 pixels high lines (only the visible lines are in the document)
+// that grows by a viewport, up to grow times, when scrolled to the bottom. A frozen page cannot be scrolled.
+type scrollingPage struct {
+	sync.Mutex
+	y, height, viewport float64
+	grow                int
+	frozen              bool
+}
+
+func (p *scrollingPage) reply(method string, params Params) (interface{}, *ProtocolError) {
+	if method != "Runtime.evaluate" {
+		return nil, nil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	value := func(v interface{}) (interface{}, *ProtocolError) {
+		return Params{"result": Params{"type": "string", "value": v}}, nil
+	}
+
+	expr := params.String("expression")
+
+	switch {
+	case strings.Contains(expr, "window.scrollX"):
+		pos, _ := json.Marshal(ScrollPosition{Y: p.y, Width: 800, Height: p.height, ViewportWidth: 800, ViewportHeight: p.viewport})
+		return value(string(pos))
+
+	case strings.HasPrefix(expr, "window.scrollBy("):
+		var dx, dy float64
+		fmt.Sscanf(expr, "window.scrollBy(%v, %v)", &dx, &dy)
+
+		if !p.frozen {
+			p.y += dy
+			if p.y > p.height-p.viewport {
+				p.y = p.height - p.viewport
+			}
+		}
+
+		if p.y >= p.height-p.viewport && p.grow > 0 {
+			p.height += p.viewport
+			p.grow--
+		}
+
+	case strings.Contains(expr, "innerText"):
+		var lines []string
+		for i := int(p.y / 100); i < int((p.y+p.viewport)/100); i++ {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+
+		return value(strings.Join(lines, "\n"))
+
+	case strings.Contains(expr, "outerHTML"):
+		return value("<html></html>")
+	}
+
+	return Params{"result": Params{"type": "undefined"}}, nil
+}
+
+func (p *scrollingPage) position() (float64, float64) {
+	p.Lock()
+	defer p.Unlock()
+	return p.y, p.height
+}
+
+func TestScrollToBottom(t *testing.T) {
+	p := &scrollingPage{height: 3000, viewport: 1000, grow: 2}
+	remote := connectFake(t, fakeCDP(t, p.reply))
+
+	if err := remote.ScrollToBottom(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if y, height := p.position(); y != 4000 || height != 5000 {
+		t.Errorf("position = %v of %v, want 4000 of 5000", y, height)
+	}
+}
+
+func TestScrollToBottomFrozen(t *testing.T) {
+	p := &scrollingPage{height: 3000, viewport: 1000, frozen: true}
+	remote := connectFake(t, fakeCDP(t, p.reply))
+
+	if err := remote.ScrollToBottom(500, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if y, _ := p.position(); y != 0 {
+		t.Errorf("position = %v, want 0", y)
+	}
+}
+
+func TestScrollToBottomLimit(t *testing.T) {
+	p := &scrollingPage{height: 3000, viewport: 1000, grow: maxScrollSteps * 2}
+	remote := connectFake(t, fakeCDP(t, p.reply))
+
+	if err := remote.ScrollToBottom(0, 0); err != ErrorScrollLimit {
+		t.Errorf("ScrollToBottom on an infinite page = %v, want %v", err, ErrorScrollLimit)
+	}
+}
+
+func TestHarvestScrollingPage(t *testing.T) {
+	p := &scrollingPage{height: 3000, viewport: 1000, grow: 1}
+	remote := connectFake(t, fakeCDP(t, p.reply))
+
+	result, err := remote.HarvestScrollingPage(0, IdlePolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Complete || len(result.Lines) != 40 || result.Lines[39] != "line 39" || result.HTML != "<html></html>" {
+		t.Errorf("result = %+v", result)
+	}
+
+	p = &scrollingPage{height: 3000, viewport: 1000, grow: maxScrollSteps * 2}
+	remote = connectFake(t, fakeCDP(t, p.reply))
+
+	result, err = remote.HarvestScrollingPage(2, IdlePolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Complete || result.Scrolls != 2 {
+		t.Errorf("result after 2 scrolls = %+v", result)
+	}
+
+	result, err = remote.HarvestScrollingPage(0, IdlePolicy{})
+	if err != ErrorScrollLimit || result == nil || result.Scrolls != maxScrollSteps {
+		t.Errorf("HarvestScrollingPage on an infinite page = %v, %v", result, err)
+	}
+}
+
+func TestHarvestFrozenPage(t *testing.T) {
+	p := &scrollingPage{height: 3000, viewport: 1000, frozen: true}
+	remote := connectFake(t, fakeCDP(t, p.reply))
+
+	result, err := remote.HarvestScrollingPage(0, IdlePolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Complete || result.Scrolls != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
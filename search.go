@@ -0,0 +1,183 @@
+package godet
+
+import (
+	"strings"
+)
+
+// PerformSearch searches for a given string (plain text, CSS selector or XPath) in the DOM tree.
+// It returns the search identifier and the number of results (see GetSearchResults).
+//
+// Note that the document must have been requested (see GetDocument).
+func (remote *RemoteDebugger) PerformSearch(query string, includeUserAgentShadowDOM bool) (string, int, error) {
+	res, err := remote.SendRequest("DOM.performSearch", Params{
+		"query":                     query,
+		"includeUserAgentShadowDOM": includeUserAgentShadowDOM,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if res == nil {
+		return "", 0, ErrorNoResponse
+	}
+
+	return Params(res).String("searchId"), Params(res).Int("resultCount"), nil
+}
+
+// GetSearchResults returns the nodeIds of the search results in the range [from, to).
+func (remote *RemoteDebugger) GetSearchResults(searchID string, from, to int) ([]int, error) {
+	res, err := remote.SendRequest("DOM.getSearchResults", Params{
+		"searchId":  searchID,
+		"fromIndex": from,
+		"toIndex":   to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list, _ := res["nodeIds"].([]interface{})
+	ids := make([]int, 0, len(list))
+
+	for _, id := range list {
+		ids = append(ids, int(id.(float64)))
+	}
+
+	return ids, nil
+}
+
+// DiscardSearchResults discards the search results.
+func (remote *RemoteDebugger) DiscardSearchResults(searchID string) error {
+	_, err := remote.SendRequest("DOM.discardSearchResults", Params{
+		"searchId": searchID,
+	})
+	return err
+}
+
+// search runs a DOM search and returns all the results.
+func (remote *RemoteDebugger) search(query string) ([]int, error) {
+	if _, err := remote.GetDocument(); err != nil {
+		return nil, err
+	}
+
+	searchID, count, err := remote.PerformSearch(query, false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer remote.DiscardSearchResults(searchID)
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	return remote.GetSearchResults(searchID, 0, count)
+}
+
+// QueryXPath returns the nodeIds of the elements matching the XPath expression.
+func (remote *RemoteDebugger) QueryXPath(expr string) ([]int, error) {
+	return remote.search(expr)
+}
+
+// xpathLiteral returns s as an XPath string literal (XPath 1.0 has no escapes).
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	if !strings.Contains(s, `'`) {
+		return `'` + s + `'`
+	}
+
+	parts := strings.Split(s, `"`)
+	for i, p := range parts {
+		parts[i] = `"` + p + `"`
+	}
+
+	return "concat(" + strings.Join(parts, `, '"', `) + ")"
+}
+
+type textSearch struct {
+	exact      bool
+	ignoreCase bool
+	tag        string
+}
+
+// TextOption defines the functional option for FindByText
+type TextOption func(ts *textSearch)
+
+// ExactText only matches elements whose (whitespace normalized) text is equal to the search text.
+func ExactText() TextOption {
+	return func(ts *textSearch) {
+		ts.exact = true
+	}
+}
+
+// IgnoreCase does a case-insensitive match. Only ASCII letters are folded, as XPath 1.0 can't do better:
+// "Über" matches "ÜBER" but "été" doesn't match "ÉTÉ".
+func IgnoreCase() TextOption {
+	return func(ts *textSearch) {
+		ts.ignoreCase = true
+	}
+}
+
+// TextTag only matches elements with the specified tag (i.e. "button").
+func TextTag(tag string) TextOption {
+	return func(ts *textSearch) {
+		ts.tag = tag
+	}
+}
+
+const (
+	upperCase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerCase = "abcdefghijklmnopqrstuvwxyz"
+)
+
+// lowerASCII folds the ASCII letters to lower case, like the XPath translate() used by FindByText.
+func lowerASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+
+		return r
+	}, s)
+}
+
+// FindByText returns the nodeIds of the innermost elements containing the specified text.
+// Text in script and style elements is ignored.
+func (remote *RemoteDebugger) FindByText(text string, options ...TextOption) ([]int, error) {
+	var ts textSearch
+
+	for _, opt := range options {
+		opt(&ts)
+	}
+
+	return remote.QueryXPath(ts.query(text))
+}
+
+// query returns the XPath expression selecting the innermost elements containing the text.
+func (ts *textSearch) query(text string) string {
+	value := "normalize-space(.)"
+	text = strings.Join(strings.Fields(text), " ")
+
+	if ts.ignoreCase {
+		value = "translate(" + value + ", '" + upperCase + "', '" + lowerCase + "')"
+		text = lowerASCII(text)
+	}
+
+	lit := xpathLiteral(text)
+
+	var match string
+	if ts.exact {
+		match = value + " = " + lit
+	} else {
+		match = "contains(" + value + ", " + lit + ")"
+	}
+
+	tag := ts.tag
+	if tag == "" {
+		tag = "*"
+	}
+
+	// innermost elements: no child element also matches
+	return "//" + tag + "[" + match + " and not(self::script or self::style) and not(*[" + match + "])]"
+}
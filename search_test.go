@@ -0,0 +1,49 @@
+package godet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXPathLiteral(t *testing.T) {
+	tests := map[string]string{
+		`plain`:         `"plain"`,
+		`say "hi"`:      `'say "hi"'`,
+		`it's "quoted"`: `concat("it's ", '"', "quoted", '"', "")`,
+	}
+
+	for s, want := range tests {
+		if got := xpathLiteral(s); got != want {
+			t.Errorf("xpathLiteral(%s) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestTextQueryIgnoreCase(t *testing.T) {
+	tests := []struct {
+		text string
+		lit  string
+	}{
+		{"Sign  In", `"sign in"`},
+		{"Über Uns", `"Über uns"`}, // only ASCII is folded, as by translate()
+		{"ÉTÉ", `"ÉtÉ"`},
+	}
+
+	for _, tt := range tests {
+		ts := textSearch{ignoreCase: true}
+		query := ts.query(tt.text)
+
+		if !strings.Contains(query, "contains(translate(normalize-space(.), '"+upperCase+"', '"+lowerCase+"'), "+tt.lit+")") {
+			t.Errorf("query(%q) = %s, want a match for %s", tt.text, query, tt.lit)
+		}
+	}
+}
+
+func TestTextQuery(t *testing.T) {
+	ts := textSearch{exact: true, tag: "button"}
+
+	want := `//button[normalize-space(.) = "OK" and not(self::script or self::style) and not(*[normalize-space(.) = "OK"])]`
+	if got := ts.query(" OK\n"); got != want {
+		t.Errorf("query = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+package godet
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// SignedCertificateTimestamp holds the details of a SCT.
+type SignedCertificateTimestamp struct {
+	Status             string  `json:"status"`
+	Origin             string  `json:"origin"`
+	LogDescription     string  `json:"logDescription"`
+	LogID              string  `json:"logId"`
+	Timestamp          float64 `json:"timestamp"`
+	HashAlgorithm      string  `json:"hashAlgorithm"`
+	SignatureAlgorithm string  `json:"signatureAlgorithm"`
+	SignatureData      string  `json:"signatureData"`
+}
+
+// SecurityDetails holds the security details of a response (Network.Response.securityDetails).
+type SecurityDetails struct {
+	Protocol                          string                       `json:"protocol"`
+	KeyExchange                       string                       `json:"keyExchange"`
+	KeyExchangeGroup                  string                       `json:"keyExchangeGroup"`
+	Cipher                            string                       `json:"cipher"`
+	Mac                               string                       `json:"mac"`
+	CertificateID                     int                          `json:"certificateId"`
+	SubjectName                       string                       `json:"subjectName"`
+	SanList                           []string                     `json:"sanList"`
+	Issuer                            string                       `json:"issuer"`
+	ValidFrom                         float64                      `json:"validFrom"`
+	ValidTo                           float64                      `json:"validTo"`
+	SignedCertificateTimestampList    []SignedCertificateTimestamp `json:"signedCertificateTimestampList"`
+	CertificateTransparencyCompliance string                       `json:"certificateTransparencyCompliance"`
+	ServerSignatureAlgorithm          int                          `json:"serverSignatureAlgorithm"`
+	EncryptedClientHello              bool                         `json:"encryptedClientHello"`
+}
+
+// ValidFromTime returns ValidFrom as time.Time.
+func (sd *SecurityDetails) ValidFromTime() time.Time {
+	return time.Unix(int64(sd.ValidFrom), 0)
+}
+
+// ValidToTime returns ValidTo as time.Time.
+func (sd *SecurityDetails) ValidToTime() time.Time {
+	return time.Unix(int64(sd.ValidTo), 0)
+}
+
+// decodeParams converts a decoded JSON value (i.e. an event parameter) into a typed structure.
+func decodeParams(v interface{}, out interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// ResponseSecurityDetails returns the security details from the Network.responseReceived payload,
+// or nil if the response was not loaded over a secure connection.
+func ResponseSecurityDetails(params Params) (*SecurityDetails, error) {
+	sd := Params(params.Map("response")).Map("securityDetails")
+	if sd == nil {
+		return nil, nil
+	}
+
+	var details SecurityDetails
+	if err := decodeParams(sd, &details); err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// GetCertificateChain returns the parsed certificate chain for the specified origin (leaf first).
+func (remote *RemoteDebugger) GetCertificateChain(origin string) ([]*x509.Certificate, error) {
+	certs, err := remote.GetCertificate(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]*x509.Certificate, 0, len(certs))
+
+	for _, c := range certs {
+		der, err := base64.StdEncoding.DecodeString(c)
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, cert)
+	}
+
+	return chain, nil
+}
+
+// SecurityIssue is a security problem reported by the browser (see CollectSecurityIssues).
+type SecurityIssue struct {
+	// Source is the event that reported the issue ("Audits" or "Log")
+	Source string `json:"source"`
+	// Code is the issue code (i.e. MixedContentIssue) or the log entry source
+	Code string `json:"code"`
+	// URL is the insecure or blocked resource, if known
+	URL string `json:"url,omitempty"`
+	// Details is a human readable description
+	Details string `json:"details"`
+}
+
+// cspLogEntry returns true for the console errors reporting a Content Security Policy violation.
+func (issue SecurityIssue) cspLogEntry() bool {
+	return issue.Source == "Log" && issue.Code == "security" &&
+		strings.Contains(strings.ToLower(issue.Details), "content security policy")
+}
+
+// SecurityReport summarizes the security issues found in the loaded page.
+type SecurityReport struct {
+	SecurityState string          `json:"securityState"`
+	MixedContent  []SecurityIssue `json:"mixedContent"`
+	CSPViolations []SecurityIssue `json:"cspViolations"`
+	InsecureForms []string        `json:"insecureForms"`
+	Other         []SecurityIssue `json:"other"`
+}
+
+type securityCollector struct {
+	state  string
+	issues []SecurityIssue
+	stop   []func()
+}
+
+// AuditsEvents enables Audits events listening.
+func (remote *RemoteDebugger) AuditsEvents(enable bool) error {
+	return remote.DomainEvents("Audits", enable)
+}
+
+// SecurityEvents enables Security events listening.
+func (remote *RemoteDebugger) SecurityEvents(enable bool) error {
+	return remote.DomainEvents("Security", enable)
+}
+
+// CollectSecurityIssues starts (or stops) collecting mixed content and CSP issues reported via
+// Audits.issueAdded and Log.entryAdded, and the page security state reported by the Security domain.
+// Requires Audits, Security and Log events (see AuditsEvents, SecurityEvents and LogEvents).
+//
+// Starting a collection discards the issues collected so far.
+func (remote *RemoteDebugger) CollectSecurityIssues(enable bool) {
+	remote.Lock()
+	sc := remote.security
+	remote.security = nil
+	remote.Unlock()
+
+	if sc != nil {
+		for _, stop := range sc.stop {
+			stop()
+		}
+	}
+
+	if !enable {
+		return
+	}
+
+	sc = &securityCollector{}
+
+	add := func(issue SecurityIssue) {
+		remote.Lock()
+		sc.issues = append(sc.issues, issue)
+		remote.Unlock()
+	}
+
+	sc.stop = append(sc.stop, remote.addHook("Audits.issueAdded", func(params Params) bool {
+		issue := Params(params.Map("issue"))
+		details := Params(issue.Map("details"))
+
+		switch code := issue.String("code"); code {
+		case "MixedContentIssue":
+			mc := Params(details.Map("mixedContentIssueDetails"))
+			add(SecurityIssue{
+				Source:  "Audits",
+				Code:    code,
+				URL:     mc.String("insecureURL"),
+				Details: mc.String("resourceType") + " " + mc.String("resolutionStatus"),
+			})
+
+		case "ContentSecurityPolicyIssue":
+			csp := Params(details.Map("contentSecurityPolicyIssueDetails"))
+			add(SecurityIssue{
+				Source:  "Audits",
+				Code:    code,
+				URL:     csp.String("blockedURL"),
+				Details: csp.String("contentSecurityPolicyViolationType") + " " + csp.String("violatedDirective"),
+			})
+
+		default:
+			add(SecurityIssue{Source: "Audits", Code: code})
+		}
+
+		return false
+	}))
+
+	sc.stop = append(sc.stop, remote.addHook("Log.entryAdded", func(params Params) bool {
+		entry := Params(params.Map("entry"))
+
+		// "violation" entries are performance warnings (i.e. long running handlers), not security issues
+		if entry.String("source") == "security" {
+			add(SecurityIssue{
+				Source:  "Log",
+				Code:    "security",
+				URL:     entry.String("url"),
+				Details: entry.String("text"),
+			})
+		}
+
+		return false
+	}))
+
+	sc.stop = append(sc.stop, remote.addHook("Security.visibleSecurityStateChanged", func(params Params) bool {
+		remote.Lock()
+		sc.state = Params(params.Map("visibleSecurityState")).String("securityState")
+		remote.Unlock()
+		return false
+	}))
+
+	remote.Lock()
+	remote.security = sc
+	remote.Unlock()
+}
+
+// SecurityReport returns the issues collected so far (see CollectSecurityIssues) and checks
+// the current page for forms submitting to insecure (http) targets.
+func (remote *RemoteDebugger) SecurityReport() (*SecurityReport, error) {
+	var report SecurityReport
+
+	remote.Lock()
+	if sc := remote.security; sc != nil {
+		report.SecurityState = sc.state
+
+		for _, issue := range sc.issues {
+			switch {
+			case issue.Code == "MixedContentIssue":
+				report.MixedContent = append(report.MixedContent, issue)
+			case issue.Code == "ContentSecurityPolicyIssue" || issue.cspLogEntry():
+				report.CSPViolations = append(report.CSPViolations, issue)
+			default:
+				report.Other = append(report.Other, issue)
+			}
+		}
+	}
+	remote.Unlock()
+
+	res, err := remote.Evaluate(`Array.from(document.forms)
+		.map(f => f.action)
+		.filter(a => a.startsWith("http:"))`)
+	if err != nil {
+		return nil, err
+	}
+
+	if forms, ok := res.([]interface{}); ok {
+		for _, f := range forms {
+			report.InsecureForms = append(report.InsecureForms, f.(string))
+		}
+	}
+
+	return &report, nil
+}
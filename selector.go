@@ -0,0 +1,487 @@
+package godet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorNoSuchNode is returned by the selector helpers if no element matches the selector
+var ErrorNoSuchNode = errors.New("no node matching selector")
+
+// Quad is a list of 4 points (x1, y1, ..., x4, y4), clockwise starting from the top left corner.
+type Quad [8]float64
+
+// Rect returns the bounding rectangle of the quad.
+func (q Quad) Rect() Rect {
+	minx, miny, maxx, maxy := q[0], q[1], q[0], q[1]
+
+	for i := 2; i < len(q); i += 2 {
+		if q[i] < minx {
+			minx = q[i]
+		}
+		if q[i] > maxx {
+			maxx = q[i]
+		}
+		if q[i+1] < miny {
+			miny = q[i+1]
+		}
+		if q[i+1] > maxy {
+			maxy = q[i+1]
+		}
+	}
+
+	return Rect{X: minx, Y: miny, Width: maxx - minx, Height: maxy - miny}
+}
+
+// Rect is a rectangle, as returned by getBoundingClientRect.
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// BoxModel holds the box model of an element (see DOM.getBoxModel).
+type BoxModel struct {
+	Content Quad `json:"content"`
+	Padding Quad `json:"padding"`
+	Border  Quad `json:"border"`
+	Margin  Quad `json:"margin"`
+	Width   int  `json:"width"`
+	Height  int  `json:"height"`
+}
+
+// DeepSelectorSeparator separates the selectors for each shadow root in a deep selector.
+// For example "my-app >>> my-dialog >>> button.ok" looks for "button.ok" in the shadow root of
+// the first "my-dialog" element in the shadow root of the first "my-app" element.
+// Only open shadow roots can be traversed.
+const DeepSelectorSeparator = ">>>"
+
+// jsString returns s as a Javascript string literal.
+// Go quoting (%q) is not valid Javascript for all strings (i.e. \U0001F600 or invalid UTF-8).
+func jsString(s string) string {
+	b, _ := json.Marshal(s) // a string can always be encoded
+	return string(b)
+}
+
+// elementExpression returns a Javascript expression evaluating to the first element matching the (possibly deep) selector, or null.
+func elementExpression(selector string) string {
+	if !strings.Contains(selector, DeepSelectorSeparator) {
+		return fmt.Sprintf("document.querySelector(%s)", jsString(selector))
+	}
+
+	return fmt.Sprintf(`(function(parts) {
+		var root = document, el = null;
+		for (var i = 0; i < parts.length; i++) {
+			if (i > 0) {
+				if (!el.shadowRoot) return null;
+				root = el.shadowRoot;
+			}
+			el = root.querySelector(parts[i].trim());
+			if (!el) return null;
+		}
+		return el;
+	})(%s.split(%s))`, jsString(selector), jsString(DeepSelectorSeparator))
+}
+
+// expressionObject returns the remote objectId of the DOM node the Javascript expression evaluates to.
+// The object should be released with ReleaseObject.
+func (remote *RemoteDebugger) expressionObject(expr string, options ...EvaluateOption) (string, error) {
+	params := Params{
+		"expression": expr,
+	}
+
+	for _, opt := range options {
+		opt(params)
+	}
+
+	res, err := remote.SendRequest("Runtime.evaluate", params)
+	if err != nil {
+		return "", err
+	}
+
+	result := Params(Params(res).Map("result"))
+	objectID := result.String("objectId")
+	if objectID == "" || result.String("subtype") != "node" {
+		return "", ErrorNoSuchNode
+	}
+
+	return objectID, nil
+}
+
+// ReleaseObject releases the remote object with the given objectId.
+func (remote *RemoteDebugger) ReleaseObject(objectID string) error {
+	_, err := remote.SendRequest("Runtime.releaseObject", Params{
+		"objectId": objectID,
+	})
+	return err
+}
+
+// expressionNode returns the nodeId of the DOM node the Javascript expression evaluates to.
+func (remote *RemoteDebugger) expressionNode(expr string, options ...EvaluateOption) (int, error) {
+	// nodes can only be requested after the document
+	if _, err := remote.GetDocument(); err != nil {
+		return 0, err
+	}
+
+	objectID, err := remote.expressionObject(expr, options...)
+	if err != nil {
+		return 0, err
+	}
+
+	defer remote.ReleaseObject(objectID)
+
+	res, err := remote.SendRequest("DOM.requestNode", Params{
+		"objectId": objectID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return Params(res).Int("nodeId"), nil
+}
+
+// QuerySelectorNode returns the nodeId of the first element in the document matching the selector.
+// The selector can traverse shadow roots (see DeepSelectorSeparator).
+func (remote *RemoteDebugger) QuerySelectorNode(selector string) (int, error) {
+	if strings.Contains(selector, DeepSelectorSeparator) {
+		return remote.expressionNode(elementExpression(selector))
+	}
+
+	doc, err := remote.GetDocument()
+	if err != nil {
+		return 0, err
+	}
+
+	root := Params(Params(doc).Map("root")).Int("nodeId")
+
+	res, err := remote.QuerySelector(root, selector)
+	if err != nil {
+		return 0, err
+	}
+
+	id := Params(res).Int("nodeId")
+	if id == 0 {
+		return 0, ErrorNoSuchNode
+	}
+
+	return id, nil
+}
+
+// SelectorBoxModel returns the box model of the first element matching the selector.
+func (remote *RemoteDebugger) SelectorBoxModel(selector string) (*BoxModel, error) {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.nodeBoxModel(id)
+}
+
+// nodeBoxModel returns the box model of a node, in the coordinates of the top-level viewport.
+func (remote *RemoteDebugger) nodeBoxModel(id int) (*BoxModel, error) {
+	raw, err := remote.sendRawReplyRequest("DOM.getBoxModel", Params{
+		"nodeId": id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Model BoxModel `json:"model"`
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return &res.Model, nil
+}
+
+// clickNode scrolls the node into view and clicks in the middle of its content box.
+func (remote *RemoteDebugger) clickNode(id int) error {
+	if _, err := remote.SendRequest("DOM.scrollIntoViewIfNeeded", Params{
+		"nodeId": id,
+	}); err != nil {
+		return err
+	}
+
+	model, err := remote.nodeBoxModel(id)
+	if err != nil {
+		return err
+	}
+
+	r := model.Content.Rect()
+	x, y := int(r.X+r.Width/2), int(r.Y+r.Height/2)
+
+	if err := remote.MouseEvent(MouseMove, x, y); err != nil {
+		return err
+	}
+	if err := remote.MouseEvent(MousePress, x, y, LeftButton(), Clicks(1)); err != nil {
+		return err
+	}
+	return remote.MouseEvent(MouseRelease, x, y, LeftButton(), Clicks(1))
+}
+
+// Click scrolls the first element matching the selector into view and clicks on it.
+func (remote *RemoteDebugger) Click(selector string) error {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return err
+	}
+
+	return remote.clickNode(id)
+}
+
+// evaluateSelector evaluates a function body with `el` set to the first element matching the selector,
+// returning ErrorNoSuchNode if there is no such element.
+func (remote *RemoteDebugger) evaluateSelector(selector, body string, options ...EvaluateOption) (interface{}, error) {
+	return remote.evaluateElement(elementExpression(selector), body, options...)
+}
+
+// evaluateElement evaluates a function body with `el` set to the element the expression evaluates to,
+// returning ErrorNoSuchNode if it's null.
+func (remote *RemoteDebugger) evaluateElement(expr, body string, options ...EvaluateOption) (interface{}, error) {
+	res, err := remote.EvaluateWrap(fmt.Sprintf(`var el = %v;
+		if (!el) return {notFound: true};
+		return {value: (function(el){%v})(el)};`, expr, body), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := res.(map[string]interface{})
+	if m == nil || m["notFound"] == true {
+		return nil, ErrorNoSuchNode
+	}
+
+	return m["value"], nil
+}
+
+// GetBoundingClientRect returns the bounding rectangle (in CSS pixels, relative to the viewport)
+// of the first element matching the selector.
+func (remote *RemoteDebugger) GetBoundingClientRect(selector string) (*Rect, error) {
+	res, err := remote.evaluateSelector(selector, `var r = el.getBoundingClientRect();
+		return {x: r.x, y: r.y, width: r.width, height: r.height};`)
+	if err != nil {
+		return nil, err
+	}
+
+	var rect Rect
+	if err := decodeParams(res, &rect); err != nil {
+		return nil, err
+	}
+
+	return &rect, nil
+}
+
+// notRendered returns true if err is the DOM.getBoxModel error for the nodes that are not rendered.
+func notRendered(err error) bool {
+	perr, ok := err.(ProtocolError)
+	return ok && strings.Contains(perr.Message, "Could not compute box model")
+}
+
+// IsVisible returns true if the first element matching the selector is rendered (has a box model),
+// is not hidden via display/visibility/opacity and intersects the viewport.
+func (remote *RemoteDebugger) IsVisible(selector string) (bool, error) {
+	if _, err := remote.SelectorBoxModel(selector); notRendered(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	res, err := remote.evaluateSelector(selector, `var s = window.getComputedStyle(el);
+		if (s.display === "none" || s.visibility === "hidden" || s.visibility === "collapse" || s.opacity === "0") return false;
+		var r = el.getBoundingClientRect();
+		if (r.width === 0 || r.height === 0) return false;
+		return r.bottom > 0 && r.right > 0 && r.top < window.innerHeight && r.left < window.innerWidth;`)
+	if err != nil {
+		return false, err
+	}
+
+	visible, _ := res.(bool)
+	return visible, nil
+}
+
+// GetAttribute returns the value of the attribute of the first element matching the selector.
+// The boolean result is false if the element doesn't have the attribute.
+func (remote *RemoteDebugger) GetAttribute(selector, name string) (string, bool, error) {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return "", false, err
+	}
+
+	res, err := remote.SendRequest("DOM.getAttributes", Params{
+		"nodeId": id,
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	// attributes are returned as a flat list of name, value pairs
+	attrs, _ := res["attributes"].([]interface{})
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i].(string) == name {
+			return attrs[i+1].(string), true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// SetAttribute sets the value of the attribute of the first element matching the selector.
+func (remote *RemoteDebugger) SetAttribute(selector, name, value string) error {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return err
+	}
+
+	return remote.SetAttributeValue(id, name, value)
+}
+
+// RemoveAttribute removes the attribute from the first element matching the selector.
+func (remote *RemoteDebugger) RemoveAttribute(selector, name string) error {
+	id, err := remote.QuerySelectorNode(selector)
+	if err != nil {
+		return err
+	}
+
+	_, err = remote.SendRequest("DOM.removeAttribute", Params{
+		"nodeId": id,
+		"name":   name,
+	})
+	return err
+}
+
+// GetProperty returns the value of a Javascript property (i.e. "value", "checked" or "dataset")
+// of the first element matching the selector.
+func (remote *RemoteDebugger) GetProperty(selector, prop string) (interface{}, error) {
+	return remote.evaluateSelector(selector, fmt.Sprintf(`return el[%s];`, jsString(prop)))
+}
+
+// EventListener describes an event listener attached to a DOM node (see GetEventListeners).
+type EventListener struct {
+	Type         string `json:"type"`
+	UseCapture   bool   `json:"useCapture"`
+	Passive      bool   `json:"passive"`
+	Once         bool   `json:"once"`
+	ScriptID     string `json:"scriptId"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+	// Handler is the description (usually the source code) of the handler function
+	Handler string `json:"handler"`
+}
+
+// GetEventListeners returns the event listeners attached to the first element matching the selector.
+func (remote *RemoteDebugger) GetEventListeners(selector string) ([]EventListener, error) {
+	objectID, err := remote.expressionObject(elementExpression(selector))
+	if err != nil {
+		return nil, err
+	}
+
+	defer remote.ReleaseObject(objectID)
+
+	raw, err := remote.sendRawReplyRequest("DOMDebugger.getEventListeners", Params{
+		"objectId": objectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Listeners []struct {
+			EventListener
+			Handler struct {
+				Description string `json:"description"`
+			} `json:"handler"`
+		} `json:"listeners"`
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	listeners := make([]EventListener, 0, len(res.Listeners))
+	for _, l := range res.Listeners {
+		listener := l.EventListener
+		listener.Handler = l.Handler.Description
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// SelectOption selects the options with the given values (or labels) in the first <select> element matching the selector,
+// deselecting all the others, and dispatches the input and change events like a user interaction would.
+// For a single-choice select only the first value is used. It returns the values of the selected options.
+func (remote *RemoteDebugger) SelectOption(selector string, values ...string) ([]string, error) {
+	jvalues, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := remote.evaluateSelector(selector, fmt.Sprintf(`var values = %s;
+		if (el.tagName !== "SELECT") return {error: "element is not a <select>"};
+		if (!el.multiple) values = values.slice(0, 1);
+		var selected = [];
+		for (var opt of el.options) {
+			var sel = !opt.disabled && (values.includes(opt.value) || values.includes(opt.label));
+			if (sel && !el.multiple && selected.length > 0) sel = false;
+			opt.selected = sel;
+			if (sel) selected.push(opt.value);
+		}
+		if (values.length > 0 && selected.length === 0) return {error: "no matching options"};
+		el.dispatchEvent(new Event("input", {bubbles: true}));
+		el.dispatchEvent(new Event("change", {bubbles: true}));
+		return {selected: selected};`, jvalues))
+	if err != nil {
+		return nil, err
+	}
+
+	m := Params(res.(map[string]interface{}))
+	if msg := m.String("error"); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	list, _ := m["selected"].([]interface{})
+	selected := make([]string, 0, len(list))
+	for _, v := range list {
+		selected = append(selected, v.(string))
+	}
+
+	return selected, nil
+}
+
+// Fill sets the value of the first input, textarea or contenteditable element matching the selector,
+// and dispatches the input and change events like a user interaction would.
+func (remote *RemoteDebugger) Fill(selector, value string) error {
+	return remote.fillElement(elementExpression(selector), value)
+}
+
+// fillElement sets the value of the element the expression evaluates to (see Fill).
+func (remote *RemoteDebugger) fillElement(expr, value string) error {
+	jvalue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	res, err := remote.evaluateElement(expr, fmt.Sprintf(`var value = %s;
+		el.focus();
+		if (el.isContentEditable) el.textContent = value;
+		else if ("value" in el) el.value = value;
+		else return {error: "element is not an input"};
+		el.dispatchEvent(new Event("input", {bubbles: true}));
+		el.dispatchEvent(new Event("change", {bubbles: true}));
+		return {};`, jvalue))
+	if err != nil {
+		return err
+	}
+
+	m, _ := res.(map[string]interface{})
+	if msg := Params(m).String("error"); msg != "" {
+		return errors.New(msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package godet
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// visibilityBrowser answers the commands used by IsVisible: the node is found unless nodeID is 0,
+// DOM.getBoxModel fails with boxErr (if set) and the visibility script returns visible.
+func visibilityBrowser(t *testing.T, nodeID int, boxErr *ProtocolError, visible bool) *Tab {
+	return fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		switch method {
+		case "DOM.getDocument":
+			return Params{"root": Params{"nodeId": 1}}, nil
+
+		case "DOM.querySelector":
+			return Params{"nodeId": nodeID}, nil
+
+		case "DOM.getBoxModel":
+			if boxErr != nil {
+				return nil, boxErr
+			}
+
+			return Params{"model": Params{"width": 10, "height": 10}}, nil
+
+		case "Runtime.evaluate":
+			return Params{"result": Params{"type": "object", "value": Params{"value": visible}}}, nil
+		}
+
+		return nil, &ProtocolError{Code: -32601, Message: "'" + method + "' wasn't found"}
+	})
+}
+
+func TestIsVisible(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeID  int
+		boxErr  *ProtocolError
+		visible bool
+		want    bool
+		err     bool
+	}{
+		{name: "visible", nodeID: 5, visible: true, want: true},
+		{name: "hidden by style", nodeID: 5, visible: false, want: false},
+		{name: "not rendered", nodeID: 5, boxErr: &ProtocolError{Code: -32000, Message: "Could not compute box model."}, want: false},
+		{name: "no node", nodeID: 0, err: true},
+		{name: "protocol error", nodeID: 5, boxErr: &ProtocolError{Code: -32000, Message: "Node with given id does not exist"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := connectFake(t, visibilityBrowser(t, tt.nodeID, tt.boxErr, tt.visible))
+			defer remote.Close()
+
+			visible, err := remote.IsVisible("#target")
+			if (err != nil) != tt.err {
+				t.Fatalf("IsVisible error = %v, want error %v", err, tt.err)
+			}
+			if visible != tt.want {
+				t.Errorf("IsVisible = %v, want %v", visible, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsVisibleClosed(t *testing.T) {
+	remote := connectFake(t, visibilityBrowser(t, 5, nil, true))
+	remote.Close()
+
+	if _, err := remote.IsVisible("#target"); err != ErrorClose {
+		t.Errorf("IsVisible on a closed connection = %v, want %v", err, ErrorClose)
+	}
+}
+
+func TestJSString(t *testing.T) {
+	for _, s := range []string{"plain", `a "quoted" \ string`, "emoji \U0001F600", "bell \a", "invalid \xff utf-8", "line\u2028separator", "</script>"} {
+		lit := jsString(s)
+
+		if strings.Contains(lit, `\U`) || strings.Contains(lit, `\a`) || strings.Contains(lit, `\x`) || strings.Contains(lit, "\u2028") {
+			t.Errorf("jsString(%q) = %s is not valid Javascript", s, lit)
+		}
+
+		var back string
+		if err := json.Unmarshal([]byte(lit), &back); err != nil {
+			t.Errorf("jsString(%q) = %s: %v", s, lit, err)
+		} else if back != strings.ToValidUTF8(s, "�") {
+			t.Errorf("jsString(%q) decodes to %q", s, back)
+		}
+	}
+}
+
+func TestElementExpression(t *testing.T) {
+	if got, want := elementExpression("[title=\"\U0001F600\"]"), `document.querySelector("[title=\"😀\"]")`; got != want {
+		t.Errorf("elementExpression = %s, want %s", got, want)
+	}
+
+	if got := elementExpression("my-app >>> button[title=\"\a\"]"); !strings.Contains(got, `("my-app \u003e\u003e\u003e button[title=\"\u0007\"]".split("\u003e\u003e\u003e"))`) {
+		t.Errorf("deep elementExpression = %s", got)
+	}
+}
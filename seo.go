@@ -0,0 +1,200 @@
+package godet
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// Hreflang is an alternate language version of the page.
+type Hreflang struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// BrokenLink is a same-origin resource that failed to load or returned an error status.
+type BrokenLink struct {
+	URL    string
+	Status int
+	Error  string
+}
+
+// SEOReport is the result of AuditSEO.
+type SEOReport struct {
+	URL         string
+	Title       string
+	Description string
+	Canonical   string
+	Robots      string
+	Hreflang    []Hreflang
+	H1Count     int
+
+	// StructuredData lists the JSON-LD blocks in the page (blocks that can't be parsed are skipped).
+	StructuredData []map[string]interface{}
+
+	// BrokenLinks lists the failed same-origin requests (only if RecordResponses is enabled).
+	BrokenLinks []BrokenLink
+
+	// Problems lists the common issues found in the page.
+	Problems []string
+}
+
+const seoAuditJS = `function content(sel, attr) {
+		var el = document.querySelector(sel);
+		return el ? (el.getAttribute(attr) || "") : "";
+	}
+	var hreflang = [];
+	document.querySelectorAll("link[rel=alternate][hreflang]").forEach(function(l) {
+		hreflang.push({lang: l.getAttribute("hreflang"), url: l.href});
+	});
+	var jsonld = [];
+	document.querySelectorAll("script[type='application/ld+json']").forEach(function(s) {
+		jsonld.push(s.textContent);
+	});
+	return JSON.stringify({
+		report: {
+			url: location.href,
+			title: document.title,
+			description: content("meta[name=description]", "content"),
+			canonical: (document.querySelector("link[rel=canonical]") || {}).href || "",
+			robots: content("meta[name=robots]", "content"),
+			hreflang: hreflang,
+			h1Count: document.querySelectorAll("h1").length
+		},
+		jsonld: jsonld
+	});`
+
+// AuditSEO collects the SEO relevant information of the current page: title, meta description,
+// canonical, hreflang, robots meta, number of h1 headings and structured data (JSON-LD).
+//
+// If RecordResponses is enabled, the same-origin requests of the current page that failed
+// are reported as broken links (the requests of the pages loaded before are ignored).
+func (remote *RemoteDebugger) AuditSEO() (*SEOReport, error) {
+	res, err := remote.EvaluateWrap(seoAuditJS)
+	if err != nil {
+		return nil, err
+	}
+
+	s, _ := res.(string)
+
+	var audit struct {
+		Report struct {
+			URL         string     `json:"url"`
+			Title       string     `json:"title"`
+			Description string     `json:"description"`
+			Canonical   string     `json:"canonical"`
+			Robots      string     `json:"robots"`
+			Hreflang    []Hreflang `json:"hreflang"`
+			H1Count     int        `json:"h1Count"`
+		} `json:"report"`
+		JSONLD []string `json:"jsonld"`
+	}
+
+	if err := json.Unmarshal([]byte(s), &audit); err != nil {
+		return nil, err
+	}
+
+	report := &SEOReport{
+		URL:         audit.Report.URL,
+		Title:       audit.Report.Title,
+		Description: audit.Report.Description,
+		Canonical:   audit.Report.Canonical,
+		Robots:      audit.Report.Robots,
+		Hreflang:    audit.Report.Hreflang,
+		H1Count:     audit.Report.H1Count,
+	}
+
+	for _, block := range audit.JSONLD {
+		report.StructuredData = append(report.StructuredData, parseJSONLD(block)...)
+	}
+
+	origin := requestOrigin(report.URL)
+
+	responses := remote.Responses()
+
+	var loaderID string
+	if len(responses) > 0 {
+		if loaderID, err = remote.mainLoaderID(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range responses {
+		if r.LoaderID != loaderID || requestOrigin(r.URL) != origin || (!r.Failed && r.Status < 400) {
+			continue
+		}
+
+		report.BrokenLinks = append(report.BrokenLinks, BrokenLink{URL: r.URL, Status: r.Status, Error: r.ErrorText})
+	}
+
+	report.Problems = seoProblems(report)
+	return report, nil
+}
+
+// seoProblems returns the common issues found in the report (the lengths are in characters).
+func seoProblems(report *SEOReport) []string {
+	var problems []string
+
+	switch n := utf8.RuneCountInString(report.Title); {
+	case n == 0:
+		problems = append(problems, "missing title")
+	case n > 60:
+		problems = append(problems, "title longer than 60 characters")
+	}
+
+	switch n := utf8.RuneCountInString(report.Description); {
+	case n == 0:
+		problems = append(problems, "missing meta description")
+	case n > 160:
+		problems = append(problems, "meta description longer than 160 characters")
+	}
+
+	if report.Canonical == "" {
+		problems = append(problems, "missing canonical")
+	}
+
+	switch report.H1Count {
+	case 0:
+		problems = append(problems, "missing h1")
+	case 1:
+	default:
+		problems = append(problems, "multiple h1")
+	}
+
+	if len(report.BrokenLinks) > 0 {
+		problems = append(problems, "broken internal links")
+	}
+
+	return problems
+}
+
+// parseJSONLD parses a JSON-LD block, that can be a single object, an array or a @graph.
+func parseJSONLD(block string) []map[string]interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(block), &v); err != nil {
+		return nil
+	}
+
+	var items []map[string]interface{}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if graph, ok := v["@graph"].([]interface{}); ok {
+			for _, item := range graph {
+				if m, ok := item.(map[string]interface{}); ok {
+					items = append(items, m)
+				}
+			}
+		} else {
+			items = append(items, v)
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				items = append(items, m)
+			}
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,126 @@
+package godet
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSEOProblems(t *testing.T) {
+	good := func() *SEOReport {
+		return &SEOReport{
+			Title:       "A good title",
+			Description: "A good description",
+			Canonical:   "https://example.com/",
+			H1Count:     1,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(r *SEOReport)
+		problems []string
+	}{
+		{"good", func(r *SEOReport) {}, nil},
+		{"no title", func(r *SEOReport) { r.Title = "" }, []string{"missing title"}},
+		{"long title", func(r *SEOReport) { r.Title = strings.Repeat("a", 61) }, []string{"title longer than 60 characters"}},
+		{"60 characters title", func(r *SEOReport) { r.Title = strings.Repeat("a", 60) }, nil},
+		{"non-ASCII title", func(r *SEOReport) { r.Title = strings.Repeat("é", 50) }, nil}, // 100 bytes
+		{"long non-ASCII title", func(r *SEOReport) { r.Title = strings.Repeat("日", 61) }, []string{"title longer than 60 characters"}},
+		{"no description", func(r *SEOReport) { r.Description = "" }, []string{"missing meta description"}},
+		{"long description", func(r *SEOReport) { r.Description = strings.Repeat("a", 161) }, []string{"meta description longer than 160 characters"}},
+		{"non-ASCII description", func(r *SEOReport) { r.Description = strings.Repeat("ü", 160) }, nil},
+		{"no canonical", func(r *SEOReport) { r.Canonical = "" }, []string{"missing canonical"}},
+		{"no h1", func(r *SEOReport) { r.H1Count = 0 }, []string{"missing h1"}},
+		{"multiple h1", func(r *SEOReport) { r.H1Count = 2 }, []string{"multiple h1"}},
+		{"broken links", func(r *SEOReport) { r.BrokenLinks = []BrokenLink{{URL: "https://example.com/x", Status: 404}} }, []string{"broken internal links"}},
+		{"everything", func(r *SEOReport) { *r = SEOReport{} }, []string{"missing title", "missing meta description", "missing canonical", "missing h1"}},
+	}
+
+	for _, tt := range tests {
+		report := good()
+		tt.change(report)
+
+		if got := seoProblems(report); !reflect.DeepEqual(got, tt.problems) {
+			t.Errorf("%s: problems = %q, want %q", tt.name, got, tt.problems)
+		}
+	}
+}
+
+func TestParseJSONLD(t *testing.T) {
+	tests := []struct {
+		block string
+		types []string
+	}{
+		{`{"@type": "Article", "headline": "x"}`, []string{"Article"}},
+		{`[{"@type": "Article"}, {"@type": "Person"}, 42]`, []string{"Article", "Person"}},
+		{`{"@context": "https://schema.org", "@graph": [{"@type": "WebSite"}, {"@type": "Organization"}]}`, []string{"WebSite", "Organization"}},
+		{`{"@type": "Article",}`, nil},
+		{`"just a string"`, nil},
+	}
+
+	for _, tt := range tests {
+		var types []string
+		for _, item := range parseJSONLD(tt.block) {
+			types = append(types, item["@type"].(string))
+		}
+
+		if !reflect.DeepEqual(types, tt.types) {
+			t.Errorf("parseJSONLD(%s) types = %q, want %q", tt.block, types, tt.types)
+		}
+	}
+}
+
+func TestAuditSEOBrokenLinks(t *testing.T) {
+	audit, _ := json.Marshal(Params{
+		"report": Params{"url": "https://example.com/page2", "title": "Page 2", "h1Count": 1},
+		"jsonld": []string{`{"@type": "WebPage"}`},
+	})
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		switch method {
+		case "Runtime.evaluate":
+			return Params{"result": Params{"type": "string", "value": string(audit)}}, nil
+
+		case "Page.getFrameTree":
+			return Params{"frameTree": Params{"frame": Params{"id": "main", "loaderId": "L2"}}}, nil
+		}
+
+		return nil, nil
+	}))
+
+	remote.RecordResponses(true)
+
+	request := func(id, loader, url string, status int) {
+		fakeEvent(remote, "Network.requestWillBeSent", Params{"requestId": id, "loaderId": loader, "request": Params{"url": url, "method": "GET"}})
+		if status > 0 {
+			fakeEvent(remote, "Network.responseReceived", Params{"requestId": id, "response": Params{"url": url, "status": status}})
+		}
+	}
+
+	request("1", "L1", "https://example.com/page1-missing.png", 404) // the previous page
+	request("2", "L2", "https://example.com/page2", 200)
+	request("3", "L2", "https://example.com/missing.png", 404)
+	request("4", "L2", "https://cdn.example.org/error.js", 500) // another origin
+	request("5", "L2", "https://example.com/unreachable.css", 0)
+	fakeEvent(remote, "Network.loadingFailed", Params{"requestId": "5", "errorText": "net::ERR_CONNECTION_RESET"})
+
+	report, err := remote.AuditSEO()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []BrokenLink{
+		{URL: "https://example.com/missing.png", Status: 404},
+		{URL: "https://example.com/unreachable.css", Error: "net::ERR_CONNECTION_RESET"},
+	}
+
+	if !reflect.DeepEqual(report.BrokenLinks, want) {
+		t.Errorf("BrokenLinks = %+v, want %+v", report.BrokenLinks, want)
+	}
+
+	if len(report.StructuredData) != 1 || report.Title != "Page 2" {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCService is the name of the gRPC service. Its methods are Navigate, Screenshot, PDF, Evaluate and HAR,
+// all taking a Request and returning a Response.
+//
+// The messages are encoded as JSON (content-subtype "json", i.e. "application/grpc+json"),
+// so that no generated protobuf code is needed: Go clients can use CallGRPC.
+const GRPCService = "godet.Server"
+
+// grpcMethods maps the operation names to the gRPC method names.
+var grpcMethods = map[string]string{
+	"navigate":   "Navigate",
+	"screenshot": "Screenshot",
+	"pdf":        "PDF",
+	"evaluate":   "Evaluate",
+	"har":        "HAR",
+}
+
+// Response is the response of the gRPC methods.
+type Response struct {
+	// ContentType is the type of the result: application/json for Result, image/png, image/jpeg
+	// or application/pdf for Data.
+	ContentType string `json:"contentType"`
+
+	// Data is the screenshot or the PDF.
+	Data []byte `json:"data,omitempty"`
+
+	// Result is the JSON value returned by Navigate (a NavigateResult), Evaluate and HAR.
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// jsonCodec encodes the gRPC messages as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// grpcServer is the interface implemented by the service handler.
+type grpcServer interface {
+	grpcCall(ctx context.Context, name string, req *Request) (*Response, error)
+}
+
+// grpcCodes maps the HTTP status of the call errors to the gRPC codes.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+	http.StatusBadGateway:          codes.Unavailable,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+func (s *Server) grpcCall(ctx context.Context, name string, req *Request) (*Response, error) {
+	out, err := s.call(ctx, name, s.operations()[name], req)
+	if err != nil {
+		code, ok := grpcCodes[err.(*callError).status]
+		if !ok {
+			code = codes.Unknown
+		}
+
+		return nil, status.Error(code, err.Error())
+	}
+
+	if out.contentType != "" {
+		return &Response{ContentType: out.contentType, Data: out.data}, nil
+	}
+
+	result, err := json.Marshal(out.value)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &Response{ContentType: "application/json", Result: result}, nil
+}
+
+// grpcMethod returns the description of the gRPC method for the operation.
+func grpcMethod(name, method string) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Request)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			handle := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(grpcServer).grpcCall(ctx, name, req.(*Request))
+			}
+
+			if interceptor == nil {
+				return handle(ctx, req)
+			}
+
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + GRPCService + "/" + method}
+			return interceptor(ctx, req, info, handle)
+		},
+	}
+}
+
+// RegisterGRPC registers the service (see GRPCService) with a gRPC server.
+func (s *Server) RegisterGRPC(g grpc.ServiceRegistrar) {
+	desc := grpc.ServiceDesc{
+		ServiceName: GRPCService,
+		HandlerType: (*grpcServer)(nil),
+	}
+
+	for name, method := range grpcMethods {
+		desc.Methods = append(desc.Methods, grpcMethod(name, method))
+	}
+
+	g.RegisterService(&desc, s)
+}
+
+// CallGRPC calls a method (i.e. "Screenshot") of the gRPC service.
+func CallGRPC(ctx context.Context, conn grpc.ClientConnInterface, method string, req *Request, options ...grpc.CallOption) (*Response, error) {
+	var resp Response
+
+	options = append(options, grpc.CallContentSubtype("json"))
+	if err := conn.Invoke(ctx, "/"+GRPCService+"/"+method, req, &resp, options...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// HAR is a minimal HTTP Archive (1.2), built from the responses recorded during a navigation.
+// Bodies and timings are not included.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARCookie    `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+
+	// EarlyHints are the headers of the 103 Early Hints response (custom field).
+	EarlyHints []HARNameValue `json:"_earlyHints,omitempty"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func nameValues(m map[string]string) []HARNameValue {
+	list := []HARNameValue{}
+	for k, v := range m {
+		list = append(list, HARNameValue{Name: k, Value: v})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// httpVersion returns the HAR version of the negotiated protocol.
+func httpVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "", "http/1.1":
+		return "HTTP/1.1"
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29", "quic":
+		return "HTTP/3"
+	}
+
+	return strings.ToUpper(protocol)
+}
+
+// harCookies converts the observed cookies. The blocked cookies are included, with the reasons in the comment.
+func harCookies(cookies []godet.ObservedCookie) []HARCookie {
+	list := []HARCookie{}
+	for _, c := range cookies {
+		hc := HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.UTC().Format(time.RFC3339)
+		}
+		if c.Blocked() {
+			hc.Comment = "blocked: " + strings.Join(c.BlockedReasons, ", ")
+		}
+
+		list = append(list, hc)
+	}
+
+	return list
+}
+
+// BuildHAR converts the recorded responses into a HAR. start is the (wall clock) time
+// the first request was sent, since the response timestamps are monotonic.
+func BuildHAR(responses []godet.ObservedResponse, start time.Time) *HAR {
+	har := &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "godet", Version: "1.0"},
+		Entries: []HAREntry{},
+	}}
+
+	var t0 float64
+	if len(responses) > 0 {
+		t0 = responses[0].Timestamp
+	}
+
+	for _, r := range responses {
+		query := []HARNameValue{}
+		if u, err := url.Parse(r.URL); err == nil {
+			for k, values := range u.Query() {
+				for _, v := range values {
+					query = append(query, HARNameValue{Name: k, Value: v})
+				}
+			}
+		}
+
+		var earlyHints []HARNameValue
+		if r.EarlyHints != nil {
+			earlyHints = nameValues(r.EarlyHints)
+		}
+
+		started := start.Add(time.Duration((r.Timestamp - t0) * float64(time.Second)))
+
+		har.Log.Entries = append(har.Log.Entries, HAREntry{
+			StartedDateTime: started.Format(time.RFC3339Nano),
+			Request: HARRequest{
+				Method:      r.Method,
+				URL:         r.URL,
+				HTTPVersion: httpVersion(r.Protocol),
+				Headers:     nameValues(r.RequestHeaders),
+				QueryString: query,
+				Cookies:     harCookies(r.Cookies),
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: HARResponse{
+				Status:      r.Status,
+				StatusText:  r.StatusText,
+				HTTPVersion: httpVersion(r.Protocol),
+				Headers:     nameValues(r.Headers),
+				Cookies:     harCookies(r.SetCookies),
+				Content:     HARContent{Size: -1, MimeType: r.MimeType},
+				RedirectURL: r.Headers["Location"],
+				HeadersSize: -1,
+				BodySize:    -1,
+				Comment:     r.ErrorText,
+				EarlyHints:  earlyHints,
+			},
+			Timings: HARTimings{Send: -1, Wait: -1, Receive: -1},
+		})
+	}
+
+	return har
+}
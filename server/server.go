@@ -0,0 +1,291 @@
+// Package server exposes high-level godet operations (navigate, screenshot, pdf, evaluate and har)
+// as an HTTP/JSON API, turning a browser into a rendering service.
+//
+// Each request runs in its own tab, allocated from a godet.Pool (or a godet.Cluster of browsers).
+//
+//	POST /navigate    {"url": ...}                               -> {"url", "title", "status"}
+//	POST /screenshot  {"url": ..., "format": "png", "quality": 80} -> image
+//	POST /pdf         {"url": ...}                               -> application/pdf
+//	POST /evaluate    {"url": ..., "expression": ...}            -> {"result"}
+//	POST /har         {"url": ...}                               -> HAR
+//
+// Only http and https URLs are accepted. Since it runs arbitrary Javascript in the browser,
+// /evaluate is disabled unless Server.AllowEvaluate is set.
+//
+// The same operations are available as a gRPC service (see RegisterGRPC).
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/raff/godet"
+)
+
+// Request is the body of all the API requests.
+type Request struct {
+	URL        string `json:"url"`
+	Timeout    int    `json:"timeout"` // milliseconds, default Server.Timeout
+	Format     string `json:"format"`  // screenshot format: png or jpeg
+	Quality    int    `json:"quality"` // jpeg quality
+	Expression string `json:"expression"`
+}
+
+// NavigateResult is the response of /navigate.
+type NavigateResult struct {
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+}
+
+// Server is an http.Handler serving the API.
+type Server struct {
+	// Pool allocates the tabs for the requests.
+	Pool godet.TabAllocator
+
+	// Timeout is the default navigation timeout.
+	Timeout time.Duration
+
+	// AllowEvaluate enables the evaluate operation.
+	AllowEvaluate bool
+
+	mux *http.ServeMux
+}
+
+var (
+	// ErrorMissingURL is returned if the request has no URL.
+	ErrorMissingURL = errors.New("missing url")
+	// ErrorInvalidURL is returned if the request URL is not an http or https URL.
+	ErrorInvalidURL = errors.New("invalid url: only http and https are allowed")
+	// ErrorEvaluateDisabled is returned by evaluate if Server.AllowEvaluate is not set.
+	ErrorEvaluateDisabled = errors.New("evaluate is disabled")
+)
+
+// New returns a Server allocating tabs from pool (a godet.Pool or godet.Cluster).
+func New(pool godet.TabAllocator) *Server {
+	s := &Server{Pool: pool, Timeout: 30 * time.Second, mux: http.NewServeMux()}
+
+	for name := range s.operations() {
+		s.mux.HandleFunc("/"+name, s.handler(name))
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// operation runs in a tab already navigated to the requested URL.
+type operation func(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error)
+
+// output is the result of an operation: a JSON value, or data of the specified content type.
+type output struct {
+	contentType string
+	data        []byte
+	value       interface{}
+}
+
+// navigation holds the details of the page load.
+type navigation struct {
+	start     time.Time
+	status    int
+	responses []godet.ObservedResponse
+}
+
+// operations maps the operation names to the operations.
+func (s *Server) operations() map[string]operation {
+	return map[string]operation{
+		"navigate":   s.navigate,
+		"screenshot": s.screenshot,
+		"pdf":        s.pdf,
+		"evaluate":   s.evaluate,
+		"har":        s.har,
+	}
+}
+
+// callError is an error with the HTTP status of the response.
+type callError struct {
+	status int
+	err    error
+}
+
+func (e *callError) Error() string {
+	return e.err.Error()
+}
+
+func (e *callError) Unwrap() error {
+	return e.err
+}
+
+// validate checks the request before a tab is allocated.
+func (s *Server) validate(name string, req *Request) error {
+	if name == "evaluate" && !s.AllowEvaluate {
+		return &callError{http.StatusForbidden, ErrorEvaluateDisabled}
+	}
+
+	if req.URL == "" {
+		return &callError{http.StatusBadRequest, ErrorMissingURL}
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &callError{http.StatusBadRequest, ErrorInvalidURL}
+	}
+
+	return nil
+}
+
+// call validates the request, acquires a tab, navigates to the URL and runs op.
+// The errors are callErrors.
+func (s *Server) call(ctx context.Context, name string, op operation, req *Request) (*output, error) {
+	if err := s.validate(name, req); err != nil {
+		return nil, err
+	}
+
+	timeout := s.Timeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tab, err := s.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, &callError{http.StatusServiceUnavailable, err}
+	}
+
+	defer s.Pool.Release(tab)
+
+	nav, err := load(tab, req.URL, timeout)
+	if err == godet.ErrorTimeout {
+		return nil, &callError{http.StatusGatewayTimeout, err}
+	} else if err != nil {
+		return nil, &callError{http.StatusBadGateway, err}
+	}
+
+	out, err := op(tab, req, nav)
+	if err != nil {
+		return nil, &callError{http.StatusInternalServerError, err}
+	}
+
+	return out, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// handler decodes the request, runs the operation and writes its output.
+func (s *Server) handler(name string) http.HandlerFunc {
+	op := s.operations()[name]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		out, err := s.call(r.Context(), name, op, &req)
+		if err != nil {
+			writeError(w, err.(*callError).status, err)
+			return
+		}
+
+		if out.contentType == "" {
+			writeJSON(w, http.StatusOK, out.value)
+			return
+		}
+
+		w.Header().Set("Content-Type", out.contentType)
+		w.Write(out.data)
+	}
+}
+
+// load navigates the tab to the URL, recording the responses.
+func load(tab *godet.RemoteDebugger, url string, timeout time.Duration) (*navigation, error) {
+	if err := tab.NetworkEvents(true); err != nil {
+		return nil, err
+	}
+
+	tab.RecordResponses(true)
+
+	nav := &navigation{start: time.Now()}
+
+	result, err := tab.NavigateAndWait(url, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	nav.responses = tab.Responses()
+	nav.status = result.Status
+
+	return nav, nil
+}
+
+func (s *Server) navigate(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error) {
+	res, err := tab.Evaluate("[location.href, document.title]")
+	if err != nil {
+		return nil, err
+	}
+
+	result := NavigateResult{Status: nav.status}
+	if l, ok := res.([]interface{}); ok && len(l) == 2 {
+		result.URL, _ = l[0].(string)
+		result.Title, _ = l[1].(string)
+	}
+
+	return &output{value: result}, nil
+}
+
+func (s *Server) screenshot(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error) {
+	format := req.Format
+	if format != "jpeg" {
+		format = "png"
+	}
+
+	img, err := tab.CaptureScreenshot(format, req.Quality, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{contentType: "image/" + format, data: img}, nil
+}
+
+func (s *Server) pdf(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error) {
+	pdf, err := tab.PrintToPDF()
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{contentType: "application/pdf", data: pdf}, nil
+}
+
+func (s *Server) evaluate(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error) {
+	res, err := tab.Evaluate(req.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{value: map[string]interface{}{"result": res}}, nil
+}
+
+func (s *Server) har(tab *godet.RemoteDebugger, req *Request, nav *navigation) (*output, error) {
+	return &output{value: BuildHAR(nav.responses, nav.start)}, nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/raff/godet"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// noTabs is a TabAllocator without tabs, that counts the allocation attempts.
+type noTabs struct {
+	sync.Mutex
+	acquired int
+}
+
+func (p *noTabs) Acquire(ctx context.Context) (*godet.RemoteDebugger, error) {
+	p.Lock()
+	p.acquired++
+	p.Unlock()
+
+	return nil, errors.New("no tabs")
+}
+
+func (p *noTabs) Release(conn *godet.RemoteDebugger) error {
+	return nil
+}
+
+func (p *noTabs) count() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.acquired
+}
+
+func TestHTTPValidation(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		tab    bool // the request gets to the tab allocation
+	}{
+		{"GET", "/navigate", "", http.StatusMethodNotAllowed, false},
+		{"POST", "/navigate", `{`, http.StatusBadRequest, false},
+		{"POST", "/navigate", `{}`, http.StatusBadRequest, false},
+		{"POST", "/screenshot", `{"url": "file:///etc/passwd"}`, http.StatusBadRequest, false},
+		{"POST", "/pdf", `{"url": "javascript:alert(1)"}`, http.StatusBadRequest, false},
+		{"POST", "/har", `{"url": "chrome://settings"}`, http.StatusBadRequest, false},
+		{"POST", "/navigate", `{"url": "http:///path"}`, http.StatusBadRequest, false},
+		{"POST", "/evaluate", `{"url": "https://example.com", "expression": "1"}`, http.StatusForbidden, false},
+		{"POST", "/navigate", `{"url": "https://example.com"}`, http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		pool := &noTabs{}
+		s := New(pool)
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s %s: status %d, want %d (%s)", tt.method, tt.path, tt.body, w.Code, tt.status, w.Body)
+		}
+		if tab := pool.count() > 0; tab != tt.tab {
+			t.Errorf("%s %s %s: tab allocated = %v", tt.method, tt.path, tt.body, tab)
+		}
+	}
+
+	pool := &noTabs{}
+	s := New(pool)
+	s.AllowEvaluate = true
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/evaluate", strings.NewReader(`{"url": "https://example.com", "expression": "1"}`)))
+
+	if w.Code != http.StatusServiceUnavailable || pool.count() != 1 {
+		t.Errorf("evaluate enabled: status %d, tabs %d", w.Code, pool.count())
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	pool := &noTabs{}
+
+	l := bufconn.Listen(1 << 16)
+	g := grpc.NewServer()
+	New(pool).RegisterGRPC(g)
+
+	go g.Serve(l)
+	defer g.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	tests := []struct {
+		method string
+		req    Request
+		code   codes.Code
+	}{
+		{"Navigate", Request{URL: "file:///etc/passwd"}, codes.InvalidArgument},
+		{"Screenshot", Request{}, codes.InvalidArgument},
+		{"Evaluate", Request{URL: "https://example.com", Expression: "1"}, codes.PermissionDenied},
+		{"PDF", Request{URL: "https://example.com"}, codes.Unavailable},
+		{"Unknown", Request{URL: "https://example.com"}, codes.Unimplemented},
+	}
+
+	for _, tt := range tests {
+		_, err := CallGRPC(context.Background(), conn, tt.method, &tt.req)
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("%s %+v: %v, want %v", tt.method, tt.req, err, tt.code)
+		}
+	}
+
+	if n := pool.count(); n != 1 {
+		t.Errorf("tabs allocated %d times, want 1", n)
+	}
+}
@@ -0,0 +1,117 @@
+package godet
+
+// TargetSession is a protocol session attached to another target (i.e. another tab, an iframe or a worker)
+// over the same connection (see AttachSession).
+//
+// The events are routed by session:
+//
+//   - the events without a sessionId (or with the session of the connection itself, see GridSession)
+//     go to the internal hooks and to the callbacks of the RemoteDebugger (see CallbackEvent);
+//   - the events of a TargetSession only go to its own callbacks: they never reach the RemoteDebugger callbacks,
+//     and they don't affect the RemoteDebugger methods waiting for events (i.e. NavigateAndWait);
+//   - the events of the other sessions (i.e. attached with AttachToTarget or SetAutoAttach) go to the
+//     RemoteDebugger callbacks, as the params include the sessionId.
+//
+// The events of all sessions are delivered in order, by the same goroutine.
+type TargetSession struct {
+	remote *RemoteDebugger
+
+	// ID is the sessionId.
+	ID string
+
+	// TargetID is the id of the attached target.
+	TargetID string
+
+	callbacks map[string]EventCallback // guarded by the RemoteDebugger lock
+	stop      func()
+}
+
+// AttachSession attaches to the target (see TargetList) in flat mode, returning a TargetSession
+// with its own event callbacks, sharing the connection.
+func (remote *RemoteDebugger) AttachSession(targetID string) (*TargetSession, error) {
+	res, err := remote.SendRequest("Target.attachToTarget", Params{
+		"targetId": targetID,
+		"flatten":  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session := &TargetSession{
+		remote:    remote,
+		ID:        Params(res).String("sessionId"),
+		TargetID:  targetID,
+		callbacks: map[string]EventCallback{},
+	}
+
+	session.stop = remote.addHook("Target.detachedFromTarget", func(params Params) bool {
+		if params.String("sessionId") == session.ID {
+			session.remove()
+		}
+
+		return false
+	})
+
+	remote.Lock()
+	if remote.sessions == nil {
+		remote.sessions = map[string]*TargetSession{}
+	}
+	remote.sessions[session.ID] = session
+	remote.Unlock()
+
+	return session, nil
+}
+
+// Sessions returns the sessions created by AttachSession that are still attached.
+func (remote *RemoteDebugger) Sessions() []*TargetSession {
+	remote.Lock()
+	defer remote.Unlock()
+
+	sessions := make([]*TargetSession, 0, len(remote.sessions))
+	for _, s := range remote.sessions {
+		sessions = append(sessions, s)
+	}
+
+	return sessions
+}
+
+// remove stops routing the events to the session.
+func (s *TargetSession) remove() {
+	s.remote.Lock()
+	delete(s.remote.sessions, s.ID)
+	s.remote.Unlock()
+
+	s.stop()
+}
+
+// SendRequest sends a request to the session target and returns the reply as a map.
+func (s *TargetSession) SendRequest(method string, params Params) (map[string]interface{}, error) {
+	rawReply, err := s.remote.sendSessionRequest(s.ID, method, params)
+	if err != nil || rawReply == nil {
+		return nil, err
+	}
+
+	return unmarshal(rawReply)
+}
+
+// CallbackEvent sets a callback for the specified event of the session (nil removes it).
+// The domain events need to be enabled in the session (i.e. SendRequest("Page.enable", nil)).
+func (s *TargetSession) CallbackEvent(method string, cb EventCallback) {
+	s.remote.Lock()
+	if cb == nil {
+		delete(s.callbacks, method)
+	} else {
+		s.callbacks[method] = cb
+	}
+	s.remote.Unlock()
+}
+
+// Detach detaches from the target. The session callbacks are not called anymore.
+func (s *TargetSession) Detach() error {
+	s.remove()
+
+	_, err := s.remote.SendRequest("Target.detachFromTarget", Params{
+		"sessionId": s.ID,
+	})
+	return err
+}
@@ -0,0 +1,142 @@
+package godet
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// feedEvents routes the messages as readMessages does, then dispatches the queued events.
+func feedEvents(t *testing.T, remote *RemoteDebugger, messages []string) {
+	t.Helper()
+
+	var raw rawMessage
+
+	for _, m := range messages {
+		if err := scanMessage([]byte(m), &raw); err != nil {
+			t.Fatalf("scanMessage(%s): %v", m, err)
+		}
+
+		if raw.method == nil {
+			continue // a reply
+		}
+
+		if message, ok := remote.eventMessage(&raw); ok {
+			remote.events <- message
+		}
+	}
+
+	close(remote.events)
+	remote.processEvents()
+}
+
+func testSession(remote *RemoteDebugger, id string) *TargetSession {
+	s := &TargetSession{
+		remote:    remote,
+		ID:        id,
+		TargetID:  "target-" + id,
+		callbacks: map[string]EventCallback{},
+	}
+
+	if remote.sessions == nil {
+		remote.sessions = map[string]*TargetSession{}
+	}
+	remote.sessions[id] = s
+	return s
+}
+
+func TestSessionRouting(t *testing.T) {
+	remote := newRemoteDebugger(nil, false)
+
+	s1 := testSession(remote, "S1")
+	s2 := testSession(remote, "S2")
+
+	var got1, got2, gotRoot, gotHook []string
+
+	record := func(list *[]string, method string) EventCallback {
+		return func(params Params) {
+			*list = append(*list, fmt.Sprintf("%v %v", method, params["n"]))
+		}
+	}
+
+	for _, method := range []string{"Page.frameNavigated", "Network.requestWillBeSent"} {
+		s1.CallbackEvent(method, record(&got1, method))
+		remote.CallbackEvent(method, record(&gotRoot, method))
+	}
+
+	s2.CallbackEvent("Page.frameNavigated", record(&got2, "Page.frameNavigated"))
+
+	remote.addHook("Page.frameNavigated", func(params Params) bool {
+		gotHook = append(gotHook, fmt.Sprint(params["n"]))
+		return false
+	})
+
+	feedEvents(t, remote, []string{
+		`{"method":"Page.frameNavigated","params":{"n":1},"sessionId":"S1"}`,
+		`{"method":"Page.frameNavigated","params":{"n":2},"sessionId":"S2"}`,
+		`{"method":"Page.frameNavigated","params":{"n":3}}`,
+		`{"method":"Network.requestWillBeSent","params":{"n":4},"sessionId":"S2"}`, // no S2 callback
+		`{"method":"Network.requestWillBeSent","params":{"n":5},"sessionId":"S1"}`,
+		`{"id":7,"result":{}}`,
+		`{"method":"Network.requestWillBeSent","params":{"n":6}}`,
+		`{"method":"Page.frameNavigated","params":{"n":7},"sessionId":"OTHER"}`, // not attached with AttachSession
+		`{"method":"Page.frameNavigated","params":{"n":8},"sessionId":"S2"}`,
+		`{"method":"Page.frameNavigated","params":{"n":9},"sessionId":"S1"}`,
+	})
+
+	expect := func(name string, got, want []string) {
+		t.Helper()
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s events = %q, want %q", name, got, want)
+		}
+	}
+
+	expect("S1", got1, []string{
+		"Page.frameNavigated 1",
+		"Network.requestWillBeSent 5",
+		"Page.frameNavigated 9",
+	})
+	expect("S2", got2, []string{
+		"Page.frameNavigated 2",
+		"Page.frameNavigated 8",
+	})
+	expect("root", gotRoot, []string{
+		"Page.frameNavigated 3",
+		"Network.requestWillBeSent 6",
+		"Page.frameNavigated 7",
+	})
+	expect("hook", gotHook, []string{"3", "7"})
+}
+
+func TestSessionRemoved(t *testing.T) {
+	remote := newRemoteDebugger(nil, false)
+
+	s := testSession(remote, "S1")
+	s.stop = func() {}
+
+	var gotSession, gotRoot int
+
+	s.CallbackEvent("Page.frameNavigated", func(Params) { gotSession++ })
+	remote.CallbackEvent("Page.frameNavigated", func(Params) { gotRoot++ })
+
+	var raw rawMessage
+	if err := scanMessage([]byte(`{"method":"Page.frameNavigated","params":{},"sessionId":"S1"}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := remote.eventMessage(&raw)
+	s.remove()
+	after, _ := remote.eventMessage(&raw)
+
+	remote.dispatch(before)
+	remote.dispatch(after)
+
+	if gotSession != 1 || gotRoot != 1 {
+		t.Errorf("got %d session and %d root events, want 1 and 1", gotSession, gotRoot)
+	}
+
+	if len(remote.Sessions()) != 0 {
+		t.Errorf("session not removed: %v", remote.Sessions())
+	}
+}
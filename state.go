@@ -0,0 +1,265 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// OriginState holds the web storage of an origin.
+type OriginState struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+}
+
+// StorageState is the browser state needed to resume a session (i.e. after logging in):
+// the cookies and the web storage of the visited origins.
+type StorageState struct {
+	Cookies []Cookie      `json:"cookies"`
+	Origins []OriginState `json:"origins"`
+}
+
+// restoreStorageJS populates the storage of the matching origin, when a new document is created.
+// Existing keys are not overwritten, so that the changes made by the page are preserved.
+const restoreStorageJS = `(function(origins) {
+  var state = origins[location.origin];
+  if (!state) return;
+
+  try {
+    [[window.localStorage, state.localStorage], [window.sessionStorage, state.sessionStorage]].forEach(function(s) {
+      var items = s[1] || {};
+      for (var k in items) {
+        if (s[0].getItem(k) === null) s[0].setItem(k, items[k]);
+      }
+    });
+  } catch (e) {}
+})(%s)`
+
+// frameOrigins collects the security origins of all the frames in the frame tree.
+func frameOrigins(tree map[string]interface{}, origins map[string]bool) {
+	if origin := Params(Params(tree).Map("frame")).String("securityOrigin"); origin != "" && origin != "null" {
+		origins[origin] = true
+	}
+
+	children, _ := tree["childFrames"].([]interface{})
+	for _, c := range children {
+		if child, ok := c.(map[string]interface{}); ok {
+			frameOrigins(child, origins)
+		}
+	}
+}
+
+// storageItems returns the local or session storage items for the origin.
+func (remote *RemoteDebugger) storageItems(origin string, local bool) (map[string]string, error) {
+	rawReply, err := remote.sendRawReplyRequest("DOMStorage.getDOMStorageItems", Params{
+		"storageId": Params{
+			"securityOrigin": origin,
+			"isLocalStorage": local,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Entries [][]string `json:"entries"`
+	}
+
+	if err := json.Unmarshal(rawReply, &reply); err != nil {
+		return nil, err
+	}
+
+	if len(reply.Entries) == 0 {
+		return nil, nil
+	}
+
+	items := map[string]string{}
+	for _, e := range reply.Entries {
+		if len(e) == 2 {
+			items[e[0]] = e[1]
+		}
+	}
+
+	return items, nil
+}
+
+// GetStorageState returns all the cookies and the local and session storage of the origins
+// loaded in the current page (main frame and iframes) plus the specified ones.
+//
+// Note that the storage of an origin is only accessible while a frame of that origin is loaded.
+func (remote *RemoteDebugger) GetStorageState(origins ...string) (*StorageState, error) {
+	cookies, err := remote.GetAllCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := remote.SendRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]bool{}
+	frameOrigins(Params(res).Map("frameTree"), all)
+
+	for _, origin := range origins {
+		all[origin] = true
+	}
+
+	if _, err := remote.SendRequest("DOMStorage.enable", nil); err != nil {
+		return nil, err
+	}
+
+	state := &StorageState{Cookies: cookies, Origins: []OriginState{}}
+
+	for origin := range all {
+		st := OriginState{Origin: origin}
+
+		if st.LocalStorage, err = remote.storageItems(origin, true); err != nil {
+			return nil, err
+		}
+		if st.SessionStorage, err = remote.storageItems(origin, false); err != nil {
+			return nil, err
+		}
+
+		if st.LocalStorage != nil || st.SessionStorage != nil {
+			state.Origins = append(state.Origins, st)
+		}
+	}
+
+	sort.Slice(state.Origins, func(i, j int) bool {
+		return state.Origins[i].Origin < state.Origins[j].Origin
+	})
+
+	return state, nil
+}
+
+// SetStorageState restores the cookies and the web storage saved by GetStorageState.
+//
+// The storage is populated when a document of the matching origin is loaded (keys that already exist
+// are not overwritten) and, for the origins already loaded in the current page, immediately.
+// Calling it again replaces the storage restored previously.
+func (remote *RemoteDebugger) SetStorageState(state *StorageState) error {
+	if len(state.Cookies) > 0 {
+		var cookies []Params
+
+		for _, c := range state.Cookies {
+			cookie := Params{
+				"name":     c.Name,
+				"value":    c.Value,
+				"domain":   c.Domain,
+				"path":     c.Path,
+				"secure":   c.Secure,
+				"httpOnly": c.HttpOnly,
+			}
+			if c.SameSite != "" {
+				cookie["sameSite"] = c.SameSite
+			}
+			if !c.Session && c.Expires > 0 {
+				cookie["expires"] = c.Expires
+			}
+
+			cookies = append(cookies, cookie)
+		}
+
+		if _, err := remote.SendRequest("Network.setCookies", Params{"cookies": cookies}); err != nil {
+			return err
+		}
+	}
+
+	remote.Lock()
+	script := remote.stateScript
+	remote.stateScript = ""
+	remote.Unlock()
+
+	if script != "" {
+		if err := remote.RemoveScriptToEvaluateOnNewDocument(script); err != nil {
+			return err
+		}
+	}
+
+	if len(state.Origins) == 0 {
+		return nil
+	}
+
+	origins := map[string]OriginState{}
+	for _, st := range state.Origins {
+		origins[st.Origin] = st
+	}
+
+	jorigins, err := json.Marshal(origins)
+	if err != nil {
+		return err
+	}
+
+	script, err = remote.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(restoreStorageJS, jorigins))
+	if err != nil {
+		return err
+	}
+
+	remote.Lock()
+	remote.stateScript = script
+	remote.Unlock()
+
+	res, err := remote.SendRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string]bool{}
+	frameOrigins(Params(res).Map("frameTree"), loaded)
+
+	if len(loaded) == 0 {
+		return nil
+	}
+
+	if _, err := remote.SendRequest("DOMStorage.enable", nil); err != nil {
+		return err
+	}
+
+	for origin := range loaded {
+		st, ok := origins[origin]
+		if !ok {
+			continue
+		}
+
+		for local, items := range map[bool]map[string]string{true: st.LocalStorage, false: st.SessionStorage} {
+			for k, v := range items {
+				if _, err := remote.SendRequest("DOMStorage.setDOMStorageItem", Params{
+					"storageId": Params{
+						"securityOrigin": origin,
+						"isLocalStorage": local,
+					},
+					"key":   k,
+					"value": v,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExportState returns the storage state (see GetStorageState) as JSON, that can be saved
+// and passed to ImportState to reuse a logged-in session.
+func (remote *RemoteDebugger) ExportState(origins ...string) ([]byte, error) {
+	state, err := remote.GetStorageState(origins...)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(state, "", "  ")
+}
+
+// ImportState restores the storage state exported by ExportState (see SetStorageState).
+func (remote *RemoteDebugger) ImportState(data []byte) error {
+	var state StorageState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	return remote.SetStorageState(&state)
+}
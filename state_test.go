@@ -0,0 +1,151 @@
+package godet
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeStorage is a browser with cookies and web storage, for the storage state tests.
+type fakeStorage struct {
+	sync.Mutex
+	cookies []Params
+	storage map[string]map[string]string // "origin local|session" to items
+	frames  []string                     // origins of the main frame and of its child frame
+	scripts []string
+}
+
+func storageKey(storageID Params) string {
+	if storageID["isLocalStorage"] == true {
+		return storageID.String("securityOrigin") + " local"
+	}
+
+	return storageID.String("securityOrigin") + " session"
+}
+
+func (fs *fakeStorage) reply(method string, params Params) (interface{}, *ProtocolError) {
+	fs.Lock()
+	defer fs.Unlock()
+
+	switch method {
+	case "Network.getCookies":
+		return Params{"cookies": fs.cookies}, nil
+
+	case "Network.setCookies":
+		cookies, _ := params["cookies"].([]interface{})
+		for _, c := range cookies {
+			fs.cookies = append(fs.cookies, Params(c.(map[string]interface{})))
+		}
+
+	case "Page.getFrameTree":
+		tree := Params{"frame": Params{"securityOrigin": "null"}}
+		if len(fs.frames) > 0 {
+			tree = Params{"frame": Params{"securityOrigin": fs.frames[0]}}
+		}
+		if len(fs.frames) > 1 {
+			tree["childFrames"] = []Params{{"frame": Params{"securityOrigin": fs.frames[1]}}}
+		}
+		return Params{"frameTree": tree}, nil
+
+	case "DOMStorage.getDOMStorageItems":
+		var entries [][]string
+		for k, v := range fs.storage[storageKey(params.Map("storageId"))] {
+			entries = append(entries, []string{k, v})
+		}
+		return Params{"entries": entries}, nil
+
+	case "DOMStorage.setDOMStorageItem":
+		key := storageKey(params.Map("storageId"))
+		if fs.storage[key] == nil {
+			fs.storage[key] = map[string]string{}
+		}
+		fs.storage[key][params.String("key")] = params.String("value")
+
+	case "Page.addScriptToEvaluateOnNewDocument":
+		fs.scripts = append(fs.scripts, params.String("source"))
+		return Params{"identifier": fmt.Sprint(len(fs.scripts))}, nil
+
+	case "Page.removeScriptToEvaluateOnNewDocument":
+		fs.scripts = append(fs.scripts, "removed "+params.String("identifier"))
+	}
+
+	return nil, nil
+}
+
+func TestStorageState(t *testing.T) {
+	src := &fakeStorage{
+		cookies: []Params{
+			{"name": "sid", "value": "1234", "domain": "app.example.com", "path": "/", "secure": true, "httpOnly": true, "session": true},
+			{"name": "theme", "value": "dark", "domain": ".example.com", "path": "/", "expires": 2000000000, "sameSite": "Lax"},
+		},
+		storage: map[string]map[string]string{
+			"https://app.example.com local":   {"token": "abc"},
+			"https://app.example.com session": {"tab": "2"},
+			"https://auth.example.com local":  {"user": "bob"},
+			"https://other.example.com local": {"x": "1"}, // not loaded nor requested
+		},
+		frames: []string{"https://app.example.com", "https://auth.example.com"},
+	}
+
+	remote := connectFake(t, fakeCDP(t, src.reply))
+
+	data, err := remote.ExportState("https://cdn.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := &fakeStorage{storage: map[string]map[string]string{}, frames: []string{"https://app.example.com"}}
+	remote = connectFake(t, fakeCDP(t, dst.reply))
+
+	if err := remote.ImportState(data); err != nil {
+		t.Fatal(err)
+	}
+
+	dst.Lock()
+
+	var cookies []string
+	for _, c := range dst.cookies {
+		cookies = append(cookies, fmt.Sprintf("%v=%v %v secure=%v httpOnly=%v sameSite=%v expires=%v",
+			c["name"], c["value"], c["domain"], c["secure"], c["httpOnly"], c["sameSite"], c["expires"]))
+	}
+	sort.Strings(cookies)
+
+	want := []string{
+		"sid=1234 app.example.com secure=true httpOnly=true sameSite=<nil> expires=<nil>",
+		"theme=dark .example.com secure=false httpOnly=false sameSite=Lax expires=2e+09",
+	}
+	if !reflect.DeepEqual(cookies, want) {
+		t.Errorf("cookies = %q, want %q", cookies, want)
+	}
+
+	// only the loaded origin is restored immediately
+	wantStorage := map[string]map[string]string{
+		"https://app.example.com local":   {"token": "abc"},
+		"https://app.example.com session": {"tab": "2"},
+	}
+	if !reflect.DeepEqual(dst.storage, wantStorage) {
+		t.Errorf("storage = %v, want %v", dst.storage, wantStorage)
+	}
+
+	// the others when loaded
+	if len(dst.scripts) != 1 || !strings.Contains(dst.scripts[0], `"https://auth.example.com":{"origin":"https://auth.example.com","localStorage":{"user":"bob"}}`) ||
+		strings.Contains(dst.scripts[0], "other.example.com") {
+		t.Errorf("scripts = %q", dst.scripts)
+	}
+
+	dst.Unlock()
+
+	if err := remote.SetStorageState(&StorageState{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst.Lock()
+	defer dst.Unlock()
+
+	if len(dst.scripts) != 2 || dst.scripts[1] != "removed 1" {
+		t.Errorf("the restore script was not removed: %q", dst.scripts)
+	}
+}
@@ -0,0 +1,163 @@
+package godet
+
+import (
+	"net/url"
+	"sort"
+)
+
+// RequestStats holds the network statistics for an origin and resource type (see CollectStats).
+type RequestStats struct {
+	Origin        string       `json:"origin"`
+	ResourceType  ResourceType `json:"resourceType"`
+	Requests      int          `json:"requests"`
+	Failures      int          `json:"failures"`
+	BytesSent     int64        `json:"bytesSent"`
+	BytesReceived int64        `json:"bytesReceived"`
+}
+
+type statsKey struct {
+	origin string
+	rtype  ResourceType
+}
+
+// networkStats accumulates RequestStats by origin and resource type.
+type networkStats struct {
+	stats    map[statsKey]*RequestStats
+	requests map[string]statsKey // in-flight requests
+	stop     []func()
+}
+
+// requestOrigin returns scheme://host[:port] for the URL (or the URL itself, for data: and similar).
+func requestOrigin(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil || pu.Host == "" {
+		return u
+	}
+
+	return pu.Scheme + "://" + pu.Host
+}
+
+func (ns *networkStats) get(key statsKey) *RequestStats {
+	st := ns.stats[key]
+	if st == nil {
+		st = &RequestStats{Origin: key.origin, ResourceType: key.rtype}
+		ns.stats[key] = st
+	}
+
+	return st
+}
+
+// CollectStats starts (or stops) collecting request counts, failures and bytes sent/received,
+// grouped by origin and resource type. Requires Network events (see NetworkEvents).
+//
+// Bytes received are the encoded (on the wire) sizes reported by the browser, while bytes sent
+// are estimated from the request line, headers and post data.
+func (remote *RemoteDebugger) CollectStats(enable bool) {
+	remote.Lock()
+	ns := remote.stats
+	if !enable {
+		remote.stats = nil
+	}
+	remote.Unlock()
+
+	if !enable {
+		if ns != nil {
+			for _, stop := range ns.stop {
+				stop()
+			}
+		}
+
+		return
+	}
+
+	if ns != nil { // already collecting
+		return
+	}
+
+	ns = &networkStats{
+		stats:    map[statsKey]*RequestStats{},
+		requests: map[string]statsKey{},
+	}
+
+	ns.stop = append(ns.stop, remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+		req := Params(params.Map("request"))
+		key := statsKey{origin: requestOrigin(req.String("url")), rtype: ResourceType(params.String("type"))}
+
+		sent := len(req.String("method")) + len(req.String("url")) + len(req.String("postData")) + 12
+		for k, v := range req.Map("headers") {
+			if s, ok := v.(string); ok {
+				sent += len(k) + len(s) + 4
+			}
+		}
+
+		remote.Lock()
+		ns.requests[params.String("requestId")] = key
+		st := ns.get(key)
+		st.Requests++
+		st.BytesSent += int64(sent)
+		remote.Unlock()
+		return false
+	}))
+
+	ns.stop = append(ns.stop, remote.addHook("Network.loadingFinished", func(params Params) bool {
+		rid := params.String("requestId")
+
+		remote.Lock()
+		if key, ok := ns.requests[rid]; ok {
+			delete(ns.requests, rid)
+			ns.get(key).BytesReceived += int64(params.Int("encodedDataLength"))
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	ns.stop = append(ns.stop, remote.addHook("Network.loadingFailed", func(params Params) bool {
+		rid := params.String("requestId")
+
+		remote.Lock()
+		if key, ok := ns.requests[rid]; ok {
+			delete(ns.requests, rid)
+			ns.get(key).Failures++
+		}
+		remote.Unlock()
+		return false
+	}))
+
+	remote.Lock()
+	remote.stats = ns
+	remote.Unlock()
+}
+
+// Stats returns the statistics collected so far, sorted by origin and resource type.
+func (remote *RemoteDebugger) Stats() []RequestStats {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if remote.stats == nil {
+		return nil
+	}
+
+	stats := make([]RequestStats, 0, len(remote.stats.stats))
+	for _, st := range remote.stats.stats {
+		stats = append(stats, *st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Origin != stats[j].Origin {
+			return stats[i].Origin < stats[j].Origin
+		}
+
+		return stats[i].ResourceType < stats[j].ResourceType
+	})
+
+	return stats
+}
+
+// ResetStats clears the statistics collected so far.
+func (remote *RemoteDebugger) ResetStats() {
+	remote.Lock()
+	if remote.stats != nil {
+		remote.stats.stats = map[statsKey]*RequestStats{}
+	}
+	remote.Unlock()
+}
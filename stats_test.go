@@ -0,0 +1,58 @@
+package godet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectStats(t *testing.T) {
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		return nil, nil
+	}))
+
+	remote.CollectStats(true)
+
+	request := func(id, url string, resourceType ResourceType, headers Params) {
+		fakeEvent(remote, "Network.requestWillBeSent", Params{
+			"requestId": id,
+			"type":      resourceType,
+			"request":   Params{"url": url, "method": "GET", "headers": headers},
+		})
+	}
+
+	request("1", "https://example.com/", ResourceTypeDocument, Params{"Accept": "*/*"})
+	request("2", "https://example.com/a.js", ResourceTypeScript, nil)
+	request("3", "https://example.com/b.js", ResourceTypeScript, nil)
+	request("4", "https://cdn.example.net:8443/c.js", ResourceTypeScript, nil)
+
+	fakeEvent(remote, "Network.loadingFinished", Params{"requestId": "1", "encodedDataLength": 1000})
+	fakeEvent(remote, "Network.loadingFinished", Params{"requestId": "2", "encodedDataLength": 200})
+	fakeEvent(remote, "Network.loadingFailed", Params{"requestId": "3", "errorText": "net::ERR_FAILED"})
+	fakeEvent(remote, "Network.loadingFinished", Params{"requestId": "3", "encodedDataLength": 300}) // already done
+	fakeEvent(remote, "Network.loadingFinished", Params{"requestId": "5", "encodedDataLength": 400}) // unknown
+
+	want := []RequestStats{
+		{Origin: "https://cdn.example.net:8443", ResourceType: ResourceTypeScript, Requests: 1, BytesSent: 3 + 33 + 12},
+		{Origin: "https://example.com", ResourceType: ResourceTypeDocument, Requests: 1, BytesSent: 3 + 20 + 12 + 6 + 3 + 4, BytesReceived: 1000},
+		{Origin: "https://example.com", ResourceType: ResourceTypeScript, Requests: 2, Failures: 1, BytesSent: 2 * (3 + 24 + 12), BytesReceived: 200},
+	}
+
+	if stats := remote.Stats(); !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	remote.ResetStats()
+	request("6", "data:image/png;base64,AAAA", ResourceTypeImage, nil)
+
+	want = []RequestStats{{Origin: "data:image/png;base64,AAAA", ResourceType: ResourceTypeImage, Requests: 1, BytesSent: 3 + 26 + 12}}
+	if stats := remote.Stats(); !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats after reset = %+v, want %+v", stats, want)
+	}
+
+	remote.CollectStats(false)
+	request("7", "https://example.com/", ResourceTypeDocument, nil)
+
+	if stats := remote.Stats(); stats != nil {
+		t.Errorf("stats after stop = %+v", stats)
+	}
+}
@@ -0,0 +1,141 @@
+// Package stealth implements an opt-in preset that hides the most common signs of browser automation
+// (navigator.webdriver, the "HeadlessChrome" user agent, the missing plugins and so on), for scraping research.
+//
+// It only covers the well known checks: it's not meant to defeat the fingerprinting services.
+package stealth
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/raff/godet"
+)
+
+// config is the configuration of Stealth.
+type config struct {
+	userAgent string
+	languages []string
+}
+
+// Option defines the functional options for Stealth.
+type Option func(c *config)
+
+// UserAgent sets the user agent (default the browser user agent, without "Headless").
+func UserAgent(ua string) Option {
+	return func(c *config) {
+		c.userAgent = ua
+	}
+}
+
+// Languages sets navigator.languages and the Accept-Language header (default "en-US", "en").
+func Languages(languages ...string) Option {
+	return func(c *config) {
+		c.languages = languages
+	}
+}
+
+// stealthJS hides the automation properties. It's called with the list of languages.
+const stealthJS = `(function(languages) {
+	var proto = Object.getPrototypeOf(navigator);
+	function define(obj, name, value) {
+		try { Object.defineProperty(obj, name, {get: function() { return value; }, configurable: true}); } catch (e) {}
+	}
+
+	define(proto, "webdriver", false);
+	define(proto, "languages", Object.freeze(languages.slice()));
+
+	if (navigator.plugins.length === 0) {
+		var mimeTypes = [
+			{type: "application/pdf", suffixes: "pdf", description: "Portable Document Format"},
+			{type: "text/pdf", suffixes: "pdf", description: "Portable Document Format"}
+		];
+		var names = ["PDF Viewer", "Chrome PDF Viewer", "Chromium PDF Viewer", "Microsoft Edge PDF Viewer", "WebKit built-in PDF"];
+		var plugins = names.map(function(name) {
+			var plugin = Object.create(Plugin.prototype);
+			define(plugin, "name", name);
+			define(plugin, "filename", "internal-pdf-viewer");
+			define(plugin, "description", "Portable Document Format");
+			define(plugin, "length", mimeTypes.length);
+			mimeTypes.forEach(function(m, i) { define(plugin, i, m); });
+			return plugin;
+		});
+		var pluginArray = Object.create(PluginArray.prototype);
+		plugins.forEach(function(p, i) { define(pluginArray, i, p); });
+		define(pluginArray, "length", plugins.length);
+		pluginArray.item = function(i) { return plugins[i] || null; };
+		pluginArray.namedItem = function(name) { return plugins.filter(function(p) { return p.name === name; })[0] || null; };
+		pluginArray.refresh = function() {};
+		define(proto, "plugins", pluginArray);
+
+		var mimeTypeArray = Object.create(MimeTypeArray.prototype);
+		mimeTypes.forEach(function(m, i) { define(mimeTypeArray, i, m); });
+		define(mimeTypeArray, "length", mimeTypes.length);
+		mimeTypeArray.item = function(i) { return mimeTypes[i] || null; };
+		mimeTypeArray.namedItem = function(type) { return mimeTypes.filter(function(m) { return m.type === type; })[0] || null; };
+		define(proto, "mimeTypes", mimeTypeArray);
+	}
+
+	if (!window.chrome) {
+		window.chrome = {};
+	}
+	if (!window.chrome.runtime) {
+		window.chrome.runtime = {};
+	}
+
+	if (navigator.permissions && window.Notification) {
+		var query = navigator.permissions.query.bind(navigator.permissions);
+		navigator.permissions.query = function(desc) {
+			if (desc && desc.name === "notifications") {
+				// headless reports "denied" while Notification.permission is "default"
+				return Promise.resolve({state: Notification.permission === "default" ? "prompt" : Notification.permission, onchange: null});
+			}
+			return query(desc);
+		};
+	}
+})`
+
+// Stealth hides the most common signs of automation in the current and in all new documents:
+// it overrides the user agent (removing "Headless") with matching UA-CH metadata and platform,
+// sets navigator.webdriver to false, adds the default PDF plugins, sets navigator.languages,
+// adds the window.chrome object and fixes the notifications permission reported by headless browsers.
+func Stealth(remote *godet.RemoteDebugger, options ...Option) error {
+	c := config{languages: []string{"en-US", "en"}}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if c.userAgent == "" {
+		version, err := remote.Version()
+		if err != nil {
+			return err
+		}
+
+		c.userAgent = strings.Replace(version.UserAgent, "HeadlessChrome", "Chrome", 1)
+	}
+
+	override := godet.NewUserAgentOverride(c.userAgent)
+	override.AcceptLanguage = strings.Join(c.languages, ",")
+
+	if err := remote.SetUserAgentOverride(override); err != nil {
+		return err
+	}
+
+	if c.languages == nil {
+		c.languages = []string{}
+	}
+
+	languages, err := json.Marshal(c.languages)
+	if err != nil {
+		return err
+	}
+
+	script := stealthJS + "(" + string(languages) + ")"
+
+	if _, err := remote.AddScriptToEvaluateOnNewDocument(script); err != nil {
+		return err
+	}
+
+	_, err = remote.Evaluate(script)
+	return err
+}
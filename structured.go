@@ -0,0 +1,148 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredData holds the structured data (JSON-LD and microdata) found in the page.
+type StructuredData struct {
+	// Items maps each schema type (i.e. "Product", without the schema.org prefix) to the items of that type.
+	// Items with multiple types are listed under each type.
+	Items map[string][]map[string]interface{}
+
+	// Errors lists the problems found: JSON-LD blocks that are not valid JSON or items without a type.
+	Errors []string
+}
+
+// Types returns the number of items for each type.
+func (sd *StructuredData) Types() map[string]int {
+	types := map[string]int{}
+
+	for t, items := range sd.Items {
+		types[t] = len(items)
+	}
+
+	return types
+}
+
+func (sd *StructuredData) add(item map[string]interface{}, source string) {
+	var types []string
+
+	switch t := item["@type"].(type) {
+	case string:
+		types = append(types, t)
+
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		sd.Errors = append(sd.Errors, fmt.Sprintf("%v item without @type", source))
+		return
+	}
+
+	for _, t := range types {
+		t = schemaType(t)
+		sd.Items[t] = append(sd.Items[t], item)
+	}
+}
+
+// schemaType removes the schema.org prefix from a type.
+func schemaType(t string) string {
+	for _, prefix := range []string{"https://schema.org/", "http://schema.org/", "schema:"} {
+		if strings.HasPrefix(t, prefix) {
+			return t[len(prefix):]
+		}
+	}
+
+	return t
+}
+
+// microdataJS converts the top-level microdata items to JSON-LD like objects.
+const microdataJS = `function value(el) {
+		if (el.hasAttribute("itemscope")) return item(el);
+		if (el.hasAttribute("content")) return el.getAttribute("content");
+		switch (el.tagName) {
+		case "A": case "AREA": case "LINK": return el.href;
+		case "IMG": case "AUDIO": case "VIDEO": case "SOURCE": case "IFRAME": case "EMBED": return el.src;
+		case "OBJECT": return el.data;
+		case "DATA": case "METER": return el.value;
+		case "TIME": return el.dateTime || el.textContent.trim();
+		}
+		return el.textContent.trim();
+	}
+	function item(scope) {
+		var obj = {};
+		var types = (scope.getAttribute("itemtype") || "").split(/\s+/).filter(Boolean);
+		if (types.length) obj["@type"] = types.length == 1 ? types[0] : types;
+		if (scope.hasAttribute("itemid")) obj["@id"] = scope.getAttribute("itemid");
+		var props = [];
+		(function walk(el) {
+			for (var c = el.firstElementChild; c; c = c.nextElementSibling) {
+				if (c.hasAttribute("itemprop")) props.push(c);
+				if (!c.hasAttribute("itemscope")) walk(c);
+			}
+		})(scope);
+		props.forEach(function(p) {
+			var v = value(p);
+			p.getAttribute("itemprop").split(/\s+/).filter(Boolean).forEach(function(name) {
+				if (!(name in obj)) obj[name] = v;
+				else if (Array.isArray(obj[name])) obj[name].push(v);
+				else obj[name] = [obj[name], v];
+			});
+		});
+		return obj;
+	}
+	var jsonld = [], microdata = [];
+	document.querySelectorAll("script[type='application/ld+json']").forEach(function(s) {
+		jsonld.push(s.textContent);
+	});
+	document.querySelectorAll("[itemscope]:not([itemprop])").forEach(function(el) {
+		microdata.push(item(el));
+	});
+	return JSON.stringify({jsonld: jsonld, microdata: microdata});`
+
+// ExtractStructuredData returns the JSON-LD and microdata items in the rendered page
+// (including the ones injected by scripts), grouped by type.
+func (remote *RemoteDebugger) ExtractStructuredData() (*StructuredData, error) {
+	res, err := remote.EvaluateWrap(microdataJS)
+	if err != nil {
+		return nil, err
+	}
+
+	s, _ := res.(string)
+
+	var raw struct {
+		JSONLD    []string                 `json:"jsonld"`
+		Microdata []map[string]interface{} `json:"microdata"`
+	}
+
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, err
+	}
+
+	sd := &StructuredData{Items: map[string][]map[string]interface{}{}}
+
+	for i, block := range raw.JSONLD {
+		if !json.Valid([]byte(block)) {
+			sd.Errors = append(sd.Errors, fmt.Sprintf("JSON-LD block %v is not valid JSON", i+1))
+			continue
+		}
+
+		for _, item := range parseJSONLD(block) {
+			sd.add(item, fmt.Sprintf("JSON-LD block %v:", i+1))
+		}
+	}
+
+	for i, item := range raw.Microdata {
+		sd.add(item, fmt.Sprintf("microdata %v:", i+1))
+	}
+
+	return sd, nil
+}
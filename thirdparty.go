@@ -0,0 +1,228 @@
+package godet
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UnexpectedThirdParty is a third party origin, not in the allowlist, requested by the page
+// (see EnableThirdPartyAllowlist).
+type UnexpectedThirdParty struct {
+	Origin        string         `json:"origin"`
+	Requests      int            `json:"requests"`
+	Blocked       int            `json:"blocked"`
+	ResourceTypes []ResourceType `json:"resourceTypes"`
+
+	// URLs are the first requested URLs (up to 5).
+	URLs []string `json:"urls"`
+
+	// Documents are the pages requesting the origin.
+	Documents []string `json:"documents"`
+}
+
+// thirdPartyState holds the state of the allowlist enforced by EnableThirdPartyAllowlist.
+type thirdPartyState struct {
+	sync.Mutex
+	allowed  []string
+	block    bool
+	document string
+	origins  map[string]*UnexpectedThirdParty
+	stop     []func()
+}
+
+// allowedHost returns true if the host is first party or in the allowlist.
+func (tp *thirdPartyState) allowedHost(host string) bool {
+	if tp.document == "" {
+		return true
+	}
+
+	if du, err := url.Parse(tp.document); err == nil && hostDomain(du.Hostname()) == hostDomain(host) {
+		return true
+	}
+
+	for _, domain := range tp.allowed {
+		if matchDomain(host, strings.TrimPrefix(domain, "*.")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check returns true if the request is allowed, updating the current document for the main frame documents.
+func (tp *thirdPartyState) check(reqURL string, resourceType ResourceType, mainFrame bool) bool {
+	u, err := url.Parse(reqURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "ws" && u.Scheme != "wss") {
+		return true // data:, blob: and similar
+	}
+
+	tp.Lock()
+	defer tp.Unlock()
+
+	if resourceType == ResourceTypeDocument && mainFrame {
+		tp.document = reqURL
+		return true
+	}
+
+	return tp.allowedHost(u.Hostname())
+}
+
+// blockedThirdParty returns true if the paused request is blocked by the third party allowlist.
+func (remote *RemoteDebugger) blockedThirdParty(params Params) bool {
+	remote.Lock()
+	tp, current := remote.thirdParties, remote.current
+	remote.Unlock()
+
+	if tp == nil || !tp.block {
+		return false
+	}
+
+	reqURL := Params(params.Map("request")).String("url")
+	resourceType := ResourceType(params.String("resourceType"))
+
+	return !tp.check(reqURL, resourceType, params.String("frameId") == current)
+}
+
+// EnableThirdPartyAllowlist reports the requests to the third party origins that are not in the allowlist
+// (see UnexpectedThirdParties) and, if block is true, blocks them (via Fetch interception).
+//
+// The allowed domains include their subdomains (i.e. "google-analytics.com" allows "www.google-analytics.com").
+// The first party domain is the domain of the page loaded in the main frame, and it's always allowed.
+// Network events are enabled, if needed. The report is reset when the allowlist is enabled again.
+func (remote *RemoteDebugger) EnableThirdPartyAllowlist(allowed []string, block bool) error {
+	remote.DisableThirdPartyAllowlist()
+
+	if err := remote.ensureDomain("Network"); err != nil {
+		return err
+	}
+
+	tp := &thirdPartyState{
+		allowed: allowed,
+		block:   block,
+		origins: map[string]*UnexpectedThirdParty{},
+	}
+
+	tp.stop = append(tp.stop, remote.addHook("Network.requestWillBeSent", func(params Params) bool {
+		req := Params(params.Map("request"))
+		reqURL := req.String("url")
+		resourceType := ResourceType(params.String("type"))
+
+		remote.Lock()
+		current := remote.current
+		remote.Unlock()
+
+		if tp.check(reqURL, resourceType, params.String("frameId") == current) {
+			return false
+		}
+
+		origin := requestOrigin(reqURL)
+
+		tp.Lock()
+		defer tp.Unlock()
+
+		u := tp.origins[origin]
+		if u == nil {
+			u = &UnexpectedThirdParty{Origin: origin}
+			tp.origins[origin] = u
+		}
+
+		u.Requests++
+		if tp.block {
+			u.Blocked++
+		}
+		if !containsResourceType(u.ResourceTypes, resourceType) {
+			u.ResourceTypes = append(u.ResourceTypes, resourceType)
+		}
+		if len(u.URLs) < 5 && !containsString(u.URLs, reqURL) {
+			u.URLs = append(u.URLs, reqURL)
+		}
+		if !containsString(u.Documents, tp.document) {
+			u.Documents = append(u.Documents, tp.document)
+		}
+
+		return false
+	}))
+
+	remote.Lock()
+	remote.thirdParties = tp
+	remote.Unlock()
+
+	if !block {
+		return nil
+	}
+
+	return remote.updateFetch()
+}
+
+// DisableThirdPartyAllowlist stops reporting and blocking the third party requests.
+// The report is still available via UnexpectedThirdParties.
+func (remote *RemoteDebugger) DisableThirdPartyAllowlist() error {
+	remote.Lock()
+	tp := remote.thirdParties
+	if tp != nil {
+		remote.thirdParties = &thirdPartyState{origins: tp.origins}
+	}
+	remote.Unlock()
+
+	if tp == nil {
+		return nil
+	}
+
+	for _, stop := range tp.stop {
+		stop()
+	}
+
+	if !tp.block {
+		return nil
+	}
+
+	return remote.updateFetch()
+}
+
+// UnexpectedThirdParties returns the third party origins not in the allowlist requested so far, sorted by origin.
+func (remote *RemoteDebugger) UnexpectedThirdParties() []UnexpectedThirdParty {
+	remote.Lock()
+	tp := remote.thirdParties
+	remote.Unlock()
+
+	if tp == nil {
+		return nil
+	}
+
+	tp.Lock()
+	defer tp.Unlock()
+
+	list := make([]UnexpectedThirdParty, 0, len(tp.origins))
+	for _, u := range tp.origins {
+		c := *u
+		c.ResourceTypes = append([]ResourceType(nil), u.ResourceTypes...)
+		c.URLs = append([]string(nil), u.URLs...)
+		c.Documents = append([]string(nil), u.Documents...)
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Origin < list[j].Origin })
+	return list
+}
+
+func containsResourceType(list []ResourceType, t ResourceType) bool {
+	for _, v := range list {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
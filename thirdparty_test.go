@@ -0,0 +1,53 @@
+package godet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThirdPartyAllowlist(t *testing.T) {
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		return nil, nil
+	}))
+
+	if err := remote.EnableThirdPartyAllowlist([]string{"*.googleapis.com", "cdn.net"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(url string, resourceType ResourceType, frameID string) {
+		fakeEvent(remote, "Network.requestWillBeSent", Params{
+			"requestId": url,
+			"frameId":   frameID,
+			"type":      resourceType,
+			"request":   Params{"url": url, "method": "GET"},
+		})
+	}
+
+	request("https://www.shop.co.uk/", ResourceTypeDocument, "fake")
+	request("https://static.shop.co.uk/app.js", ResourceTypeScript, "fake")     // first party
+	request("https://fonts.googleapis.com/css", ResourceTypeStylesheet, "fake") // allowed
+	request("https://img.cdn.net/logo.png", ResourceTypeImage, "fake")          // allowed
+	request("https://other.co.uk/pixel.gif", ResourceTypeImage, "fake")         // co.uk is a public suffix, not a domain
+	request("https://ads.example/frame.html", ResourceTypeDocument, "child")    // not the main frame
+	request("https://ads.example/ad.js", ResourceTypeScript, "child")
+	request("data:image/png;base64,AAAA", ResourceTypeImage, "fake")
+
+	request("http://10.0.0.1:8080/", ResourceTypeDocument, "fake")
+	request("http://10.0.0.1:8080/api", ResourceTypeXHR, "fake")
+	request("http://10.0.0.2/api", ResourceTypeXHR, "fake") // another IP
+
+	var origins []string
+	for _, u := range remote.UnexpectedThirdParties() {
+		origins = append(origins, u.Origin)
+	}
+
+	want := []string{"http://10.0.0.2", "https://ads.example", "https://other.co.uk"}
+	if !reflect.DeepEqual(origins, want) {
+		t.Errorf("unexpected third parties = %q, want %q", origins, want)
+	}
+
+	ads := remote.UnexpectedThirdParties()[1]
+	if ads.Requests != 2 || ads.Blocked != 0 || !reflect.DeepEqual(ads.Documents, []string{"https://www.shop.co.uk/"}) {
+		t.Errorf("ads = %+v", ads)
+	}
+}
@@ -0,0 +1,264 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TraceStep is a high-level action recorded in a Trace.
+type TraceStep struct {
+	Action   string        `json:"action"`
+	Selector string        `json:"selector,omitempty"`
+	Value    string        `json:"value,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+
+	// URL is the page URL after the action.
+	URL string `json:"url,omitempty"`
+
+	// Before and After are the screenshots taken before and after the action (file names relative to the trace directory).
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Trace is a timeline of the high-level actions (navigations, clicks, fills, waits) performed on a tab,
+// with their timing and screenshots, so that a run can be reviewed step by step. See StartTrace.
+type Trace struct {
+	// Dir is the directory where the trace (trace.json, trace.html and the screenshots) is saved.
+	Dir   string      `json:"-"`
+	Start time.Time   `json:"start"`
+	Steps []TraceStep `json:"steps"`
+
+	screenshots bool
+
+	sync.Mutex
+	depth int
+}
+
+// StartTrace starts recording the actions performed via ReplayActions, Page and Locator in a trace
+// saved in dir. If screenshots is true a screenshot is taken before and after each action.
+// The values typed in password fields and the Sensitive recorded actions are masked.
+func (remote *RemoteDebugger) StartTrace(dir string, screenshots bool) (*Trace, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	t := &Trace{Dir: dir, Start: time.Now(), screenshots: screenshots}
+
+	remote.Lock()
+	remote.trace = t
+	remote.Unlock()
+
+	return t, nil
+}
+
+// StopTrace stops recording the trace and saves it in the trace directory, as trace.json and trace.html.
+func (remote *RemoteDebugger) StopTrace() (*Trace, error) {
+	remote.Lock()
+	t := remote.trace
+	remote.trace = nil
+	remote.Unlock()
+
+	if t == nil {
+		return nil, nil
+	}
+
+	return t, t.Save()
+}
+
+// maskedValue replaces the sensitive values (i.e. passwords) in the traces.
+const maskedValue = "********"
+
+// traceStep performs the action, recording it in the trace (if any).
+// Actions performed by another traced action are not recorded.
+func (remote *RemoteDebugger) traceStep(action, selector, value string, f func() error) error {
+	return remote.traceAction(action, selector, func() string { return value }, f)
+}
+
+// traceInput is traceStep for the actions typing the value in the element the expression evaluates to:
+// the value is masked if the element is a password field (or can't be checked).
+func (remote *RemoteDebugger) traceInput(action, selector, expr, value string, f func() error) error {
+	return remote.traceAction(action, selector, func() string {
+		res, err := remote.evaluateElement(expr, `return el.type === "password" || /password/i.test(el.autocomplete || "");`)
+		if password, ok := res.(bool); err != nil || !ok || password {
+			return maskedValue
+		}
+
+		return value
+	}, f)
+}
+
+// traceAction performs the action, recording it in the trace (if any) with the value returned by value,
+// that is only called when the action is recorded.
+func (remote *RemoteDebugger) traceAction(action, selector string, value func() string, f func() error) error {
+	remote.Lock()
+	t := remote.trace
+	remote.Unlock()
+
+	if t == nil {
+		return f()
+	}
+
+	t.Lock()
+	t.depth++
+	nested := t.depth > 1
+	index := len(t.Steps) + 1
+	t.Unlock()
+
+	defer func() {
+		t.Lock()
+		t.depth--
+		t.Unlock()
+	}()
+
+	if nested {
+		return f()
+	}
+
+	step := TraceStep{Action: action, Selector: selector}
+
+	if t.screenshots {
+		step.Before = t.screenshot(remote, fmt.Sprintf("step-%03d-before.jpg", index))
+	}
+
+	step.Start = time.Now()
+	err := f()
+	step.Duration = time.Since(step.Start)
+
+	if err != nil {
+		step.Error = err.Error()
+	}
+
+	step.Value = value()
+
+	if res, err := remote.Evaluate("document.location.href"); err == nil {
+		step.URL, _ = res.(string)
+	}
+
+	if t.screenshots {
+		step.After = t.screenshot(remote, fmt.Sprintf("step-%03d-after.jpg", index))
+	}
+
+	t.Lock()
+	t.Steps = append(t.Steps, step)
+	t.Unlock()
+
+	return err
+}
+
+// screenshot saves a screenshot in the trace directory, returning its name (or "" if it failed).
+func (t *Trace) screenshot(remote *RemoteDebugger, name string) string {
+	data, err := remote.CaptureScreenshot("jpeg", 60, true)
+	if err != nil {
+		return ""
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(t.Dir, name), data, 0644); err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// Failed returns the first failed step, or nil.
+func (t *Trace) Failed() *TraceStep {
+	t.Lock()
+	defer t.Unlock()
+
+	for i := range t.Steps {
+		if t.Steps[i].Error != "" {
+			return &t.Steps[i]
+		}
+	}
+
+	return nil
+}
+
+// Save saves the trace in its directory, as trace.json and trace.html.
+func (t *Trace) Save() error {
+	t.Lock()
+	defer t.Unlock()
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(t.Dir, "trace.json"), data, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(t.Dir, "trace.html"))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	return traceTemplate.Execute(f, t)
+}
+
+// WriteHTML writes the trace as an HTML page. The screenshots are referenced relative to the trace directory.
+func (t *Trace) WriteHTML(w io.Writer) error {
+	t.Lock()
+	defer t.Unlock()
+
+	return traceTemplate.Execute(w, t)
+}
+
+// ReadTrace reads a trace saved in dir.
+func ReadTrace(dir string) (*Trace, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "trace.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Trace{Dir: dir}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+var traceTemplate = template.Must(template.New("trace").Funcs(template.FuncMap{
+	"offset": func(start, t time.Time) string { return t.Sub(start).Round(time.Millisecond).String() },
+	"ms":     func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+	"inc":    func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>godet trace {{.Start.Format "2006-01-02 15:04:05"}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.step { border: 1px solid #ccc; border-radius: 4px; margin-bottom: 1em; padding: 0.5em; }
+.step.failed { border-color: #c00; background: #fee; }
+.step h3 { margin: 0 0 0.5em 0; font-size: 1em; }
+.step .meta { color: #666; font-size: 0.9em; }
+.step .error { color: #c00; white-space: pre-wrap; }
+.step img { max-width: 45%; border: 1px solid #ddd; margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>Trace {{.Start.Format "2006-01-02 15:04:05"}}</h1>
+{{$start := .Start}}
+{{range $i, $s := .Steps}}
+<div class="step{{if $s.Error}} failed{{end}}">
+<h3>{{inc $i}}. {{$s.Action}} {{$s.Selector}} {{$s.Value}}</h3>
+<div class="meta">at +{{offset $start $s.Start}}, took {{ms $s.Duration}}{{if $s.URL}} &mdash; {{$s.URL}}{{end}}</div>
+{{if $s.Error}}<div class="error">{{$s.Error}}</div>{{end}}
+{{if $s.Before}}<a href="{{$s.Before}}"><img src="{{$s.Before}}" title="before"></a>{{end}}
+{{if $s.After}}<a href="{{$s.After}}"><img src="{{$s.After}}" title="after"></a>{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
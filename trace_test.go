@@ -0,0 +1,88 @@
+package godet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loginBrowser answers the commands of the Page and Locator fill actions on a login form,
+// where the elements selected by "#password" are password fields.
+func loginBrowser(method string, params Params) (interface{}, *ProtocolError) {
+	value := func(v interface{}) (interface{}, *ProtocolError) {
+		return Params{"result": Params{"type": "object", "value": v}}, nil
+	}
+
+	switch method {
+	case "DOM.getDocument":
+		return Params{"root": Params{"nodeId": 1}}, nil
+
+	case "DOM.querySelector":
+		return Params{"nodeId": 2}, nil
+
+	case "Runtime.evaluate":
+		expr := params.String("expression")
+
+		switch {
+		case strings.Contains(expr, "el.autocomplete"):
+			return value(Params{"value": strings.Contains(expr, "#password")})
+
+		case strings.Contains(expr, "var value ="):
+			return value(Params{"value": Params{}})
+
+		case strings.Contains(expr, "document.location.href"):
+			return value("https://example.com/login")
+
+		default: // the locator state
+			return value(elementState{Attached: true, Visible: true, Stable: true, Enabled: true})
+		}
+	}
+
+	return nil, nil
+}
+
+func TestTraceMasksPasswords(t *testing.T) {
+	remote := connectFake(t, fakeCDP(t, loginBrowser))
+	page := NewPage(remote)
+
+	dir := t.TempDir()
+	if _, err := remote.StartTrace(dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := page.Fill("#user", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := page.Fill("#password", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := page.Locator("#password").Fill("s3cret"); err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := remote.StopTrace()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	for _, step := range trace.Steps {
+		values = append(values, step.Action+"="+step.Value)
+	}
+
+	if got, want := strings.Join(values, " "), "fill=alice fill=******** fill=********"; got != want {
+		t.Errorf("steps = %s, want %s", got, want)
+	}
+
+	for _, name := range []string{"trace.json", "trace.html"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(data), "s3cret") {
+			t.Errorf("the password is saved in %s", name)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package godet
+
+import (
+	"regexp"
+	"strings"
+)
+
+// UserAgentBrand is a brand reported by the User-Agent Client Hints (i.e. "Google Chrome" and its version).
+type UserAgentBrand struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+}
+
+// UserAgentMetadata is the User-Agent Client Hints metadata, reported by navigator.userAgentData
+// and the Sec-CH-UA headers.
+type UserAgentMetadata struct {
+	Brands          []UserAgentBrand `json:"brands,omitempty"`
+	FullVersionList []UserAgentBrand `json:"fullVersionList,omitempty"`
+	Platform        string           `json:"platform"`        // i.e. "Windows", "macOS", "Linux" or "Android"
+	PlatformVersion string           `json:"platformVersion"` // i.e. "10.0.0"
+	Architecture    string           `json:"architecture"`    // i.e. "x86" or "arm"
+	Model           string           `json:"model"`           // the device model, for mobile devices
+	Mobile          bool             `json:"mobile"`
+	Bitness         string           `json:"bitness,omitempty"` // i.e. "64"
+	Wow64           bool             `json:"wow64,omitempty"`
+}
+
+// UserAgentOverride is the browser identity set by SetUserAgentOverride.
+type UserAgentOverride struct {
+	UserAgent string `json:"userAgent"`
+
+	// AcceptLanguage is the Accept-Language header (and navigator.language), if set.
+	AcceptLanguage string `json:"acceptLanguage,omitempty"`
+
+	// Platform is the navigator.platform value (i.e. "Win32"), if set.
+	Platform string `json:"platform,omitempty"`
+
+	// Metadata is the User-Agent Client Hints metadata. If nil, the browser derives it from its own version,
+	// that may not match the user agent.
+	Metadata *UserAgentMetadata `json:"userAgentMetadata,omitempty"`
+}
+
+var chromeVersion = regexp.MustCompile(`Chrome/((\d+)[\d.]*)`)
+
+// NewUserAgentOverride returns the override for the user agent, with the navigator.platform value
+// and (for Chrome user agents) the Client Hints metadata consistent with it.
+func NewUserAgentOverride(userAgent string) UserAgentOverride {
+	override := UserAgentOverride{UserAgent: userAgent}
+
+	var md UserAgentMetadata
+
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		override.Platform = "Linux armv8l"
+		md.Platform, md.Architecture = "Android", "arm"
+
+		// "Linux; Android 13; Pixel 7)"
+		if i := strings.Index(userAgent, "Android"); i >= 0 {
+			if fields := strings.Split(strings.SplitN(userAgent[i:], ")", 2)[0], ";"); len(fields) > 1 {
+				md.PlatformVersion = strings.TrimSpace(strings.TrimPrefix(fields[0], "Android"))
+				md.Model = strings.TrimSpace(fields[1])
+			}
+		}
+
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		if strings.Contains(userAgent, "iPad") {
+			override.Platform = "iPad"
+		} else {
+			override.Platform = "iPhone"
+		}
+
+		return override // Safari doesn't support Client Hints
+
+	case strings.Contains(userAgent, "Windows"):
+		override.Platform = "Win32"
+		md.Platform, md.PlatformVersion, md.Architecture, md.Bitness = "Windows", "10.0.0", "x86", "64"
+
+	case strings.Contains(userAgent, "Mac OS X"):
+		override.Platform = "MacIntel"
+		md.Platform, md.PlatformVersion, md.Architecture, md.Bitness = "macOS", "13.0.0", "arm", "64"
+
+	default:
+		override.Platform = "Linux x86_64"
+		md.Platform, md.Architecture, md.Bitness = "Linux", "x86", "64"
+	}
+
+	m := chromeVersion.FindStringSubmatch(userAgent)
+	if m == nil {
+		return override
+	}
+
+	full, major := m[1], m[2]
+
+	md.Mobile = strings.Contains(userAgent, "Mobile")
+	md.Brands = []UserAgentBrand{
+		{Brand: "Not_A Brand", Version: "8"},
+		{Brand: "Chromium", Version: major},
+		{Brand: "Google Chrome", Version: major},
+	}
+	md.FullVersionList = []UserAgentBrand{
+		{Brand: "Not_A Brand", Version: "8.0.0.0"},
+		{Brand: "Chromium", Version: full},
+		{Brand: "Google Chrome", Version: full},
+	}
+
+	override.Metadata = &md
+	return override
+}
+
+// SetUserAgentOverride overrides the user agent, and optionally the Accept-Language header, navigator.platform and
+// the User-Agent Client Hints metadata, so that the sites checking the Client Hints see an identity consistent
+// with the user agent (see NewUserAgentOverride).
+func (remote *RemoteDebugger) SetUserAgentOverride(override UserAgentOverride) error {
+	params := Params{"userAgent": override.UserAgent}
+
+	if override.AcceptLanguage != "" {
+		params["acceptLanguage"] = override.AcceptLanguage
+	}
+	if override.Platform != "" {
+		params["platform"] = override.Platform
+	}
+	if override.Metadata != nil {
+		params["userAgentMetadata"] = override.Metadata
+	}
+
+	_, err := remote.SendRequest("Network.setUserAgentOverride", params)
+	return err
+}
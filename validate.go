@@ -0,0 +1,209 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ResponseValidator validates the body of a response (see ValidateResponses).
+// It returns an error describing the violation, or nil.
+type ResponseValidator func(url string, body []byte) error
+
+// JSONResponse adapts a validator for decoded JSON values (i.e. from a JSON Schema library)
+// to a ResponseValidator. Bodies that are not valid JSON are reported as violations.
+func JSONResponse(validate func(v interface{}) error) ResponseValidator {
+	return func(url string, body []byte) error {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return fmt.Errorf("invalid JSON: %v", err)
+		}
+
+		return validate(v)
+	}
+}
+
+// ResponseViolation is a response that failed validation.
+type ResponseViolation struct {
+	// Navigation is the URL of the top-level document when the response was received.
+	Navigation string
+
+	URL    string
+	Status int
+	Err    error
+}
+
+type responseValidator struct {
+	pattern  string
+	validate ResponseValidator
+}
+
+// validationState holds the validators and the violations found (see ValidateResponses).
+type validationState struct {
+	sync.Mutex
+	validators []responseValidator
+	navigation string
+	pending    map[string]*ResponseViolation
+	violations []ResponseViolation
+	stop       []func()
+}
+
+// ValidateResponses runs the validator on the body of every response whose URL matches urlPattern
+// (with '*' and '?' wildcards), as they are received. The violations are available via ResponseViolations.
+// Multiple validators can be added; ClearResponseValidators removes them.
+//
+// Network and Page events are enabled, if needed.
+func (remote *RemoteDebugger) ValidateResponses(urlPattern string, validator ResponseValidator) error {
+	remote.Lock()
+	vs := remote.validation
+	if vs == nil {
+		vs = &validationState{pending: map[string]*ResponseViolation{}}
+		remote.validation = vs
+	}
+	remote.Unlock()
+
+	vs.Lock()
+	vs.validators = append(vs.validators, responseValidator{urlPattern, validator})
+	install := vs.stop == nil
+	if install {
+		vs.stop = []func(){}
+	}
+	vs.Unlock()
+
+	if !install {
+		return nil
+	}
+
+	stop := []func(){
+		remote.addHook("Page.frameNavigated", func(params Params) bool {
+			frame := Params(params.Map("frame"))
+			if frame.String("parentId") == "" {
+				vs.Lock()
+				vs.navigation = frame.String("url")
+				vs.Unlock()
+			}
+			return false
+		}),
+		remote.addHook("Network.responseReceived", func(params Params) bool {
+			resp := Params(params.Map("response"))
+			url := resp.String("url")
+
+			vs.Lock()
+			for _, v := range vs.validators {
+				if matchURLPattern(v.pattern, url) {
+					vs.pending[params.String("requestId")] = &ResponseViolation{
+						Navigation: vs.navigation,
+						URL:        url,
+						Status:     resp.Int("status"),
+					}
+					break
+				}
+			}
+			vs.Unlock()
+			return false
+		}),
+		remote.addHook("Network.loadingFinished", func(params Params) bool {
+			id := params.String("requestId")
+
+			vs.Lock()
+			pending := vs.pending[id]
+			delete(vs.pending, id)
+			validators := vs.validators
+			vs.Unlock()
+
+			if pending == nil {
+				return false
+			}
+
+			body, err := remote.GetResponseBody(id)
+			if err != nil {
+				return false
+			}
+
+			for _, v := range validators {
+				if !matchURLPattern(v.pattern, pending.URL) {
+					continue
+				}
+
+				if err := v.validate(pending.URL, body); err != nil {
+					violation := *pending
+					violation.Err = err
+
+					vs.Lock()
+					vs.violations = append(vs.violations, violation)
+					vs.Unlock()
+				}
+			}
+			return false
+		}),
+		remote.addHook("Network.loadingFailed", func(params Params) bool {
+			vs.Lock()
+			delete(vs.pending, params.String("requestId"))
+			vs.Unlock()
+			return false
+		}),
+	}
+
+	vs.Lock()
+	vs.stop = stop
+	vs.Unlock()
+
+	if err := remote.ensureDomain("Page"); err != nil {
+		return err
+	}
+
+	return remote.ensureDomain("Network")
+}
+
+// ResponseViolations returns the violations found so far by the response validators.
+// If reset is true, the violations are discarded.
+func (remote *RemoteDebugger) ResponseViolations(reset bool) []ResponseViolation {
+	remote.Lock()
+	vs := remote.validation
+	remote.Unlock()
+
+	if vs == nil {
+		return nil
+	}
+
+	vs.Lock()
+	defer vs.Unlock()
+
+	violations := vs.violations
+	if reset {
+		vs.violations = nil
+	}
+
+	return violations
+}
+
+// ResponseViolationsByNavigation groups the violations by the top-level document URL.
+func (remote *RemoteDebugger) ResponseViolationsByNavigation() map[string][]ResponseViolation {
+	byNavigation := map[string][]ResponseViolation{}
+
+	for _, v := range remote.ResponseViolations(false) {
+		byNavigation[v.Navigation] = append(byNavigation[v.Navigation], v)
+	}
+
+	return byNavigation
+}
+
+// ClearResponseValidators removes all the response validators and the violations found.
+func (remote *RemoteDebugger) ClearResponseValidators() {
+	remote.Lock()
+	vs := remote.validation
+	remote.validation = nil
+	remote.Unlock()
+
+	if vs == nil {
+		return
+	}
+
+	vs.Lock()
+	stop := vs.stop
+	vs.Unlock()
+
+	for _, remove := range stop {
+		remove()
+	}
+}
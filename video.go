@@ -0,0 +1,242 @@
+package godet
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FFmpegPath is the ffmpeg executable used by RecordVideo to encode the videos.
+var FFmpegPath = "ffmpeg"
+
+// ErrorNoFFmpeg is returned by RecordVideo if ffmpeg is not available
+var ErrorNoFFmpeg = errors.New("ffmpeg not found")
+
+// VideoOption defines the functional options for RecordVideo.
+type VideoOption func(vr *VideoRecorder)
+
+// VideoFPS sets the frame rate of the video (default 25).
+// The screencast only produces frames when the page changes, so frames are repeated as needed.
+func VideoFPS(fps int) VideoOption {
+	return func(vr *VideoRecorder) {
+		vr.fps = fps
+	}
+}
+
+// VideoSize sets the maximum width and height of the video frames (default: the viewport size).
+func VideoSize(width, height int) VideoOption {
+	return func(vr *VideoRecorder) {
+		vr.width, vr.height = width, height
+	}
+}
+
+// VideoQuality sets the JPEG quality (0-100) of the captured frames (default 80).
+func VideoQuality(quality int) VideoOption {
+	return func(vr *VideoRecorder) {
+		vr.quality = quality
+	}
+}
+
+type videoFrame struct {
+	data      []byte
+	timestamp float64
+}
+
+// VideoRecorder records the tab to a WebM video, see RecordVideo.
+type VideoRecorder struct {
+	remote *RemoteDebugger
+
+	// File is the path of the video file.
+	File string
+
+	fps     int
+	width   int
+	height  int
+	quality int
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	frames chan videoFrame
+	done   chan error
+
+	stopOnce   sync.Once
+	removeHook func()
+
+	sync.Mutex
+	stopped bool
+}
+
+// RecordVideo starts recording the tab to a WebM video in dir, combining the screencast frames
+// (Page.startScreencast) with their timing. The frames are encoded by ffmpeg (see FFmpegPath).
+//
+// Call Stop on the returned VideoRecorder to finish the video. Page events are enabled, if needed.
+func (remote *RemoteDebugger) RecordVideo(dir string, options ...VideoOption) (*VideoRecorder, error) {
+	path, err := exec.LookPath(FFmpegPath)
+	if err != nil {
+		return nil, ErrorNoFFmpeg
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	vr := &VideoRecorder{
+		remote:  remote,
+		File:    filepath.Join(dir, fmt.Sprintf("%v-%v.webm", time.Now().Format("20060102-150405"), current)),
+		fps:     25,
+		quality: 80,
+		frames:  make(chan videoFrame, 64),
+		done:    make(chan error, 1),
+	}
+
+	for _, opt := range options {
+		opt(vr)
+	}
+
+	vr.cmd = exec.Command(path, "-y", "-loglevel", "error",
+		"-f", "image2pipe", "-c:v", "mjpeg", "-framerate", fmt.Sprint(vr.fps), "-i", "-",
+		"-c:v", "libvpx", "-b:v", "1M", "-deadline", "realtime", "-pix_fmt", "yuv420p",
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2", vr.File)
+
+	if vr.stdin, err = vr.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+
+	var stderr strings.Builder
+	vr.cmd.Stderr = &stderr
+
+	if err := vr.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := vr.encode()
+		vr.stdin.Close()
+
+		if werr := vr.cmd.Wait(); werr != nil && err == nil {
+			err = fmt.Errorf("ffmpeg: %v %v", werr, strings.TrimSpace(stderr.String()))
+		}
+
+		vr.done <- err
+	}()
+
+	vr.removeHook = remote.addHook("Page.screencastFrame", func(params Params) bool {
+		if _, err := remote.SendRequest("Page.screencastFrameAck", Params{
+			"sessionId": params.Int("sessionId"),
+		}); err != nil && remote.verbose {
+			log.Println("screencastFrameAck", err)
+		}
+
+		data, err := base64.StdEncoding.DecodeString(params.String("data"))
+		if err != nil {
+			return true
+		}
+
+		ts, _ := Params(params.Map("metadata"))["timestamp"].(float64)
+		if ts == 0 {
+			ts = float64(time.Now().UnixNano()) / 1e9
+		}
+
+		vr.Lock()
+		if !vr.stopped {
+			select {
+			case vr.frames <- videoFrame{data: data, timestamp: ts}:
+			default:
+				// the encoder is falling behind: drop the frame (the previous one is repeated)
+			}
+		}
+		vr.Unlock()
+
+		return true
+	})
+
+	if err := remote.ensureDomain("Page"); err != nil {
+		vr.Stop()
+		return nil, err
+	}
+
+	params := Params{
+		"format":  "jpeg",
+		"quality": vr.quality,
+	}
+	if vr.width > 0 {
+		params["maxWidth"] = vr.width
+	}
+	if vr.height > 0 {
+		params["maxHeight"] = vr.height
+	}
+
+	if _, err := remote.SendRequest("Page.startScreencast", params); err != nil {
+		vr.Stop()
+		return nil, err
+	}
+
+	return vr, nil
+}
+
+// encode writes the frames to ffmpeg at a constant frame rate, repeating each frame
+// until the time of the next one.
+func (vr *VideoRecorder) encode() error {
+	var last []byte
+	var next float64
+	var err error
+
+	interval := 1 / float64(vr.fps)
+
+	for f := range vr.frames {
+		if last == nil {
+			last, next = f.data, f.timestamp
+			continue
+		}
+
+		for ; next < f.timestamp && err == nil; next += interval {
+			_, err = vr.stdin.Write(last)
+		}
+
+		if f.data != nil {
+			last = f.data
+		}
+	}
+
+	if last != nil && err == nil {
+		_, err = vr.stdin.Write(last)
+	}
+
+	return err
+}
+
+// Stop stops the recording and waits for the video to be encoded, returning the path of the video file.
+func (vr *VideoRecorder) Stop() (string, error) {
+	var err error
+
+	vr.stopOnce.Do(func() {
+		_, serr := vr.remote.SendRequest("Page.stopScreencast", nil)
+		vr.removeHook()
+
+		// the last frame lasts until now
+		vr.Lock()
+		vr.stopped = true
+		vr.frames <- videoFrame{timestamp: float64(time.Now().UnixNano()) / 1e9}
+		close(vr.frames)
+		vr.Unlock()
+
+		err = <-vr.done
+		if err == nil {
+			err = serr
+		}
+	})
+
+	return vr.File, err
+}
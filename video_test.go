@@ -0,0 +1,107 @@
+package godet
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordVideo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+
+	dir := t.TempDir()
+
+	// a fake ffmpeg that writes the frames it receives to the output file
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	if err := ioutil.WriteFile(ffmpeg, []byte("#!/bin/sh\nfor a; do out=$a; done\ncat > \"$out\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(path string) { FFmpegPath = path }(FFmpegPath)
+	FFmpegPath = filepath.Join(dir, "missing")
+
+	var lock sync.Mutex
+	var calls []string
+
+	remote := connectFake(t, fakeCDP(t, func(method string, params Params) (interface{}, *ProtocolError) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch method {
+		case "Page.startScreencast":
+			calls = append(calls, fmt.Sprintf("%v %v %v %v", method, params.String("format"), params.Int("quality"), params.Int("maxWidth")))
+		case "Page.screencastFrameAck":
+			calls = append(calls, fmt.Sprintf("%v %v", method, params.Int("sessionId")))
+		case "Page.stopScreencast":
+			calls = append(calls, method)
+		}
+
+		return nil, nil
+	}))
+
+	if _, err := remote.RecordVideo(dir); err != ErrorNoFFmpeg {
+		t.Fatalf("RecordVideo without ffmpeg = %v", err)
+	}
+
+	FFmpegPath = ffmpeg
+
+	vr, err := remote.RecordVideo(filepath.Join(dir, "videos"), VideoFPS(4), VideoSize(640, 0), VideoQuality(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the frames at 4 fps: A is repeated until B, B until Stop
+	start := float64(time.Now().Unix() - 2)
+
+	frame := func(session int, data string, timestamp float64) {
+		fakeEvent(remote, "Page.screencastFrame", Params{
+			"sessionId": session,
+			"data":      base64.StdEncoding.EncodeToString([]byte(data)),
+			"metadata":  Params{"timestamp": timestamp},
+		})
+	}
+
+	frame(1, "A", start)
+	frame(2, "B", start+1)
+
+	file, err := vr.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(file, filepath.Join(dir, "videos")+string(os.PathSeparator)) || !strings.HasSuffix(file, "-fake.webm") {
+		t.Errorf("file = %v", file)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	video := string(data)
+	if n := strings.Count(video, "B"); !strings.HasPrefix(video, "AAAAB") || strings.Count(video, "A") != 4 || n < 5 || n > 10 {
+		t.Errorf("frames = %q, want 4 A and 5 to 9 B", video)
+	}
+
+	if again, err := vr.Stop(); again != file || err != nil {
+		t.Errorf("second Stop = %v, %v", again, err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	want := []string{"Page.startScreencast jpeg 50 640", "Page.screencastFrameAck 1", "Page.screencastFrameAck 2", "Page.stopScreencast"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,170 @@
+package godet
+
+import (
+	"sync"
+	"time"
+)
+
+// MatchedResponse is the response returned by WaitForResponse.
+type MatchedResponse struct {
+	ObservedResponse
+
+	// Body is only set if requested with ResponseBody.
+	Body []byte
+}
+
+// ResponseWaitOption defines the functional options for ExpectResponse and WaitForResponse.
+type ResponseWaitOption func(rw *ResponseWaiter)
+
+// ResponseBody also retrieves the body of the matched response (after it's fully loaded).
+func ResponseBody() ResponseWaitOption {
+	return func(rw *ResponseWaiter) {
+		rw.body = true
+	}
+}
+
+// ResponseType only matches responses of the specified resource type (i.e. XHR or Fetch).
+func ResponseType(rtype ResourceType) ResponseWaitOption {
+	return func(rw *ResponseWaiter) {
+		rw.rtype = rtype
+	}
+}
+
+// ResponseWaiter waits for a response, see ExpectResponse.
+type ResponseWaiter struct {
+	remote *RemoteDebugger
+
+	pattern string
+	rtype   ResourceType
+	body    bool
+
+	sync.Mutex
+	matched *MatchedResponse
+	done    chan struct{}
+	stop    []func()
+}
+
+// ExpectResponse starts waiting for a response whose URL matches urlPattern (with '*' and '?' wildcards),
+// returning a ResponseWaiter. Call it before the action that triggers the request, then call Wait.
+// Network events are enabled, if needed.
+func (remote *RemoteDebugger) ExpectResponse(urlPattern string, options ...ResponseWaitOption) (*ResponseWaiter, error) {
+	rw := &ResponseWaiter{
+		remote:  remote,
+		pattern: urlPattern,
+		done:    make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(rw)
+	}
+
+	finished := func(params Params) bool {
+		rw.Lock()
+		if rw.matched != nil && rw.matched.RequestID == params.String("requestId") {
+			if _, failed := params["errorText"]; failed {
+				rw.matched.Failed = true
+				rw.matched.ErrorText = params.String("errorText")
+			}
+
+			rw.finish()
+		}
+		rw.Unlock()
+		return false
+	}
+
+	rw.stop = append(rw.stop, remote.addHook("Network.responseReceived", func(params Params) bool {
+		rtype := ResourceType(params.String("type"))
+		resp := params.Map("response")
+
+		if rw.rtype != "" && rw.rtype != rtype {
+			return false
+		}
+		if !matchURLPattern(rw.pattern, Params(resp).String("url")) {
+			return false
+		}
+
+		rw.Lock()
+		if rw.matched == nil {
+			rw.matched = &MatchedResponse{ObservedResponse: ObservedResponse{
+				RequestID: params.String("requestId"),
+				URL:       Params(resp).String("url"),
+				Type:      rtype,
+			}}
+			rw.matched.setResponse(resp)
+
+			if !rw.body {
+				rw.finish()
+			}
+		}
+		rw.Unlock()
+		return false
+	}))
+
+	if rw.body {
+		rw.stop = append(rw.stop,
+			remote.addHook("Network.loadingFinished", finished),
+			remote.addHook("Network.loadingFailed", finished))
+	}
+
+	if err := remote.ensureDomain("Network"); err != nil {
+		rw.Cancel()
+		return nil, err
+	}
+
+	return rw, nil
+}
+
+// finish signals the response is available. It must be called with the lock held.
+func (rw *ResponseWaiter) finish() {
+	select {
+	case <-rw.done:
+	default:
+		close(rw.done)
+	}
+}
+
+// Cancel stops waiting for the response.
+func (rw *ResponseWaiter) Cancel() {
+	for _, stop := range rw.stop {
+		stop()
+	}
+}
+
+// Wait waits for the response, up to timeout, or returns ErrorTimeout.
+func (rw *ResponseWaiter) Wait(timeout time.Duration) (*MatchedResponse, error) {
+	defer rw.Cancel()
+
+	select {
+	case <-rw.done:
+	case <-time.After(timeout):
+		return nil, ErrorTimeout
+	}
+
+	rw.Lock()
+	matched := rw.matched
+	rw.Unlock()
+
+	if rw.body && !matched.Failed {
+		body, err := rw.remote.GetResponseBody(matched.RequestID)
+		if err != nil {
+			return matched, err
+		}
+
+		matched.Body = body
+	}
+
+	return matched, nil
+}
+
+// WaitForResponse waits for a response whose URL matches urlPattern, up to timeout.
+//
+// Only the responses received after the call are matched, so if the request is triggered by an action
+// use ExpectResponse before the action and wait after.
+func (remote *RemoteDebugger) WaitForResponse(urlPattern string, timeout time.Duration, options ...ResponseWaitOption) (*MatchedResponse, error) {
+	rw, err := remote.ExpectResponse(urlPattern, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rw.Wait(timeout)
+}